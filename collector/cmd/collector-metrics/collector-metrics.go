@@ -1,16 +1,20 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/analogj/scrutiny/collector/pkg/collector"
 	"github.com/analogj/scrutiny/collector/pkg/config"
+	"github.com/analogj/scrutiny/collector/pkg/daemon"
 	"github.com/analogj/scrutiny/collector/pkg/errors"
 	"github.com/analogj/scrutiny/webapp/backend/pkg/version"
 	"github.com/sirupsen/logrus"
@@ -100,32 +104,9 @@ OPTIONS:
 				Name:  "run",
 				Usage: "Run the scrutiny smartctl metrics collector",
 				Action: func(c *cli.Context) error {
-					if c.IsSet("config") {
-						err = config.ReadConfig(c.String("config")) // Find and read the config file
-						if err != nil {                             // Handle errors reading the config file
-							//ignore "could not find config file"
-							fmt.Printf("Could not find config file at specified path: %s", c.String("config"))
-							return err
-						}
-					}
-					//override config with flags if set
-					if c.IsSet("host-id") {
-						config.Set("host.id", c.String("host-id")) // set/override the host-id using CLI.
-					}
-
-					if c.Bool("debug") {
-						config.Set("log.level", "DEBUG")
-					}
-
-					if c.IsSet("log-file") {
-						config.Set("log.file", c.String("log-file"))
-					}
-
-					if c.IsSet("api-endpoint") {
-						//if the user is providing an api-endpoint with a basepath (eg. http://localhost:8080/scrutiny),
-						//we need to ensure the basepath has a trailing slash, otherwise the url.Parse() path concatenation doesnt work.
-						apiEndpoint := strings.TrimSuffix(c.String("api-endpoint"), "/") + "/"
-						config.Set("api.endpoint", apiEndpoint)
+					err = ApplyFlags(c, config)
+					if err != nil {
+						return err
 					}
 
 					collectorLogger, logFile, err := CreateLogger(config)
@@ -151,37 +132,26 @@ OPTIONS:
 					return metricCollector.Run()
 				},
 
-				Flags: []cli.Flag{
-					&cli.StringFlag{
-						Name:  "config",
-						Usage: "Specify the path to the devices file",
-					},
-					&cli.StringFlag{
-						Name:    "api-endpoint",
-						Usage:   "The api server endpoint",
-						EnvVars: []string{"COLLECTOR_API_ENDPOINT", "SCRUTINY_API_ENDPOINT"},
-						//SCRUTINY_API_ENDPOINT is deprecated, but kept for backwards compatibility
-					},
-
-					&cli.StringFlag{
-						Name:    "log-file",
-						Usage:   "Path to file for logging. Leave empty to use STDOUT",
-						EnvVars: []string{"COLLECTOR_LOG_FILE"},
-					},
+				Flags: collectorFlags(),
+			},
+			{
+				Name:  "daemon",
+				Usage: "Run the scrutiny smartctl metrics collector on a schedule",
+				Action: func(c *cli.Context) error {
+					err = ApplyFlags(c, config)
+					if err != nil {
+						return err
+					}
 
-					&cli.BoolFlag{
-						Name:    "debug",
-						Usage:   "Enable debug logging",
-						EnvVars: []string{"COLLECTOR_DEBUG", "DEBUG"},
-					},
+					return RunDaemon(c, config, configFilePath)
+				},
 
+				Flags: append(collectorFlags(),
 					&cli.StringFlag{
-						Name:    "host-id",
-						Usage:   "Host identifier/label, used for grouping devices",
-						Value:   "",
-						EnvVars: []string{"COLLECTOR_HOST_ID"},
+						Name:  "schedule",
+						Usage: "Cron expression used to schedule the collector, eg. '0 0 * * *'",
 					},
-				},
+				),
 			},
 		},
 	}
@@ -192,6 +162,135 @@ OPTIONS:
 	}
 }
 
+func collectorFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:  "config",
+			Usage: "Specify the path to the config file",
+		},
+		&cli.StringFlag{
+			Name:    "api-endpoint",
+			Usage:   "The api server endpoint",
+			EnvVars: []string{"COLLECTOR_API_ENDPOINT", "SCRUTINY_API_ENDPOINT"},
+			//SCRUTINY_API_ENDPOINT is deprecated, but kept for backwards compatibility
+		},
+
+		&cli.StringFlag{
+			Name:    "log-file",
+			Usage:   "Path to file for logging. Leave empty to use STDOUT",
+			EnvVars: []string{"COLLECTOR_LOG_FILE"},
+		},
+
+		&cli.BoolFlag{
+			Name:    "debug",
+			Usage:   "Enable debug logging",
+			EnvVars: []string{"COLLECTOR_DEBUG", "DEBUG"},
+		},
+
+		&cli.StringFlag{
+			Name:    "host-id",
+			Usage:   "Host identifier/label, used for grouping devices",
+			Value:   "",
+			EnvVars: []string{"COLLECTOR_HOST_ID"},
+		},
+	}
+}
+
+// ApplyFlags loads the config file specified using the `--config` flag, and overrides the config with any flags that are set.
+func ApplyFlags(c *cli.Context, appConfig config.Interface) error {
+	if c.IsSet("config") {
+		err := appConfig.ReadConfig(c.String("config")) // Find and read the config file
+		if err != nil {                                 // Handle errors reading the config file
+			//ignore "could not find config file"
+			fmt.Printf("Could not find config file at specified path: %s", c.String("config"))
+			return err
+		}
+	}
+	//override config with flags if set
+	if c.IsSet("host-id") {
+		appConfig.Set("host.id", c.String("host-id")) // set/override the host-id using CLI.
+	}
+
+	if c.Bool("debug") {
+		appConfig.Set("log.level", "DEBUG")
+	}
+
+	if c.IsSet("log-file") {
+		appConfig.Set("log.file", c.String("log-file"))
+	}
+
+	if c.IsSet("api-endpoint") {
+		//if the user is providing an api-endpoint with a basepath (eg. http://localhost:8080/scrutiny),
+		//we need to ensure the basepath has a trailing slash, otherwise the url.Parse() path concatenation doesnt work.
+		apiEndpoint := strings.TrimSuffix(c.String("api-endpoint"), "/") + "/"
+		appConfig.Set("api.endpoint", apiEndpoint)
+	}
+
+	if c.IsSet("schedule") {
+		appConfig.Set("daemon.schedule", c.String("schedule"))
+	}
+	return nil
+}
+
+// RunDaemon runs the metrics collector on the `daemon.schedule` cron schedule, until a SIGTERM or SIGINT is received.
+func RunDaemon(c *cli.Context, appConfig config.Interface, configFilePath string) error {
+	collectorLogger, logFile, err := CreateLogger(appConfig)
+	if logFile != nil {
+		defer logFile.Close()
+	}
+	if err != nil {
+		return err
+	}
+
+	collectorDaemon, err := daemon.New(
+		collectorLogger,
+		// the config is re-read from disk when the daemon receives a SIGHUP
+		func() (config.Interface, error) {
+			return LoadDaemonConfig(c, configFilePath, collectorLogger)
+		},
+		func(ctx context.Context, runConfig config.Interface) error {
+			metricCollector, err := collector.CreateMetricsCollector(
+				runConfig,
+				collectorLogger,
+				runConfig.GetString("api.endpoint"),
+			)
+			if err != nil {
+				return err
+			}
+			return metricCollector.RunContext(ctx)
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP, syscall.SIGTERM, os.Interrupt)
+	return collectorDaemon.Start(signals)
+}
+
+// LoadDaemonConfig re-reads the config file from disk, and applies the flags. The log level of the existing logger is
+// updated, other log settings require a restart.
+func LoadDaemonConfig(c *cli.Context, configFilePath string, logger *logrus.Entry) (config.Interface, error) {
+	appConfig, err := config.Create()
+	if err != nil {
+		return nil, err
+	}
+	err = appConfig.ReadConfig(configFilePath)
+	if _, ok := err.(errors.ConfigFileMissingError); !ok && err != nil {
+		return nil, err
+	}
+	err = ApplyFlags(c, appConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	if level, err := logrus.ParseLevel(appConfig.GetString("log.level")); err == nil {
+		logger.Logger.SetLevel(level)
+	}
+	return appConfig, nil
+}
+
 func CreateLogger(appConfig config.Interface) (*logrus.Entry, *os.File, error) {
 	logger := logrus.WithFields(logrus.Fields{
 		"type": "metrics",
@@ -3,9 +3,20 @@ package collector
 import (
 	"bytes"
 	"encoding/json"
+	stderrors "errors"
+	"fmt"
 	"net/http"
+	"net/url"
 	"time"
 
+	"github.com/analogj/scrutiny/collector/pkg/config"
+	"github.com/analogj/scrutiny/collector/pkg/detect"
+	"github.com/analogj/scrutiny/collector/pkg/errors"
+	"github.com/analogj/scrutiny/collector/pkg/models"
+	"github.com/analogj/scrutiny/collector/pkg/spool"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/models/collector"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/version"
+	"github.com/gofrs/uuid/v5"
 	"github.com/sirupsen/logrus"
 )
 
@@ -13,6 +24,31 @@ var httpClient = &http.Client{Timeout: 60 * time.Second}
 
 type BaseCollector struct {
 	logger *logrus.Entry
+
+	// sent as a bearer token, when the API server requires collector authentication
+	apiToken string
+
+	// payloads that could not be delivered to the API server are stored here. nil when spooling is disabled.
+	spool *spool.Spool
+}
+
+// createSpool returns nil if spooling is disabled, or the spool directory cannot be created.
+func createSpool(appConfig config.Interface, logger *logrus.Entry) *spool.Spool {
+	if !appConfig.GetBool("spool.enable") {
+		return nil
+	}
+
+	payloadSpool, err := spool.New(
+		appConfig.GetString("spool.dir"),
+		int64(appConfig.GetInt("spool.max_size_mb"))*1024*1024,
+		time.Duration(appConfig.GetInt("spool.max_age_days"))*24*time.Hour,
+		logger,
+	)
+	if err != nil {
+		logger.Warnf("Spooling disabled, undelivered payloads will be lost: %v", err)
+		return nil
+	}
+	return payloadSpool
 }
 
 func (c *BaseCollector) postJson(url string, body interface{}, target interface{}) error {
@@ -21,15 +57,219 @@ func (c *BaseCollector) postJson(url string, body interface{}, target interface{
 		return err
 	}
 
-	r, err := httpClient.Post(url, "application/json", bytes.NewBuffer(requestBody))
+	return c.postPayload(url, requestBody, target)
+}
+
+// postPayload posts a json payload to the API server. The response is decoded into target, unless target is nil.
+func (c *BaseCollector) postPayload(url string, payload []byte, target interface{}) error {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(collector.VersionHeader, version.VERSION)
+	if len(c.apiToken) > 0 {
+		req.Header.Set("Authorization", "Bearer "+c.apiToken)
+	}
+
+	r, err := httpClient.Do(req)
 	if err != nil {
 		return err
 	}
 	defer r.Body.Close()
 
+	if r.StatusCode >= http.StatusInternalServerError || (target == nil && r.StatusCode >= http.StatusBadRequest) {
+		return errors.ApiServerResponseError(r.StatusCode)
+	}
+	if target == nil {
+		return nil
+	}
 	return json.NewDecoder(r.Body).Decode(target)
 }
 
+// publish posts a payload to the API server. If the API server is unavailable, the payload is spooled, and delivered
+// during the next run.
+func (c *BaseCollector) publish(apiEndpointBase *url.URL, endpoint string, scrutinyUuid uuid.UUID, payload []byte) error {
+	apiEndpoint, _ := url.Parse(apiEndpointBase.String())
+	apiEndpoint, _ = apiEndpoint.Parse(endpoint)
+
+	err := c.postPayload(apiEndpoint.String(), payload, nil)
+	if err != nil && isApiServerUnavailable(err) && c.spool != nil {
+		if spoolErr := c.spool.Write(endpoint, scrutinyUuid, payload); spoolErr != nil {
+			c.logger.Errorf("Could not spool payload for %s: %v", endpoint, spoolErr)
+		}
+	}
+	return err
+}
+
+// replaySpool delivers spooled payloads to the API server, oldest first. Each payload is retried with exponential
+// backoff, if the API server is still unavailable the remaining payloads are left in the spool for the next run.
+func (c *BaseCollector) replaySpool(appConfig config.Interface, apiEndpointBase *url.URL) {
+	if c.spool == nil {
+		return
+	}
+	if err := c.spool.Prune(); err != nil {
+		c.logger.Warnf("Could not prune spool: %v", err)
+	}
+	entries, err := c.spool.Entries()
+	if err != nil {
+		c.logger.Errorf("Could not read spool: %v", err)
+		return
+	}
+	if len(entries) == 0 {
+		return
+	}
+
+	c.logger.Infof("Replaying %d spooled payloads", len(entries))
+	for ndx, entry := range entries {
+		apiEndpoint, _ := url.Parse(apiEndpointBase.String())
+		apiEndpoint, _ = apiEndpoint.Parse(entry.Endpoint)
+
+		err := c.postPayloadWithRetry(appConfig, apiEndpoint.String(), entry.Payload)
+		if err != nil && isApiServerUnavailable(err) {
+			c.logger.Errorf("API server is unavailable, %d spooled payloads will be replayed during the next run: %v", len(entries)-ndx, err)
+			return
+		} else if err != nil && !isPayloadRejected(err) {
+			// the API server refused the collector (eg. an invalid api token, or rate limiting), not the payload.
+			c.logger.Errorf("API server refused the collector, %d spooled payloads will be replayed during the next run: %v", len(entries)-ndx, err)
+			return
+		} else if err != nil {
+			// the API server rejected the payload, retrying will not help.
+			c.logger.Errorf("Discarding spooled payload for %s (%s): %v", entry.Endpoint, entry.CreatedAt, err)
+		}
+
+		if err := c.spool.Remove(entry); err != nil {
+			c.logger.Errorf("Could not remove spooled payload for %s: %v", entry.Endpoint, err)
+		}
+	}
+}
+
+func (c *BaseCollector) postPayloadWithRetry(appConfig config.Interface, url string, payload []byte) error {
+	backoff := time.Duration(appConfig.GetInt("spool.retry_backoff")) * time.Second
+	for attempt := 1; ; attempt++ {
+		err := c.postPayload(url, payload, nil)
+		if err == nil || !isApiServerUnavailable(err) || attempt > appConfig.GetInt("spool.retry_attempts") {
+			return err
+		}
+
+		c.logger.Warnf("API server is unavailable, retrying in %s (attempt %d/%d)", backoff, attempt, appConfig.GetInt("spool.retry_attempts"))
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// isApiServerUnavailable returns true if the request failed due to a network error or a server error.
+// Other errors mean the API server rejected the payload.
+func isApiServerUnavailable(err error) bool {
+	var responseErr errors.ApiServerResponseError
+	if stderrors.As(err, &responseErr) {
+		return responseErr >= http.StatusInternalServerError
+	}
+	var urlErr *url.Error
+	return stderrors.As(err, &urlErr)
+}
+
+// isPayloadRejected returns true if the API server rejected the payload itself (it is invalid), so it should not be
+// delivered again.
+func isPayloadRejected(err error) bool {
+	var responseErr errors.ApiServerResponseError
+	if stderrors.As(err, &responseErr) {
+		return responseErr == http.StatusBadRequest || responseErr == http.StatusUnprocessableEntity
+	}
+	return false
+}
+
+// registerDevices detects the storage devices on this host and registers them with the API server.
+// The API server filters & validates the devices, and returns the list that should be processed by the collector.
+func (c *BaseCollector) registerDevices(appConfig config.Interface, apiEndpointBase *url.URL) ([]models.Device, error) {
+	apiEndpoint, _ := url.Parse(apiEndpointBase.String())
+	apiEndpoint, _ = apiEndpoint.Parse("api/devices/register") //this acts like filepath.Join()
+
+	deviceRespWrapper := new(models.DeviceWrapper)
+
+	deviceDetector := detect.Detect{
+		Logger: c.logger,
+		Config: appConfig,
+	}
+	rawDetectedStorageDevices, err := deviceDetector.Start()
+	if err != nil {
+		return nil, err
+	}
+
+	// Ignore any device without a Scrutiny UUID. This should never happen...
+	detectedStorageDevices := make([]models.Device, 0, len(rawDetectedStorageDevices))
+	for _, device := range rawDetectedStorageDevices {
+		if device.ScrutinyUUID.IsNil() {
+			c.logger.Errorf("Device %s has no scrutiny UUID; skipping (no data association possible).", device.DeviceName)
+			c.logger.Debugf("Raw detected device: model=%q serial=%q wwn=%q ScrutinyUUID=%s",
+				device.ModelName, device.SerialNumber, device.WWN, device.ScrutinyUUID)
+			continue
+		}
+		detectedStorageDevices = append(detectedStorageDevices, device)
+	}
+
+	c.logger.Infof("Sending %d/%d detected devices to API for filtering & validation",
+		len(detectedStorageDevices), len(rawDetectedStorageDevices))
+	jsonObj, _ := json.Marshal(detectedStorageDevices)
+	c.logger.Debugf("Detected devices: %v", string(jsonObj))
+	err = c.postJson(apiEndpoint.String(), models.DeviceWrapper{
+		Data: detectedStorageDevices,
+	}, &deviceRespWrapper)
+	if err != nil && isApiServerUnavailable(err) && c.spool != nil {
+		// spool the registration, so the devices exist before their spooled smart data is replayed.
+		c.logger.Warnf("API server is unavailable, collecting the previously registered devices: %v", err)
+		registerPayload, _ := json.Marshal(models.DeviceWrapper{Data: detectedStorageDevices})
+		if spoolErr := c.spool.Write("api/devices/register", uuid.Nil, registerPayload); spoolErr != nil {
+			return nil, fmt.Errorf("could not spool device registration: %w", spoolErr)
+		}
+		return c.previouslyRegisteredDevices(detectedStorageDevices)
+	} else if err != nil {
+		return nil, err
+	}
+
+	if !deviceRespWrapper.Success {
+		c.logger.Errorln("An error occurred while retrieving filtered devices")
+		c.logger.Debugln(deviceRespWrapper)
+		return nil, errors.ApiServerCommunicationError("An error occurred while retrieving filtered devices")
+	}
+	c.logger.Debugln(deviceRespWrapper)
+
+	if c.spool != nil {
+		registration, _ := json.Marshal(deviceRespWrapper.Data)
+		if err := c.spool.WriteRegistration(registration); err != nil {
+			c.logger.Warnf("Could not store device registration: %v", err)
+		}
+	}
+	return deviceRespWrapper.Data, nil
+}
+
+// previouslyRegisteredDevices returns the detected devices that were accepted by the API server during the last
+// successful registration, so devices filtered by the API server are not collected while it is unavailable.
+func (c *BaseCollector) previouslyRegisteredDevices(detectedStorageDevices []models.Device) ([]models.Device, error) {
+	registration, err := c.spool.ReadRegistration()
+	if err != nil {
+		return nil, fmt.Errorf("API server is unavailable, and no devices were registered previously: %w", err)
+	}
+	var registeredDevices []models.Device
+	if err := json.Unmarshal(registration, &registeredDevices); err != nil {
+		return nil, fmt.Errorf("could not read previous device registration: %w", err)
+	}
+	registeredUuids := map[uuid.UUID]bool{}
+	for _, device := range registeredDevices {
+		registeredUuids[device.ScrutinyUUID] = true
+	}
+
+	devices := []models.Device{}
+	for _, device := range detectedStorageDevices {
+		if registeredUuids[device.ScrutinyUUID] {
+			devices = append(devices, device)
+		} else {
+			c.logger.Infof("Skipping device %s, it was not registered with the API server", device.DeviceName)
+		}
+	}
+	return devices, nil
+}
+
 // http://www.linuxguide.it/command_line/linux-manpage/do.php?file=smartctl#sect7
 func (c *BaseCollector) LogSmartctlExitCode(exitCode int) {
 	if exitCode&0x01 != 0 {
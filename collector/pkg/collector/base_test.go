@@ -0,0 +1,227 @@
+package collector
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	mock_config "github.com/analogj/scrutiny/collector/pkg/config/mock"
+	"github.com/analogj/scrutiny/collector/pkg/models"
+	"github.com/analogj/scrutiny/collector/pkg/spool"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/version"
+	"github.com/gofrs/uuid/v5"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func newTestBaseCollector(t *testing.T) BaseCollector {
+	logger := logrus.WithFields(logrus.Fields{})
+	payloadSpool, err := spool.New(t.TempDir(), 0, 0, logger)
+	require.NoError(t, err)
+	return BaseCollector{logger: logger, spool: payloadSpool}
+}
+
+func TestBaseCollector_Publish_SpoolsWhenUnavailable(t *testing.T) {
+	//setup
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	apiEndpoint, _ := url.Parse(server.URL + "/")
+	bc := newTestBaseCollector(t)
+	scrutinyUuid := uuid.Must(uuid.NewV4())
+
+	//test
+	err := bc.publish(apiEndpoint, "api/device/"+scrutinyUuid.String()+"/smart", scrutinyUuid, []byte(`{"local_time": {"time_t": 1700000000}}`))
+
+	//assert
+	require.Error(t, err)
+	entries, err := bc.spool.Entries()
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, scrutinyUuid, entries[0].ScrutinyUUID)
+	require.JSONEq(t, `{"local_time": {"time_t": 1700000000}}`, string(entries[0].Payload))
+}
+
+func TestBaseCollector_Publish_DoesNotSpoolRejectedPayload(t *testing.T) {
+	//setup
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+	apiEndpoint, _ := url.Parse(server.URL + "/")
+	bc := newTestBaseCollector(t)
+	scrutinyUuid := uuid.Must(uuid.NewV4())
+
+	//test
+	err := bc.publish(apiEndpoint, "api/device/"+scrutinyUuid.String()+"/smart", scrutinyUuid, []byte(`{}`))
+
+	//assert
+	require.Error(t, err)
+	entries, err := bc.spool.Entries()
+	require.NoError(t, err)
+	require.Empty(t, entries)
+}
+
+func TestBaseCollector_ReplaySpool(t *testing.T) {
+	//setup
+	requests := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, r.URL.Path+" "+string(body))
+		// the first attempt fails, as if the API server was still starting up
+		if len(requests) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"success": true}`))
+	}))
+	defer server.Close()
+	apiEndpoint, _ := url.Parse(server.URL + "/")
+
+	mockCtrl := gomock.NewController(t)
+	fakeConfig := mock_config.NewMockInterface(mockCtrl)
+	fakeConfig.EXPECT().GetInt("spool.retry_attempts").AnyTimes().Return(3)
+	fakeConfig.EXPECT().GetInt("spool.retry_backoff").AnyTimes().Return(0)
+
+	bc := newTestBaseCollector(t)
+	require.NoError(t, bc.spool.Write("api/devices/register", uuid.Nil, []byte(`{"data":[]}`)))
+	require.NoError(t, bc.spool.Write("api/device/first/smart", uuid.Must(uuid.NewV4()), []byte(`{"local_time":{"time_t":1}}`)))
+
+	//test
+	bc.replaySpool(fakeConfig, apiEndpoint)
+
+	//assert
+	require.Equal(t, []string{
+		`/api/devices/register {"data":[]}`,
+		`/api/devices/register {"data":[]}`,
+		`/api/device/first/smart {"local_time":{"time_t":1}}`,
+	}, requests)
+	entries, err := bc.spool.Entries()
+	require.NoError(t, err)
+	require.Empty(t, entries)
+}
+
+func TestBaseCollector_ReplaySpool_StillUnavailable(t *testing.T) {
+	//setup
+	requestCount := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestCount++
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+	apiEndpoint, _ := url.Parse(server.URL + "/")
+
+	mockCtrl := gomock.NewController(t)
+	fakeConfig := mock_config.NewMockInterface(mockCtrl)
+	fakeConfig.EXPECT().GetInt("spool.retry_attempts").AnyTimes().Return(2)
+	fakeConfig.EXPECT().GetInt("spool.retry_backoff").AnyTimes().Return(0)
+
+	bc := newTestBaseCollector(t)
+	require.NoError(t, bc.spool.Write("api/device/first/smart", uuid.Must(uuid.NewV4()), []byte(`{}`)))
+	require.NoError(t, bc.spool.Write("api/device/second/smart", uuid.Must(uuid.NewV4()), []byte(`{}`)))
+
+	//test
+	bc.replaySpool(fakeConfig, apiEndpoint)
+
+	//assert (the first payload is retried, the second payload is not attempted)
+	require.Equal(t, 3, requestCount)
+	entries, err := bc.spool.Entries()
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+}
+
+func TestBaseCollector_ReplaySpool_Refused(t *testing.T) {
+	for _, statusCode := range []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusTooManyRequests, http.StatusUnprocessableEntity} {
+		t.Run(http.StatusText(statusCode), func(t *testing.T) {
+			//setup
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(statusCode)
+			}))
+			defer server.Close()
+			apiEndpoint, _ := url.Parse(server.URL + "/")
+
+			mockCtrl := gomock.NewController(t)
+			fakeConfig := mock_config.NewMockInterface(mockCtrl)
+			fakeConfig.EXPECT().GetInt("spool.retry_attempts").AnyTimes().Return(2)
+			fakeConfig.EXPECT().GetInt("spool.retry_backoff").AnyTimes().Return(0)
+
+			bc := newTestBaseCollector(t)
+			require.NoError(t, bc.spool.Write("api/device/first/smart", uuid.Must(uuid.NewV4()), []byte(`{}`)))
+			require.NoError(t, bc.spool.Write("api/device/second/smart", uuid.Must(uuid.NewV4()), []byte(`{}`)))
+
+			//test
+			bc.replaySpool(fakeConfig, apiEndpoint)
+
+			//assert (invalid payloads are discarded, other payloads are kept until the collector is accepted)
+			entries, err := bc.spool.Entries()
+			require.NoError(t, err)
+			if statusCode == http.StatusBadRequest || statusCode == http.StatusUnprocessableEntity {
+				require.Empty(t, entries)
+			} else {
+				require.Len(t, entries, 2)
+			}
+		})
+	}
+}
+
+func TestBaseCollector_Publish_SendsApiToken(t *testing.T) {
+	//setup
+	authorizationHeader := ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorizationHeader = r.Header.Get("Authorization")
+		w.Write([]byte(`{"success": true}`))
+	}))
+	defer server.Close()
+	apiEndpoint, _ := url.Parse(server.URL + "/")
+	bc := newTestBaseCollector(t)
+	bc.apiToken = "scrutiny_0123456789abcdef"
+	scrutinyUuid := uuid.Must(uuid.NewV4())
+
+	//test
+	err := bc.publish(apiEndpoint, "api/device/"+scrutinyUuid.String()+"/smart", scrutinyUuid, []byte(`{}`))
+
+	//assert
+	require.NoError(t, err)
+	require.Equal(t, "Bearer scrutiny_0123456789abcdef", authorizationHeader)
+}
+
+func TestBaseCollector_Publish_SendsCollectorVersion(t *testing.T) {
+	//setup
+	versionHeader := ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		versionHeader = r.Header.Get("X-Scrutiny-Collector-Version")
+		w.Write([]byte(`{"success": true}`))
+	}))
+	defer server.Close()
+	apiEndpoint, _ := url.Parse(server.URL + "/")
+	bc := newTestBaseCollector(t)
+	scrutinyUuid := uuid.Must(uuid.NewV4())
+
+	//test
+	err := bc.publish(apiEndpoint, "api/device/"+scrutinyUuid.String()+"/smart", scrutinyUuid, []byte(`{}`))
+
+	//assert
+	require.NoError(t, err)
+	require.Equal(t, version.VERSION, versionHeader)
+}
+
+func TestBaseCollector_PreviouslyRegisteredDevices(t *testing.T) {
+	//setup
+	bc := newTestBaseCollector(t)
+	registeredDevice := models.Device{DeviceName: "sda", ScrutinyUUID: uuid.Must(uuid.NewV4())}
+	filteredDevice := models.Device{DeviceName: "sdb", ScrutinyUUID: uuid.Must(uuid.NewV4())}
+	_, missingErr := bc.previouslyRegisteredDevices([]models.Device{registeredDevice, filteredDevice})
+	require.NoError(t, bc.spool.WriteRegistration([]byte(`[{"device_name": "sda", "scrutiny_uuid": "`+registeredDevice.ScrutinyUUID.String()+`"}]`)))
+
+	//test
+	devices, err := bc.previouslyRegisteredDevices([]models.Device{registeredDevice, filteredDevice})
+
+	//assert
+	require.Error(t, missingErr)
+	require.NoError(t, err)
+	require.Equal(t, []models.Device{registeredDevice}, devices)
+}
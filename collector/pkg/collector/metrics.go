@@ -1,8 +1,7 @@
 package collector
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
 	"net/url"
 	"os"
@@ -17,6 +16,7 @@ import (
 	"github.com/analogj/scrutiny/collector/pkg/models"
 	"github.com/gofrs/uuid/v5"
 	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
 )
 
 type MetricsCollector struct {
@@ -24,6 +24,9 @@ type MetricsCollector struct {
 	BaseCollector
 	apiEndpoint *url.URL
 	shell       shell.Interface
+
+	// used to determine which devices share a storage controller
+	deviceControllerId func(deviceName string) string
 }
 
 func CreateMetricsCollector(appConfig config.Interface, logger *logrus.Entry, apiEndpoint string) (MetricsCollector, error) {
@@ -36,79 +39,66 @@ func CreateMetricsCollector(appConfig config.Interface, logger *logrus.Entry, ap
 		config:      appConfig,
 		apiEndpoint: apiEndpointUrl,
 		BaseCollector: BaseCollector{
-			logger: logger,
+			logger:   logger,
+			apiToken: appConfig.GetString("api.token"),
+			spool:    createSpool(appConfig, logger),
 		},
-		shell: shell.Create(),
+		shell:              shell.Create(),
+		deviceControllerId: detect.DeviceControllerId,
 	}
 
 	return sc, nil
 }
 
 func (mc *MetricsCollector) Run() error {
-	err := mc.Validate()
-	if err != nil {
-		return err
-	}
-
-	apiEndpoint, _ := url.Parse(mc.apiEndpoint.String())
-	apiEndpoint, _ = apiEndpoint.Parse("api/devices/register") //this acts like filepath.Join()
-
-	deviceRespWrapper := new(models.DeviceWrapper)
+	return mc.RunContext(context.Background())
+}
 
-	deviceDetector := detect.Detect{
-		Logger: mc.logger,
-		Config: mc.config,
-	}
-	rawDetectedStorageDevices, err := deviceDetector.Start()
+// RunContext collects the metrics of every device. When the context is cancelled (eg. when the daemon is stopping) the
+// running smartctl processes are killed, and the remaining devices are skipped.
+func (mc *MetricsCollector) RunContext(ctx context.Context) error {
+	err := mc.Validate()
 	if err != nil {
 		return err
 	}
 
-	// Ignore any device without a Scrutiny UUID. This should never happen...
-	detectedStorageDevices := make([]models.Device, 0, len(rawDetectedStorageDevices))
-	for _, device := range rawDetectedStorageDevices {
-		if device.ScrutinyUUID.IsNil() {
-			mc.logger.Errorf("Device %s has no scrutiny UUID; skipping (no data association possible).", device.DeviceName)
-			mc.logger.Debugf("Raw detected device: model=%q serial=%q wwn=%q ScrutinyUUID=%s",
-				device.ModelName, device.SerialNumber, device.WWN, device.ScrutinyUUID)
-			continue
-		}
-		detectedStorageDevices = append(detectedStorageDevices, device)
-	}
+	// deliver the payloads from previous runs first, so they are stored in the order they were collected.
+	mc.replaySpool(mc.config, mc.apiEndpoint)
 
-	mc.logger.Infof("Sending %d/%d detected devices to API for filtering & validation",
-		len(detectedStorageDevices), len(rawDetectedStorageDevices))
-	jsonObj, _ := json.Marshal(detectedStorageDevices)
-	mc.logger.Debugf("Detected devices: %v", string(jsonObj))
-	err = mc.postJson(apiEndpoint.String(), models.DeviceWrapper{
-		Data: detectedStorageDevices,
-	}, &deviceRespWrapper)
+	devices, err := mc.registerDevices(mc.config, mc.apiEndpoint)
 	if err != nil {
 		return err
 	}
 
-	if !deviceRespWrapper.Success {
-		mc.logger.Errorln("An error occurred while retrieving filtered devices")
-		mc.logger.Debugln(deviceRespWrapper)
-		return errors.ApiServerCommunicationError("An error occurred while retrieving filtered devices")
-	} else {
-		mc.logger.Debugln(deviceRespWrapper)
-		//var wg sync.WaitGroup
-		for _, device := range deviceRespWrapper.Data {
-			// execute collection in parallel go-routines
-			//wg.Add(1)
-			//go mc.Collect(&wg, device.WWN, device.DeviceName, device.DeviceType)
-			mc.Collect(device.ScrutinyUUID, device.DeviceName, device.DeviceType)
-
-			if mc.config.GetInt("commands.metrics_smartctl_wait") > 0 {
-				time.Sleep(time.Duration(mc.config.GetInt("commands.metrics_smartctl_wait")) * time.Second)
+	// devices behind the same controller are collected sequentially, controllers are collected in parallel.
+	collectorGroup := new(errgroup.Group)
+	collectorGroup.SetLimit(max(mc.config.GetInt("commands.metrics_concurrency"), 1))
+	for _, deviceGroup := range mc.groupDevicesByController(devices) {
+		collectorGroup.Go(func() error {
+			for _, device := range deviceGroup {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				_ = mc.Collect(ctx, device.ScrutinyUUID, device.DeviceName, device.DeviceType)
+
+				if mc.config.GetInt("commands.metrics_smartctl_wait") > 0 {
+					select {
+					case <-ctx.Done():
+					case <-time.After(time.Duration(mc.config.GetInt("commands.metrics_smartctl_wait")) * time.Second):
+					}
+				}
 			}
-		}
+			return nil
+		})
+	}
 
-		//mc.logger.Infoln("Main: Waiting for workers to finish")
-		//wg.Wait()
-		mc.logger.Infoln("Main: Completed")
+	mc.logger.Infoln("Main: Waiting for workers to finish")
+	_ = collectorGroup.Wait()
+	if ctx.Err() != nil {
+		mc.logger.Warnln("Main: Cancelled, the remaining devices were not collected")
+		return ctx.Err()
 	}
+	mc.logger.Infoln("Main: Completed")
 
 	return nil
 }
@@ -124,14 +114,41 @@ func (mc *MetricsCollector) Validate() error {
 	return nil
 }
 
-// func (mc *MetricsCollector) Collect(wg *sync.WaitGroup, deviceWWN string, deviceName string, deviceType string) {
-func (mc *MetricsCollector) Collect(scrutiny_uuid uuid.UUID, deviceName string, deviceType string) {
-	//defer wg.Done()
+// groupDevicesByController groups devices that must not be queried at the same time.
+// Devices behind a RAID controller (eg. megaraid,N) share a single device file, and are always grouped together.
+// When `commands.metrics_controller_grouping` is enabled, devices attached to the same HBA are also grouped together.
+func (mc *MetricsCollector) groupDevicesByController(devices []models.Device) [][]models.Device {
+	groupKeys := []string{}
+	groups := map[string][]models.Device{}
+	for _, device := range devices {
+		groupKey := device.DeviceName
+		if mc.config.GetBool("commands.metrics_controller_grouping") {
+			if controllerId := mc.deviceControllerId(device.DeviceName); len(controllerId) > 0 {
+				groupKey = controllerId
+			}
+		}
+
+		if _, exists := groups[groupKey]; !exists {
+			groupKeys = append(groupKeys, groupKey)
+		}
+		groups[groupKey] = append(groups[groupKey], device)
+	}
+
+	deviceGroups := make([][]models.Device, 0, len(groupKeys))
+	for _, groupKey := range groupKeys {
+		deviceGroups = append(deviceGroups, groups[groupKey])
+	}
+	return deviceGroups
+}
+
+// Collect runs smartctl for the device, and publishes the results. The error is returned (after being logged) when the
+// results could not be collected or published.
+func (mc *MetricsCollector) Collect(ctx context.Context, scrutiny_uuid uuid.UUID, deviceName string, deviceType string) error {
 	// Run() filters out devices with nil ScrutinyUUIDs before calling Collect, so this should never
 	// happen; guarded here in case Collect is called from elsewhere in the future.
 	if scrutiny_uuid.IsNil() {
 		mc.logger.Errorf("Device %s has no scrutiny UUID; skipping collection (no data association possible).", deviceName)
-		return
+		return fmt.Errorf("device %s has no scrutiny UUID", deviceName)
 	}
 	mc.logger.Infof("Collecting smartctl results for %s\n", deviceName)
 
@@ -143,38 +160,45 @@ func (mc *MetricsCollector) Collect(scrutiny_uuid uuid.UUID, deviceName string,
 	}
 	args = append(args, fullDeviceName)
 
-	result, err := mc.shell.Command(mc.logger, mc.config.GetString("commands.metrics_smartctl_bin"), args, "", os.Environ())
+	if timeout := mc.config.GetInt("commands.metrics_smartctl_timeout"); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
+		defer cancel()
+	}
+
+	result, err := mc.shell.CommandContext(ctx, mc.logger, mc.config.GetString("commands.metrics_smartctl_bin"), args, "", os.Environ())
 	resultBytes := []byte(result)
 	if err != nil {
-		if exitError, ok := err.(*exec.ExitError); ok {
+		if ctx.Err() == context.Canceled {
+			mc.logger.Warnf("smartctl was killed while processing %s, the collector is stopping\n", deviceName)
+			return ctx.Err()
+		} else if ctx.Err() != nil {
+			// the smartctl process was killed, the output is incomplete.
+			mc.logger.Errorf("smartctl did not complete within %ds while processing %s, skipping\n", mc.config.GetInt("commands.metrics_smartctl_timeout"), deviceName)
+			return ctx.Err()
+		} else if exitError, ok := err.(*exec.ExitError); ok {
 			// smartctl command exited with an error, we should still push the data to the API server
 			mc.logger.Errorf("smartctl returned an error code (%d) while processing %s\n", exitError.ExitCode(), deviceName)
 			mc.LogSmartctlExitCode(exitError.ExitCode())
-			mc.Publish(scrutiny_uuid, resultBytes)
+			return mc.Publish(scrutiny_uuid, resultBytes)
 		} else {
 			mc.logger.Errorf("error while attempting to execute smartctl: %s\n", deviceName)
 			mc.logger.Errorf("ERROR MESSAGE: %v", err)
 			mc.logger.Errorf("IGNORING RESULT: %v", result)
+			return err
 		}
-		return
 	} else {
 		//successful run, pass the results directly to webapp backend for parsing and processing.
-		mc.Publish(scrutiny_uuid, resultBytes)
+		return mc.Publish(scrutiny_uuid, resultBytes)
 	}
 }
 
 func (mc *MetricsCollector) Publish(scrutinyUuid uuid.UUID, payload []byte) error {
 	mc.logger.Infof("Publishing smartctl results for %s\n", scrutinyUuid)
 
-	apiEndpoint, _ := url.Parse(mc.apiEndpoint.String())
-	apiEndpoint, _ = apiEndpoint.Parse(fmt.Sprintf("api/device/%s/smart", scrutinyUuid.String()))
-
-	resp, err := httpClient.Post(apiEndpoint.String(), "application/json", bytes.NewBuffer(payload))
+	err := mc.publish(mc.apiEndpoint, fmt.Sprintf("api/device/%s/smart", scrutinyUuid.String()), scrutinyUuid, payload)
 	if err != nil {
 		mc.logger.Errorf("An error occurred while publishing SMART data for device (%s): %v", scrutinyUuid, err)
-		return err
 	}
-	defer resp.Body.Close()
-
-	return nil
+	return err
 }
@@ -1,9 +1,19 @@
 package collector
 
 import (
-	"github.com/stretchr/testify/require"
+	"context"
+	"errors"
 	"net/url"
 	"testing"
+	"time"
+
+	mock_shell "github.com/analogj/scrutiny/collector/pkg/common/shell/mock"
+	mock_config "github.com/analogj/scrutiny/collector/pkg/config/mock"
+	"github.com/analogj/scrutiny/collector/pkg/models"
+	"github.com/gofrs/uuid/v5"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
 )
 
 func TestApiEndpointParse(t *testing.T) {
@@ -36,3 +46,99 @@ func TestApiEndpointParse_WithBasepathWithTrailingSlash(t *testing.T) {
 	url2, _ := baseURL.Parse("/d/e")
 	require.Equal(t, "http://localhost:8080/d/e", url2.String())
 }
+
+func TestGroupDevicesByController(t *testing.T) {
+	//setup
+	mockCtrl := gomock.NewController(t)
+	fakeConfig := mock_config.NewMockInterface(mockCtrl)
+	fakeConfig.EXPECT().GetBool("commands.metrics_controller_grouping").AnyTimes().Return(true)
+
+	mc := MetricsCollector{
+		config: fakeConfig,
+		deviceControllerId: func(deviceName string) string {
+			return map[string]string{
+				"sda":   "0000:01:00.0",
+				"sdb":   "0000:01:00.0",
+				"nvme0": "0000:3d:00.0",
+			}[deviceName]
+		},
+	}
+	devices := []models.Device{
+		{DeviceName: "sda", DeviceType: "scsi"},
+		{DeviceName: "bus/0", DeviceType: "megaraid,0"},
+		{DeviceName: "nvme0", DeviceType: "nvme"},
+		{DeviceName: "sdb", DeviceType: "scsi"},
+		{DeviceName: "bus/0", DeviceType: "megaraid,1"},
+	}
+
+	//test
+	groups := mc.groupDevicesByController(devices)
+
+	//assert
+	require.Equal(t, [][]models.Device{
+		{{DeviceName: "sda", DeviceType: "scsi"}, {DeviceName: "sdb", DeviceType: "scsi"}},
+		{{DeviceName: "bus/0", DeviceType: "megaraid,0"}, {DeviceName: "bus/0", DeviceType: "megaraid,1"}},
+		{{DeviceName: "nvme0", DeviceType: "nvme"}},
+	}, groups)
+}
+
+func TestGroupDevicesByController_GroupingDisabled(t *testing.T) {
+	//setup
+	mockCtrl := gomock.NewController(t)
+	fakeConfig := mock_config.NewMockInterface(mockCtrl)
+	fakeConfig.EXPECT().GetBool("commands.metrics_controller_grouping").AnyTimes().Return(false)
+
+	mc := MetricsCollector{
+		config: fakeConfig,
+		deviceControllerId: func(deviceName string) string {
+			return "0000:01:00.0"
+		},
+	}
+	devices := []models.Device{
+		{DeviceName: "sda", DeviceType: "scsi"},
+		{DeviceName: "sdb", DeviceType: "scsi"},
+		{DeviceName: "bus/0", DeviceType: "megaraid,0"},
+		{DeviceName: "bus/0", DeviceType: "megaraid,1"},
+	}
+
+	//test
+	groups := mc.groupDevicesByController(devices)
+
+	//assert
+	require.Equal(t, 3, len(groups))
+	require.Equal(t, 2, len(groups[2]))
+}
+
+func TestCollect_Timeout(t *testing.T) {
+	//setup
+	mockCtrl := gomock.NewController(t)
+	fakeConfig := mock_config.NewMockInterface(mockCtrl)
+	fakeConfig.EXPECT().GetCommandMetricsSmartArgs("/dev/sda").AnyTimes().Return("--xall --json")
+	fakeConfig.EXPECT().GetString("commands.metrics_smartctl_bin").AnyTimes().Return("smartctl")
+	fakeConfig.EXPECT().GetInt("commands.metrics_smartctl_timeout").AnyTimes().Return(1)
+
+	fakeShell := mock_shell.NewMockInterface(mockCtrl)
+	fakeShell.EXPECT().CommandContext(gomock.Any(), gomock.Any(), "smartctl", []string{"--xall", "--json", "/dev/sda"}, "", gomock.Any()).
+		DoAndReturn(func(ctx context.Context, logger *logrus.Entry, cmdName string, cmdArgs []string, workingDir string, environ []string) (string, error) {
+			_, hasDeadline := ctx.Deadline()
+			require.True(t, hasDeadline)
+			<-ctx.Done()
+			return "{", errors.New("signal: killed")
+		})
+
+	mc := MetricsCollector{
+		config:        fakeConfig,
+		BaseCollector: BaseCollector{logger: logrus.WithFields(logrus.Fields{})},
+		shell:         fakeShell,
+	}
+
+	//test (the incomplete smartctl output is not published, so no api endpoint is required)
+	startedAt := time.Now()
+	err := mc.Collect(context.Background(), uuid.Must(uuid.NewV4()), "sda", "scsi")
+
+	//assert
+	require.Error(t, err)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.GreaterOrEqual(t, time.Since(startedAt), time.Second)
+	require.Less(t, time.Since(startedAt), 5*time.Second)
+}
@@ -1,31 +1,267 @@
 package collector
 
 import (
-	"github.com/sirupsen/logrus"
+	"encoding/json"
+	"fmt"
 	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/analogj/scrutiny/collector/pkg/common/shell"
+	"github.com/analogj/scrutiny/collector/pkg/config"
+	"github.com/analogj/scrutiny/collector/pkg/detect"
+	"github.com/analogj/scrutiny/collector/pkg/errors"
+	"github.com/analogj/scrutiny/collector/pkg/models"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/models/collector"
+	"github.com/gofrs/uuid/v5"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	SelfTestTypeShort      = "short"
+	SelfTestTypeLong       = "long"
+	SelfTestTypeConveyance = "conveyance"
 )
 
+// self-tests are run in this order, a device can only run a single self-test at a time.
+var selfTestTypes = []string{SelfTestTypeShort, SelfTestTypeLong, SelfTestTypeConveyance}
+
+// used when smartctl does not report the recommended polling time for a self-test (eg. NVMe devices)
+const defaultSelfTestTimeout = 24 * time.Hour
+
 type SelfTestCollector struct {
+	config config.Interface
 	BaseCollector
-
 	apiEndpoint *url.URL
-	logger      *logrus.Entry
+	shell       shell.Interface
+}
+
+// selfTestRun tracks the progress of a self-test that has been started on a device
+type selfTestRun struct {
+	device   models.Device
+	testType string
+	deadline time.Time
 }
 
-func CreateSelfTestCollector(logger *logrus.Entry, apiEndpoint string) (SelfTestCollector, error) {
+func CreateSelfTestCollector(appConfig config.Interface, logger *logrus.Entry, apiEndpoint string) (SelfTestCollector, error) {
 	apiEndpointUrl, err := url.Parse(apiEndpoint)
 	if err != nil {
 		return SelfTestCollector{}, err
 	}
 
 	stc := SelfTestCollector{
+		config:      appConfig,
 		apiEndpoint: apiEndpointUrl,
-		logger:      logger,
+		BaseCollector: BaseCollector{
+			logger:   logger,
+			apiToken: appConfig.GetString("api.token"),
+			spool:    createSpool(appConfig, logger),
+		},
+		shell: shell.Create(),
 	}
 
 	return stc, nil
 }
 
 func (sc *SelfTestCollector) Run() error {
+	err := sc.Validate()
+	if err != nil {
+		return err
+	}
+
+	// deliver the payloads from previous runs first, so they are stored in the order they were collected.
+	sc.replaySpool(sc.config, sc.apiEndpoint)
+
+	devices, err := sc.registerDevices(sc.config, sc.apiEndpoint)
+	if err != nil {
+		return err
+	}
+
+	for _, testType := range selfTestTypes {
+		if !sc.config.GetBool(fmt.Sprintf("collect.%s.enable", testType)) {
+			continue
+		}
+
+		// self-tests run in the device firmware, so we start the test on every device before waiting for the results.
+		runs := []*selfTestRun{}
+		for _, device := range devices {
+			run, err := sc.StartSelfTest(device, testType)
+			if err != nil {
+				sc.logger.Errorf("Could not start %s self-test for %s: %v", testType, device.DeviceName, err)
+				continue
+			}
+			runs = append(runs, run)
+		}
+
+		sc.WaitForSelfTests(runs)
+	}
+
+	sc.logger.Infoln("Main: Completed")
+	return nil
+}
+
+func (sc *SelfTestCollector) Validate() error {
+	sc.logger.Infoln("Verifying required tools")
+	_, lookErr := exec.LookPath(sc.config.GetString("commands.metrics_smartctl_bin"))
+
+	if lookErr != nil {
+		return errors.DependencyMissingError(fmt.Sprintf("%s binary is missing", sc.config.GetString("commands.metrics_smartctl_bin")))
+	}
+
 	return nil
 }
+
+// StartSelfTest checks that the device supports the requested self-test, and that no other self-test is running, before
+// starting the test with `smartctl --test`
+func (sc *SelfTestCollector) StartSelfTest(device models.Device, testType string) (*selfTestRun, error) {
+	if device.ScrutinyUUID.IsNil() {
+		return nil, fmt.Errorf("device has no scrutiny UUID")
+	}
+
+	_, status, err := sc.SelfTestStatus(device)
+	if err != nil {
+		return nil, err
+	}
+
+	var pollingMinutes int
+	switch status.Device.Protocol {
+	case "ATA":
+		capabilities := status.AtaSmartData.Capabilities
+		if !capabilities.SelfTestsSupported {
+			return nil, fmt.Errorf("device does not support self-tests")
+		}
+		if testType == SelfTestTypeConveyance && !capabilities.ConveyanceSelfTestSupported {
+			return nil, fmt.Errorf("device does not support conveyance self-tests")
+		}
+		switch testType {
+		case SelfTestTypeShort:
+			pollingMinutes = status.AtaSmartData.SelfTest.PollingMinutes.Short
+		case SelfTestTypeLong:
+			pollingMinutes = status.AtaSmartData.SelfTest.PollingMinutes.Extended
+		case SelfTestTypeConveyance:
+			pollingMinutes = status.AtaSmartData.SelfTest.PollingMinutes.Conveyance
+		}
+	case "NVMe":
+		if testType == SelfTestTypeConveyance {
+			return nil, fmt.Errorf("NVMe devices do not support conveyance self-tests")
+		}
+	default:
+		return nil, fmt.Errorf("self-tests are not supported for %s devices", status.Device.Protocol)
+	}
+
+	if selfTestInProgress(status) {
+		return nil, fmt.Errorf("a self-test is already in progress")
+	}
+
+	sc.logger.Infof("Starting %s self-test for %s", testType, device.DeviceName)
+	_, err = sc.smartctl(device, sc.config.GetString(fmt.Sprintf("collect.%s.command", testType)))
+	if err != nil {
+		return nil, err
+	}
+
+	return &selfTestRun{
+		device:   device,
+		testType: testType,
+		deadline: time.Now().Add(sc.selfTestTimeout(pollingMinutes)),
+	}, nil
+}
+
+// WaitForSelfTests polls the status of each running self-test, and publishes the self-test log once the test completes.
+func (sc *SelfTestCollector) WaitForSelfTests(runs []*selfTestRun) {
+	pollWait := time.Duration(sc.config.GetInt("commands.selftest_poll_wait")) * time.Second
+
+	for len(runs) > 0 {
+		time.Sleep(pollWait)
+
+		pending := []*selfTestRun{}
+		for _, run := range runs {
+			rawStatus, status, err := sc.SelfTestStatus(run.device)
+			if err != nil {
+				sc.logger.Errorf("Could not retrieve %s self-test status for %s: %v", run.testType, run.device.DeviceName, err)
+			} else if !selfTestInProgress(status) {
+				sc.logger.Infof("%s self-test completed for %s", run.testType, run.device.DeviceName)
+				if err := sc.Publish(run.device.ScrutinyUUID, rawStatus); err != nil {
+					sc.logger.Errorf("Could not publish %s self-test results for %s: %v", run.testType, run.device.DeviceName, err)
+				}
+				continue
+			}
+
+			if time.Now().After(run.deadline) {
+				sc.logger.Errorf("Timed out waiting for %s self-test to complete for %s", run.testType, run.device.DeviceName)
+				continue
+			}
+			pending = append(pending, run)
+		}
+		runs = pending
+	}
+}
+
+// SelfTestStatus retrieves the self-test capabilities, progress & log for a device.
+// The raw smartctl output is returned so that it can be published to the API server.
+func (sc *SelfTestCollector) SelfTestStatus(device models.Device) ([]byte, collector.SmartInfo, error) {
+	var status collector.SmartInfo
+
+	result, err := sc.smartctl(device, sc.config.GetString("commands.selftest_args"))
+	if err != nil {
+		return nil, status, err
+	}
+
+	resultBytes := []byte(result)
+	err = json.Unmarshal(resultBytes, &status)
+	return resultBytes, status, err
+}
+
+func (sc *SelfTestCollector) Publish(scrutinyUuid uuid.UUID, payload []byte) error {
+	sc.logger.Infof("Publishing smartctl self-test results for %s\n", scrutinyUuid)
+
+	err := sc.publish(sc.apiEndpoint, fmt.Sprintf("api/device/%s/selftest", scrutinyUuid.String()), scrutinyUuid, payload)
+	if err != nil {
+		sc.logger.Errorf("An error occurred while publishing self-test data for device (%s): %v", scrutinyUuid, err)
+	}
+	return err
+}
+
+func (sc *SelfTestCollector) smartctl(device models.Device, argString string) (string, error) {
+	fullDeviceName := fmt.Sprintf("%s%s", detect.DevicePrefix(), device.DeviceName)
+	args := strings.Split(argString, " ")
+	//only include the device type if its a non-standard one. In some cases ata drives are detected as scsi in docker, and metadata is lost.
+	if len(device.DeviceType) > 0 && device.DeviceType != "scsi" && device.DeviceType != "ata" {
+		args = append(args, "--device", device.DeviceType)
+	}
+	args = append(args, fullDeviceName)
+
+	result, err := sc.shell.Command(sc.logger, sc.config.GetString("commands.metrics_smartctl_bin"), args, "", os.Environ())
+	if exitError, ok := err.(*exec.ExitError); ok {
+		// bits 0-2 of the smartctl exit code mean the command failed, bits 3-7 report the health of the device
+		sc.LogSmartctlExitCode(exitError.ExitCode())
+		if exitError.ExitCode()&0x07 == 0 {
+			return result, nil
+		}
+	}
+	return result, err
+}
+
+// selfTestTimeout returns the maximum amount of time to wait for a self-test to complete.
+func (sc *SelfTestCollector) selfTestTimeout(pollingMinutes int) time.Duration {
+	if timeout := sc.config.GetInt("commands.selftest_timeout"); timeout > 0 {
+		return time.Duration(timeout) * time.Minute
+	}
+	if pollingMinutes > 0 {
+		// the recommended polling time is an estimate, give the device some leeway.
+		return time.Duration(pollingMinutes*2+10) * time.Minute
+	}
+	return defaultSelfTestTimeout
+}
+
+func selfTestInProgress(status collector.SmartInfo) bool {
+	switch status.Device.Protocol {
+	case "ATA":
+		// the upper nibble of the self-test execution status is 0xF while a self-test is in progress
+		return status.AtaSmartData.SelfTest.Status.Value>>4 == 0xF
+	case "NVMe":
+		return status.NvmeSelfTestLog.CurrentSelfTestOperation.Value != 0
+	}
+	return false
+}
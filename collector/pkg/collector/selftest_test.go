@@ -0,0 +1,137 @@
+package collector
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	mock_shell "github.com/analogj/scrutiny/collector/pkg/common/shell/mock"
+	mock_config "github.com/analogj/scrutiny/collector/pkg/config/mock"
+	"github.com/analogj/scrutiny/collector/pkg/models"
+	"github.com/gofrs/uuid/v5"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func newTestSelfTestCollector(t *testing.T, apiEndpoint string, shellResults ...string) SelfTestCollector {
+	mockCtrl := gomock.NewController(t)
+	fakeConfig := mock_config.NewMockInterface(mockCtrl)
+	fakeConfig.EXPECT().GetString("commands.metrics_smartctl_bin").AnyTimes().Return("smartctl")
+	fakeConfig.EXPECT().GetString("commands.selftest_args").AnyTimes().Return("--capabilities --log=selftest --json")
+	fakeConfig.EXPECT().GetString("collect.short.command").AnyTimes().Return("--test=short --json")
+	fakeConfig.EXPECT().GetInt("commands.selftest_poll_wait").AnyTimes().Return(0)
+	fakeConfig.EXPECT().GetInt("commands.selftest_timeout").AnyTimes().Return(0)
+
+	fakeShell := mock_shell.NewMockInterface(mockCtrl)
+	calls := []any{}
+	for _, result := range shellResults {
+		calls = append(calls, fakeShell.EXPECT().Command(gomock.Any(), "smartctl", gomock.Any(), "", gomock.Any()).Return(result, nil))
+	}
+	gomock.InOrder(calls...)
+
+	apiEndpointUrl, _ := url.Parse(apiEndpoint)
+	return SelfTestCollector{
+		config:        fakeConfig,
+		apiEndpoint:   apiEndpointUrl,
+		BaseCollector: BaseCollector{logger: logrus.WithFields(logrus.Fields{})},
+		shell:         fakeShell,
+	}
+}
+
+func readTestData(t *testing.T, path string) string {
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestSelfTestCollector_StartSelfTest(t *testing.T) {
+	//setup
+	stc := newTestSelfTestCollector(t, "http://localhost:8080/",
+		readTestData(t, "testdata/smartctl_selftest_ata_completed.json"),
+		"",
+	)
+	device := models.Device{DeviceName: "sda", DeviceType: "sat", ScrutinyUUID: uuid.Must(uuid.NewV4())}
+
+	//test
+	run, err := stc.StartSelfTest(device, SelfTestTypeShort)
+
+	//assert
+	require.NoError(t, err)
+	require.Equal(t, SelfTestTypeShort, run.testType)
+	require.WithinDuration(t, time.Now().Add(14*time.Minute), run.deadline, time.Minute)
+}
+
+func TestSelfTestCollector_StartSelfTest_AlreadyInProgress(t *testing.T) {
+	//setup
+	stc := newTestSelfTestCollector(t, "http://localhost:8080/",
+		readTestData(t, "testdata/smartctl_selftest_ata_in_progress.json"),
+	)
+	device := models.Device{DeviceName: "sda", DeviceType: "sat", ScrutinyUUID: uuid.Must(uuid.NewV4())}
+
+	//test
+	_, err := stc.StartSelfTest(device, SelfTestTypeShort)
+
+	//assert
+	require.EqualError(t, err, "a self-test is already in progress")
+}
+
+func TestSelfTestCollector_StartSelfTest_NvmeConveyance(t *testing.T) {
+	//setup
+	stc := newTestSelfTestCollector(t, "http://localhost:8080/",
+		readTestData(t, "testdata/smartctl_selftest_nvme_completed.json"),
+	)
+	device := models.Device{DeviceName: "nvme0", DeviceType: "nvme", ScrutinyUUID: uuid.Must(uuid.NewV4())}
+
+	//test
+	_, err := stc.StartSelfTest(device, SelfTestTypeConveyance)
+
+	//assert
+	require.EqualError(t, err, "NVMe devices do not support conveyance self-tests")
+}
+
+func TestSelfTestCollector_WaitForSelfTests(t *testing.T) {
+	//setup
+	publishedPaths := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		publishedPaths = append(publishedPaths, r.URL.Path)
+		w.Write([]byte(`{"success": true}`))
+	}))
+	defer server.Close()
+
+	stc := newTestSelfTestCollector(t, server.URL+"/",
+		readTestData(t, "testdata/smartctl_selftest_ata_in_progress.json"),
+		readTestData(t, "testdata/smartctl_selftest_ata_completed.json"),
+	)
+	device := models.Device{DeviceName: "sda", DeviceType: "sat", ScrutinyUUID: uuid.Must(uuid.FromString("7c1a4d2e-8f3b-5a6c-9d0e-1f2a3b4c5d6e"))}
+
+	//test
+	stc.WaitForSelfTests([]*selfTestRun{{device: device, testType: SelfTestTypeShort, deadline: time.Now().Add(time.Hour)}})
+
+	//assert
+	require.Equal(t, []string{"/api/device/7c1a4d2e-8f3b-5a6c-9d0e-1f2a3b4c5d6e/selftest"}, publishedPaths)
+}
+
+func TestSelfTestCollector_WaitForSelfTests_Timeout(t *testing.T) {
+	//setup
+	publishedPaths := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		publishedPaths = append(publishedPaths, r.URL.Path)
+		w.Write([]byte(`{"success": true}`))
+	}))
+	defer server.Close()
+
+	stc := newTestSelfTestCollector(t, server.URL+"/",
+		readTestData(t, "testdata/smartctl_selftest_ata_in_progress.json"),
+	)
+	device := models.Device{DeviceName: "sda", DeviceType: "sat", ScrutinyUUID: uuid.Must(uuid.NewV4())}
+
+	//test (a deadline in the past means we give up after the first poll)
+	stc.WaitForSelfTests([]*selfTestRun{{device: device, testType: SelfTestTypeShort, deadline: time.Now().Add(-time.Minute)}})
+
+	//assert (the in-progress status is not published)
+	require.Empty(t, publishedPaths)
+}
@@ -1,6 +1,8 @@
 package shell
 
 import (
+	"context"
+
 	"github.com/sirupsen/logrus"
 )
 
@@ -8,4 +10,5 @@ import (
 // mockgen -source=collector/pkg/common/shell/interface.go -destination=collector/pkg/common/shell/mock/mock_shell.go
 type Interface interface {
 	Command(logger *logrus.Entry, cmdName string, cmdArgs []string, workingDir string, environ []string) (string, error)
+	CommandContext(ctx context.Context, logger *logrus.Entry, cmdName string, cmdArgs []string, workingDir string, environ []string) (string, error)
 }
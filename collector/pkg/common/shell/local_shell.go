@@ -2,6 +2,7 @@ package shell
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"io"
 	"os/exec"
@@ -14,9 +15,14 @@ import (
 type localShell struct{}
 
 func (s *localShell) Command(logger *logrus.Entry, cmdName string, cmdArgs []string, workingDir string, environ []string) (string, error) {
+	return s.CommandContext(context.Background(), logger, cmdName, cmdArgs, workingDir, environ)
+}
+
+// CommandContext executes a command, the command is killed if the context is cancelled or times out before it completes.
+func (s *localShell) CommandContext(ctx context.Context, logger *logrus.Entry, cmdName string, cmdArgs []string, workingDir string, environ []string) (string, error) {
 	logger.Infof("Executing command: %s %s", cmdName, strings.Join(cmdArgs, " "))
 
-	cmd := exec.Command(cmdName, cmdArgs...)
+	cmd := exec.CommandContext(ctx, cmdName, cmdArgs...)
 	var stdBuffer bytes.Buffer
 
 	logWriters := []io.Writer{
@@ -1,10 +1,12 @@
 package shell
 
 import (
+	"context"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/require"
 	"os/exec"
 	"testing"
+	"time"
 )
 
 func TestLocalShellCommand(t *testing.T) {
@@ -64,3 +66,19 @@ func TestLocalShellCommand_InvalidCommand(t *testing.T) {
 	_, castOk := err.(*exec.ExitError)
 	require.False(t, castOk)
 }
+
+func TestLocalShellCommandContext_Timeout(t *testing.T) {
+	t.Parallel()
+
+	//setup
+	testShell := localShell{}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	//test
+	_, err := testShell.CommandContext(ctx, logrus.WithField("exec", "test"), "sleep", []string{"5"}, "", nil)
+
+	//assert
+	require.Error(t, err)
+	require.ErrorIs(t, ctx.Err(), context.DeadlineExceeded)
+}
@@ -1,10 +1,16 @@
 // Code generated by MockGen. DO NOT EDIT.
 // Source: collector/pkg/common/shell/interface.go
+//
+// Generated by this command:
+//
+//	mockgen -source=collector/pkg/common/shell/interface.go -destination=collector/pkg/common/shell/mock/mock_shell.go
+//
 
 // Package mock_shell is a generated GoMock package.
 package mock_shell
 
 import (
+	context "context"
 	reflect "reflect"
 
 	logrus "github.com/sirupsen/logrus"
@@ -15,6 +21,7 @@ import (
 type MockInterface struct {
 	ctrl     *gomock.Controller
 	recorder *MockInterfaceMockRecorder
+	isgomock struct{}
 }
 
 // MockInterfaceMockRecorder is the mock recorder for MockInterface.
@@ -44,7 +51,22 @@ func (m *MockInterface) Command(logger *logrus.Entry, cmdName string, cmdArgs []
 }
 
 // Command indicates an expected call of Command.
-func (mr *MockInterfaceMockRecorder) Command(logger, cmdName, cmdArgs, workingDir, environ interface{}) *gomock.Call {
+func (mr *MockInterfaceMockRecorder) Command(logger, cmdName, cmdArgs, workingDir, environ any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Command", reflect.TypeOf((*MockInterface)(nil).Command), logger, cmdName, cmdArgs, workingDir, environ)
 }
+
+// CommandContext mocks base method.
+func (m *MockInterface) CommandContext(ctx context.Context, logger *logrus.Entry, cmdName string, cmdArgs []string, workingDir string, environ []string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CommandContext", ctx, logger, cmdName, cmdArgs, workingDir, environ)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CommandContext indicates an expected call of CommandContext.
+func (mr *MockInterfaceMockRecorder) CommandContext(ctx, logger, cmdName, cmdArgs, workingDir, environ any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CommandContext", reflect.TypeOf((*MockInterface)(nil).CommandContext), ctx, logger, cmdName, cmdArgs, workingDir, environ)
+}
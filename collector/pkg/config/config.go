@@ -43,19 +43,45 @@ func (c *configuration) Init() error {
 	c.SetDefault("log.file", "")
 
 	c.SetDefault("api.endpoint", "http://localhost:8080")
+	c.SetDefault("api.token", "")
 
 	c.SetDefault("commands.metrics_smartctl_bin", "smartctl")
 	c.SetDefault("commands.metrics_scan_args", "--scan --json")
 	c.SetDefault("commands.metrics_info_args", "--info --json")
 	c.SetDefault("commands.metrics_smart_args", "--xall --json")
 	c.SetDefault("commands.metrics_smartctl_wait", 0)
+	c.SetDefault("commands.metrics_smartctl_timeout", 0)
+	c.SetDefault("commands.metrics_concurrency", 1)
+	c.SetDefault("commands.metrics_controller_grouping", true)
+	c.SetDefault("commands.selftest_args", "--capabilities --log=selftest --json")
+	c.SetDefault("commands.selftest_poll_wait", 60)
+	c.SetDefault("commands.selftest_timeout", 0)
+
+	c.SetDefault("collect.short.enable", true)
+	c.SetDefault("collect.short.command", "--test=short --json")
+	c.SetDefault("collect.long.enable", false)
+	c.SetDefault("collect.long.command", "--test=long --json")
+	c.SetDefault("collect.conveyance.enable", false)
+	c.SetDefault("collect.conveyance.command", "--test=conveyance --json")
+
+	c.SetDefault("spool.enable", true)
+	c.SetDefault("spool.dir", "/opt/scrutiny/spool")
+	c.SetDefault("spool.max_size_mb", 50)
+	c.SetDefault("spool.max_age_days", 7)
+	c.SetDefault("spool.retry_attempts", 5)
+	c.SetDefault("spool.retry_backoff", 1)
+
+	c.SetDefault("daemon.schedule", "0 0 * * *")
+	c.SetDefault("daemon.run_on_startup", true)
+	c.SetDefault("daemon.status_file", "/opt/scrutiny/collector-status.json")
+	c.SetDefault("daemon.shutdown_timeout", 60)
 
 	//configure env variable parsing.
 	c.SetEnvPrefix("COLLECTOR")
 	c.SetEnvKeyReplacer(strings.NewReplacer("-", "_", ".", "_"))
 	c.AutomaticEnv()
-
-	//c.SetDefault("collect.short.command", "-a -o on -S on")
+	// COLLECTOR_CRON_SCHEDULE is used by the docker images to configure the cron schedule.
+	_ = c.BindEnv("daemon.schedule", "COLLECTOR_DAEMON_SCHEDULE", "COLLECTOR_CRON_SCHEDULE")
 
 	c.SetDefault("allow_listed_devices", []string{})
 
@@ -114,6 +140,7 @@ func (c *configuration) ValidateConfig() error {
 		"commands.metrics_scan_args":  c.GetString("commands.metrics_scan_args"),
 		"commands.metrics_info_args":  c.GetString("commands.metrics_info_args"),
 		"commands.metrics_smart_args": c.GetString("commands.metrics_smart_args"),
+		"commands.selftest_args":      c.GetString("commands.selftest_args"),
 	}
 
 	errorStrings := []string{}
@@ -0,0 +1,251 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/analogj/scrutiny/collector/pkg/config"
+	"github.com/robfig/cron/v3"
+	"github.com/sirupsen/logrus"
+)
+
+// LoadConfigFunc (re)loads the collector configuration. It is called on startup, and whenever the daemon receives a SIGHUP.
+type LoadConfigFunc func() (config.Interface, error)
+
+// RunFunc runs the collector once, using the current configuration. The context is cancelled when the running collector
+// doesn't complete within `daemon.shutdown_timeout` of the daemon stopping.
+type RunFunc func(ctx context.Context, appConfig config.Interface) error
+
+// Status is written to `daemon.status_file` whenever the daemon state changes, so that it can be used for health checks.
+type Status struct {
+	Pid                int        `json:"pid"`
+	StartedAt          time.Time  `json:"started_at"`
+	Schedule           string     `json:"schedule"`
+	NextRunAt          *time.Time `json:"next_run_at,omitempty"`
+	Running            bool       `json:"running"`
+	RunCount           int        `json:"run_count"`
+	LastRunStartedAt   *time.Time `json:"last_run_started_at,omitempty"`
+	LastRunCompletedAt *time.Time `json:"last_run_completed_at,omitempty"`
+	LastRunError       string     `json:"last_run_error,omitempty"`
+	LastSuccessAt      *time.Time `json:"last_success_at,omitempty"`
+}
+
+// Daemon runs the collector on a cron schedule, until it receives a SIGTERM or SIGINT.
+type Daemon struct {
+	logger     *logrus.Entry
+	loadConfig LoadConfigFunc
+	run        RunFunc
+
+	mutex    sync.Mutex
+	config   config.Interface
+	schedule cron.Schedule
+	cron     *cron.Cron
+	entryId  cron.EntryID
+	status   Status
+
+	// only a single collector run is allowed at a time, running collectors are waited for during shutdown.
+	runMutex  sync.Mutex
+	runs      sync.WaitGroup
+	runCtx    context.Context
+	runCancel context.CancelFunc
+}
+
+func New(logger *logrus.Entry, loadConfig LoadConfigFunc, run RunFunc) (*Daemon, error) {
+	appConfig, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	runCtx, runCancel := context.WithCancel(context.Background())
+	d := &Daemon{
+		runCtx:     runCtx,
+		runCancel:  runCancel,
+		logger:     logger,
+		loadConfig: loadConfig,
+		run:        run,
+		cron:       cron.New(),
+		status: Status{
+			Pid:       os.Getpid(),
+			StartedAt: time.Now(),
+		},
+	}
+	if err := d.applyConfig(appConfig); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Start schedules the collector, and blocks until a SIGTERM or SIGINT is received on the signals channel.
+// SIGHUP reloads the configuration.
+func (d *Daemon) Start(signals <-chan os.Signal) error {
+	d.cron.Start()
+	d.logger.Infof("Collector daemon started, schedule: %s", d.status.Schedule)
+
+	if d.config.GetBool("daemon.run_on_startup") {
+		d.runs.Add(1)
+		go func() {
+			defer d.runs.Done()
+			d.collect()
+		}()
+	}
+	d.writeStatus()
+
+	for sig := range signals {
+		switch sig {
+		case syscall.SIGHUP:
+			d.Reload()
+		case syscall.SIGTERM, os.Interrupt:
+			d.Stop()
+			return nil
+		}
+	}
+	d.Stop()
+	return nil
+}
+
+// Reload re-reads the configuration, and reschedules the collector if the schedule changed.
+// The current configuration is kept if the new configuration is invalid.
+func (d *Daemon) Reload() {
+	d.logger.Infoln("Reloading collector configuration")
+	appConfig, err := d.loadConfig()
+	if err != nil {
+		d.logger.Errorf("Could not reload configuration, keeping the current configuration: %v", err)
+		return
+	}
+	if err := d.applyConfig(appConfig); err != nil {
+		d.logger.Errorf("Could not reload configuration, keeping the current configuration: %v", err)
+		return
+	}
+	d.logger.Infof("Configuration reloaded, schedule: %s", d.status.Schedule)
+	d.writeStatus()
+}
+
+// Stop stops scheduling new runs, and waits for the running collector to complete. The running collector is cancelled
+// (killing smartctl) if it doesn't complete within `daemon.shutdown_timeout` seconds.
+func (d *Daemon) Stop() {
+	d.mutex.Lock()
+	shutdownTimeout := time.Duration(d.config.GetInt("daemon.shutdown_timeout")) * time.Second
+	d.mutex.Unlock()
+
+	d.logger.Infof("Stopping collector daemon, waiting up to %s for the running collector to complete", shutdownTimeout)
+	// waits for the scheduled & startup runs
+	cronDone := d.cron.Stop().Done()
+	runsDone := make(chan struct{})
+	go func() {
+		<-cronDone
+		d.runs.Wait()
+		close(runsDone)
+	}()
+	select {
+	case <-runsDone:
+	case <-time.After(shutdownTimeout):
+		d.logger.Warnln("The running collector did not complete before the shutdown timeout, cancelling it")
+		d.runCancel()
+		<-runsDone
+	}
+	d.runCancel()
+
+	d.mutex.Lock()
+	d.status.NextRunAt = nil
+	d.mutex.Unlock()
+	d.writeStatus()
+	d.logger.Infoln("Collector daemon stopped")
+}
+
+func (d *Daemon) applyConfig(appConfig config.Interface) error {
+	// docker-compose users commonly quote the cron schedule
+	spec := strings.Trim(strings.TrimSpace(appConfig.GetString("daemon.schedule")), `"'`)
+	schedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		return fmt.Errorf("invalid daemon.schedule %q: %w", spec, err)
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.entryId != 0 {
+		d.cron.Remove(d.entryId)
+	}
+	d.entryId = d.cron.Schedule(schedule, cron.FuncJob(d.collect))
+	d.config = appConfig
+	d.schedule = schedule
+	d.status.Schedule = spec
+	d.status.NextRunAt = timePtr(schedule.Next(time.Now()))
+	return nil
+}
+
+// collect runs the collector, unless a previous run is still in progress.
+func (d *Daemon) collect() {
+	if !d.runMutex.TryLock() {
+		d.logger.Warnln("Skipping scheduled run, the previous collector run is still in progress")
+		return
+	}
+	defer d.runMutex.Unlock()
+
+	d.mutex.Lock()
+	appConfig := d.config
+	d.status.Running = true
+	d.status.RunCount++
+	d.status.LastRunStartedAt = timePtr(time.Now())
+	d.mutex.Unlock()
+	d.writeStatus()
+
+	err := d.run(d.runCtx, appConfig)
+	if err != nil {
+		d.logger.Errorf("Collector run failed: %v", err)
+	}
+
+	d.mutex.Lock()
+	completedAt := time.Now()
+	d.status.Running = false
+	d.status.LastRunCompletedAt = timePtr(completedAt)
+	d.status.NextRunAt = timePtr(d.schedule.Next(completedAt))
+	if err != nil {
+		d.status.LastRunError = err.Error()
+	} else {
+		d.status.LastRunError = ""
+		d.status.LastSuccessAt = timePtr(completedAt)
+	}
+	d.mutex.Unlock()
+	d.writeStatus()
+}
+
+// Status returns a copy of the current daemon status.
+func (d *Daemon) Status() Status {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return d.status
+}
+
+func (d *Daemon) writeStatus() {
+	d.mutex.Lock()
+	statusFile := d.config.GetString("daemon.status_file")
+	statusData, err := json.MarshalIndent(d.status, "", "  ")
+	d.mutex.Unlock()
+
+	if len(statusFile) == 0 {
+		return
+	}
+	if err == nil {
+		// write to a temp file first, so that health checks never read a partially written status
+		tempFile := filepath.Join(filepath.Dir(statusFile), "."+filepath.Base(statusFile)+".tmp")
+		err = os.WriteFile(tempFile, statusData, 0644)
+		if err == nil {
+			err = os.Rename(tempFile, statusFile)
+		}
+	}
+	if err != nil {
+		d.logger.Warnf("Could not write daemon status file %s: %v", statusFile, err)
+	}
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
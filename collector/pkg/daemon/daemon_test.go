@@ -0,0 +1,138 @@
+package daemon_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/analogj/scrutiny/collector/pkg/config"
+	mock_config "github.com/analogj/scrutiny/collector/pkg/config/mock"
+	"github.com/analogj/scrutiny/collector/pkg/daemon"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func newTestConfig(t *testing.T, schedule string, statusFile string) config.Interface {
+	mockCtrl := gomock.NewController(t)
+	fakeConfig := mock_config.NewMockInterface(mockCtrl)
+	fakeConfig.EXPECT().GetString("daemon.schedule").AnyTimes().Return(schedule)
+	fakeConfig.EXPECT().GetString("daemon.status_file").AnyTimes().Return(statusFile)
+	fakeConfig.EXPECT().GetBool("daemon.run_on_startup").AnyTimes().Return(true)
+	fakeConfig.EXPECT().GetInt("daemon.shutdown_timeout").AnyTimes().Return(1)
+	return fakeConfig
+}
+
+func readStatus(t *testing.T, statusFile string) daemon.Status {
+	statusData, err := os.ReadFile(statusFile)
+	require.NoError(t, err)
+	var status daemon.Status
+	require.NoError(t, json.Unmarshal(statusData, &status))
+	return status
+}
+
+func TestNew_InvalidSchedule(t *testing.T) {
+	//setup
+	appConfig := newTestConfig(t, "every day", "")
+
+	//test
+	_, err := daemon.New(logrus.WithFields(logrus.Fields{}), func() (config.Interface, error) {
+		return appConfig, nil
+	}, func(ctx context.Context, appConfig config.Interface) error {
+		return nil
+	})
+
+	//assert
+	require.Error(t, err)
+}
+
+func TestDaemon_Start_RunOnStartup(t *testing.T) {
+	//setup
+	statusFile := filepath.Join(t.TempDir(), "status.json")
+	appConfig := newTestConfig(t, `"0 0 * * *"`, statusFile)
+	signals := make(chan os.Signal, 1)
+	runCount := 0
+	collectorDaemon, err := daemon.New(logrus.WithFields(logrus.Fields{}), func() (config.Interface, error) {
+		return appConfig, nil
+	}, func(ctx context.Context, appConfig config.Interface) error {
+		runCount++
+		// stop the daemon while the collector is running, the daemon should wait for the run to complete.
+		signals <- syscall.SIGTERM
+		return errors.New("api server unavailable")
+	})
+	require.NoError(t, err)
+
+	//test
+	err = collectorDaemon.Start(signals)
+
+	//assert
+	require.NoError(t, err)
+	require.Equal(t, 1, runCount)
+	status := readStatus(t, statusFile)
+	require.Equal(t, "0 0 * * *", status.Schedule)
+	require.False(t, status.Running)
+	require.Equal(t, 1, status.RunCount)
+	require.NotNil(t, status.LastRunCompletedAt)
+	require.Equal(t, "api server unavailable", status.LastRunError)
+	require.Nil(t, status.LastSuccessAt)
+	require.Nil(t, status.NextRunAt)
+}
+
+func TestDaemon_Start_ShutdownTimeout(t *testing.T) {
+	//setup
+	appConfig := newTestConfig(t, "0 0 * * *", "")
+	signals := make(chan os.Signal, 1)
+	var runErr error
+	collectorDaemon, err := daemon.New(logrus.WithFields(logrus.Fields{}), func() (config.Interface, error) {
+		return appConfig, nil
+	}, func(ctx context.Context, appConfig config.Interface) error {
+		// stop the daemon while the collector is stuck, the collector should be cancelled after the shutdown timeout.
+		signals <- syscall.SIGTERM
+		<-ctx.Done()
+		runErr = ctx.Err()
+		return runErr
+	})
+	require.NoError(t, err)
+
+	//test
+	startedAt := time.Now()
+	err = collectorDaemon.Start(signals)
+
+	//assert
+	require.NoError(t, err)
+	require.ErrorIs(t, runErr, context.Canceled)
+	require.GreaterOrEqual(t, time.Since(startedAt), time.Second)
+	require.Less(t, time.Since(startedAt), 5*time.Second)
+	require.False(t, collectorDaemon.Status().Running)
+}
+
+func TestDaemon_Reload(t *testing.T) {
+	//setup
+	configs := []config.Interface{
+		newTestConfig(t, "0 0 * * *", ""),
+		newTestConfig(t, "@hourly", ""),
+		newTestConfig(t, "not a schedule", ""),
+	}
+	loadCount := 0
+	collectorDaemon, err := daemon.New(logrus.WithFields(logrus.Fields{}), func() (config.Interface, error) {
+		loadCount++
+		return configs[loadCount-1], nil
+	}, func(ctx context.Context, appConfig config.Interface) error {
+		return nil
+	})
+	require.NoError(t, err)
+
+	//test
+	collectorDaemon.Reload()
+	reloadedSchedule := collectorDaemon.Status().Schedule
+	collectorDaemon.Reload()
+
+	//assert
+	require.Equal(t, "@hourly", reloadedSchedule)
+	require.Equal(t, "@hourly", collectorDaemon.Status().Schedule, "invalid configuration should be ignored")
+}
@@ -0,0 +1,108 @@
+package detect
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/analogj/scrutiny/collector/pkg/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeviceControllerId(t *testing.T) {
+	//setup
+	sysfsRoot := t.TempDir()
+	helperSysfsDevice(t, sysfsRoot, "block", "sda", "devices/pci0000:00/0000:00:01.0/0000:01:00.0/host0/port-0:0/end_device-0:0/target0:0:0/0:0:0:0/block/sda")
+	helperSysfsDevice(t, sysfsRoot, "nvme", "nvme0", "devices/pci0000:00/0000:00:1d.0/0000:3d:00.0/nvme/nvme0")
+
+	//test & assert
+	require.Equal(t, "0000:01:00.0", deviceControllerId(sysfsRoot, "sda"))
+	require.Equal(t, "0000:3d:00.0", deviceControllerId(sysfsRoot, "nvme0"))
+	require.Equal(t, "", deviceControllerId(sysfsRoot, "bus/0"))
+}
+
+func TestPopulateSysfsTopologyInfo(t *testing.T) {
+	//setup
+	sysfsRoot := t.TempDir()
+	scsiDevicePath := "devices/pci0000:00/0000:00:01.0/0000:01:00.0/host0/port-0:0/end_device-0:0/target0:0:0/0:0:0:0"
+	helperSysfsDevice(t, sysfsRoot, "block", "sda", scsiDevicePath+"/block/sda")
+	require.NoError(t, os.Symlink(filepath.Join(sysfsRoot, scsiDevicePath), filepath.Join(sysfsRoot, scsiDevicePath, "block", "sda", "device")))
+	helperSysfsDevice(t, sysfsRoot, "block", "sdb", "devices/pci0000:00/0000:00:1f.2/ata1/host1/target1:0:0/1:0:0:0/block/sdb")
+	legacyScsiDevicePath := "devices/pci0000:00/0000:00:02.0/0000:02:00.0/host2/target2:0:1/2:0:1:0"
+	helperSysfsDevice(t, sysfsRoot, "block", "sdc", legacyScsiDevicePath+"/block/sdc")
+	require.NoError(t, os.Symlink(filepath.Join(sysfsRoot, legacyScsiDevicePath), filepath.Join(sysfsRoot, legacyScsiDevicePath, "block", "sdc", "device")))
+
+	// SES enclosure with the device in the second slot
+	enclosurePath := filepath.Join(sysfsRoot, "class", "enclosure", "0:0:8:0")
+	require.NoError(t, os.MkdirAll(filepath.Join(enclosurePath, "Slot 01"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(enclosurePath, "Slot 02"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(enclosurePath, "id"), []byte("0x500056b36789abff\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(enclosurePath, "Slot 02", "slot"), []byte("2\n"), 0644))
+	require.NoError(t, os.Symlink(filepath.Join(sysfsRoot, scsiDevicePath), filepath.Join(enclosurePath, "Slot 02", "device")))
+
+	// SES enclosure without a logical identifier (older kernels)
+	legacyEnclosurePath := filepath.Join(sysfsRoot, "class", "enclosure", "2:0:9:0")
+	require.NoError(t, os.MkdirAll(filepath.Join(legacyEnclosurePath, "Disk001"), 0755))
+	require.NoError(t, os.Symlink(filepath.Join(sysfsRoot, legacyScsiDevicePath), filepath.Join(legacyEnclosurePath, "Disk001", "device")))
+
+	sesDevice := models.Device{DeviceName: "sda"}
+	ahciDevice := models.Device{DeviceName: "sdb"}
+	legacySesDevice := models.Device{DeviceName: "sdc"}
+
+	//test
+	populateSysfsTopologyInfo(sysfsRoot, &sesDevice)
+	populateSysfsTopologyInfo(sysfsRoot, &ahciDevice)
+	populateSysfsTopologyInfo(sysfsRoot, &legacySesDevice)
+
+	//assert
+	require.Equal(t, "0000:01:00.0", sesDevice.ControllerPciAddress)
+	require.Equal(t, "0x500056b36789abff", sesDevice.EnclosureId)
+	require.Equal(t, "2", sesDevice.EnclosureSlot)
+
+	require.Equal(t, "2:0:9:0", legacySesDevice.EnclosureId)
+	require.Equal(t, "Disk001", legacySesDevice.EnclosureSlot)
+
+	require.Equal(t, "0000:00:1f.2", ahciDevice.ControllerPciAddress)
+	require.Empty(t, ahciDevice.EnclosureId)
+	require.Empty(t, ahciDevice.EnclosureSlot)
+}
+
+func TestPopulateSysfsUdevInfo(t *testing.T) {
+	//setup
+	sysfsRoot := t.TempDir()
+	udevDataRoot := t.TempDir()
+	helperSysfsDevice(t, sysfsRoot, "block", "sda", "devices/pci0000:00/0000:00:1f.2/ata1/host1/target1:0:0/1:0:0:0/block/sda")
+	require.NoError(t, os.WriteFile(filepath.Join(sysfsRoot, "class", "block", "sda", "dev"), []byte("8:0\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(udevDataRoot, "b8:0"), []byte("S:disk/by-id/ata-WDC_WD140EDGZ\nS:disk/by-path/pci-0000:00:1f.2-ata-1\nE:ID_BUS=ata\nE:ID_SERIAL=WDC_WD140EDGZ\n"), 0644))
+
+	// the NVMe controller is a character device, the udev info is read from its namespace block device
+	nvmeControllerPath := "devices/pci0000:00/0000:00:1d.0/0000:3d:00.0/nvme/nvme0"
+	helperSysfsDevice(t, sysfsRoot, "nvme", "nvme0", nvmeControllerPath)
+	helperSysfsDevice(t, sysfsRoot, "block", "nvme0n1", nvmeControllerPath+"/nvme0n1")
+	require.NoError(t, os.WriteFile(filepath.Join(sysfsRoot, "class", "block", "nvme0n1", "dev"), []byte("259:0\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(udevDataRoot, "b259:0"), []byte("S:disk/by-path/pci-0000:3d:00.0-nvme-1\nE:ID_BUS=nvme\n"), 0644))
+
+	ataDevice := models.Device{DeviceName: "sda"}
+	nvmeDevice := models.Device{DeviceName: "nvme0"}
+	missingDevice := models.Device{DeviceName: "nvme1"}
+
+	//test
+	ataErr := populateSysfsUdevInfo(sysfsRoot, udevDataRoot, &ataDevice)
+	nvmeErr := populateSysfsUdevInfo(sysfsRoot, udevDataRoot, &nvmeDevice)
+	missingErr := populateSysfsUdevInfo(sysfsRoot, udevDataRoot, &missingDevice)
+
+	//assert
+	require.NoError(t, ataErr)
+	require.Equal(t, "/dev/disk/by-path/pci-0000:00:1f.2-ata-1", ataDevice.DeviceByPath)
+	require.Equal(t, "ata-WDC_WD140EDGZ", ataDevice.DeviceSerialID)
+	require.NoError(t, nvmeErr)
+	require.Equal(t, "/dev/disk/by-path/pci-0000:3d:00.0-nvme-1", nvmeDevice.DeviceByPath)
+	require.Error(t, missingErr)
+}
+
+func helperSysfsDevice(t *testing.T, sysfsRoot string, deviceClass string, deviceName string, devicePath string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Join(sysfsRoot, devicePath), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(sysfsRoot, "class", deviceClass), 0755))
+	require.NoError(t, os.Symlink(filepath.Join(sysfsRoot, devicePath), filepath.Join(sysfsRoot, "class", deviceClass, deviceName)))
+}
@@ -90,6 +90,12 @@ func (d *Detect) findMissingDevices(detectedDevices []models.Device) ([]models.D
 	return missingDevices, nil
 }
 
+// DeviceControllerId returns the storage controller the device is attached to.
+// Not supported on this OS, devices are only grouped by device name.
+func DeviceControllerId(deviceName string) string {
+	return ""
+}
+
 // WWN values NVMe and SCSI
 func (d *Detect) wwnFallback(detectedDevice *models.Device) {
 	block, err := ghw.Block()
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/analogj/scrutiny/collector/pkg/common/shell"
@@ -27,11 +29,41 @@ func (d *Detect) Start() ([]models.Device, error) {
 	for ndx := range detectedDevices {
 		d.SmartCtlInfo(&detectedDevices[ndx])   //ignore errors.
 		populateUdevInfo(&detectedDevices[ndx]) //ignore errors.
+		populateTopologyInfo(&detectedDevices[ndx])
 	}
 
 	return detectedDevices, nil
 }
 
+// PCI addresses in sysfs device paths look like "0000:00:1f.2" (domain:bus:device.function)
+var pciAddressPattern = regexp.MustCompile(`^[0-9a-f]{4}:[0-9a-f]{2}:[0-9a-f]{2}\.[0-7]$`)
+
+// DeviceControllerId returns the PCI address of the storage controller (HBA, AHCI or NVMe controller) the device is
+// attached to. An empty string is returned if the controller cannot be determined (eg. devices behind a RAID controller).
+func DeviceControllerId(deviceName string) string {
+	return deviceControllerId("/sys", deviceName)
+}
+
+func deviceControllerId(sysfsRoot string, deviceName string) string {
+	// block devices (sda) are listed in /sys/class/block, NVMe controllers (nvme0) are listed in /sys/class/nvme
+	for _, deviceClass := range []string{"block", "nvme"} {
+		devicePath, err := filepath.EvalSymlinks(filepath.Join(sysfsRoot, "class", deviceClass, deviceName))
+		if err != nil {
+			continue
+		}
+
+		// the device path is nested under every PCI bridge between the CPU and the device, the controller is the last one.
+		controllerId := ""
+		for _, pathComponent := range strings.Split(devicePath, string(filepath.Separator)) {
+			if pciAddressPattern.MatchString(pathComponent) {
+				controllerId = pathComponent
+			}
+		}
+		return controllerId
+	}
+	return ""
+}
+
 // WWN values NVMe and SCSI
 func (d *Detect) wwnFallback(detectedDevice *models.Device) {
 	block, err := ghw.Block()
@@ -54,9 +86,13 @@ func (d *Detect) wwnFallback(detectedDevice *models.Device) {
 // - https://github.com/jaypipes/ghw/issues/59#issue-361915216
 // udev exposes its data in a standardized way under /run/udev/data/....
 func populateUdevInfo(detectedDevice *models.Device) error {
+	return populateSysfsUdevInfo("/sys", "/run/udev/data", detectedDevice)
+}
+
+func populateSysfsUdevInfo(sysfsRoot string, udevDataRoot string, detectedDevice *models.Device) error {
 	// Get device major:minor numbers
 	// `cat /sys/class/block/sda/dev`
-	devNo, err := os.ReadFile(filepath.Join("/sys/class/block/", detectedDevice.DeviceName, "dev"))
+	devNo, err := os.ReadFile(filepath.Join(sysfsRoot, "class", "block", udevBlockDeviceName(sysfsRoot, detectedDevice.DeviceName), "dev"))
 	if err != nil {
 		return err
 	}
@@ -64,7 +100,7 @@ func populateUdevInfo(detectedDevice *models.Device) error {
 	// Look up block device in udev runtime database
 	// `cat /run/udev/data/b8:0`
 	udevID := "b" + strings.TrimSpace(string(devNo))
-	udevBytes, err := os.ReadFile(filepath.Join("/run/udev/data/", udevID))
+	udevBytes, err := os.ReadFile(filepath.Join(udevDataRoot, udevID))
 	if err != nil {
 		return err
 	}
@@ -92,5 +128,77 @@ func populateUdevInfo(detectedDevice *models.Device) error {
 		detectedDevice.DeviceSerialID = fmt.Sprintf("%s-%s", udevInfo["ID_BUS"], deviceSerialID)
 	}
 
+	// persistent symlinks, eg. disk/by-path/pci-0000:00:1f.2-ata-1
+	for _, deviceMountPath := range deviceMountPaths {
+		if strings.HasPrefix(deviceMountPath, "disk/by-path/") {
+			detectedDevice.DeviceByPath = filepath.Join("/dev", deviceMountPath)
+			break
+		}
+	}
+
 	return nil
 }
+
+// udevBlockDeviceName returns the block device used to look up the udev info. smartctl scans NVMe controllers (nvme0),
+// which are character devices without udev disk info (eg. by-path symlinks), so the first namespace block device of the
+// controller (nvme0n1, listed in /sys/class/nvme/nvme0/) is used instead.
+func udevBlockDeviceName(sysfsRoot string, deviceName string) string {
+	if _, err := os.Stat(filepath.Join(sysfsRoot, "class", "block", deviceName)); err == nil {
+		return deviceName
+	}
+	namespacePaths, err := filepath.Glob(filepath.Join(sysfsRoot, "class", "nvme", deviceName, deviceName+"n*"))
+	if err != nil || len(namespacePaths) == 0 {
+		return deviceName
+	}
+	sort.Strings(namespacePaths)
+	return filepath.Base(namespacePaths[0])
+}
+
+// populateTopologyInfo sets the physical location of the device: the storage controller, and the SES enclosure slot
+// (only available for disks in a SES capable backplane or JBOD).
+func populateTopologyInfo(detectedDevice *models.Device) {
+	populateSysfsTopologyInfo("/sys", detectedDevice)
+}
+
+func populateSysfsTopologyInfo(sysfsRoot string, detectedDevice *models.Device) {
+	detectedDevice.ControllerPciAddress = deviceControllerId(sysfsRoot, detectedDevice.DeviceName)
+	detectedDevice.EnclosureId, detectedDevice.EnclosureSlot = deviceEnclosureSlot(sysfsRoot, detectedDevice.DeviceName)
+}
+
+// deviceEnclosureSlot finds the SES enclosure component linked to the device. Each enclosure is listed in
+// /sys/class/enclosure/<enclosure>/, with a directory for each slot containing a `device` symlink to the installed
+// SCSI device (the same device as /sys/class/block/<device name>/device). The enclosure is identified by its SES logical
+// identifier (/sys/class/enclosure/<enclosure>/id), which unlike the <enclosure> SCSI address (H:C:T:L) doesn't change
+// when the enclosure is connected to another port or the SCSI hosts are renumbered after a reboot.
+func deviceEnclosureSlot(sysfsRoot string, deviceName string) (string, string) {
+	scsiDevicePath, err := filepath.EvalSymlinks(filepath.Join(sysfsRoot, "class", "block", deviceName, "device"))
+	if err != nil {
+		return "", ""
+	}
+
+	slotDevicePaths, err := filepath.Glob(filepath.Join(sysfsRoot, "class", "enclosure", "*", "*", "device"))
+	if err != nil {
+		return "", ""
+	}
+	for _, slotDevicePath := range slotDevicePaths {
+		resolvedPath, err := filepath.EvalSymlinks(slotDevicePath)
+		if err != nil || resolvedPath != scsiDevicePath {
+			continue
+		}
+
+		slotPath := filepath.Dir(slotDevicePath)
+		enclosurePath := filepath.Dir(slotPath)
+		// older kernels don't expose the logical identifier, fallback to the SCSI address
+		enclosureId := filepath.Base(enclosurePath)
+		if logicalId, err := os.ReadFile(filepath.Join(enclosurePath, "id")); err == nil && len(strings.TrimSpace(string(logicalId))) > 0 {
+			enclosureId = strings.TrimSpace(string(logicalId))
+		}
+		// the component name is vendor specific (eg. "Slot 01", "Disk001" or "1"), prefer the slot number when available
+		slot := filepath.Base(slotPath)
+		if slotNumber, err := os.ReadFile(filepath.Join(slotPath, "slot")); err == nil && len(strings.TrimSpace(string(slotNumber))) > 0 {
+			slot = strings.TrimSpace(string(slotNumber))
+		}
+		return enclosureId, slot
+	}
+	return "", ""
+}
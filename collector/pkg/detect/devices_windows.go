@@ -25,6 +25,12 @@ func (d *Detect) Start() ([]models.Device, error) {
 	return detectedDevices, nil
 }
 
+// DeviceControllerId returns the storage controller the device is attached to.
+// Not supported on this OS, devices are only grouped by device name.
+func DeviceControllerId(deviceName string) string {
+	return ""
+}
+
 // WWN values NVMe and SCSI
 func (d *Detect) wwnFallback(detectedDevice *models.Device) {
 	// No fallback on windows
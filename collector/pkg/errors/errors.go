@@ -31,3 +31,10 @@ type ApiServerCommunicationError string
 func (str ApiServerCommunicationError) Error() string {
 	return fmt.Sprintf("ApiServerCommunicationError: %q", string(str))
 }
+
+// Raised when the API server responds with an error status code
+type ApiServerResponseError int
+
+func (code ApiServerResponseError) Error() string {
+	return fmt.Sprintf("ApiServerResponseError: %d", int(code))
+}
@@ -13,6 +13,12 @@ type Device struct {
 	DeviceSerialID string `json:"device_serial_id"`
 	DeviceLabel    string `json:"device_label"`
 
+	// Physical location of the device, used to find the bay to pull
+	DeviceByPath         string `json:"device_by_path"`         // eg. /dev/disk/by-path/pci-0000:00:1f.2-ata-1
+	EnclosureId          string `json:"enclosure_id"`           // SES enclosure logical identifier (eg. 0x500056b36789abff), or SCSI address (eg. 0:0:8:0)
+	EnclosureSlot        string `json:"enclosure_slot"`         // slot within the SES enclosure
+	ControllerPciAddress string `json:"controller_pci_address"` // eg. 0000:00:1f.2
+
 	Manufacturer   string `json:"manufacturer"`
 	ModelName      string `json:"model_name"`
 	InterfaceType  string `json:"interface_type"`
@@ -0,0 +1,245 @@
+package spool
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/sirupsen/logrus"
+)
+
+// Entry is a payload that could not be delivered to the API server.
+// The payload is stored unmodified, so smartctl results keep their original `local_time`.
+type Entry struct {
+	CreatedAt    time.Time       `json:"created_at"`
+	ScrutinyUUID uuid.UUID       `json:"scrutiny_uuid"`
+	Endpoint     string          `json:"endpoint"` // relative to the API endpoint, eg. api/device/<uuid>/smart
+	Payload      json.RawMessage `json:"payload"`
+
+	filePath string
+}
+
+// spoolFile is a spooled entry, as listed in the spool directory. Listing the entries does not read their payloads.
+type spoolFile struct {
+	name      string
+	createdAt time.Time
+	size      int64
+}
+
+// Spool stores undelivered payloads on disk, one file per payload. File names are prefixed with the creation timestamp,
+// so that entries can be replayed in the order they were created.
+type Spool struct {
+	dir          string
+	maxSizeBytes int64
+	maxAge       time.Duration
+	logger       *logrus.Entry
+
+	// payloads are spooled by concurrent collector workers
+	mutex sync.Mutex
+}
+
+// New creates a spool in the specified directory. A maxSizeBytes or maxAge of 0 disables the corresponding limit.
+func New(dir string, maxSizeBytes int64, maxAge time.Duration, logger *logrus.Entry) (*Spool, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("could not create spool directory %s: %w", dir, err)
+	}
+
+	return &Spool{
+		dir:          dir,
+		maxSizeBytes: maxSizeBytes,
+		maxAge:       maxAge,
+		logger:       logger,
+	}, nil
+}
+
+func (s *Spool) Write(endpoint string, scrutinyUuid uuid.UUID, payload []byte) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	entry := Entry{
+		CreatedAt:    time.Now(),
+		ScrutinyUUID: scrutinyUuid,
+		Endpoint:     endpoint,
+		Payload:      payload,
+	}
+	if !json.Valid(payload) {
+		return fmt.Errorf("payload for %s is not valid json", endpoint)
+	}
+
+	entryBytes, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	// write to a temp file first, so that a partially written entry is never replayed
+	fileName := fmt.Sprintf("%020d-%s.json", entry.CreatedAt.UnixNano(), scrutinyUuid.String())
+	tempFilePath := filepath.Join(s.dir, "."+fileName+".tmp")
+	if err := os.WriteFile(tempFilePath, entryBytes, 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tempFilePath, filepath.Join(s.dir, fileName)); err != nil {
+		return err
+	}
+	s.logger.Infof("Spooled payload for %s (%d bytes)", endpoint, len(entryBytes))
+
+	return s.prune()
+}
+
+// Entries returns all spooled entries, oldest first.
+func (s *Spool) Entries() ([]Entry, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.entries()
+}
+
+func (s *Spool) entries() ([]Entry, error) {
+	files, err := s.files()
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []Entry{}
+	for _, file := range files {
+		filePath := filepath.Join(s.dir, file.name)
+		entryBytes, err := os.ReadFile(filePath)
+		if os.IsNotExist(err) {
+			// removed by a concurrent Prune
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		var entry Entry
+		if err := json.Unmarshal(entryBytes, &entry); err != nil {
+			s.logger.Warnf("Removing corrupt spool entry %s: %v", file.name, err)
+			_ = os.Remove(filePath)
+			continue
+		}
+		entry.filePath = filePath
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// files lists the spooled entries, oldest first, with their size and creation time (from the file name).
+func (s *Spool) files() ([]spoolFile, error) {
+	dirEntries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []spoolFile{}
+	for _, dirEntry := range dirEntries {
+		if dirEntry.IsDir() || strings.HasPrefix(dirEntry.Name(), ".") || filepath.Ext(dirEntry.Name()) != ".json" {
+			continue
+		}
+		info, err := dirEntry.Info()
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		file := spoolFile{name: dirEntry.Name(), createdAt: info.ModTime(), size: info.Size()}
+		timestamp, _, _ := strings.Cut(file.name, "-")
+		if unixNano, err := strconv.ParseInt(timestamp, 10, 64); err == nil {
+			file.createdAt = time.Unix(0, unixNano)
+		}
+		files = append(files, file)
+	}
+
+	// file names start with a zero-padded timestamp, so lexical order is creation order
+	sort.Slice(files, func(i, j int) bool { return files[i].name < files[j].name })
+	return files, nil
+}
+
+func (s *Spool) Remove(entry Entry) error {
+	err := os.Remove(entry.filePath)
+	if os.IsNotExist(err) {
+		// already removed by Prune
+		return nil
+	}
+	return err
+}
+
+// registrationFileName stores the last successful device registration response. The extension is not .json, so it is
+// never replayed as an entry.
+const registrationFileName = "registered_devices.cache"
+
+// WriteRegistration stores the last successful device registration response, used when the API server is unavailable.
+func (s *Spool) WriteRegistration(payload []byte) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	tempFilePath := filepath.Join(s.dir, "."+registrationFileName+".tmp")
+	if err := os.WriteFile(tempFilePath, payload, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tempFilePath, filepath.Join(s.dir, registrationFileName))
+}
+
+// ReadRegistration returns the last successful device registration response, see WriteRegistration.
+func (s *Spool) ReadRegistration() ([]byte, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return os.ReadFile(filepath.Join(s.dir, registrationFileName))
+}
+
+// Prune removes entries older than the max age, and then the oldest entries until the spool is smaller than the max size.
+func (s *Spool) Prune() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.prune()
+}
+
+// prune only lists the spool directory, entries are not read. It runs after every Write.
+func (s *Spool) prune() error {
+	files, err := s.files()
+	if err != nil {
+		return err
+	}
+
+	totalSize := int64(0)
+	retained := []spoolFile{}
+	for _, file := range files {
+		if s.maxAge > 0 && time.Since(file.createdAt) > s.maxAge {
+			s.logger.Warnf("Discarding spooled payload %s, older than %s", file.name, s.maxAge)
+			if err := s.removeFile(file); err != nil {
+				return err
+			}
+			continue
+		}
+		totalSize += file.size
+		retained = append(retained, file)
+	}
+
+	for _, file := range retained {
+		if s.maxSizeBytes <= 0 || totalSize <= s.maxSizeBytes {
+			break
+		}
+		s.logger.Warnf("Discarding spooled payload %s, spool is larger than %d bytes", file.name, s.maxSizeBytes)
+		if err := s.removeFile(file); err != nil {
+			return err
+		}
+		totalSize -= file.size
+	}
+	return nil
+}
+
+func (s *Spool) removeFile(file spoolFile) error {
+	err := os.Remove(filepath.Join(s.dir, file.name))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
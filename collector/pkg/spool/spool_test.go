@@ -0,0 +1,142 @@
+package spool_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/analogj/scrutiny/collector/pkg/spool"
+	"github.com/gofrs/uuid/v5"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpool_WriteEntries(t *testing.T) {
+	//setup
+	payloadSpool, err := spool.New(t.TempDir(), 0, 0, logrus.WithFields(logrus.Fields{}))
+	require.NoError(t, err)
+	firstUuid := uuid.Must(uuid.NewV4())
+	secondUuid := uuid.Must(uuid.NewV4())
+
+	//test
+	require.NoError(t, payloadSpool.Write("api/device/first/smart", firstUuid, []byte(`{"local_time": {"time_t": 1700000000}}`)))
+	require.NoError(t, payloadSpool.Write("api/device/second/smart", secondUuid, []byte(`{"local_time": {"time_t": 1700000060}}`)))
+	entries, err := payloadSpool.Entries()
+
+	//assert
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	require.Equal(t, "api/device/first/smart", entries[0].Endpoint)
+	require.Equal(t, firstUuid, entries[0].ScrutinyUUID)
+	require.JSONEq(t, `{"local_time": {"time_t": 1700000000}}`, string(entries[0].Payload))
+	require.Equal(t, "api/device/second/smart", entries[1].Endpoint)
+	require.Equal(t, secondUuid, entries[1].ScrutinyUUID)
+}
+
+func TestSpool_Write_InvalidPayload(t *testing.T) {
+	//setup
+	payloadSpool, err := spool.New(t.TempDir(), 0, 0, logrus.WithFields(logrus.Fields{}))
+	require.NoError(t, err)
+
+	//test
+	err = payloadSpool.Write("api/device/first/smart", uuid.Must(uuid.NewV4()), []byte(`smartctl output`))
+
+	//assert
+	require.Error(t, err)
+}
+
+func TestSpool_Remove(t *testing.T) {
+	//setup
+	payloadSpool, err := spool.New(t.TempDir(), 0, 0, logrus.WithFields(logrus.Fields{}))
+	require.NoError(t, err)
+	require.NoError(t, payloadSpool.Write("api/device/first/smart", uuid.Must(uuid.NewV4()), []byte(`{}`)))
+	entries, err := payloadSpool.Entries()
+	require.NoError(t, err)
+
+	//test
+	require.NoError(t, payloadSpool.Remove(entries[0]))
+	entries, err = payloadSpool.Entries()
+
+	//assert
+	require.NoError(t, err)
+	require.Empty(t, entries)
+}
+
+func TestSpool_Prune_MaxSize(t *testing.T) {
+	//setup
+	dir := t.TempDir()
+	unlimitedSpool, err := spool.New(dir, 0, 0, logrus.WithFields(logrus.Fields{}))
+	require.NoError(t, err)
+	for _, endpoint := range []string{"api/device/first/smart", "api/device/second/smart", "api/device/third/smart"} {
+		require.NoError(t, unlimitedSpool.Write(endpoint, uuid.Must(uuid.NewV4()), []byte(`{"smartctl": {}}`)))
+	}
+	entries, err := unlimitedSpool.Entries()
+	require.NoError(t, err)
+	entrySize := int64(0)
+	for _, entry := range entries {
+		info, err := os.Stat(filepath.Join(dir, entryFileName(t, dir, entry.ScrutinyUUID)))
+		require.NoError(t, err)
+		entrySize = max(entrySize, info.Size())
+	}
+	payloadSpool, err := spool.New(dir, entrySize*2, 0, logrus.WithFields(logrus.Fields{}))
+	require.NoError(t, err)
+
+	//test
+	require.NoError(t, payloadSpool.Prune())
+	entries, err = payloadSpool.Entries()
+
+	//assert
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	require.Equal(t, "api/device/second/smart", entries[0].Endpoint)
+	require.Equal(t, "api/device/third/smart", entries[1].Endpoint)
+}
+
+func TestSpool_Prune_MaxAge(t *testing.T) {
+	//setup
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(
+		filepath.Join(dir, "00000000001600000000-00000000-0000-0000-0000-000000000000.json"),
+		[]byte(`{"created_at": "2020-09-13T12:26:40Z", "endpoint": "api/devices/register", "payload": {}}`),
+		0600,
+	))
+	payloadSpool, err := spool.New(dir, 0, 7*24*time.Hour, logrus.WithFields(logrus.Fields{}))
+	require.NoError(t, err)
+	require.NoError(t, payloadSpool.Write("api/device/first/smart", uuid.Must(uuid.NewV4()), []byte(`{}`)))
+
+	//test
+	entries, err := payloadSpool.Entries()
+
+	//assert
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, "api/device/first/smart", entries[0].Endpoint)
+}
+
+func entryFileName(t *testing.T, dir string, scrutinyUuid uuid.UUID) string {
+	matches, err := filepath.Glob(filepath.Join(dir, "*-"+scrutinyUuid.String()+".json"))
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+	return filepath.Base(matches[0])
+}
+
+func TestSpool_Registration(t *testing.T) {
+	//setup
+	payloadSpool, err := spool.New(t.TempDir(), 0, 0, logrus.WithFields(logrus.Fields{}))
+	require.NoError(t, err)
+	_, missingErr := payloadSpool.ReadRegistration()
+
+	//test
+	require.NoError(t, payloadSpool.WriteRegistration([]byte(`[{"device_name": "sda"}]`)))
+	registration, err := payloadSpool.ReadRegistration()
+	entries, entriesErr := payloadSpool.Entries()
+
+	//assert
+	require.Error(t, missingErr)
+	require.NoError(t, err)
+	require.JSONEq(t, `[{"device_name": "sda"}]`, string(registration))
+	// the registration is not a spooled payload
+	require.NoError(t, entriesErr)
+	require.Empty(t, entries)
+}
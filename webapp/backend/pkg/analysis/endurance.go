@@ -0,0 +1,161 @@
+package analysis
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/analogj/scrutiny/webapp/backend/pkg"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/models"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/models/measurements"
+)
+
+// ATA SSD wear indicators, in order of preference. The normalized value counts down from 100 as the flash wears out.
+var ataWearAttributes = []string{
+	"177", // Wear Leveling Count
+	"231", // SSD Life Left
+	"233", // Media Wearout Indicator
+}
+
+// EndurancePoint is the cumulative write volume & wear of a device at a point in the (downsampled) history.
+type EndurancePoint struct {
+	Date            time.Time `json:"date"`
+	BytesWritten    *int64    `json:"bytes_written,omitempty"`
+	WearPercentUsed *float64  `json:"wear_percent_used,omitempty"`
+}
+
+// Endurance describes the write volume & flash wear of an SSD, and projects when it will wear out.
+// Rates are calculated from the history (linear regression), and are nil when the history spans less than a day.
+type Endurance struct {
+	Lifetime
+
+	// the wear indicator reported by the device (NVMe percentage_used, or ATA 177/231/233)
+	WearAttributeId string   `json:"wear_attribute_id,omitempty"`
+	WearPercentUsed *float64 `json:"wear_percent_used,omitempty"`
+	// ratio of flash writes to host writes, only available for devices reporting both (ATA 247 & 248)
+	WriteAmplification *float64 `json:"write_amplification,omitempty"`
+
+	DailyBytesWritten *float64 `json:"daily_bytes_written,omitempty"`
+	DailyWearPercent  *float64 `json:"daily_wear_percent,omitempty"` // percentage points per day
+
+	// the earliest of the projected dates the wear indicator reaches 100%, or the rated endurance is used up
+	LifeRemainingDays    *float64   `json:"life_remaining_days,omitempty"`
+	EstimatedWearOutDate *time.Time `json:"estimated_wear_out_date,omitempty"`
+	WearOutBasis         string     `json:"wear_out_basis,omitempty"` // the wear attribute id, or rated_tbw
+
+	History []EndurancePoint `json:"history,omitempty"`
+}
+
+// AnalyzeEndurance derives the daily write volume, wear rate & projected wear-out date of a device from its SMART
+// history. The rated endurance is determined as described in CalculateLifetime.
+func AnalyzeEndurance(device models.Device, rule *models.WarrantyRule, history []measurements.Smart, now time.Time) Endurance {
+	// history is usually returned newest first
+	sortedHistory := append([]measurements.Smart{}, history...)
+	sort.SliceStable(sortedHistory, func(i, j int) bool {
+		return sortedHistory[i].Date.Before(sortedHistory[j].Date)
+	})
+
+	var latest *measurements.Smart
+	if len(sortedHistory) > 0 {
+		latest = &sortedHistory[len(sortedHistory)-1]
+	}
+	endurance := Endurance{Lifetime: CalculateLifetime(device, rule, latest, now)}
+	if latest == nil {
+		return endurance
+	}
+
+	var writeDays, writeValues, wearDays, wearValues []float64
+	for _, smart := range sortedHistory {
+		point := EndurancePoint{Date: smart.Date}
+		days := smart.Date.Sub(sortedHistory[0].Date).Hours() / 24
+		if bytesWritten, ok := BytesWritten(device, rule, smart); ok {
+			point.BytesWritten = &bytesWritten
+			writeDays = append(writeDays, days)
+			writeValues = append(writeValues, float64(bytesWritten))
+		}
+		if attributeId, wearPercentUsed, ok := wearPercentUsed(smart); ok {
+			point.WearPercentUsed = &wearPercentUsed
+			endurance.WearAttributeId = attributeId
+			wearDays = append(wearDays, days)
+			wearValues = append(wearValues, wearPercentUsed)
+		}
+		if point.BytesWritten != nil || point.WearPercentUsed != nil {
+			endurance.History = append(endurance.History, point)
+		}
+	}
+	if _, wearPercentUsed, ok := wearPercentUsed(*latest); ok {
+		endurance.WearPercentUsed = &wearPercentUsed
+	}
+	endurance.WriteAmplification = writeAmplification(*latest)
+	endurance.DailyBytesWritten = dailyRate(writeDays, writeValues)
+	endurance.DailyWearPercent = dailyRate(wearDays, wearValues)
+
+	// project the remaining life from the wear indicator, and from the rated endurance. The earliest date is used.
+	if endurance.WearPercentUsed != nil {
+		if remainingDays, ok := remainingDays(100-*endurance.WearPercentUsed, endurance.DailyWearPercent); ok {
+			endurance.LifeRemainingDays = &remainingDays
+			endurance.WearOutBasis = endurance.WearAttributeId
+		}
+	}
+	if endurance.BytesWritten != nil && endurance.RatedTbw > 0 {
+		remainingBytes := endurance.RatedTbw*bytesPerTerabyte - float64(*endurance.BytesWritten)
+		if remainingDays, ok := remainingDays(remainingBytes, endurance.DailyBytesWritten); ok &&
+			(endurance.LifeRemainingDays == nil || remainingDays < *endurance.LifeRemainingDays) {
+			endurance.LifeRemainingDays = &remainingDays
+			endurance.WearOutBasis = "rated_tbw"
+		}
+	}
+	if endurance.LifeRemainingDays != nil {
+		estimatedWearOutDate := now.Add(time.Duration(*endurance.LifeRemainingDays * 24 * float64(time.Hour)))
+		endurance.EstimatedWearOutDate = &estimatedWearOutDate
+	}
+	return endurance
+}
+
+// wearPercentUsed returns the wear indicator of the device, as a percentage of the flash endurance used
+func wearPercentUsed(smart measurements.Smart) (string, float64, bool) {
+	switch smart.DeviceProtocol {
+	case pkg.DeviceProtocolNvme:
+		if attr, ok := smart.Attributes["percentage_used"].(*measurements.SmartNvmeAttribute); ok {
+			return attr.AttributeId, float64(attr.Value), true
+		}
+	case pkg.DeviceProtocolAta:
+		for _, attributeId := range ataWearAttributes {
+			if attr, ok := smart.Attributes[attributeId].(*measurements.SmartAtaAttribute); ok && attr.Value >= 0 && attr.Value <= 100 {
+				return attributeId, float64(100 - attr.Value), true
+			}
+		}
+	}
+	return "", 0, false
+}
+
+// writeAmplification uses the Host Program Page Count (247) & FTL Program Page Count (248) attributes reported by
+// Micron/Crucial SSDs.
+func writeAmplification(smart measurements.Smart) *float64 {
+	hostPages, hostOk := smart.Attributes["247"].(*measurements.SmartAtaAttribute)
+	ftlPages, ftlOk := smart.Attributes["248"].(*measurements.SmartAtaAttribute)
+	if !hostOk || !ftlOk || hostPages.RawValue <= 0 || ftlPages.RawValue < 0 {
+		return nil
+	}
+	writeAmplification := float64(hostPages.RawValue+ftlPages.RawValue) / float64(hostPages.RawValue)
+	return &writeAmplification
+}
+
+func dailyRate(days []float64, values []float64) *float64 {
+	if len(days) < 2 || days[len(days)-1]-days[0] < minimumTrendDays {
+		return nil
+	}
+	slope := math.Max(linearRegressionSlope(days, values), 0)
+	return &slope
+}
+
+func remainingDays(remaining float64, dailyRate *float64) (float64, bool) {
+	if remaining <= 0 {
+		return 0, true
+	}
+	if dailyRate == nil || *dailyRate <= 0 {
+		return 0, false
+	}
+	days := remaining / *dailyRate
+	return days, days <= maximumEstimateDays
+}
@@ -0,0 +1,100 @@
+package analysis_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/analogj/scrutiny/webapp/backend/pkg"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/analysis"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/models"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/models/measurements"
+	"github.com/stretchr/testify/require"
+)
+
+// 100GB written & 1% wear per day, oldest first, one point per day. History is returned newest first.
+func nvmeEnduranceHistory(now time.Time, days int) []measurements.Smart {
+	history := []measurements.Smart{}
+	for ndx := 0; ndx < days; ndx++ {
+		history = append([]measurements.Smart{{
+			Date:           now.AddDate(0, 0, ndx-days+1),
+			DeviceProtocol: pkg.DeviceProtocolNvme,
+			Attributes: map[string]measurements.SmartAttribute{
+				"data_units_written": &measurements.SmartNvmeAttribute{AttributeId: "data_units_written", Value: int64(ndx+1) * 100 * 1000 * 1000 * 1000 / (512 * 1000)},
+				"percentage_used":    &measurements.SmartNvmeAttribute{AttributeId: "percentage_used", Value: int64(50 + ndx)},
+			},
+		}}, history...)
+	}
+	return history
+}
+
+func TestAnalyzeEndurance_NoHistory(t *testing.T) {
+	//test
+	endurance := analysis.AnalyzeEndurance(models.Device{}, nil, nil, time.Now())
+
+	//assert
+	require.Nil(t, endurance.BytesWritten)
+	require.Nil(t, endurance.LifeRemainingDays)
+	require.Empty(t, endurance.History)
+}
+
+func TestAnalyzeEndurance_WearIndicator(t *testing.T) {
+	//setup
+	now := time.Now()
+
+	//test
+	endurance := analysis.AnalyzeEndurance(models.Device{}, nil, nvmeEnduranceHistory(now, 10), now)
+
+	//assert
+	require.Len(t, endurance.History, 10)
+	require.Equal(t, "percentage_used", endurance.WearAttributeId)
+	require.Equal(t, 59.0, *endurance.WearPercentUsed)
+	require.InDelta(t, 100*1000*1000*1000, *endurance.DailyBytesWritten, 100*1000)
+	require.InDelta(t, 1, *endurance.DailyWearPercent, 0.001)
+	// 41% remaining at 1% per day
+	require.Equal(t, "percentage_used", endurance.WearOutBasis)
+	require.InDelta(t, 41, *endurance.LifeRemainingDays, 0.001)
+	require.WithinDuration(t, now.AddDate(0, 0, 41), *endurance.EstimatedWearOutDate, time.Minute)
+}
+
+func TestAnalyzeEndurance_RatedTbw(t *testing.T) {
+	//setup
+	now := time.Now()
+	// 1TB written after 10 days, 2TB remaining at 100GB per day
+	ratedTbw := 3.0
+	device := models.Device{RatedTbw: &ratedTbw}
+
+	//test
+	endurance := analysis.AnalyzeEndurance(device, nil, nvmeEnduranceHistory(now, 10), now)
+
+	//assert
+	require.Equal(t, "rated_tbw", endurance.WearOutBasis)
+	require.InDelta(t, 20, *endurance.LifeRemainingDays, 0.01)
+	require.InDelta(t, 33.3, *endurance.EndurancePercentUsed, 0.1)
+}
+
+func TestAnalyzeEndurance_Ata(t *testing.T) {
+	//setup
+	now := time.Now()
+	history := []measurements.Smart{{
+		Date:           now,
+		DeviceProtocol: pkg.DeviceProtocolAta,
+		Attributes: map[string]measurements.SmartAttribute{
+			"177": &measurements.SmartAtaAttribute{AttributeId: 177, Value: 95},
+			"241": &measurements.SmartAtaAttribute{AttributeId: 241, RawValue: 1000},
+			"247": &measurements.SmartAtaAttribute{AttributeId: 247, RawValue: 400},
+			"248": &measurements.SmartAtaAttribute{AttributeId: 248, RawValue: 200},
+		},
+	}}
+
+	//test
+	endurance := analysis.AnalyzeEndurance(models.Device{}, nil, history, now)
+
+	//assert
+	require.Equal(t, "177", endurance.WearAttributeId)
+	require.Equal(t, 5.0, *endurance.WearPercentUsed)
+	require.Equal(t, int64(512000), *endurance.BytesWritten)
+	require.Equal(t, 1.5, *endurance.WriteAmplification)
+	// a single point is not enough to calculate rates
+	require.Nil(t, endurance.DailyBytesWritten)
+	require.Nil(t, endurance.LifeRemainingDays)
+}
@@ -0,0 +1,213 @@
+package analysis
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/analogj/scrutiny/webapp/backend/pkg"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/models/measurements"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/thresholds"
+)
+
+// a projected failure further away than this does not contribute to the failure score
+const predictionHorizonDays = 365.0
+
+// estimates further away than this are not returned (and would overflow time.Duration)
+const maximumEstimateDays = 100 * 365.0
+
+// the minimum time span between the oldest & newest point, before the rate of change is calculated
+const minimumTrendDays = 1.0
+
+// trendAttribute is a critical attribute, where a growing value indicates that the device is degrading.
+// The limit is the value at which Scrutiny marks the attribute as failed (see failureLimit).
+type trendAttribute struct {
+	AttributeId string
+	// the ATA attribute whose observed thresholds are used as the limit, for NVMe & SCSI attributes which always fail
+	// their SMART threshold (0) on the first error, and have no observed failure rates.
+	ObservedAttributeId int
+}
+
+var trendAttributes = map[string][]trendAttribute{
+	pkg.DeviceProtocolAta: {
+		{AttributeId: "5"},   // Reallocated Sectors Count
+		{AttributeId: "187"}, // Reported Uncorrectable Errors
+		{AttributeId: "197"}, // Current Pending Sector Count
+		{AttributeId: "198"}, // (Offline) Uncorrectable Sector Count
+	},
+	pkg.DeviceProtocolNvme: {
+		{AttributeId: "percentage_used"}, // SMART threshold (100% of the rated endurance)
+		{AttributeId: "media_errors"},    // SMART threshold (the first unrecovered error)
+	},
+	pkg.DeviceProtocolScsi: {
+		// grown defects are remapped sectors, so the Reallocated Sectors Count failure rates are used
+		{AttributeId: "scsi_grown_defect_list", ObservedAttributeId: 5},
+	},
+}
+
+type FailurePrediction struct {
+	// 0 (no degradation) to 100 (the attribute limit has been reached)
+	Score int
+	// the attribute responsible for the score
+	AttributeId string
+	// the date the attribute is projected to reach its limit, nil if the attribute is not growing
+	EstimatedFailureDate *time.Time
+}
+
+// PredictFailure uses the rate of change of critical attributes to calculate a failure score and estimate when the
+// attribute limit will be reached. The attribute with the highest score is returned. Attributes ignored by the
+// attribute overrides are skipped, and user-defined failure limits replace the built-in limits.
+//
+// Half of the score is based on how close the current value is to the limit, the other half on how soon the
+// limit will be reached (linear regression over the history, within a 1 year horizon).
+func PredictFailure(deviceProtocol string, history []measurements.Smart, overrides map[string]thresholds.AttributeOverride, now time.Time) FailurePrediction {
+	prediction := FailurePrediction{}
+	if len(history) == 0 {
+		return prediction
+	}
+
+	// history is usually returned newest first
+	sortedHistory := append([]measurements.Smart{}, history...)
+	sort.SliceStable(sortedHistory, func(i, j int) bool {
+		return sortedHistory[i].Date.Before(sortedHistory[j].Date)
+	})
+
+	for _, trendAttr := range trendAttributes[deviceProtocol] {
+		var override *thresholds.AttributeOverride
+		if attrOverride, ok := overrides[trendAttr.AttributeId]; ok {
+			override = &attrOverride
+		}
+		limit, ok := failureLimit(trendAttr, sortedHistory[len(sortedHistory)-1].Attributes[trendAttr.AttributeId], override)
+		if !ok {
+			continue
+		}
+		attrPrediction, ok := predictAttribute(trendAttr.AttributeId, limit, sortedHistory, now)
+		if !ok {
+			continue
+		}
+		if attrPrediction.Score > prediction.Score ||
+			(attrPrediction.Score == prediction.Score && attrPrediction.EstimatedFailureDate != nil &&
+				(prediction.EstimatedFailureDate == nil || attrPrediction.EstimatedFailureDate.Before(*prediction.EstimatedFailureDate))) {
+			prediction = attrPrediction
+		}
+	}
+	return prediction
+}
+
+// failureLimit returns the lowest value at which the attribute is marked as failed, using the same rules as the
+// attribute status: the user-defined failure limit, the observed thresholds (ATA) or the SMART threshold (NVMe, SCSI).
+// false if the attribute is ignored, or is never failed.
+func failureLimit(trendAttr trendAttribute, latestAttr measurements.SmartAttribute, override *thresholds.AttributeOverride) (float64, bool) {
+	if override.IsIgnored() {
+		return 0, false
+	}
+	if override.HasLimits() {
+		// growing values are only failing when the ideal direction is low
+		if override.FailLimit == nil || override.IdealOr(thresholds.ObservedThresholdIdealLow) != thresholds.ObservedThresholdIdealLow {
+			return 0, false
+		}
+		return float64(*override.FailLimit + 1), true
+	}
+
+	observedAttributeId := trendAttr.ObservedAttributeId
+	if ataAttr, ok := latestAttr.(*measurements.SmartAtaAttribute); ok {
+		observedAttributeId = ataAttr.AttributeId
+	}
+	if observedAttributeId > 0 {
+		smartMetadata, ok := thresholds.AtaMetadata[observedAttributeId]
+		if !ok {
+			return 0, false
+		}
+		limit, ok := smartMetadata.FailureLimit(override.CriticalOr(smartMetadata.Critical))
+		return float64(limit), ok
+	}
+
+	// the attribute fails when the value is greater than the SMART threshold
+	if !override.CriticalOr(true) {
+		return 0, false
+	}
+	var threshold int64
+	switch typedAttr := latestAttr.(type) {
+	case *measurements.SmartNvmeAttribute:
+		threshold = typedAttr.Threshold
+	case *measurements.SmartScsiAttribute:
+		threshold = typedAttr.Threshold
+	default:
+		return 0, false
+	}
+	if threshold < 0 {
+		return 0, false
+	}
+	return float64(threshold + 1), true
+}
+
+func predictAttribute(attributeId string, limit float64, sortedHistory []measurements.Smart, now time.Time) (FailurePrediction, bool) {
+	var days, values []float64
+	for _, smart := range sortedHistory {
+		value, ok := attributeValue(smart.Attributes[attributeId])
+		if !ok {
+			continue
+		}
+		days = append(days, smart.Date.Sub(sortedHistory[0].Date).Hours()/24)
+		values = append(values, value)
+	}
+	if len(values) == 0 {
+		return FailurePrediction{}, false
+	}
+
+	prediction := FailurePrediction{AttributeId: attributeId}
+	current := values[len(values)-1]
+	if current >= limit {
+		prediction.Score = 100
+		prediction.EstimatedFailureDate = &now
+		return prediction, true
+	}
+	score := 50 * math.Max(current, 0) / limit
+
+	if days[len(days)-1]-days[0] >= minimumTrendDays {
+		if slope := linearRegressionSlope(days, values); slope > 0 {
+			daysToLimit := (limit - current) / slope
+			if daysToLimit <= maximumEstimateDays {
+				estimatedFailureDate := now.Add(time.Duration(daysToLimit * 24 * float64(time.Hour)))
+				prediction.EstimatedFailureDate = &estimatedFailureDate
+			}
+			if daysToLimit < predictionHorizonDays {
+				score += 50 * (1 - daysToLimit/predictionHorizonDays)
+			}
+		}
+	}
+
+	prediction.Score = int(math.Round(math.Min(score, 100)))
+	return prediction, true
+}
+
+// attributeValue returns the value that grows as the device degrades (the raw value for ATA attributes)
+func attributeValue(attr measurements.SmartAttribute) (float64, bool) {
+	switch typedAttr := attr.(type) {
+	case *measurements.SmartAtaAttribute:
+		return float64(typedAttr.RawValue), true
+	case *measurements.SmartNvmeAttribute:
+		return float64(typedAttr.Value), true
+	case *measurements.SmartScsiAttribute:
+		return float64(typedAttr.Value), true
+	default:
+		return 0, false
+	}
+}
+
+// least squares slope of values over days (units per day)
+func linearRegressionSlope(days []float64, values []float64) float64 {
+	n := float64(len(days))
+	var sumX, sumY, sumXY, sumXX float64
+	for i := range days {
+		sumX += days[i]
+		sumY += values[i]
+		sumXY += days[i] * values[i]
+		sumXX += days[i] * days[i]
+	}
+	denominator := n*sumXX - sumX*sumX
+	if denominator == 0 {
+		return 0
+	}
+	return (n*sumXY - sumX*sumY) / denominator
+}
@@ -0,0 +1,144 @@
+package analysis_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/analogj/scrutiny/webapp/backend/pkg"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/analysis"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/models/measurements"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/thresholds"
+	"github.com/stretchr/testify/require"
+)
+
+func ataHistory(now time.Time, reallocatedSectors ...int64) []measurements.Smart {
+	// values are provided oldest first, one point per day. History is returned newest first.
+	history := []measurements.Smart{}
+	for ndx, rawValue := range reallocatedSectors {
+		history = append([]measurements.Smart{{
+			Date: now.AddDate(0, 0, ndx-len(reallocatedSectors)+1),
+			Attributes: map[string]measurements.SmartAttribute{
+				"5":   &measurements.SmartAtaAttribute{AttributeId: 5, RawValue: rawValue},
+				"197": &measurements.SmartAtaAttribute{AttributeId: 197, RawValue: 0},
+			},
+		}}, history...)
+	}
+	return history
+}
+
+func TestPredictFailure_NoHistory(t *testing.T) {
+	//test
+	prediction := analysis.PredictFailure(pkg.DeviceProtocolAta, nil, nil, time.Now())
+
+	//assert
+	require.Equal(t, analysis.FailurePrediction{}, prediction)
+}
+
+func TestPredictFailure_Stable(t *testing.T) {
+	//setup
+	now := time.Now()
+
+	//test
+	prediction := analysis.PredictFailure(pkg.DeviceProtocolAta, ataHistory(now, 0, 0, 0, 0), nil, now)
+
+	//assert
+	require.Equal(t, 0, prediction.Score)
+	require.Nil(t, prediction.EstimatedFailureDate)
+}
+
+func TestPredictFailure_Growing(t *testing.T) {
+	//setup
+	now := time.Now()
+
+	//test
+	// 1 newly reallocated sector per day, 13 remaining before the observed threshold limit (17)
+	prediction := analysis.PredictFailure(pkg.DeviceProtocolAta, ataHistory(now, 1, 2, 3, 4), nil, now)
+
+	//assert
+	require.Equal(t, "5", prediction.AttributeId)
+	require.NotNil(t, prediction.EstimatedFailureDate)
+	require.WithinDuration(t, now.AddDate(0, 0, 13), *prediction.EstimatedFailureDate, time.Minute)
+	// 50 * 4/17 + 50 * (1 - 13/365)
+	require.Equal(t, 60, prediction.Score)
+}
+
+func TestPredictFailure_LimitReached(t *testing.T) {
+	//setup
+	now := time.Now()
+
+	//test
+	prediction := analysis.PredictFailure(pkg.DeviceProtocolAta, ataHistory(now, 150), nil, now)
+
+	//assert
+	require.Equal(t, 100, prediction.Score)
+	require.Equal(t, "5", prediction.AttributeId)
+	require.Equal(t, now, *prediction.EstimatedFailureDate)
+}
+
+func TestPredictFailure_Nvme(t *testing.T) {
+	//setup
+	now := time.Now()
+	history := []measurements.Smart{}
+	for day := 0; day < 30; day++ {
+		history = append(history, measurements.Smart{
+			Date: now.AddDate(0, 0, -day),
+			Attributes: map[string]measurements.SmartAttribute{
+				"percentage_used": &measurements.SmartNvmeAttribute{AttributeId: "percentage_used", Value: int64(50 - day/10), Threshold: 100},
+				"media_errors":    &measurements.SmartNvmeAttribute{AttributeId: "media_errors", Value: 0, Threshold: 0},
+			},
+		})
+	}
+
+	//test
+	prediction := analysis.PredictFailure(pkg.DeviceProtocolNvme, history, nil, now)
+
+	//assert
+	require.Equal(t, "percentage_used", prediction.AttributeId)
+	require.NotNil(t, prediction.EstimatedFailureDate)
+	require.True(t, prediction.EstimatedFailureDate.After(now.AddDate(1, 0, 0)))
+	require.Equal(t, 25, prediction.Score)
+}
+
+func TestPredictFailure_Scsi(t *testing.T) {
+	//setup
+	now := time.Now()
+	history := []measurements.Smart{}
+	for day := 0; day < 4; day++ {
+		history = append(history, measurements.Smart{
+			Date: now.AddDate(0, 0, -day),
+			Attributes: map[string]measurements.SmartAttribute{
+				"scsi_grown_defect_list": &measurements.SmartScsiAttribute{AttributeId: "scsi_grown_defect_list", Value: int64(4 - day), Threshold: 0},
+			},
+		})
+	}
+
+	//test
+	prediction := analysis.PredictFailure(pkg.DeviceProtocolScsi, history, nil, now)
+
+	//assert (the Reallocated Sectors Count observed threshold limit is used, instead of the SMART threshold)
+	require.Equal(t, "scsi_grown_defect_list", prediction.AttributeId)
+	require.WithinDuration(t, now.AddDate(0, 0, 13), *prediction.EstimatedFailureDate, time.Minute)
+	require.Equal(t, 60, prediction.Score)
+}
+
+func TestPredictFailure_AttributeOverrides(t *testing.T) {
+	//setup
+	now := time.Now()
+	failLimit := int64(103)
+
+	//test
+	ignoredPrediction := analysis.PredictFailure(pkg.DeviceProtocolAta, ataHistory(now, 150), map[string]thresholds.AttributeOverride{
+		"5": {Ignore: true},
+	}, now)
+	limitPrediction := analysis.PredictFailure(pkg.DeviceProtocolAta, ataHistory(now, 1, 2, 3, 4), map[string]thresholds.AttributeOverride{
+		"5": {FailLimit: &failLimit},
+	}, now)
+
+	//assert
+	require.Equal(t, analysis.FailurePrediction{}, ignoredPrediction)
+	// 1 newly reallocated sector per day, 100 remaining before the user-defined limit (values above 103 are failing)
+	require.Equal(t, "5", limitPrediction.AttributeId)
+	require.WithinDuration(t, now.AddDate(0, 0, 100), *limitPrediction.EstimatedFailureDate, time.Minute)
+	// 50 * 4/104 + 50 * (1 - 100/365)
+	require.Equal(t, 38, limitPrediction.Score)
+}
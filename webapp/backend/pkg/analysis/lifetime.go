@@ -0,0 +1,123 @@
+package analysis
+
+import (
+	"math"
+	"time"
+
+	"github.com/analogj/scrutiny/webapp/backend/pkg"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/models"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/models/measurements"
+)
+
+// NVMe data units are reported in thousands of 512 byte units
+const nvmeDataUnitBytes = 512 * 1000
+
+// ATA LBA counters are assumed to use 512 byte sectors when the logical block size of the device is unknown
+const defaultAtaSectorBytes = 512
+
+const bytesPerTerabyte = 1000 * 1000 * 1000 * 1000
+
+// ATA attributes reporting the total LBAs written, in order of preference
+var ataBytesWrittenAttributes = []string{
+	"241", // Total LBAs Written
+	"246", // Total Host Sector Writes (Micron/Crucial)
+}
+
+// Lifetime describes how much of the warranty period & rated write endurance of a device has been used.
+// Fields are nil (or zero) when they can't be determined from the device metadata & warranty rule.
+type Lifetime struct {
+	WarrantyStart          *time.Time `json:"warranty_start,omitempty"`
+	WarrantyStartEstimated bool       `json:"warranty_start_estimated,omitempty"` // no purchase date, see CalculateLifetime
+	WarrantyEnd            *time.Time `json:"warranty_end,omitempty"`
+	WarrantyPercentUsed    *float64   `json:"warranty_percent_used,omitempty"` // 0-100
+
+	BytesWritten         *int64   `json:"bytes_written,omitempty"`
+	RatedTbw             float64  `json:"rated_tbw,omitempty"`
+	EndurancePercentUsed *float64 `json:"endurance_percent_used,omitempty"` // may exceed 100
+}
+
+// BytesWritten returns the total bytes written to the device, calculated from the NVMe `data_units_written` attribute,
+// or the ATA Total LBAs Written (241/246) attributes. ATA attributes are counted in logical blocks of the device, unless
+// the (optional) warranty rule sets the unit of attribute 241.
+func BytesWritten(device models.Device, rule *models.WarrantyRule, smart measurements.Smart) (int64, bool) {
+	switch smart.DeviceProtocol {
+	case pkg.DeviceProtocolNvme:
+		if attr, ok := smart.Attributes["data_units_written"].(*measurements.SmartNvmeAttribute); ok {
+			return attr.Value * nvmeDataUnitBytes, true
+		}
+	case pkg.DeviceProtocolAta:
+		for _, attributeId := range ataBytesWrittenAttributes {
+			if attr, ok := smart.Attributes[attributeId].(*measurements.SmartAtaAttribute); ok && attr.RawValue > 0 {
+				return attr.RawValue * ataUnitBytes(device, rule, attributeId), true
+			}
+		}
+	}
+	return 0, false
+}
+
+// ataUnitBytes returns the number of bytes per unit of an ATA bytes written attribute.
+func ataUnitBytes(device models.Device, rule *models.WarrantyRule, attributeId string) int64 {
+	if attributeId == "241" && rule != nil && rule.Attribute241UnitBytes > 0 {
+		return rule.Attribute241UnitBytes
+	}
+	if device.LogicalBlockSize > 0 {
+		return int64(device.LogicalBlockSize)
+	}
+	return defaultAtaSectorBytes
+}
+
+// CalculateLifetime calculates the warranty & rated endurance consumption of a device. The warranty expiry date &
+// rated TBW set on the device take precedence over the (optional) warranty rule. When no purchase date is set, the
+// warranty start is estimated from the power on hours of the latest SMART data (the device may have been powered off
+// before it was installed), otherwise it is unknown.
+// latest is the most recent SMART data for the device, and may be nil.
+func CalculateLifetime(device models.Device, rule *models.WarrantyRule, latest *measurements.Smart, now time.Time) Lifetime {
+	lifetime := Lifetime{}
+
+	if device.PurchaseDate != nil {
+		lifetime.WarrantyStart = device.PurchaseDate
+	} else if latest != nil && latest.PowerOnHours > 0 && !latest.Date.IsZero() {
+		warrantyStart := latest.Date.Add(-time.Duration(latest.PowerOnHours) * time.Hour)
+		lifetime.WarrantyStart = &warrantyStart
+		lifetime.WarrantyStartEstimated = true
+	}
+
+	if device.WarrantyExpiryDate != nil {
+		lifetime.WarrantyEnd = device.WarrantyExpiryDate
+	} else if rule != nil && rule.WarrantyYears > 0 && lifetime.WarrantyStart != nil {
+		warrantyEnd := lifetime.WarrantyStart.AddDate(0, int(math.Round(rule.WarrantyYears*12)), 0)
+		lifetime.WarrantyEnd = &warrantyEnd
+	}
+
+	if lifetime.WarrantyEnd != nil {
+		if !now.Before(*lifetime.WarrantyEnd) {
+			percentUsed := 100.0
+			lifetime.WarrantyPercentUsed = &percentUsed
+		} else if lifetime.WarrantyStart != nil && lifetime.WarrantyEnd.After(*lifetime.WarrantyStart) {
+			warrantyDuration := lifetime.WarrantyEnd.Sub(*lifetime.WarrantyStart)
+			percentUsed := math.Max(float64(now.Sub(*lifetime.WarrantyStart))/float64(warrantyDuration)*100, 0)
+			lifetime.WarrantyPercentUsed = &percentUsed
+		}
+	}
+
+	if device.RatedTbw != nil && *device.RatedTbw > 0 {
+		lifetime.RatedTbw = *device.RatedTbw
+	} else if rule != nil && rule.RatedTbw > 0 {
+		lifetime.RatedTbw = rule.RatedTbw
+	} else if rule != nil && rule.RatedDwpd > 0 && device.Capacity > 0 {
+		// drive writes per day, every day for the duration of the warranty
+		lifetime.RatedTbw = rule.RatedDwpd * float64(device.Capacity) / bytesPerTerabyte * 365 * rule.WarrantyYears
+	}
+
+	if latest != nil {
+		if bytesWritten, ok := BytesWritten(device, rule, *latest); ok {
+			lifetime.BytesWritten = &bytesWritten
+			if lifetime.RatedTbw > 0 {
+				percentUsed := float64(bytesWritten) / (lifetime.RatedTbw * bytesPerTerabyte) * 100
+				lifetime.EndurancePercentUsed = &percentUsed
+			}
+		}
+	}
+
+	return lifetime
+}
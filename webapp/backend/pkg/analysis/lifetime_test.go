@@ -0,0 +1,172 @@
+package analysis_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/analogj/scrutiny/webapp/backend/pkg"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/analysis"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/models"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/models/measurements"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBytesWritten_Nvme(t *testing.T) {
+	//setup
+	smart := measurements.Smart{
+		DeviceProtocol: pkg.DeviceProtocolNvme,
+		Attributes: map[string]measurements.SmartAttribute{
+			"data_units_written": &measurements.SmartNvmeAttribute{AttributeId: "data_units_written", Value: 2000},
+		},
+	}
+
+	//test
+	bytesWritten, ok := analysis.BytesWritten(models.Device{}, nil, smart)
+
+	//assert
+	require.True(t, ok)
+	require.Equal(t, int64(1024000000), bytesWritten)
+}
+
+func TestBytesWritten_Ata(t *testing.T) {
+	//setup
+	smart := measurements.Smart{
+		DeviceProtocol: pkg.DeviceProtocolAta,
+		Attributes: map[string]measurements.SmartAttribute{
+			"246": &measurements.SmartAtaAttribute{AttributeId: 246, RawValue: 1000},
+		},
+	}
+
+	//test
+	bytesWritten, ok := analysis.BytesWritten(models.Device{}, nil, smart)
+
+	//assert
+	require.True(t, ok)
+	require.Equal(t, int64(512000), bytesWritten)
+
+	// 241 is preferred when both are reported
+	smart.Attributes["241"] = &measurements.SmartAtaAttribute{AttributeId: 241, RawValue: 10}
+	bytesWritten, ok = analysis.BytesWritten(models.Device{}, nil, smart)
+	require.True(t, ok)
+	require.Equal(t, int64(5120), bytesWritten)
+}
+
+func TestBytesWritten_AtaUnits(t *testing.T) {
+	//setup
+	smart := measurements.Smart{
+		DeviceProtocol: pkg.DeviceProtocolAta,
+		Attributes: map[string]measurements.SmartAttribute{
+			"241": &measurements.SmartAtaAttribute{AttributeId: 241, RawValue: 10},
+		},
+	}
+	device := models.Device{LogicalBlockSize: 4096}
+	rule := &models.WarrantyRule{Attribute241UnitBytes: 32 * 1024 * 1024}
+
+	//test
+	blockBytesWritten, blockOk := analysis.BytesWritten(device, nil, smart)
+	ruleBytesWritten, ruleOk := analysis.BytesWritten(device, rule, smart)
+
+	//assert
+	require.True(t, blockOk)
+	require.Equal(t, int64(40960), blockBytesWritten)
+	require.True(t, ruleOk)
+	require.Equal(t, int64(335544320), ruleBytesWritten)
+
+	// the rule unit only applies to attribute 241
+	delete(smart.Attributes, "241")
+	smart.Attributes["246"] = &measurements.SmartAtaAttribute{AttributeId: 246, RawValue: 10}
+	bytesWritten, ok := analysis.BytesWritten(device, rule, smart)
+	require.True(t, ok)
+	require.Equal(t, int64(40960), bytesWritten)
+}
+
+func TestBytesWritten_Unavailable(t *testing.T) {
+	//test
+	_, ok := analysis.BytesWritten(models.Device{}, nil, measurements.Smart{
+		DeviceProtocol: pkg.DeviceProtocolScsi,
+		Attributes:     map[string]measurements.SmartAttribute{},
+	})
+
+	//assert
+	require.False(t, ok)
+}
+
+func TestCalculateLifetime_WarrantyRule(t *testing.T) {
+	//setup
+	purchaseDate := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+	device := models.Device{ModelName: "WDC WD40EFRX", PurchaseDate: &purchaseDate}
+	rule := &models.WarrantyRule{ModelRegex: "^WDC", WarrantyYears: 5}
+
+	//test
+	lifetime := analysis.CalculateLifetime(device, rule, nil, now)
+
+	//assert
+	require.Equal(t, time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC), *lifetime.WarrantyEnd)
+	require.InDelta(t, 50, *lifetime.WarrantyPercentUsed, 0.1)
+	require.Nil(t, lifetime.BytesWritten)
+	require.Nil(t, lifetime.EndurancePercentUsed)
+}
+
+func TestCalculateLifetime_EstimatedWarrantyStart(t *testing.T) {
+	//setup
+	now := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+	device := models.Device{ModelName: "WDC WD40EFRX", CreatedAt: now.Add(-time.Hour)}
+	rule := &models.WarrantyRule{ModelRegex: "^WDC", WarrantyYears: 5}
+	latest := &measurements.Smart{Date: now, PowerOnHours: 365 * 24}
+
+	//test
+	unknownStart := analysis.CalculateLifetime(device, rule, nil, now)
+	lifetime := analysis.CalculateLifetime(device, rule, latest, now)
+
+	//assert (the date the device was first seen by Scrutiny is not used)
+	require.Nil(t, unknownStart.WarrantyStart)
+	require.Nil(t, unknownStart.WarrantyEnd)
+	require.Nil(t, unknownStart.WarrantyPercentUsed)
+
+	require.True(t, lifetime.WarrantyStartEstimated)
+	require.Equal(t, now.Add(-365*24*time.Hour), *lifetime.WarrantyStart)
+	require.InDelta(t, 20, *lifetime.WarrantyPercentUsed, 0.1)
+}
+
+func TestCalculateLifetime_WarrantyExpired(t *testing.T) {
+	//setup
+	warrantyExpiryDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	device := models.Device{CreatedAt: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC), WarrantyExpiryDate: &warrantyExpiryDate}
+
+	//test
+	lifetime := analysis.CalculateLifetime(device, &models.WarrantyRule{WarrantyYears: 10}, nil, time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC))
+
+	//assert
+	require.Equal(t, warrantyExpiryDate, *lifetime.WarrantyEnd)
+	require.Equal(t, 100.0, *lifetime.WarrantyPercentUsed)
+}
+
+func TestCalculateLifetime_Endurance(t *testing.T) {
+	//setup
+	now := time.Now()
+	latest := &measurements.Smart{
+		DeviceProtocol: pkg.DeviceProtocolNvme,
+		Attributes: map[string]measurements.SmartAttribute{
+			// 300 TB written
+			"data_units_written": &measurements.SmartNvmeAttribute{AttributeId: "data_units_written", Value: 300 * 1000 * 1000 * 1000 * 1000 / (512 * 1000)},
+		},
+	}
+	// 1TB drive, 0.3 drive writes per day for 5 years = 547.5 TBW
+	device := models.Device{Capacity: 1000 * 1000 * 1000 * 1000, DeviceProtocol: pkg.DeviceProtocolNvme}
+	rule := &models.WarrantyRule{WarrantyYears: 5, RatedDwpd: 0.3}
+
+	//test
+	lifetime := analysis.CalculateLifetime(device, rule, latest, now)
+
+	//assert
+	require.InDelta(t, 547.5, lifetime.RatedTbw, 0.001)
+	require.InDelta(t, 54.8, *lifetime.EndurancePercentUsed, 0.1)
+
+	// the rated TBW set on the device takes precedence
+	ratedTbw := 600.0
+	device.RatedTbw = &ratedTbw
+	lifetime = analysis.CalculateLifetime(device, rule, latest, now)
+	require.Equal(t, 600.0, lifetime.RatedTbw)
+	require.InDelta(t, 50, *lifetime.EndurancePercentUsed, 0.1)
+}
@@ -40,6 +40,20 @@ func (c *configuration) Init() error {
 	c.SetDefault("log.file", "")
 
 	c.SetDefault("notify.urls", []string{})
+	c.SetDefault("notify.templates.subject", "")
+	c.SetDefault("notify.templates.message", "")
+	c.SetDefault("notify.dashboard_url", "")
+	c.SetDefault("notify.timeout_seconds", 30)
+	c.SetDefault("notify.retries", 2)
+	c.SetDefault("notify.retry_backoff_seconds", 5)
+	c.SetDefault("notify.delivery_retention_days", 30)
+	c.SetDefault("notify.digest.enabled", false)
+	c.SetDefault("notify.digest.interval_seconds", 300)
+	c.SetDefault("notify.digest.quiet_seconds", 60)
+	c.SetDefault("notify.lifetime.warranty_percentages", []int{90, 100})
+	c.SetDefault("notify.lifetime.endurance_percentages", []int{80, 90, 100})
+
+	c.SetDefault("web.timeseries.backend", "influxdb")
 
 	c.SetDefault("web.influxdb.scheme", "http")
 	c.SetDefault("web.influxdb.host", "localhost")
@@ -52,6 +66,19 @@ func (c *configuration) Init() error {
 	c.SetDefault("web.influxdb.tls.insecure_skip_verify", false)
 	c.SetDefault("web.influxdb.retention_policy", true)
 
+	c.SetDefault("web.auth.enabled", false)
+	c.SetDefault("web.auth.session_ttl_hours", 168)
+	c.SetDefault("web.auth.cookie_secure", false)
+	c.SetDefault("web.auth.admin.username", "admin")
+	c.SetDefault("web.auth.admin.password", "")
+	c.SetDefault("web.auth.forward_auth.enabled", false)
+	c.SetDefault("web.auth.forward_auth.user_header", "Remote-User")
+	c.SetDefault("web.auth.forward_auth.groups_header", "Remote-Groups")
+	c.SetDefault("web.auth.forward_auth.admin_group", "admin")
+	c.SetDefault("web.auth.forward_auth.trusted_proxies", []string{"127.0.0.1/32", "::1/128"})
+	c.SetDefault("web.auth.collector.enabled", false)
+	c.SetDefault("web.auth.metrics.token", "")
+
 	//c.SetDefault("disks.include", []string{})
 	//c.SetDefault("disks.exclude", []string{})
 
@@ -30,9 +30,10 @@ func AttributeStatusHas(b, flag AttributeStatus) bool               { return b&f
 type DeviceStatus uint8
 
 const (
-	DeviceStatusPassed         DeviceStatus = 0
-	DeviceStatusFailedSmart    DeviceStatus = 1
-	DeviceStatusFailedScrutiny DeviceStatus = 2
+	DeviceStatusPassed          DeviceStatus = 0
+	DeviceStatusFailedSmart     DeviceStatus = 1
+	DeviceStatusFailedScrutiny  DeviceStatus = 2
+	DeviceStatusWarningScrutiny DeviceStatus = 4 // at least one attribute has a warning, the device is not failing
 )
 
 func DeviceStatusSet(b, flag DeviceStatus) DeviceStatus    { return b | flag }
@@ -65,3 +66,11 @@ const (
 	//shortcut
 	MetricsStatusThresholdBoth MetricsStatusThreshold = 3
 )
+
+// UserRole determines which API routes a user can access when authentication is enabled.
+type UserRole string
+
+const (
+	UserRoleAdmin    UserRole = "admin"    // can modify devices, settings & users
+	UserRoleReadOnly UserRole = "readonly" // can only view the dashboard
+)
@@ -2,11 +2,14 @@ package database
 
 import (
 	"context"
+	"time"
 
 	"github.com/analogj/scrutiny/webapp/backend/pkg"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/analysis"
 	"github.com/analogj/scrutiny/webapp/backend/pkg/models"
 	"github.com/analogj/scrutiny/webapp/backend/pkg/models/collector"
 	"github.com/analogj/scrutiny/webapp/backend/pkg/models/measurements"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/thresholds"
 	"github.com/gofrs/uuid/v5"
 )
 
@@ -18,14 +21,29 @@ type DeviceRepo interface {
 
 	RegisterDevice(ctx context.Context, dev models.Device) error
 	GetDevices(ctx context.Context) ([]models.Device, error)
+	QueryDevices(ctx context.Context, query models.DeviceQuery) ([]models.Device, string, error)
 	UpdateDevice(ctx context.Context, scrutiny_uuid uuid.UUID, collectorSmartData collector.SmartInfo) (models.Device, error)
 	UpdateDeviceStatus(ctx context.Context, scrutiny_uuid uuid.UUID, status pkg.DeviceStatus) (models.Device, error)
+	UpdateDeviceFailurePrediction(ctx context.Context, scrutiny_uuid uuid.UUID, prediction analysis.FailurePrediction) (models.Device, error)
+	UpdateDeviceEndurance(ctx context.Context, scrutiny_uuid uuid.UUID, endurance analysis.Endurance) (models.Device, error)
 	GetDeviceDetails(ctx context.Context, scrutiny_uuid uuid.UUID) (models.Device, error)
+	UpdateDeviceStale(ctx context.Context, scrutiny_uuid uuid.UUID, stale bool) error
 	UpdateDeviceArchived(ctx context.Context, scrutiny_uuid uuid.UUID, archived bool) error
 	DeleteDevice(ctx context.Context, scrutiny_uuid uuid.UUID) error
+	UpdateDeviceMetadata(ctx context.Context, device models.Device, changes []models.DeviceMetadataChange) error
+	GetDeviceMetadataChanges(ctx context.Context, scrutiny_uuid uuid.UUID, limit int) ([]models.DeviceMetadataChange, error)
+
+	CreateDeviceReplacement(ctx context.Context, replacement models.DeviceReplacement) (models.DeviceReplacement, error)
+	GetDeviceReplacements(ctx context.Context, scrutiny_uuid uuid.UUID) ([]models.DeviceReplacement, error)
+	DeleteDeviceReplacement(ctx context.Context, scrutiny_uuid uuid.UUID, id uint) error
 
 	SaveSmartAttributes(ctx context.Context, scrutiny_uuid uuid.UUID, collectorSmartData collector.SmartInfo) (measurements.Smart, error)
 	GetSmartAttributeHistory(ctx context.Context, scrutiny_uuid uuid.UUID, durationKey string, selectEntries int, selectEntriesOffset int, attributes []string) ([]measurements.Smart, error)
+	GetLatestSmartAttributes(ctx context.Context) (map[uuid.UUID]measurements.Smart, error)
+	GetAnalysisSmartHistory(ctx context.Context, scrutiny_uuid uuid.UUID, latest measurements.Smart) ([]measurements.Smart, error)
+
+	SaveSmartSelfTests(ctx context.Context, scrutiny_uuid uuid.UUID, deviceProtocol string, collectorSmartData collector.SmartInfo) ([]measurements.SmartSelfTest, error)
+	GetSmartSelfTestHistory(ctx context.Context, scrutiny_uuid uuid.UUID, durationKey string) ([]measurements.SmartSelfTest, error)
 
 	SaveSmartTemperature(ctx context.Context, scrutiny_uuid uuid.UUID, deviceProtocol string, collectorSmartData collector.SmartInfo, discardSCTTempHistory bool) error
 
@@ -34,4 +52,65 @@ type DeviceRepo interface {
 
 	LoadSettings(ctx context.Context) (*models.Settings, error)
 	SaveSettings(ctx context.Context, settings models.Settings) error
+
+	CreateCollectorToken(ctx context.Context, hostId string, description string) (models.CollectorToken, string, error)
+	GetCollectorTokens(ctx context.Context) ([]models.CollectorToken, error)
+	DeleteCollectorToken(ctx context.Context, id uint) error
+	ValidateCollectorToken(ctx context.Context, tokenValue string) (models.CollectorToken, error)
+
+	CreateUser(ctx context.Context, username string, password string, role pkg.UserRole) (models.User, error)
+	GetUsers(ctx context.Context) ([]models.User, error)
+	DeleteUser(ctx context.Context, id uint) error
+	AuthenticateUser(ctx context.Context, username string, password string) (models.User, error)
+	CreateUserSession(ctx context.Context, userId uint, ttl time.Duration) (string, error)
+	GetSessionUser(ctx context.Context, tokenValue string) (models.User, error)
+	DeleteUserSession(ctx context.Context, tokenValue string) error
+	EnsureAdminUser(ctx context.Context) error
+
+	GetAttributeOverrideRules(ctx context.Context) ([]models.AttributeOverrideRule, error)
+	CreateAttributeOverrideRule(ctx context.Context, rule models.AttributeOverrideRule) (models.AttributeOverrideRule, error)
+	UpdateAttributeOverrideRule(ctx context.Context, id uint, rule models.AttributeOverrideRule) (models.AttributeOverrideRule, error)
+	DeleteAttributeOverrideRule(ctx context.Context, id uint) error
+	GetAttributeOverrides(ctx context.Context, device models.Device) (map[string]thresholds.AttributeOverride, error)
+
+	GetWarrantyRules(ctx context.Context) ([]models.WarrantyRule, error)
+	CreateWarrantyRule(ctx context.Context, rule models.WarrantyRule) (models.WarrantyRule, error)
+	DeleteWarrantyRule(ctx context.Context, id uint) error
+	GetWarrantyRule(ctx context.Context, device models.Device) (*models.WarrantyRule, error)
+
+	GetNotificationState(ctx context.Context, scrutiny_uuid uuid.UUID) (models.NotificationState, error)
+	SaveNotificationState(ctx context.Context, state models.NotificationState) error
+	SaveNotificationDelivery(ctx context.Context, delivery models.NotificationDelivery) error
+	GetNotificationDeliveries(ctx context.Context, scrutinyUUID string, limit int) ([]models.NotificationDelivery, error)
+	PruneNotificationDeliveries(ctx context.Context, before time.Time) (int64, error)
+
+	SaveHostCheckin(ctx context.Context, hostId string, checkinAt time.Time, collectorVersion string, platform string) error
+	GetHosts(ctx context.Context) ([]models.Host, error)
+	GetHost(ctx context.Context, hostId string) (models.Host, error)
+	GetHostSummaries(ctx context.Context) ([]models.HostSummary, error)
+	GetHostSummary(ctx context.Context, hostId string) (models.HostSummary, error)
+	GetHostDevices(ctx context.Context, hostIds []string) ([]models.Device, error)
+	UpdateHostStale(ctx context.Context, hostId string, stale bool) error
+	UpdateHostMetadata(ctx context.Context, host models.Host) error
+	UpdateHostArchived(ctx context.Context, hostId string, archived bool) error
+	DeleteHost(ctx context.Context, hostId string) error
+}
+
+// TimeSeriesRepo stores the `smart`, `temp` & `self_test` measurements, and is responsible for down-sampling them over time.
+// The backend is selected using `web.timeseries.backend` (influxdb or sqlite).
+type TimeSeriesRepo interface {
+	Close() error
+	HealthCheck(ctx context.Context) error
+
+	WriteSmart(ctx context.Context, smart measurements.Smart) error
+	WriteTemperatures(ctx context.Context, scrutiny_uuid uuid.UUID, temperatures []measurements.SmartTemperature) error
+	WriteSelfTest(ctx context.Context, scrutiny_uuid uuid.UUID, selfTest measurements.SmartSelfTest) error
+
+	GetSmartAttributeHistory(ctx context.Context, scrutiny_uuid uuid.UUID, durationKey string, selectEntries int, selectEntriesOffset int, attributes []string) ([]measurements.Smart, error)
+	GetSmartTemperatureHistory(ctx context.Context, durationKey string) (map[uuid.UUID][]measurements.SmartTemperature, error)
+	GetSmartSelfTestHistory(ctx context.Context, scrutiny_uuid uuid.UUID, durationKey string) ([]measurements.SmartSelfTest, error)
+	GetLatestSmartSummaries(ctx context.Context) (map[uuid.UUID]*models.SmartSummary, error)
+	GetLatestSmartAttributes(ctx context.Context) (map[uuid.UUID]measurements.Smart, error)
+
+	DeleteDeviceData(ctx context.Context, scrutiny_uuid uuid.UUID) error
 }
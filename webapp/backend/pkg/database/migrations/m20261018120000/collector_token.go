@@ -0,0 +1,19 @@
+package m20261018120000
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Deprecated: m20261018120000.CollectorToken is deprecated, only used by db migrations
+type CollectorToken struct {
+	//GORM attributes, see: http://gorm.io/docs/conventions.html
+	gorm.Model
+
+	HostId      string     `json:"host_id" gorm:"not null"`
+	Description string     `json:"description"`
+	TokenPrefix string     `json:"token_prefix"`
+	TokenHash   string     `json:"-" gorm:"uniqueIndex;not null"`
+	LastUsedAt  *time.Time `json:"last_used_at"`
+}
@@ -0,0 +1,27 @@
+package m20261018130000
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Deprecated: m20261018130000.User is deprecated, only used by db migrations
+type User struct {
+	//GORM attributes, see: http://gorm.io/docs/conventions.html
+	gorm.Model
+
+	Username     string `json:"username" gorm:"uniqueIndex;not null"`
+	PasswordHash string `json:"-"`
+	Role         string `json:"role" gorm:"not null"`
+}
+
+// Deprecated: m20261018130000.UserSession is deprecated, only used by db migrations
+type UserSession struct {
+	//GORM attributes, see: http://gorm.io/docs/conventions.html
+	gorm.Model
+
+	UserID    uint      `gorm:"index;not null"`
+	TokenHash string    `gorm:"uniqueIndex;not null"`
+	ExpiresAt time.Time `gorm:"not null"`
+}
@@ -0,0 +1,23 @@
+package m20261018150000
+
+import (
+	"gorm.io/gorm"
+)
+
+// Deprecated: m20261018150000.AttributeOverrideRule is deprecated, only used by db migrations
+type AttributeOverrideRule struct {
+	//GORM attributes, see: http://gorm.io/docs/conventions.html
+	gorm.Model
+
+	ScrutinyUUID string `json:"scrutiny_uuid,omitempty"`
+	ModelRegex   string `json:"model_regex,omitempty"`
+	Protocol     string `json:"protocol,omitempty"`
+
+	AttributeId string `json:"attribute_id" gorm:"not null"`
+
+	Ignore    bool   `json:"ignore"`
+	Ideal     string `json:"ideal,omitempty"`
+	Critical  *bool  `json:"critical,omitempty"`
+	WarnLimit *int64 `json:"warn_limit,omitempty"`
+	FailLimit *int64 `json:"fail_limit,omitempty"`
+}
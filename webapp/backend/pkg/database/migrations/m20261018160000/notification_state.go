@@ -0,0 +1,19 @@
+package m20261018160000
+
+import (
+	"time"
+
+	"github.com/analogj/scrutiny/webapp/backend/pkg"
+	"github.com/gofrs/uuid/v5"
+)
+
+// Deprecated: m20261018160000.NotificationState is deprecated, only used by db migrations
+type NotificationState struct {
+	ScrutinyUUID uuid.UUID        `json:"scrutiny_uuid" gorm:"primaryKey"`
+	DeviceStatus pkg.DeviceStatus `json:"device_status"`
+	NotifiedAt   time.Time        `json:"notified_at"`
+}
+
+func (s NotificationState) TableName() string {
+	return "notification_states"
+}
@@ -0,0 +1,21 @@
+package m20261018170000
+
+import (
+	"time"
+
+	"github.com/analogj/scrutiny/webapp/backend/pkg"
+	"github.com/gofrs/uuid/v5"
+)
+
+// Deprecated: m20261018170000.NotificationState is deprecated, only used by db migrations
+type NotificationState struct {
+	ScrutinyUUID uuid.UUID        `json:"scrutiny_uuid" gorm:"primaryKey"`
+	DeviceStatus pkg.DeviceStatus `json:"device_status"`
+	NotifiedAt   time.Time        `json:"notified_at"`
+
+	WarningNotifiedAt *time.Time `json:"warning_notified_at,omitempty"`
+}
+
+func (s NotificationState) TableName() string {
+	return "notification_states"
+}
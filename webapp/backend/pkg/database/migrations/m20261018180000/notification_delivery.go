@@ -0,0 +1,29 @@
+package m20261018180000
+
+import (
+	"time"
+)
+
+// Deprecated: m20261018180000.NotificationDelivery is deprecated, only used by db migrations
+type NotificationDelivery struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+
+	ScrutinyUUID string `json:"scrutiny_uuid,omitempty" gorm:"index"`
+	HostId       string `json:"host_id,omitempty"`
+	FailureType  string `json:"failure_type"`
+	Subject      string `json:"subject"`
+	Test         bool   `json:"test"`
+
+	TargetType string `json:"target_type"`
+	Target     string `json:"target"`
+
+	Attempt    int    `json:"attempt"`
+	Success    bool   `json:"success"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+func (d NotificationDelivery) TableName() string {
+	return "notification_deliveries"
+}
@@ -0,0 +1,16 @@
+package m20261018190000
+
+import (
+	"time"
+)
+
+// Deprecated: m20261018190000.HostCheckin is deprecated, only used by db migrations
+type HostCheckin struct {
+	HostId        string    `json:"host_id" gorm:"primaryKey"`
+	LastCheckinAt time.Time `json:"last_checkin_at"`
+	Stale         bool      `json:"stale"`
+}
+
+func (h HostCheckin) TableName() string {
+	return "host_checkins"
+}
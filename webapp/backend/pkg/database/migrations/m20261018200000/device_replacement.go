@@ -0,0 +1,24 @@
+package m20261018200000
+
+import (
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+// Deprecated: m20261018200000.DeviceReplacement is deprecated, only used by db migrations
+type DeviceReplacement struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"created_at"`
+
+	HostId     string `json:"host_id"`
+	DeviceName string `json:"device_name"`
+
+	ReplacedScrutinyUUID    uuid.UUID `json:"replaced_scrutiny_uuid" gorm:"index"`
+	ReplacementScrutinyUUID uuid.UUID `json:"replacement_scrutiny_uuid" gorm:"index"`
+	Automatic               bool      `json:"automatic"`
+}
+
+func (r DeviceReplacement) TableName() string {
+	return "device_replacements"
+}
@@ -0,0 +1,23 @@
+package m20261018220000
+
+import (
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+// Deprecated: m20261018220000.DeviceMetadataChange is deprecated, only used by db migrations
+type DeviceMetadataChange struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"created_at"`
+
+	ScrutinyUUID uuid.UUID `json:"scrutiny_uuid" gorm:"index"`
+	Field        string    `json:"field"`
+	OldValue     string    `json:"old_value"`
+	NewValue     string    `json:"new_value"`
+	ChangedBy    string    `json:"changed_by,omitempty"`
+}
+
+func (c DeviceMetadataChange) TableName() string {
+	return "device_metadata_changes"
+}
@@ -0,0 +1,20 @@
+package m20261018230000
+
+import "gorm.io/gorm"
+
+// Deprecated: m20261018230000.WarrantyRule is deprecated, only used by db migrations
+type WarrantyRule struct {
+	//GORM attributes, see: http://gorm.io/docs/conventions.html
+	gorm.Model
+
+	ModelRegex  string `json:"model_regex" gorm:"not null"`
+	Description string `json:"description,omitempty"`
+
+	WarrantyYears float64 `json:"warranty_years,omitempty"`
+	RatedTbw      float64 `json:"rated_tbw,omitempty"`
+	RatedDwpd     float64 `json:"rated_dwpd,omitempty"`
+}
+
+func (r WarrantyRule) TableName() string {
+	return "warranty_rules"
+}
@@ -0,0 +1,27 @@
+package m20261018260000
+
+import (
+	"time"
+)
+
+// Deprecated: m20261018260000.Host is deprecated, only used by db migrations
+type Host struct {
+	HostId    string    `json:"host_id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Tags        []string `json:"tags" gorm:"serializer:json"`
+
+	LastCheckinAt    time.Time `json:"last_checkin_at"`
+	CollectorVersion string    `json:"collector_version"`
+	Platform         string    `json:"platform"`
+
+	Stale    bool `json:"stale"`
+	Archived bool `json:"archived"`
+}
+
+func (h Host) TableName() string {
+	return "hosts"
+}
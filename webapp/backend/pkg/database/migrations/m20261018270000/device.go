@@ -0,0 +1,76 @@
+package m20261018270000
+
+import (
+	"time"
+
+	"github.com/analogj/scrutiny/webapp/backend/pkg"
+	"github.com/gofrs/uuid/v5"
+)
+
+type Device struct {
+	//GORM attributes, see: http://gorm.io/docs/conventions.html
+	Archived  bool `json:"archived" gorm:"index"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt *time.Time
+
+	WWN string `json:"wwn"`
+
+	DeviceName     string `json:"device_name"`
+	DeviceUUID     string `json:"device_uuid"`
+	DeviceSerialID string `json:"device_serial_id"`
+	DeviceLabel    string `json:"device_label"`
+
+	DeviceByPath         string `json:"device_by_path"`
+	EnclosureId          string `json:"enclosure_id"`
+	EnclosureSlot        string `json:"enclosure_slot"`
+	ControllerPciAddress string `json:"controller_pci_address"`
+
+	Manufacturer   string `json:"manufacturer"`
+	ModelName      string `json:"model_name"`
+	InterfaceType  string `json:"interface_type"`
+	InterfaceSpeed string `json:"interface_speed"`
+	SerialNumber   string `json:"serial_number"`
+	Firmware       string `json:"firmware"`
+	RotationSpeed  int    `json:"rotational_speed"`
+	Capacity       int64  `json:"capacity" gorm:"index"`
+	FormFactor     string `json:"form_factor"`
+	SmartSupport   bool   `json:"smart_support"`
+	DeviceProtocol string `json:"device_protocol" gorm:"index"` //protocol determines which smart attribute types are available (ATA, NVMe, SCSI)
+	DeviceType     string `json:"device_type"`                  //device type is used for querying with -d/t flag, should only be used by collector.
+
+	LogicalBlockSize int `json:"logical_block_size,omitempty"`
+
+	// User provided metadata
+	Label  string `json:"label"`
+	HostId string `json:"host_id" gorm:"index"`
+
+	// User provided inventory metadata
+	Location           string     `json:"location"`
+	PurchaseDate       *time.Time `json:"purchase_date,omitempty"`
+	WarrantyExpiryDate *time.Time `json:"warranty_expiry_date,omitempty"`
+	RmaUrl             string     `json:"rma_url"`
+	PurchaseCost       *float64   `json:"purchase_cost,omitempty"`
+	Notes              string     `json:"notes"`
+
+	RatedTbw *float64 `json:"rated_tbw,omitempty"`
+
+	// Data set by Scrutiny
+	DeviceStatus pkg.DeviceStatus `json:"device_status" gorm:"index"`
+	ScrutinyUUID uuid.UUID        `json:"scrutiny_uuid" gorm:"primaryKey;uniqueIndex"`
+
+	// Failure prediction
+	FailureScore            int        `json:"failure_score"`
+	FailureScoreAttributeId string     `json:"failure_score_attribute_id,omitempty"`
+	EstimatedFailureDate    *time.Time `json:"estimated_failure_date,omitempty"`
+
+	// SSD endurance
+	DailyBytesWritten    *float64   `json:"daily_bytes_written,omitempty"`
+	WearPercentUsed      *float64   `json:"wear_percent_used,omitempty"`
+	EndurancePercentUsed *float64   `json:"endurance_percent_used,omitempty"`
+	EstimatedWearOutDate *time.Time `json:"estimated_wear_out_date,omitempty"`
+
+	// Missing device detection
+	LastSeenAt *time.Time `json:"last_seen_at,omitempty"`
+	Stale      bool       `json:"stale"`
+}
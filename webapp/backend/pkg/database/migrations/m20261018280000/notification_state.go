@@ -0,0 +1,26 @@
+package m20261018280000
+
+import (
+	"time"
+
+	"github.com/analogj/scrutiny/webapp/backend/pkg"
+	"github.com/gofrs/uuid/v5"
+)
+
+// Deprecated: m20261018280000.NotificationState is deprecated, only used by db migrations
+type NotificationState struct {
+	ScrutinyUUID uuid.UUID        `json:"scrutiny_uuid" gorm:"primaryKey"`
+	DeviceStatus pkg.DeviceStatus `json:"device_status"`
+	NotifiedAt   time.Time        `json:"notified_at"`
+
+	FailingAttributes []string `json:"failing_attributes,omitempty" gorm:"serializer:json"`
+
+	WarningNotifiedAt *time.Time `json:"warning_notified_at,omitempty"`
+
+	WarrantyNotifiedPercent  int `json:"warranty_notified_percent"`
+	EnduranceNotifiedPercent int `json:"endurance_notified_percent"`
+}
+
+func (s NotificationState) TableName() string {
+	return "notification_states"
+}
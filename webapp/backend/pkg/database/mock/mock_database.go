@@ -12,11 +12,14 @@ package mock_database
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	pkg "github.com/analogj/scrutiny/webapp/backend/pkg"
+	analysis "github.com/analogj/scrutiny/webapp/backend/pkg/analysis"
 	models "github.com/analogj/scrutiny/webapp/backend/pkg/models"
 	collector "github.com/analogj/scrutiny/webapp/backend/pkg/models/collector"
 	measurements "github.com/analogj/scrutiny/webapp/backend/pkg/models/measurements"
+	thresholds "github.com/analogj/scrutiny/webapp/backend/pkg/thresholds"
 	uuid "github.com/gofrs/uuid/v5"
 	gomock "go.uber.org/mock/gomock"
 )
@@ -45,6 +48,21 @@ func (m *MockDeviceRepo) EXPECT() *MockDeviceRepoMockRecorder {
 	return m.recorder
 }
 
+// AuthenticateUser mocks base method.
+func (m *MockDeviceRepo) AuthenticateUser(ctx context.Context, username, password string) (models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthenticateUser", ctx, username, password)
+	ret0, _ := ret[0].(models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AuthenticateUser indicates an expected call of AuthenticateUser.
+func (mr *MockDeviceRepoMockRecorder) AuthenticateUser(ctx, username, password any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthenticateUser", reflect.TypeOf((*MockDeviceRepo)(nil).AuthenticateUser), ctx, username, password)
+}
+
 // Close mocks base method.
 func (m *MockDeviceRepo) Close() error {
 	m.ctrl.T.Helper()
@@ -59,6 +77,125 @@ func (mr *MockDeviceRepoMockRecorder) Close() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockDeviceRepo)(nil).Close))
 }
 
+// CreateAttributeOverrideRule mocks base method.
+func (m *MockDeviceRepo) CreateAttributeOverrideRule(ctx context.Context, rule models.AttributeOverrideRule) (models.AttributeOverrideRule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAttributeOverrideRule", ctx, rule)
+	ret0, _ := ret[0].(models.AttributeOverrideRule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAttributeOverrideRule indicates an expected call of CreateAttributeOverrideRule.
+func (mr *MockDeviceRepoMockRecorder) CreateAttributeOverrideRule(ctx, rule any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAttributeOverrideRule", reflect.TypeOf((*MockDeviceRepo)(nil).CreateAttributeOverrideRule), ctx, rule)
+}
+
+// CreateCollectorToken mocks base method.
+func (m *MockDeviceRepo) CreateCollectorToken(ctx context.Context, hostId, description string) (models.CollectorToken, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateCollectorToken", ctx, hostId, description)
+	ret0, _ := ret[0].(models.CollectorToken)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// CreateCollectorToken indicates an expected call of CreateCollectorToken.
+func (mr *MockDeviceRepoMockRecorder) CreateCollectorToken(ctx, hostId, description any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCollectorToken", reflect.TypeOf((*MockDeviceRepo)(nil).CreateCollectorToken), ctx, hostId, description)
+}
+
+// CreateDeviceReplacement mocks base method.
+func (m *MockDeviceRepo) CreateDeviceReplacement(ctx context.Context, replacement models.DeviceReplacement) (models.DeviceReplacement, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateDeviceReplacement", ctx, replacement)
+	ret0, _ := ret[0].(models.DeviceReplacement)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateDeviceReplacement indicates an expected call of CreateDeviceReplacement.
+func (mr *MockDeviceRepoMockRecorder) CreateDeviceReplacement(ctx, replacement any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateDeviceReplacement", reflect.TypeOf((*MockDeviceRepo)(nil).CreateDeviceReplacement), ctx, replacement)
+}
+
+// CreateUser mocks base method.
+func (m *MockDeviceRepo) CreateUser(ctx context.Context, username, password string, role pkg.UserRole) (models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUser", ctx, username, password, role)
+	ret0, _ := ret[0].(models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateUser indicates an expected call of CreateUser.
+func (mr *MockDeviceRepoMockRecorder) CreateUser(ctx, username, password, role any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockDeviceRepo)(nil).CreateUser), ctx, username, password, role)
+}
+
+// CreateUserSession mocks base method.
+func (m *MockDeviceRepo) CreateUserSession(ctx context.Context, userId uint, ttl time.Duration) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUserSession", ctx, userId, ttl)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateUserSession indicates an expected call of CreateUserSession.
+func (mr *MockDeviceRepoMockRecorder) CreateUserSession(ctx, userId, ttl any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUserSession", reflect.TypeOf((*MockDeviceRepo)(nil).CreateUserSession), ctx, userId, ttl)
+}
+
+// CreateWarrantyRule mocks base method.
+func (m *MockDeviceRepo) CreateWarrantyRule(ctx context.Context, rule models.WarrantyRule) (models.WarrantyRule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWarrantyRule", ctx, rule)
+	ret0, _ := ret[0].(models.WarrantyRule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateWarrantyRule indicates an expected call of CreateWarrantyRule.
+func (mr *MockDeviceRepoMockRecorder) CreateWarrantyRule(ctx, rule any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWarrantyRule", reflect.TypeOf((*MockDeviceRepo)(nil).CreateWarrantyRule), ctx, rule)
+}
+
+// DeleteAttributeOverrideRule mocks base method.
+func (m *MockDeviceRepo) DeleteAttributeOverrideRule(ctx context.Context, id uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAttributeOverrideRule", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAttributeOverrideRule indicates an expected call of DeleteAttributeOverrideRule.
+func (mr *MockDeviceRepoMockRecorder) DeleteAttributeOverrideRule(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAttributeOverrideRule", reflect.TypeOf((*MockDeviceRepo)(nil).DeleteAttributeOverrideRule), ctx, id)
+}
+
+// DeleteCollectorToken mocks base method.
+func (m *MockDeviceRepo) DeleteCollectorToken(ctx context.Context, id uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteCollectorToken", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteCollectorToken indicates an expected call of DeleteCollectorToken.
+func (mr *MockDeviceRepoMockRecorder) DeleteCollectorToken(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCollectorToken", reflect.TypeOf((*MockDeviceRepo)(nil).DeleteCollectorToken), ctx, id)
+}
+
 // DeleteDevice mocks base method.
 func (m *MockDeviceRepo) DeleteDevice(ctx context.Context, scrutiny_uuid uuid.UUID) error {
 	m.ctrl.T.Helper()
@@ -73,6 +210,150 @@ func (mr *MockDeviceRepoMockRecorder) DeleteDevice(ctx, scrutiny_uuid any) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteDevice", reflect.TypeOf((*MockDeviceRepo)(nil).DeleteDevice), ctx, scrutiny_uuid)
 }
 
+// DeleteDeviceReplacement mocks base method.
+func (m *MockDeviceRepo) DeleteDeviceReplacement(ctx context.Context, scrutiny_uuid uuid.UUID, id uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteDeviceReplacement", ctx, scrutiny_uuid, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteDeviceReplacement indicates an expected call of DeleteDeviceReplacement.
+func (mr *MockDeviceRepoMockRecorder) DeleteDeviceReplacement(ctx, scrutiny_uuid, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteDeviceReplacement", reflect.TypeOf((*MockDeviceRepo)(nil).DeleteDeviceReplacement), ctx, scrutiny_uuid, id)
+}
+
+// DeleteHost mocks base method.
+func (m *MockDeviceRepo) DeleteHost(ctx context.Context, hostId string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteHost", ctx, hostId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteHost indicates an expected call of DeleteHost.
+func (mr *MockDeviceRepoMockRecorder) DeleteHost(ctx, hostId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteHost", reflect.TypeOf((*MockDeviceRepo)(nil).DeleteHost), ctx, hostId)
+}
+
+// DeleteUser mocks base method.
+func (m *MockDeviceRepo) DeleteUser(ctx context.Context, id uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUser", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUser indicates an expected call of DeleteUser.
+func (mr *MockDeviceRepoMockRecorder) DeleteUser(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockDeviceRepo)(nil).DeleteUser), ctx, id)
+}
+
+// DeleteUserSession mocks base method.
+func (m *MockDeviceRepo) DeleteUserSession(ctx context.Context, tokenValue string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUserSession", ctx, tokenValue)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUserSession indicates an expected call of DeleteUserSession.
+func (mr *MockDeviceRepoMockRecorder) DeleteUserSession(ctx, tokenValue any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUserSession", reflect.TypeOf((*MockDeviceRepo)(nil).DeleteUserSession), ctx, tokenValue)
+}
+
+// DeleteWarrantyRule mocks base method.
+func (m *MockDeviceRepo) DeleteWarrantyRule(ctx context.Context, id uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWarrantyRule", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteWarrantyRule indicates an expected call of DeleteWarrantyRule.
+func (mr *MockDeviceRepoMockRecorder) DeleteWarrantyRule(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWarrantyRule", reflect.TypeOf((*MockDeviceRepo)(nil).DeleteWarrantyRule), ctx, id)
+}
+
+// EnsureAdminUser mocks base method.
+func (m *MockDeviceRepo) EnsureAdminUser(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnsureAdminUser", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EnsureAdminUser indicates an expected call of EnsureAdminUser.
+func (mr *MockDeviceRepoMockRecorder) EnsureAdminUser(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnsureAdminUser", reflect.TypeOf((*MockDeviceRepo)(nil).EnsureAdminUser), ctx)
+}
+
+// GetAnalysisSmartHistory mocks base method.
+func (m *MockDeviceRepo) GetAnalysisSmartHistory(ctx context.Context, scrutiny_uuid uuid.UUID, latest measurements.Smart) ([]measurements.Smart, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAnalysisSmartHistory", ctx, scrutiny_uuid, latest)
+	ret0, _ := ret[0].([]measurements.Smart)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAnalysisSmartHistory indicates an expected call of GetAnalysisSmartHistory.
+func (mr *MockDeviceRepoMockRecorder) GetAnalysisSmartHistory(ctx, scrutiny_uuid, latest any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAnalysisSmartHistory", reflect.TypeOf((*MockDeviceRepo)(nil).GetAnalysisSmartHistory), ctx, scrutiny_uuid, latest)
+}
+
+// GetAttributeOverrideRules mocks base method.
+func (m *MockDeviceRepo) GetAttributeOverrideRules(ctx context.Context) ([]models.AttributeOverrideRule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAttributeOverrideRules", ctx)
+	ret0, _ := ret[0].([]models.AttributeOverrideRule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAttributeOverrideRules indicates an expected call of GetAttributeOverrideRules.
+func (mr *MockDeviceRepoMockRecorder) GetAttributeOverrideRules(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAttributeOverrideRules", reflect.TypeOf((*MockDeviceRepo)(nil).GetAttributeOverrideRules), ctx)
+}
+
+// GetAttributeOverrides mocks base method.
+func (m *MockDeviceRepo) GetAttributeOverrides(ctx context.Context, device models.Device) (map[string]thresholds.AttributeOverride, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAttributeOverrides", ctx, device)
+	ret0, _ := ret[0].(map[string]thresholds.AttributeOverride)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAttributeOverrides indicates an expected call of GetAttributeOverrides.
+func (mr *MockDeviceRepoMockRecorder) GetAttributeOverrides(ctx, device any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAttributeOverrides", reflect.TypeOf((*MockDeviceRepo)(nil).GetAttributeOverrides), ctx, device)
+}
+
+// GetCollectorTokens mocks base method.
+func (m *MockDeviceRepo) GetCollectorTokens(ctx context.Context) ([]models.CollectorToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCollectorTokens", ctx)
+	ret0, _ := ret[0].([]models.CollectorToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCollectorTokens indicates an expected call of GetCollectorTokens.
+func (mr *MockDeviceRepoMockRecorder) GetCollectorTokens(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCollectorTokens", reflect.TypeOf((*MockDeviceRepo)(nil).GetCollectorTokens), ctx)
+}
+
 // GetDeviceDetails mocks base method.
 func (m *MockDeviceRepo) GetDeviceDetails(ctx context.Context, scrutiny_uuid uuid.UUID) (models.Device, error) {
 	m.ctrl.T.Helper()
@@ -88,6 +369,36 @@ func (mr *MockDeviceRepoMockRecorder) GetDeviceDetails(ctx, scrutiny_uuid any) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeviceDetails", reflect.TypeOf((*MockDeviceRepo)(nil).GetDeviceDetails), ctx, scrutiny_uuid)
 }
 
+// GetDeviceMetadataChanges mocks base method.
+func (m *MockDeviceRepo) GetDeviceMetadataChanges(ctx context.Context, scrutiny_uuid uuid.UUID, limit int) ([]models.DeviceMetadataChange, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeviceMetadataChanges", ctx, scrutiny_uuid, limit)
+	ret0, _ := ret[0].([]models.DeviceMetadataChange)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeviceMetadataChanges indicates an expected call of GetDeviceMetadataChanges.
+func (mr *MockDeviceRepoMockRecorder) GetDeviceMetadataChanges(ctx, scrutiny_uuid, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeviceMetadataChanges", reflect.TypeOf((*MockDeviceRepo)(nil).GetDeviceMetadataChanges), ctx, scrutiny_uuid, limit)
+}
+
+// GetDeviceReplacements mocks base method.
+func (m *MockDeviceRepo) GetDeviceReplacements(ctx context.Context, scrutiny_uuid uuid.UUID) ([]models.DeviceReplacement, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeviceReplacements", ctx, scrutiny_uuid)
+	ret0, _ := ret[0].([]models.DeviceReplacement)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeviceReplacements indicates an expected call of GetDeviceReplacements.
+func (mr *MockDeviceRepoMockRecorder) GetDeviceReplacements(ctx, scrutiny_uuid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeviceReplacements", reflect.TypeOf((*MockDeviceRepo)(nil).GetDeviceReplacements), ctx, scrutiny_uuid)
+}
+
 // GetDevices mocks base method.
 func (m *MockDeviceRepo) GetDevices(ctx context.Context) ([]models.Device, error) {
 	m.ctrl.T.Helper()
@@ -103,6 +414,141 @@ func (mr *MockDeviceRepoMockRecorder) GetDevices(ctx any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDevices", reflect.TypeOf((*MockDeviceRepo)(nil).GetDevices), ctx)
 }
 
+// GetHost mocks base method.
+func (m *MockDeviceRepo) GetHost(ctx context.Context, hostId string) (models.Host, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetHost", ctx, hostId)
+	ret0, _ := ret[0].(models.Host)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetHost indicates an expected call of GetHost.
+func (mr *MockDeviceRepoMockRecorder) GetHost(ctx, hostId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHost", reflect.TypeOf((*MockDeviceRepo)(nil).GetHost), ctx, hostId)
+}
+
+// GetHostDevices mocks base method.
+func (m *MockDeviceRepo) GetHostDevices(ctx context.Context, hostIds []string) ([]models.Device, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetHostDevices", ctx, hostIds)
+	ret0, _ := ret[0].([]models.Device)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetHostDevices indicates an expected call of GetHostDevices.
+func (mr *MockDeviceRepoMockRecorder) GetHostDevices(ctx, hostIds any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHostDevices", reflect.TypeOf((*MockDeviceRepo)(nil).GetHostDevices), ctx, hostIds)
+}
+
+// GetHostSummaries mocks base method.
+func (m *MockDeviceRepo) GetHostSummaries(ctx context.Context) ([]models.HostSummary, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetHostSummaries", ctx)
+	ret0, _ := ret[0].([]models.HostSummary)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetHostSummaries indicates an expected call of GetHostSummaries.
+func (mr *MockDeviceRepoMockRecorder) GetHostSummaries(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHostSummaries", reflect.TypeOf((*MockDeviceRepo)(nil).GetHostSummaries), ctx)
+}
+
+// GetHostSummary mocks base method.
+func (m *MockDeviceRepo) GetHostSummary(ctx context.Context, hostId string) (models.HostSummary, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetHostSummary", ctx, hostId)
+	ret0, _ := ret[0].(models.HostSummary)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetHostSummary indicates an expected call of GetHostSummary.
+func (mr *MockDeviceRepoMockRecorder) GetHostSummary(ctx, hostId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHostSummary", reflect.TypeOf((*MockDeviceRepo)(nil).GetHostSummary), ctx, hostId)
+}
+
+// GetHosts mocks base method.
+func (m *MockDeviceRepo) GetHosts(ctx context.Context) ([]models.Host, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetHosts", ctx)
+	ret0, _ := ret[0].([]models.Host)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetHosts indicates an expected call of GetHosts.
+func (mr *MockDeviceRepoMockRecorder) GetHosts(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHosts", reflect.TypeOf((*MockDeviceRepo)(nil).GetHosts), ctx)
+}
+
+// GetLatestSmartAttributes mocks base method.
+func (m *MockDeviceRepo) GetLatestSmartAttributes(ctx context.Context) (map[uuid.UUID]measurements.Smart, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLatestSmartAttributes", ctx)
+	ret0, _ := ret[0].(map[uuid.UUID]measurements.Smart)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLatestSmartAttributes indicates an expected call of GetLatestSmartAttributes.
+func (mr *MockDeviceRepoMockRecorder) GetLatestSmartAttributes(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLatestSmartAttributes", reflect.TypeOf((*MockDeviceRepo)(nil).GetLatestSmartAttributes), ctx)
+}
+
+// GetNotificationDeliveries mocks base method.
+func (m *MockDeviceRepo) GetNotificationDeliveries(ctx context.Context, scrutinyUUID string, limit int) ([]models.NotificationDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNotificationDeliveries", ctx, scrutinyUUID, limit)
+	ret0, _ := ret[0].([]models.NotificationDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetNotificationDeliveries indicates an expected call of GetNotificationDeliveries.
+func (mr *MockDeviceRepoMockRecorder) GetNotificationDeliveries(ctx, scrutinyUUID, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNotificationDeliveries", reflect.TypeOf((*MockDeviceRepo)(nil).GetNotificationDeliveries), ctx, scrutinyUUID, limit)
+}
+
+// GetNotificationState mocks base method.
+func (m *MockDeviceRepo) GetNotificationState(ctx context.Context, scrutiny_uuid uuid.UUID) (models.NotificationState, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNotificationState", ctx, scrutiny_uuid)
+	ret0, _ := ret[0].(models.NotificationState)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetNotificationState indicates an expected call of GetNotificationState.
+func (mr *MockDeviceRepoMockRecorder) GetNotificationState(ctx, scrutiny_uuid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNotificationState", reflect.TypeOf((*MockDeviceRepo)(nil).GetNotificationState), ctx, scrutiny_uuid)
+}
+
+// GetSessionUser mocks base method.
+func (m *MockDeviceRepo) GetSessionUser(ctx context.Context, tokenValue string) (models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSessionUser", ctx, tokenValue)
+	ret0, _ := ret[0].(models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSessionUser indicates an expected call of GetSessionUser.
+func (mr *MockDeviceRepoMockRecorder) GetSessionUser(ctx, tokenValue any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSessionUser", reflect.TypeOf((*MockDeviceRepo)(nil).GetSessionUser), ctx, tokenValue)
+}
+
 // GetSmartAttributeHistory mocks base method.
 func (m *MockDeviceRepo) GetSmartAttributeHistory(ctx context.Context, scrutiny_uuid uuid.UUID, durationKey string, selectEntries, selectEntriesOffset int, attributes []string) ([]measurements.Smart, error) {
 	m.ctrl.T.Helper()
@@ -118,6 +564,21 @@ func (mr *MockDeviceRepoMockRecorder) GetSmartAttributeHistory(ctx, scrutiny_uui
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSmartAttributeHistory", reflect.TypeOf((*MockDeviceRepo)(nil).GetSmartAttributeHistory), ctx, scrutiny_uuid, durationKey, selectEntries, selectEntriesOffset, attributes)
 }
 
+// GetSmartSelfTestHistory mocks base method.
+func (m *MockDeviceRepo) GetSmartSelfTestHistory(ctx context.Context, scrutiny_uuid uuid.UUID, durationKey string) ([]measurements.SmartSelfTest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSmartSelfTestHistory", ctx, scrutiny_uuid, durationKey)
+	ret0, _ := ret[0].([]measurements.SmartSelfTest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSmartSelfTestHistory indicates an expected call of GetSmartSelfTestHistory.
+func (mr *MockDeviceRepoMockRecorder) GetSmartSelfTestHistory(ctx, scrutiny_uuid, durationKey any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSmartSelfTestHistory", reflect.TypeOf((*MockDeviceRepo)(nil).GetSmartSelfTestHistory), ctx, scrutiny_uuid, durationKey)
+}
+
 // GetSmartTemperatureHistory mocks base method.
 func (m *MockDeviceRepo) GetSmartTemperatureHistory(ctx context.Context, durationKey string) (map[uuid.UUID][]measurements.SmartTemperature, error) {
 	m.ctrl.T.Helper()
@@ -148,6 +609,51 @@ func (mr *MockDeviceRepoMockRecorder) GetSummary(ctx any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSummary", reflect.TypeOf((*MockDeviceRepo)(nil).GetSummary), ctx)
 }
 
+// GetUsers mocks base method.
+func (m *MockDeviceRepo) GetUsers(ctx context.Context) ([]models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUsers", ctx)
+	ret0, _ := ret[0].([]models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUsers indicates an expected call of GetUsers.
+func (mr *MockDeviceRepoMockRecorder) GetUsers(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsers", reflect.TypeOf((*MockDeviceRepo)(nil).GetUsers), ctx)
+}
+
+// GetWarrantyRule mocks base method.
+func (m *MockDeviceRepo) GetWarrantyRule(ctx context.Context, device models.Device) (*models.WarrantyRule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWarrantyRule", ctx, device)
+	ret0, _ := ret[0].(*models.WarrantyRule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWarrantyRule indicates an expected call of GetWarrantyRule.
+func (mr *MockDeviceRepoMockRecorder) GetWarrantyRule(ctx, device any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWarrantyRule", reflect.TypeOf((*MockDeviceRepo)(nil).GetWarrantyRule), ctx, device)
+}
+
+// GetWarrantyRules mocks base method.
+func (m *MockDeviceRepo) GetWarrantyRules(ctx context.Context) ([]models.WarrantyRule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWarrantyRules", ctx)
+	ret0, _ := ret[0].([]models.WarrantyRule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWarrantyRules indicates an expected call of GetWarrantyRules.
+func (mr *MockDeviceRepoMockRecorder) GetWarrantyRules(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWarrantyRules", reflect.TypeOf((*MockDeviceRepo)(nil).GetWarrantyRules), ctx)
+}
+
 // HealthCheck mocks base method.
 func (m *MockDeviceRepo) HealthCheck(ctx context.Context) error {
 	m.ctrl.T.Helper()
@@ -177,6 +683,37 @@ func (mr *MockDeviceRepoMockRecorder) LoadSettings(ctx any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadSettings", reflect.TypeOf((*MockDeviceRepo)(nil).LoadSettings), ctx)
 }
 
+// PruneNotificationDeliveries mocks base method.
+func (m *MockDeviceRepo) PruneNotificationDeliveries(ctx context.Context, before time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PruneNotificationDeliveries", ctx, before)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PruneNotificationDeliveries indicates an expected call of PruneNotificationDeliveries.
+func (mr *MockDeviceRepoMockRecorder) PruneNotificationDeliveries(ctx, before any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PruneNotificationDeliveries", reflect.TypeOf((*MockDeviceRepo)(nil).PruneNotificationDeliveries), ctx, before)
+}
+
+// QueryDevices mocks base method.
+func (m *MockDeviceRepo) QueryDevices(ctx context.Context, query models.DeviceQuery) ([]models.Device, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "QueryDevices", ctx, query)
+	ret0, _ := ret[0].([]models.Device)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// QueryDevices indicates an expected call of QueryDevices.
+func (mr *MockDeviceRepoMockRecorder) QueryDevices(ctx, query any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryDevices", reflect.TypeOf((*MockDeviceRepo)(nil).QueryDevices), ctx, query)
+}
+
 // RegisterDevice mocks base method.
 func (m *MockDeviceRepo) RegisterDevice(ctx context.Context, dev models.Device) error {
 	m.ctrl.T.Helper()
@@ -191,6 +728,48 @@ func (mr *MockDeviceRepoMockRecorder) RegisterDevice(ctx, dev any) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterDevice", reflect.TypeOf((*MockDeviceRepo)(nil).RegisterDevice), ctx, dev)
 }
 
+// SaveHostCheckin mocks base method.
+func (m *MockDeviceRepo) SaveHostCheckin(ctx context.Context, hostId string, checkinAt time.Time, collectorVersion, platform string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveHostCheckin", ctx, hostId, checkinAt, collectorVersion, platform)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveHostCheckin indicates an expected call of SaveHostCheckin.
+func (mr *MockDeviceRepoMockRecorder) SaveHostCheckin(ctx, hostId, checkinAt, collectorVersion, platform any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveHostCheckin", reflect.TypeOf((*MockDeviceRepo)(nil).SaveHostCheckin), ctx, hostId, checkinAt, collectorVersion, platform)
+}
+
+// SaveNotificationDelivery mocks base method.
+func (m *MockDeviceRepo) SaveNotificationDelivery(ctx context.Context, delivery models.NotificationDelivery) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveNotificationDelivery", ctx, delivery)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveNotificationDelivery indicates an expected call of SaveNotificationDelivery.
+func (mr *MockDeviceRepoMockRecorder) SaveNotificationDelivery(ctx, delivery any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveNotificationDelivery", reflect.TypeOf((*MockDeviceRepo)(nil).SaveNotificationDelivery), ctx, delivery)
+}
+
+// SaveNotificationState mocks base method.
+func (m *MockDeviceRepo) SaveNotificationState(ctx context.Context, state models.NotificationState) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveNotificationState", ctx, state)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveNotificationState indicates an expected call of SaveNotificationState.
+func (mr *MockDeviceRepoMockRecorder) SaveNotificationState(ctx, state any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveNotificationState", reflect.TypeOf((*MockDeviceRepo)(nil).SaveNotificationState), ctx, state)
+}
+
 // SaveSettings mocks base method.
 func (m *MockDeviceRepo) SaveSettings(ctx context.Context, settings models.Settings) error {
 	m.ctrl.T.Helper()
@@ -220,6 +799,21 @@ func (mr *MockDeviceRepoMockRecorder) SaveSmartAttributes(ctx, scrutiny_uuid, co
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveSmartAttributes", reflect.TypeOf((*MockDeviceRepo)(nil).SaveSmartAttributes), ctx, scrutiny_uuid, collectorSmartData)
 }
 
+// SaveSmartSelfTests mocks base method.
+func (m *MockDeviceRepo) SaveSmartSelfTests(ctx context.Context, scrutiny_uuid uuid.UUID, deviceProtocol string, collectorSmartData collector.SmartInfo) ([]measurements.SmartSelfTest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveSmartSelfTests", ctx, scrutiny_uuid, deviceProtocol, collectorSmartData)
+	ret0, _ := ret[0].([]measurements.SmartSelfTest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SaveSmartSelfTests indicates an expected call of SaveSmartSelfTests.
+func (mr *MockDeviceRepoMockRecorder) SaveSmartSelfTests(ctx, scrutiny_uuid, deviceProtocol, collectorSmartData any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveSmartSelfTests", reflect.TypeOf((*MockDeviceRepo)(nil).SaveSmartSelfTests), ctx, scrutiny_uuid, deviceProtocol, collectorSmartData)
+}
+
 // SaveSmartTemperature mocks base method.
 func (m *MockDeviceRepo) SaveSmartTemperature(ctx context.Context, scrutiny_uuid uuid.UUID, deviceProtocol string, collectorSmartData collector.SmartInfo, discardSCTTempHistory bool) error {
 	m.ctrl.T.Helper()
@@ -234,6 +828,21 @@ func (mr *MockDeviceRepoMockRecorder) SaveSmartTemperature(ctx, scrutiny_uuid, d
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveSmartTemperature", reflect.TypeOf((*MockDeviceRepo)(nil).SaveSmartTemperature), ctx, scrutiny_uuid, deviceProtocol, collectorSmartData, discardSCTTempHistory)
 }
 
+// UpdateAttributeOverrideRule mocks base method.
+func (m *MockDeviceRepo) UpdateAttributeOverrideRule(ctx context.Context, id uint, rule models.AttributeOverrideRule) (models.AttributeOverrideRule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateAttributeOverrideRule", ctx, id, rule)
+	ret0, _ := ret[0].(models.AttributeOverrideRule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateAttributeOverrideRule indicates an expected call of UpdateAttributeOverrideRule.
+func (mr *MockDeviceRepoMockRecorder) UpdateAttributeOverrideRule(ctx, id, rule any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAttributeOverrideRule", reflect.TypeOf((*MockDeviceRepo)(nil).UpdateAttributeOverrideRule), ctx, id, rule)
+}
+
 // UpdateDevice mocks base method.
 func (m *MockDeviceRepo) UpdateDevice(ctx context.Context, scrutiny_uuid uuid.UUID, collectorSmartData collector.SmartInfo) (models.Device, error) {
 	m.ctrl.T.Helper()
@@ -263,6 +872,64 @@ func (mr *MockDeviceRepoMockRecorder) UpdateDeviceArchived(ctx, scrutiny_uuid, a
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateDeviceArchived", reflect.TypeOf((*MockDeviceRepo)(nil).UpdateDeviceArchived), ctx, scrutiny_uuid, archived)
 }
 
+// UpdateDeviceEndurance mocks base method.
+func (m *MockDeviceRepo) UpdateDeviceEndurance(ctx context.Context, scrutiny_uuid uuid.UUID, endurance analysis.Endurance) (models.Device, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateDeviceEndurance", ctx, scrutiny_uuid, endurance)
+	ret0, _ := ret[0].(models.Device)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateDeviceEndurance indicates an expected call of UpdateDeviceEndurance.
+func (mr *MockDeviceRepoMockRecorder) UpdateDeviceEndurance(ctx, scrutiny_uuid, endurance any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateDeviceEndurance", reflect.TypeOf((*MockDeviceRepo)(nil).UpdateDeviceEndurance), ctx, scrutiny_uuid, endurance)
+}
+
+// UpdateDeviceFailurePrediction mocks base method.
+func (m *MockDeviceRepo) UpdateDeviceFailurePrediction(ctx context.Context, scrutiny_uuid uuid.UUID, prediction analysis.FailurePrediction) (models.Device, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateDeviceFailurePrediction", ctx, scrutiny_uuid, prediction)
+	ret0, _ := ret[0].(models.Device)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateDeviceFailurePrediction indicates an expected call of UpdateDeviceFailurePrediction.
+func (mr *MockDeviceRepoMockRecorder) UpdateDeviceFailurePrediction(ctx, scrutiny_uuid, prediction any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateDeviceFailurePrediction", reflect.TypeOf((*MockDeviceRepo)(nil).UpdateDeviceFailurePrediction), ctx, scrutiny_uuid, prediction)
+}
+
+// UpdateDeviceMetadata mocks base method.
+func (m *MockDeviceRepo) UpdateDeviceMetadata(ctx context.Context, device models.Device, changes []models.DeviceMetadataChange) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateDeviceMetadata", ctx, device, changes)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateDeviceMetadata indicates an expected call of UpdateDeviceMetadata.
+func (mr *MockDeviceRepoMockRecorder) UpdateDeviceMetadata(ctx, device, changes any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateDeviceMetadata", reflect.TypeOf((*MockDeviceRepo)(nil).UpdateDeviceMetadata), ctx, device, changes)
+}
+
+// UpdateDeviceStale mocks base method.
+func (m *MockDeviceRepo) UpdateDeviceStale(ctx context.Context, scrutiny_uuid uuid.UUID, stale bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateDeviceStale", ctx, scrutiny_uuid, stale)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateDeviceStale indicates an expected call of UpdateDeviceStale.
+func (mr *MockDeviceRepoMockRecorder) UpdateDeviceStale(ctx, scrutiny_uuid, stale any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateDeviceStale", reflect.TypeOf((*MockDeviceRepo)(nil).UpdateDeviceStale), ctx, scrutiny_uuid, stale)
+}
+
 // UpdateDeviceStatus mocks base method.
 func (m *MockDeviceRepo) UpdateDeviceStatus(ctx context.Context, scrutiny_uuid uuid.UUID, status pkg.DeviceStatus) (models.Device, error) {
 	m.ctrl.T.Helper()
@@ -277,3 +944,243 @@ func (mr *MockDeviceRepoMockRecorder) UpdateDeviceStatus(ctx, scrutiny_uuid, sta
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateDeviceStatus", reflect.TypeOf((*MockDeviceRepo)(nil).UpdateDeviceStatus), ctx, scrutiny_uuid, status)
 }
+
+// UpdateHostArchived mocks base method.
+func (m *MockDeviceRepo) UpdateHostArchived(ctx context.Context, hostId string, archived bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateHostArchived", ctx, hostId, archived)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateHostArchived indicates an expected call of UpdateHostArchived.
+func (mr *MockDeviceRepoMockRecorder) UpdateHostArchived(ctx, hostId, archived any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateHostArchived", reflect.TypeOf((*MockDeviceRepo)(nil).UpdateHostArchived), ctx, hostId, archived)
+}
+
+// UpdateHostMetadata mocks base method.
+func (m *MockDeviceRepo) UpdateHostMetadata(ctx context.Context, host models.Host) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateHostMetadata", ctx, host)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateHostMetadata indicates an expected call of UpdateHostMetadata.
+func (mr *MockDeviceRepoMockRecorder) UpdateHostMetadata(ctx, host any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateHostMetadata", reflect.TypeOf((*MockDeviceRepo)(nil).UpdateHostMetadata), ctx, host)
+}
+
+// UpdateHostStale mocks base method.
+func (m *MockDeviceRepo) UpdateHostStale(ctx context.Context, hostId string, stale bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateHostStale", ctx, hostId, stale)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateHostStale indicates an expected call of UpdateHostStale.
+func (mr *MockDeviceRepoMockRecorder) UpdateHostStale(ctx, hostId, stale any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateHostStale", reflect.TypeOf((*MockDeviceRepo)(nil).UpdateHostStale), ctx, hostId, stale)
+}
+
+// ValidateCollectorToken mocks base method.
+func (m *MockDeviceRepo) ValidateCollectorToken(ctx context.Context, tokenValue string) (models.CollectorToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ValidateCollectorToken", ctx, tokenValue)
+	ret0, _ := ret[0].(models.CollectorToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ValidateCollectorToken indicates an expected call of ValidateCollectorToken.
+func (mr *MockDeviceRepoMockRecorder) ValidateCollectorToken(ctx, tokenValue any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateCollectorToken", reflect.TypeOf((*MockDeviceRepo)(nil).ValidateCollectorToken), ctx, tokenValue)
+}
+
+// MockTimeSeriesRepo is a mock of TimeSeriesRepo interface.
+type MockTimeSeriesRepo struct {
+	ctrl     *gomock.Controller
+	recorder *MockTimeSeriesRepoMockRecorder
+	isgomock struct{}
+}
+
+// MockTimeSeriesRepoMockRecorder is the mock recorder for MockTimeSeriesRepo.
+type MockTimeSeriesRepoMockRecorder struct {
+	mock *MockTimeSeriesRepo
+}
+
+// NewMockTimeSeriesRepo creates a new mock instance.
+func NewMockTimeSeriesRepo(ctrl *gomock.Controller) *MockTimeSeriesRepo {
+	mock := &MockTimeSeriesRepo{ctrl: ctrl}
+	mock.recorder = &MockTimeSeriesRepoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTimeSeriesRepo) EXPECT() *MockTimeSeriesRepoMockRecorder {
+	return m.recorder
+}
+
+// Close mocks base method.
+func (m *MockTimeSeriesRepo) Close() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Close")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Close indicates an expected call of Close.
+func (mr *MockTimeSeriesRepoMockRecorder) Close() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockTimeSeriesRepo)(nil).Close))
+}
+
+// DeleteDeviceData mocks base method.
+func (m *MockTimeSeriesRepo) DeleteDeviceData(ctx context.Context, scrutiny_uuid uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteDeviceData", ctx, scrutiny_uuid)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteDeviceData indicates an expected call of DeleteDeviceData.
+func (mr *MockTimeSeriesRepoMockRecorder) DeleteDeviceData(ctx, scrutiny_uuid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteDeviceData", reflect.TypeOf((*MockTimeSeriesRepo)(nil).DeleteDeviceData), ctx, scrutiny_uuid)
+}
+
+// GetLatestSmartAttributes mocks base method.
+func (m *MockTimeSeriesRepo) GetLatestSmartAttributes(ctx context.Context) (map[uuid.UUID]measurements.Smart, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLatestSmartAttributes", ctx)
+	ret0, _ := ret[0].(map[uuid.UUID]measurements.Smart)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLatestSmartAttributes indicates an expected call of GetLatestSmartAttributes.
+func (mr *MockTimeSeriesRepoMockRecorder) GetLatestSmartAttributes(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLatestSmartAttributes", reflect.TypeOf((*MockTimeSeriesRepo)(nil).GetLatestSmartAttributes), ctx)
+}
+
+// GetLatestSmartSummaries mocks base method.
+func (m *MockTimeSeriesRepo) GetLatestSmartSummaries(ctx context.Context) (map[uuid.UUID]*models.SmartSummary, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLatestSmartSummaries", ctx)
+	ret0, _ := ret[0].(map[uuid.UUID]*models.SmartSummary)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLatestSmartSummaries indicates an expected call of GetLatestSmartSummaries.
+func (mr *MockTimeSeriesRepoMockRecorder) GetLatestSmartSummaries(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLatestSmartSummaries", reflect.TypeOf((*MockTimeSeriesRepo)(nil).GetLatestSmartSummaries), ctx)
+}
+
+// GetSmartAttributeHistory mocks base method.
+func (m *MockTimeSeriesRepo) GetSmartAttributeHistory(ctx context.Context, scrutiny_uuid uuid.UUID, durationKey string, selectEntries, selectEntriesOffset int, attributes []string) ([]measurements.Smart, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSmartAttributeHistory", ctx, scrutiny_uuid, durationKey, selectEntries, selectEntriesOffset, attributes)
+	ret0, _ := ret[0].([]measurements.Smart)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSmartAttributeHistory indicates an expected call of GetSmartAttributeHistory.
+func (mr *MockTimeSeriesRepoMockRecorder) GetSmartAttributeHistory(ctx, scrutiny_uuid, durationKey, selectEntries, selectEntriesOffset, attributes any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSmartAttributeHistory", reflect.TypeOf((*MockTimeSeriesRepo)(nil).GetSmartAttributeHistory), ctx, scrutiny_uuid, durationKey, selectEntries, selectEntriesOffset, attributes)
+}
+
+// GetSmartSelfTestHistory mocks base method.
+func (m *MockTimeSeriesRepo) GetSmartSelfTestHistory(ctx context.Context, scrutiny_uuid uuid.UUID, durationKey string) ([]measurements.SmartSelfTest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSmartSelfTestHistory", ctx, scrutiny_uuid, durationKey)
+	ret0, _ := ret[0].([]measurements.SmartSelfTest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSmartSelfTestHistory indicates an expected call of GetSmartSelfTestHistory.
+func (mr *MockTimeSeriesRepoMockRecorder) GetSmartSelfTestHistory(ctx, scrutiny_uuid, durationKey any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSmartSelfTestHistory", reflect.TypeOf((*MockTimeSeriesRepo)(nil).GetSmartSelfTestHistory), ctx, scrutiny_uuid, durationKey)
+}
+
+// GetSmartTemperatureHistory mocks base method.
+func (m *MockTimeSeriesRepo) GetSmartTemperatureHistory(ctx context.Context, durationKey string) (map[uuid.UUID][]measurements.SmartTemperature, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSmartTemperatureHistory", ctx, durationKey)
+	ret0, _ := ret[0].(map[uuid.UUID][]measurements.SmartTemperature)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSmartTemperatureHistory indicates an expected call of GetSmartTemperatureHistory.
+func (mr *MockTimeSeriesRepoMockRecorder) GetSmartTemperatureHistory(ctx, durationKey any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSmartTemperatureHistory", reflect.TypeOf((*MockTimeSeriesRepo)(nil).GetSmartTemperatureHistory), ctx, durationKey)
+}
+
+// HealthCheck mocks base method.
+func (m *MockTimeSeriesRepo) HealthCheck(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HealthCheck", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// HealthCheck indicates an expected call of HealthCheck.
+func (mr *MockTimeSeriesRepoMockRecorder) HealthCheck(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HealthCheck", reflect.TypeOf((*MockTimeSeriesRepo)(nil).HealthCheck), ctx)
+}
+
+// WriteSelfTest mocks base method.
+func (m *MockTimeSeriesRepo) WriteSelfTest(ctx context.Context, scrutiny_uuid uuid.UUID, selfTest measurements.SmartSelfTest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WriteSelfTest", ctx, scrutiny_uuid, selfTest)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WriteSelfTest indicates an expected call of WriteSelfTest.
+func (mr *MockTimeSeriesRepoMockRecorder) WriteSelfTest(ctx, scrutiny_uuid, selfTest any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteSelfTest", reflect.TypeOf((*MockTimeSeriesRepo)(nil).WriteSelfTest), ctx, scrutiny_uuid, selfTest)
+}
+
+// WriteSmart mocks base method.
+func (m *MockTimeSeriesRepo) WriteSmart(ctx context.Context, smart measurements.Smart) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WriteSmart", ctx, smart)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WriteSmart indicates an expected call of WriteSmart.
+func (mr *MockTimeSeriesRepoMockRecorder) WriteSmart(ctx, smart any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteSmart", reflect.TypeOf((*MockTimeSeriesRepo)(nil).WriteSmart), ctx, smart)
+}
+
+// WriteTemperatures mocks base method.
+func (m *MockTimeSeriesRepo) WriteTemperatures(ctx context.Context, scrutiny_uuid uuid.UUID, temperatures []measurements.SmartTemperature) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WriteTemperatures", ctx, scrutiny_uuid, temperatures)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WriteTemperatures indicates an expected call of WriteTemperatures.
+func (mr *MockTimeSeriesRepoMockRecorder) WriteTemperatures(ctx, scrutiny_uuid, temperatures any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteTemperatures", reflect.TypeOf((*MockTimeSeriesRepo)(nil).WriteTemperatures), ctx, scrutiny_uuid, temperatures)
+}
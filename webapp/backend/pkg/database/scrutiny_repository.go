@@ -2,21 +2,16 @@ package database
 
 import (
 	"context"
-	"crypto/tls"
-	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
 	"net/url"
+	"sync"
 	"time"
 
 	"github.com/analogj/scrutiny/webapp/backend/pkg/config"
 	"github.com/analogj/scrutiny/webapp/backend/pkg/models"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/models/measurements"
 	"github.com/glebarez/sqlite"
 	"github.com/gofrs/uuid/v5"
-	influxdb2 "github.com/influxdata/influxdb-client-go/v2"
-	"github.com/influxdata/influxdb-client-go/v2/api"
-	"github.com/influxdata/influxdb-client-go/v2/domain"
 	"github.com/sirupsen/logrus"
 
 	"gorm.io/gorm"
@@ -37,6 +32,9 @@ const (
 	DURATION_KEY_MONTH   = "month"
 	DURATION_KEY_YEAR    = "year"
 	DURATION_KEY_FOREVER = "forever"
+
+	TimeSeriesBackendInfluxDB = "influxdb"
+	TimeSeriesBackendSQLite   = "sqlite"
 )
 
 //// GormLogger is a custom logger for Gorm, making it use logrus.
@@ -93,91 +91,26 @@ func NewScrutinyRepository(appConfig config.Interface, globalLogger logrus.Field
 	//database.SetLogger()
 
 	////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
-	// InfluxDB setup
+	// Time-series setup (InfluxDB or SQLite)
 	////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
-
-	// Create a new client using an InfluxDB server base URL and an authentication token
-	influxdbUrl := fmt.Sprintf("%s://%s:%s", appConfig.GetString("web.influxdb.scheme"), appConfig.GetString("web.influxdb.host"), appConfig.GetString("web.influxdb.port"))
-	globalLogger.Debugf("InfluxDB url: %s", influxdbUrl)
-
-	tlsConfig := &tls.Config{
-		InsecureSkipVerify: appConfig.GetBool("web.influxdb.tls.insecure_skip_verify"),
-	}
-	globalLogger.Infof("InfluxDB certificate verification: %t\n", !tlsConfig.InsecureSkipVerify)
-
-	client := influxdb2.NewClientWithOptions(
-		influxdbUrl,
-		appConfig.GetString("web.influxdb.token"),
-		influxdb2.DefaultOptions().SetTLSConfig(tlsConfig),
-	)
-
-	//if !appConfig.IsSet("web.influxdb.token") {
-	globalLogger.Debugf("Determine Influxdb setup status...")
-	influxSetupComplete, err := InfluxSetupComplete(influxdbUrl, tlsConfig)
-	if err != nil {
-		return nil, fmt.Errorf("failed to check influxdb setup status - %w", err)
-	}
-
-	if !influxSetupComplete {
-		globalLogger.Debugf("Influxdb un-initialized, running first-time setup...")
-
-		// if no token is provided, but we have a valid server, we're going to assume this is the first setup of our server.
-		// we will initialize with a predetermined username & password, that you should change.
-
-		// metrics bucket will have a retention period of 8 days (since it will be down-sampled once a week)
-		// in seconds (60seconds * 60minutes * 24hours * 15 days) = 1_296_000 (see EnsureBucket() function)
-		_, err := client.SetupWithToken(
-			backgroundContext,
-			appConfig.GetString("web.influxdb.init_username"),
-			appConfig.GetString("web.influxdb.init_password"),
-			appConfig.GetString("web.influxdb.org"),
-			appConfig.GetString("web.influxdb.bucket"),
-			0,
-			appConfig.GetString("web.influxdb.token"),
-		)
-		if err != nil {
-			return nil, err
-		}
-	}
-
-	// Use blocking write client for writes to desired bucket
-	writeAPI := client.WriteAPIBlocking(appConfig.GetString("web.influxdb.org"), appConfig.GetString("web.influxdb.bucket"))
-
-	// Get query client
-	queryAPI := client.QueryAPI(appConfig.GetString("web.influxdb.org"))
-
-	// Get task client
-	taskAPI := client.TasksAPI()
-
-	if writeAPI == nil || queryAPI == nil || taskAPI == nil {
-		return nil, fmt.Errorf("failed to connect to influxdb")
-	}
-
-	deviceRepo := scrutinyRepository{
-		appConfig:      appConfig,
-		logger:         globalLogger,
-		influxClient:   client,
-		influxWriteApi: writeAPI,
-		influxQueryApi: queryAPI,
-		influxTaskApi:  taskAPI,
-		gormClient:     database,
-	}
-
-	orgInfo, err := client.OrganizationsAPI().FindOrganizationByName(backgroundContext, appConfig.GetString("web.influxdb.org"))
-	if err != nil {
-		return nil, err
+	var timeSeries TimeSeriesRepo
+	switch appConfig.GetString("web.timeseries.backend") {
+	case "", TimeSeriesBackendInfluxDB:
+		timeSeries, err = newInfluxTimeSeriesRepository(backgroundContext, appConfig, globalLogger)
+	case TimeSeriesBackendSQLite:
+		timeSeries, err = newSqliteTimeSeriesRepository(backgroundContext, globalLogger, database)
+	default:
+		err = fmt.Errorf("unknown time-series backend: %s", appConfig.GetString("web.timeseries.backend"))
 	}
-
-	// Initialize Buckets (if necessary)
-	err = deviceRepo.EnsureBuckets(backgroundContext, orgInfo)
 	if err != nil {
 		return nil, err
 	}
 
-	// Initialize Background Tasks
-	err = deviceRepo.EnsureTasks(backgroundContext, *orgInfo.Id)
-	if err != nil {
-		return nil, err
+	deviceRepo := scrutinyRepository{
+		appConfig:  appConfig,
+		logger:     globalLogger,
+		timeSeries: timeSeries,
+		gormClient: database,
 	}
 
 	////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -196,27 +129,36 @@ type scrutinyRepository struct {
 	appConfig config.Interface
 	logger    logrus.FieldLogger
 
-	influxWriteApi api.WriteAPIBlocking
-	influxQueryApi api.QueryAPI
-	influxTaskApi  api.TasksAPI
-	influxClient   influxdb2.Client
+	timeSeries TimeSeriesRepo
 
 	gormClient *gorm.DB
+
+	// attribute history used by the failure prediction & endurance analysis, see GetAnalysisSmartHistory
+	analysisHistoryMu sync.Mutex
+	analysisHistory   map[uuid.UUID]cachedSmartHistory
+
+	// compiled attribute override rules used when processing uploaded SMART data, see GetAttributeOverrides.
+	// nil until loaded, and reset whenever a rule is created, updated or deleted.
+	attributeOverrideRulesMu sync.Mutex
+	attributeOverrideRules   []compiledAttributeOverrideRule
+}
+
+// how long the attribute history used by the failure prediction & endurance analysis is cached for
+const analysisHistoryCacheTTL = 24 * time.Hour
+
+type cachedSmartHistory struct {
+	loadedAt time.Time
+	history  []measurements.Smart
 }
 
 func (sr *scrutinyRepository) Close() error {
-	sr.influxClient.Close()
-	return nil
+	return sr.timeSeries.Close()
 }
 
 func (sr *scrutinyRepository) HealthCheck(ctx context.Context) error {
-	//check influxdb
-	status, err := sr.influxClient.Health(ctx)
-	if err != nil {
-		return fmt.Errorf("influxdb healthcheck failed: %w", err)
-	}
-	if status.Status != "pass" {
-		return fmt.Errorf("influxdb healthcheckf failed: status=%s", status.Status)
+	//check time-series database
+	if err := sr.timeSeries.HealthCheck(ctx); err != nil {
+		return err
 	}
 
 	//check sqlite db.
@@ -232,104 +174,6 @@ func (sr *scrutinyRepository) HealthCheck(ctx context.Context) error {
 
 }
 
-func InfluxSetupComplete(influxEndpoint string, tlsConfig *tls.Config) (bool, error) {
-	influxUri, err := url.Parse(influxEndpoint)
-	if err != nil {
-		return false, err
-	}
-	influxUri, err = influxUri.Parse("/api/v2/setup")
-	if err != nil {
-		return false, err
-	}
-
-	client := &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}}
-	res, err := client.Get(influxUri.String())
-	if err != nil {
-		return false, err
-	}
-
-	body, err := io.ReadAll(res.Body)
-	if err != nil {
-		return false, err
-	}
-
-	type SetupStatus struct {
-		Allowed bool `json:"allowed"`
-	}
-	var data SetupStatus
-	err = json.Unmarshal(body, &data)
-	if err != nil {
-		return false, err
-	}
-	return !data.Allowed, nil
-}
-
-func (sr *scrutinyRepository) EnsureBuckets(ctx context.Context, org *domain.Organization) error {
-
-	var mainBucketRetentionRule domain.RetentionRule
-	var weeklyBucketRetentionRule domain.RetentionRule
-	var monthlyBucketRetentionRule domain.RetentionRule
-	if sr.appConfig.GetBool("web.influxdb.retention_policy") {
-
-		// in tests, we may not want to set a retention policy. If "false", we can set data with old timestamps,
-		// then manually run the down sampling scripts. This should be true for production environments.
-		mainBucketRetentionRule = domain.RetentionRule{EverySeconds: RETENTION_PERIOD_15_DAYS_IN_SECONDS}
-		weeklyBucketRetentionRule = domain.RetentionRule{EverySeconds: RETENTION_PERIOD_9_WEEKS_IN_SECONDS}
-		monthlyBucketRetentionRule = domain.RetentionRule{EverySeconds: RETENTION_PERIOD_25_MONTHS_IN_SECONDS}
-	}
-
-	mainBucket := sr.appConfig.GetString("web.influxdb.bucket")
-	if foundMainBucket, foundErr := sr.influxClient.BucketsAPI().FindBucketByName(ctx, mainBucket); foundErr != nil {
-		// metrics bucket will have a retention period of 15 days (since it will be down-sampled once a week)
-		_, err := sr.influxClient.BucketsAPI().CreateBucketWithName(ctx, org, mainBucket, mainBucketRetentionRule)
-		if err != nil {
-			return err
-		}
-	} else if sr.appConfig.GetBool("web.influxdb.retention_policy") {
-		//correctly set the retention period for the main bucket (cant do it during setup/creation)
-		foundMainBucket.RetentionRules = domain.RetentionRules{mainBucketRetentionRule}
-		sr.influxClient.BucketsAPI().UpdateBucket(ctx, foundMainBucket)
-	}
-
-	//create buckets (used for downsampling)
-	weeklyBucket := fmt.Sprintf("%s_weekly", sr.appConfig.GetString("web.influxdb.bucket"))
-	if foundWeeklyBucket, foundErr := sr.influxClient.BucketsAPI().FindBucketByName(ctx, weeklyBucket); foundErr != nil {
-		// metrics_weekly bucket will have a retention period of 8+1 weeks (since it will be down-sampled once a month)
-		_, err := sr.influxClient.BucketsAPI().CreateBucketWithName(ctx, org, weeklyBucket, weeklyBucketRetentionRule)
-		if err != nil {
-			return err
-		}
-	} else if sr.appConfig.GetBool("web.influxdb.retention_policy") {
-		//correctly set the retention period for the bucket (may not be able to do it during setup/creation)
-		foundWeeklyBucket.RetentionRules = domain.RetentionRules{weeklyBucketRetentionRule}
-		sr.influxClient.BucketsAPI().UpdateBucket(ctx, foundWeeklyBucket)
-	}
-
-	monthlyBucket := fmt.Sprintf("%s_monthly", sr.appConfig.GetString("web.influxdb.bucket"))
-	if foundMonthlyBucket, foundErr := sr.influxClient.BucketsAPI().FindBucketByName(ctx, monthlyBucket); foundErr != nil {
-		// metrics_monthly bucket will have a retention period of 24+1 months (since it will be down-sampled once a year)
-		_, err := sr.influxClient.BucketsAPI().CreateBucketWithName(ctx, org, monthlyBucket, monthlyBucketRetentionRule)
-		if err != nil {
-			return err
-		}
-	} else if sr.appConfig.GetBool("web.influxdb.retention_policy") {
-		//correctly set the retention period for the bucket (may not be able to do it during setup/creation)
-		foundMonthlyBucket.RetentionRules = domain.RetentionRules{monthlyBucketRetentionRule}
-		sr.influxClient.BucketsAPI().UpdateBucket(ctx, foundMonthlyBucket)
-	}
-
-	yearlyBucket := fmt.Sprintf("%s_yearly", sr.appConfig.GetString("web.influxdb.bucket"))
-	if _, foundErr := sr.influxClient.BucketsAPI().FindBucketByName(ctx, yearlyBucket); foundErr != nil {
-		// metrics_yearly bucket will have an infinite retention period
-		_, err := sr.influxClient.BucketsAPI().CreateBucketWithName(ctx, org, yearlyBucket)
-		if err != nil {
-			return err
-		}
-	}
-
-	return nil
-}
-
 ////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 // DeviceSummary
 ////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -347,86 +191,17 @@ func (sr *scrutinyRepository) GetSummary(ctx context.Context) (map[uuid.UUID]*mo
 		summaries[device.ScrutinyUUID] = &models.DeviceSummary{Device: device}
 	}
 
-	// Get parser flux query result
-	//appConfig.GetString("web.influxdb.bucket")
-	queryStr := fmt.Sprintf(`
-  	import "influxdata/influxdb/schema"
-  	bucketBaseName = "%s"
-
-	dailyData = from(bucket: bucketBaseName)
-	|> range(start: -10y, stop: now())
-	|> filter(fn: (r) => r["_measurement"] == "smart" )
-	|> filter(fn: (r) => r["_field"] == "temp" or r["_field"] == "power_on_hours" or r["_field"] == "date")
-	|> last()
-	|> schema.fieldsAsCols()
-	|> group(columns: ["scrutiny_uuid"])
-	
-	weeklyData = from(bucket: bucketBaseName + "_weekly")
-	|> range(start: -10y, stop: now())
-	|> filter(fn: (r) => r["_measurement"] == "smart" )
-	|> filter(fn: (r) => r["_field"] == "temp" or r["_field"] == "power_on_hours" or r["_field"] == "date")
-	|> last()
-	|> schema.fieldsAsCols()
-	|> group(columns: ["scrutiny_uuid"])
-	
-	monthlyData = from(bucket: bucketBaseName + "_monthly")
-	|> range(start: -10y, stop: now())
-	|> filter(fn: (r) => r["_measurement"] == "smart" )
-	|> filter(fn: (r) => r["_field"] == "temp" or r["_field"] == "power_on_hours" or r["_field"] == "date")
-	|> last()
-	|> schema.fieldsAsCols()
-	|> group(columns: ["scrutiny_uuid"])
-	
-	yearlyData = from(bucket: bucketBaseName + "_yearly")
-	|> range(start: -10y, stop: now())
-	|> filter(fn: (r) => r["_measurement"] == "smart" )
-	|> filter(fn: (r) => r["_field"] == "temp" or r["_field"] == "power_on_hours" or r["_field"] == "date")
-	|> last()
-	|> schema.fieldsAsCols()
-	|> group(columns: ["scrutiny_uuid"])
-	
-	union(tables: [dailyData, weeklyData, monthlyData, yearlyData])
-	|> sort(columns: ["_time"], desc: false)
-	|> group(columns: ["scrutiny_uuid"])
-	|> last(column: "scrutiny_uuid")
-	|> yield(name: "last")
-		`,
-		sr.appConfig.GetString("web.influxdb.bucket"),
-	)
-
-	result, err := sr.influxQueryApi.Query(ctx, queryStr)
-	if err == nil {
-		// Use Next() to iterate over query result lines
-		for result.Next() {
-			// Observe when there is new grouping key producing new table
-			if result.TableChanged() {
-				//fmt.Printf("table: %s\n", result.TableMetadata().String())
-			}
-			// read result
-
-			//get summary data from Influxdb.
-			//result.Record().Values()
-			if scrutinyUUIDString, ok := result.Record().Values()["scrutiny_uuid"]; ok {
-				scrutinyUUID := uuid.Must(uuid.FromString(scrutinyUUIDString.(string)))
-
-				//ensure summaries is intialized for this scrutiny_uuid
-				if _, exists := summaries[scrutinyUUID]; !exists {
-					summaries[scrutinyUUID] = &models.DeviceSummary{}
-				}
-
-				summaries[scrutinyUUID].SmartResults = &models.SmartSummary{
-					Temp:          result.Record().Values()["temp"].(int64),
-					PowerOnHours:  result.Record().Values()["power_on_hours"].(int64),
-					CollectorDate: result.Record().Values()["_time"].(time.Time),
-				}
-			}
-		}
-		if result.Err() != nil {
-			fmt.Printf("Query error: %s\n", result.Err().Error())
-		}
-	} else {
+	smartSummaries, err := sr.timeSeries.GetLatestSmartSummaries(ctx)
+	if err != nil {
 		return nil, err
 	}
+	for scrutinyUUID, smartSummary := range smartSummaries {
+		//ensure summaries is intialized for this scrutiny_uuid
+		if _, exists := summaries[scrutinyUUID]; !exists {
+			summaries[scrutinyUUID] = &models.DeviceSummary{}
+		}
+		summaries[scrutinyUUID].SmartResults = smartSummary
+	}
 
 	deviceTempHistory, err := sr.GetSmartTemperatureHistory(ctx, DURATION_KEY_FOREVER)
 	if err != nil {
@@ -448,47 +223,7 @@ func (sr *scrutinyRepository) GetSummary(ctx context.Context) (map[uuid.UUID]*mo
 // Helper Methods
 ////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 
-func (sr *scrutinyRepository) lookupBucketName(durationKey string) string {
-	switch durationKey {
-	case DURATION_KEY_DAY:
-	case DURATION_KEY_WEEK:
-		//data stored in the last week
-		return sr.appConfig.GetString("web.influxdb.bucket")
-	case DURATION_KEY_MONTH:
-		// data stored in the last month (after the first week)
-		return fmt.Sprintf("%s_weekly", sr.appConfig.GetString("web.influxdb.bucket"))
-	case DURATION_KEY_YEAR:
-		// data stored in the last year (after the first month)
-		return fmt.Sprintf("%s_monthly", sr.appConfig.GetString("web.influxdb.bucket"))
-	case DURATION_KEY_FOREVER:
-		//data stored before the last year
-		return fmt.Sprintf("%s_yearly", sr.appConfig.GetString("web.influxdb.bucket"))
-	}
-	return sr.appConfig.GetString("web.influxdb.bucket")
-}
-
-func (sr *scrutinyRepository) lookupDuration(durationKey string) []string {
-	switch durationKey {
-	case DURATION_KEY_DAY:
-		//data stored in the last day
-		return []string{"-1d", "now()"}
-	case DURATION_KEY_WEEK:
-		//data stored in the last week
-		return []string{"-1w", "now()"}
-	case DURATION_KEY_MONTH:
-		// data stored in the last month (after the first week)
-		return []string{"-1mo", "-1w"}
-	case DURATION_KEY_YEAR:
-		// data stored in the last year (after the first month)
-		return []string{"-1y", "-1mo"}
-	case DURATION_KEY_FOREVER:
-		//data stored before the last year
-		return []string{"-10y", "-1y"}
-	}
-	return []string{"-1w", "now()"}
-}
-
-func (sr *scrutinyRepository) lookupResolution(durationKey string) string {
+func lookupResolution(durationKey string) string {
 	switch durationKey {
 	case DURATION_KEY_DAY:
 		// Return data with higher resolution for daily summaries
@@ -499,7 +234,7 @@ func (sr *scrutinyRepository) lookupResolution(durationKey string) string {
 	}
 }
 
-func (sr *scrutinyRepository) lookupNestedDurationKeys(durationKey string) []string {
+func lookupNestedDurationKeys(durationKey string) []string {
 	switch durationKey {
 	case DURATION_KEY_DAY:
 		//all data is stored in a single bucket, but we want a finer resolution
@@ -0,0 +1,243 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/analogj/scrutiny/webapp/backend/pkg"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/models"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/thresholds"
+	"github.com/gofrs/uuid/v5"
+	"gorm.io/gorm"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Attribute Override Rules
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func (sr *scrutinyRepository) GetAttributeOverrideRules(ctx context.Context) ([]models.AttributeOverrideRule, error) {
+	rules := []models.AttributeOverrideRule{}
+	if err := sr.gormClient.WithContext(ctx).Order("id").Find(&rules).Error; err != nil {
+		return nil, fmt.Errorf("could not get attribute override rules from DB: %w", err)
+	}
+	return rules, nil
+}
+
+func (sr *scrutinyRepository) CreateAttributeOverrideRule(ctx context.Context, rule models.AttributeOverrideRule) (models.AttributeOverrideRule, error) {
+	rule.ID = 0
+	if err := validateAttributeOverrideRule(&rule); err != nil {
+		return models.AttributeOverrideRule{}, err
+	}
+	if err := sr.gormClient.WithContext(ctx).Create(&rule).Error; err != nil {
+		return models.AttributeOverrideRule{}, fmt.Errorf("could not create attribute override rule: %w", err)
+	}
+	sr.resetAttributeOverrideRules()
+	return rule, nil
+}
+
+// UpdateAttributeOverrideRule replaces all fields of the existing rule.
+func (sr *scrutinyRepository) UpdateAttributeOverrideRule(ctx context.Context, id uint, rule models.AttributeOverrideRule) (models.AttributeOverrideRule, error) {
+	var existingRule models.AttributeOverrideRule
+	if err := sr.gormClient.WithContext(ctx).First(&existingRule, id).Error; err != nil {
+		return models.AttributeOverrideRule{}, fmt.Errorf("attribute override rule %d not found: %w", id, err)
+	}
+	if err := validateAttributeOverrideRule(&rule); err != nil {
+		return models.AttributeOverrideRule{}, err
+	}
+
+	rule.Model = existingRule.Model
+	// Save writes zero values & nil pointers, so overrides can be removed from the rule.
+	if err := sr.gormClient.WithContext(ctx).Save(&rule).Error; err != nil {
+		return models.AttributeOverrideRule{}, fmt.Errorf("could not update attribute override rule %d: %w", id, err)
+	}
+	sr.resetAttributeOverrideRules()
+	return rule, nil
+}
+
+func (sr *scrutinyRepository) DeleteAttributeOverrideRule(ctx context.Context, id uint) error {
+	result := sr.gormClient.WithContext(ctx).Unscoped().Delete(&models.AttributeOverrideRule{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("attribute override rule %d not found: %w", id, gorm.ErrRecordNotFound)
+	}
+	sr.resetAttributeOverrideRules()
+	return nil
+}
+
+// GetAttributeOverrides returns the overrides that apply to the device, keyed by attribute id.
+// When multiple rules match an attribute, the most specific rule is used (device > model > protocol), with the newest
+// rule winning ties. The rules are only loaded from the database once, and reloaded after they are changed.
+func (sr *scrutinyRepository) GetAttributeOverrides(ctx context.Context, device models.Device) (map[string]thresholds.AttributeOverride, error) {
+	rules, err := sr.getCompiledAttributeOverrideRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	matchingRules := []models.AttributeOverrideRule{}
+	for _, rule := range rules {
+		if rule.matches(device) {
+			matchingRules = append(matchingRules, rule.AttributeOverrideRule)
+		}
+	}
+	sort.SliceStable(matchingRules, func(i, j int) bool {
+		return attributeOverrideRuleSpecificity(matchingRules[i]) < attributeOverrideRuleSpecificity(matchingRules[j])
+	})
+
+	overrides := map[string]thresholds.AttributeOverride{}
+	for _, rule := range matchingRules {
+		overrides[rule.AttributeId] = rule.ToAttributeOverride()
+	}
+	return overrides, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Helper Methods
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// getDeviceAttributeOverrides is used when processing uploaded SMART data. Failures are logged, and the built-in
+// thresholds are used instead.
+func (sr *scrutinyRepository) getDeviceAttributeOverrides(ctx context.Context, scrutiny_uuid uuid.UUID) map[string]thresholds.AttributeOverride {
+	device, err := sr.GetDeviceDetails(ctx, scrutiny_uuid)
+	if err != nil {
+		sr.logger.Warnf("Could not get device %s, attribute overrides will not be applied: %v", scrutiny_uuid, err)
+		return nil
+	}
+	overrides, err := sr.GetAttributeOverrides(ctx, device)
+	if err != nil {
+		sr.logger.Warnf("Could not get attribute overrides for device %s: %v", scrutiny_uuid, err)
+		return nil
+	}
+	return overrides
+}
+
+type compiledAttributeOverrideRule struct {
+	models.AttributeOverrideRule
+	modelRegex *regexp.Regexp
+}
+
+// getCompiledAttributeOverrideRules returns the cached rules, loading & compiling them if they were changed since
+// they were last loaded.
+func (sr *scrutinyRepository) getCompiledAttributeOverrideRules(ctx context.Context) ([]compiledAttributeOverrideRule, error) {
+	sr.attributeOverrideRulesMu.Lock()
+	defer sr.attributeOverrideRulesMu.Unlock()
+	if sr.attributeOverrideRules != nil {
+		return sr.attributeOverrideRules, nil
+	}
+
+	rules, err := sr.GetAttributeOverrideRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+	compiledRules := make([]compiledAttributeOverrideRule, 0, len(rules))
+	for _, rule := range rules {
+		compiledRule := compiledAttributeOverrideRule{AttributeOverrideRule: rule}
+		if len(rule.ModelRegex) > 0 {
+			// the regex is validated when the rule is saved
+			compiledRule.modelRegex, err = regexp.Compile(rule.ModelRegex)
+			if err != nil {
+				sr.logger.Warnf("Ignoring attribute override rule %d, invalid model_regex: %v", rule.ID, err)
+				continue
+			}
+		}
+		compiledRules = append(compiledRules, compiledRule)
+	}
+	sr.attributeOverrideRules = compiledRules
+	return compiledRules, nil
+}
+
+func (sr *scrutinyRepository) resetAttributeOverrideRules() {
+	sr.attributeOverrideRulesMu.Lock()
+	defer sr.attributeOverrideRulesMu.Unlock()
+	sr.attributeOverrideRules = nil
+}
+
+func (rule compiledAttributeOverrideRule) matches(device models.Device) bool {
+	if len(rule.ScrutinyUUID) > 0 && !strings.EqualFold(rule.ScrutinyUUID, device.ScrutinyUUID.String()) {
+		return false
+	}
+	if len(rule.Protocol) > 0 && !strings.EqualFold(rule.Protocol, device.DeviceProtocol) {
+		return false
+	}
+	if rule.modelRegex != nil && !rule.modelRegex.MatchString(device.ModelName) {
+		return false
+	}
+	return true
+}
+
+func attributeOverrideRuleSpecificity(rule models.AttributeOverrideRule) int {
+	specificity := 0
+	if len(rule.ScrutinyUUID) > 0 {
+		specificity += 4
+	}
+	if len(rule.ModelRegex) > 0 {
+		specificity += 2
+	}
+	if len(rule.Protocol) > 0 {
+		specificity += 1
+	}
+	return specificity
+}
+
+func validateAttributeOverrideRule(rule *models.AttributeOverrideRule) error {
+	rule.ScrutinyUUID = strings.TrimSpace(rule.ScrutinyUUID)
+	rule.ModelRegex = strings.TrimSpace(rule.ModelRegex)
+	rule.Protocol = strings.TrimSpace(rule.Protocol)
+	rule.AttributeId = strings.TrimSpace(rule.AttributeId)
+	rule.Ideal = strings.ToLower(strings.TrimSpace(rule.Ideal))
+
+	if len(rule.AttributeId) == 0 {
+		return fmt.Errorf("attribute_id is required")
+	}
+	if len(rule.ScrutinyUUID) == 0 && len(rule.ModelRegex) == 0 && len(rule.Protocol) == 0 {
+		return fmt.Errorf("at least one of scrutiny_uuid, model_regex or protocol is required")
+	}
+	if len(rule.ScrutinyUUID) > 0 {
+		if _, err := uuid.FromString(rule.ScrutinyUUID); err != nil {
+			return fmt.Errorf("invalid scrutiny_uuid: %w", err)
+		}
+	}
+	if len(rule.ModelRegex) > 0 {
+		if _, err := regexp.Compile(rule.ModelRegex); err != nil {
+			return fmt.Errorf("invalid model_regex: %w", err)
+		}
+	}
+	if len(rule.Protocol) > 0 {
+		switch {
+		case strings.EqualFold(rule.Protocol, pkg.DeviceProtocolAta):
+			rule.Protocol = pkg.DeviceProtocolAta
+		case strings.EqualFold(rule.Protocol, pkg.DeviceProtocolNvme):
+			rule.Protocol = pkg.DeviceProtocolNvme
+		case strings.EqualFold(rule.Protocol, pkg.DeviceProtocolScsi):
+			rule.Protocol = pkg.DeviceProtocolScsi
+		default:
+			return fmt.Errorf("invalid protocol %q (ATA, NVMe or SCSI)", rule.Protocol)
+		}
+	}
+	if len(rule.Ideal) > 0 && rule.Ideal != thresholds.ObservedThresholdIdealLow && rule.Ideal != thresholds.ObservedThresholdIdealHigh {
+		return fmt.Errorf("invalid ideal %q (low or high)", rule.Ideal)
+	}
+	if !rule.Ignore && len(rule.Ideal) == 0 && rule.Critical == nil && rule.WarnLimit == nil && rule.FailLimit == nil {
+		return fmt.Errorf("at least one override (ignore, ideal, critical, warn_limit or fail_limit) is required")
+	}
+	// the ATA observed thresholds are failure rate buckets, so the ideal direction is only used to compare the limits
+	if len(rule.Ideal) > 0 && rule.WarnLimit == nil && rule.FailLimit == nil && isAtaAttributeOverrideRule(*rule) {
+		return fmt.Errorf("ideal can only be set together with warn_limit or fail_limit for ATA attributes")
+	}
+	return nil
+}
+
+// isAtaAttributeOverrideRule returns true if the rule targets an ATA attribute. ATA attribute ids are numeric, while
+// NVMe & SCSI attribute ids are names (eg. media_errors).
+func isAtaAttributeOverrideRule(rule models.AttributeOverrideRule) bool {
+	if len(rule.Protocol) > 0 {
+		return rule.Protocol == pkg.DeviceProtocolAta
+	}
+	_, err := strconv.Atoi(rule.AttributeId)
+	return err == nil
+}
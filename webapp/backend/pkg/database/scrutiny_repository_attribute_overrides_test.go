@@ -0,0 +1,176 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/analogj/scrutiny/webapp/backend/pkg"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/models"
+	"github.com/gofrs/uuid/v5"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func Test_AttributeOverrideRules(t *testing.T) {
+	//setup
+	deviceRepo := newTestSqliteRepository(t, &models.AttributeOverrideRule{})
+	ctx := context.Background()
+	failLimit := int64(10)
+
+	//test
+	createdRule, createErr := deviceRepo.CreateAttributeOverrideRule(ctx, models.AttributeOverrideRule{
+		Protocol:    "nvme",
+		AttributeId: "media_errors",
+		FailLimit:   &failLimit,
+	})
+	updatedRule, updateErr := deviceRepo.UpdateAttributeOverrideRule(ctx, createdRule.ID, models.AttributeOverrideRule{
+		Protocol:    pkg.DeviceProtocolNvme,
+		AttributeId: "media_errors",
+		Ignore:      true,
+	})
+	rules, listErr := deviceRepo.GetAttributeOverrideRules(ctx)
+	deleteErr := deviceRepo.DeleteAttributeOverrideRule(ctx, createdRule.ID)
+	missingDeleteErr := deviceRepo.DeleteAttributeOverrideRule(ctx, createdRule.ID)
+	_, missingUpdateErr := deviceRepo.UpdateAttributeOverrideRule(ctx, createdRule.ID, updatedRule)
+
+	//assert
+	require.NoError(t, createErr)
+	require.NotZero(t, createdRule.ID)
+	require.Equal(t, pkg.DeviceProtocolNvme, createdRule.Protocol)
+
+	require.NoError(t, updateErr)
+	require.Equal(t, createdRule.ID, updatedRule.ID)
+	require.Equal(t, createdRule.CreatedAt.Unix(), updatedRule.CreatedAt.Unix())
+
+	require.NoError(t, listErr)
+	require.Len(t, rules, 1)
+	require.True(t, rules[0].Ignore)
+	require.Nil(t, rules[0].FailLimit)
+
+	require.NoError(t, deleteErr)
+	require.ErrorIs(t, missingDeleteErr, gorm.ErrRecordNotFound)
+	require.Error(t, missingUpdateErr)
+}
+
+func Test_CreateAttributeOverrideRule_Invalid(t *testing.T) {
+	//setup
+	deviceRepo := newTestSqliteRepository(t, &models.AttributeOverrideRule{})
+	ctx := context.Background()
+	invalidRules := []models.AttributeOverrideRule{
+		// missing attribute
+		{Protocol: pkg.DeviceProtocolAta, Ignore: true},
+		// missing scope
+		{AttributeId: "5", Ignore: true},
+		// missing override
+		{Protocol: pkg.DeviceProtocolAta, AttributeId: "5"},
+		{ScrutinyUUID: "not-a-uuid", AttributeId: "5", Ignore: true},
+		{ModelRegex: "WDC[", AttributeId: "5", Ignore: true},
+		{Protocol: "SATA", AttributeId: "5", Ignore: true},
+		{Protocol: pkg.DeviceProtocolAta, AttributeId: "5", Ideal: "medium"},
+		// ideal has no effect on ATA attributes without limits
+		{Protocol: pkg.DeviceProtocolAta, AttributeId: "5", Ideal: "high"},
+		{ModelRegex: "^WDC", AttributeId: "194", Ideal: "low"},
+	}
+
+	for _, rule := range invalidRules {
+		//test
+		_, err := deviceRepo.CreateAttributeOverrideRule(ctx, rule)
+
+		//assert
+		require.Error(t, err, "rule should be invalid: %+v", rule)
+	}
+	rules, err := deviceRepo.GetAttributeOverrideRules(ctx)
+	require.NoError(t, err)
+	require.Empty(t, rules)
+}
+
+func Test_CreateAttributeOverrideRule_Ideal(t *testing.T) {
+	//setup
+	deviceRepo := newTestSqliteRepository(t, &models.AttributeOverrideRule{})
+	ctx := context.Background()
+	warnLimit := int64(10)
+
+	//test
+	_, nvmeErr := deviceRepo.CreateAttributeOverrideRule(ctx, models.AttributeOverrideRule{Protocol: pkg.DeviceProtocolNvme, AttributeId: "available_spare", Ideal: "low"})
+	_, ataLimitErr := deviceRepo.CreateAttributeOverrideRule(ctx, models.AttributeOverrideRule{Protocol: pkg.DeviceProtocolAta, AttributeId: "231", Ideal: "high", WarnLimit: &warnLimit})
+
+	//assert
+	require.NoError(t, nvmeErr)
+	require.NoError(t, ataLimitErr)
+}
+
+func Test_GetAttributeOverrides(t *testing.T) {
+	//setup
+	deviceRepo := newTestSqliteRepository(t, &models.AttributeOverrideRule{})
+	ctx := context.Background()
+	device := models.Device{
+		ScrutinyUUID:   uuid.Must(uuid.NewV4()),
+		ModelName:      "WDC WD140EDGZ-11B1PA0",
+		DeviceProtocol: pkg.DeviceProtocolAta,
+	}
+	warnLimit := int64(1)
+	failLimit := int64(50)
+	critical := false
+
+	// the device rule is created first, but is more specific than the later model & protocol rules
+	for _, rule := range []models.AttributeOverrideRule{
+		{ScrutinyUUID: device.ScrutinyUUID.String(), AttributeId: "5", FailLimit: &failLimit},
+		{ModelRegex: "^WDC WD140", AttributeId: "5", Ignore: true},
+		{Protocol: pkg.DeviceProtocolAta, AttributeId: "5", Ignore: true},
+		{Protocol: pkg.DeviceProtocolAta, AttributeId: "197", WarnLimit: &warnLimit},
+		{ModelRegex: "^WDC WD140", AttributeId: "197", Critical: &critical},
+		{ModelRegex: "^ST", AttributeId: "198", Ignore: true},
+		{Protocol: pkg.DeviceProtocolNvme, AttributeId: "media_errors", Ignore: true},
+	} {
+		_, err := deviceRepo.CreateAttributeOverrideRule(ctx, rule)
+		require.NoError(t, err)
+	}
+
+	//test
+	overrides, err := deviceRepo.GetAttributeOverrides(ctx, device)
+
+	//assert
+	require.NoError(t, err)
+	require.Len(t, overrides, 2)
+	require.False(t, overrides["5"].Ignore)
+	require.Equal(t, failLimit, *overrides["5"].FailLimit)
+	require.Nil(t, overrides["197"].WarnLimit)
+	require.False(t, *overrides["197"].Critical)
+}
+
+func Test_GetAttributeOverrides_Cache(t *testing.T) {
+	//setup
+	deviceRepo := newTestSqliteRepository(t, &models.AttributeOverrideRule{})
+	ctx := context.Background()
+	device := models.Device{
+		ScrutinyUUID:   uuid.Must(uuid.NewV4()),
+		ModelName:      "WDC WD140EDGZ-11B1PA0",
+		DeviceProtocol: pkg.DeviceProtocolAta,
+	}
+	createdRule, err := deviceRepo.CreateAttributeOverrideRule(ctx, models.AttributeOverrideRule{ModelRegex: "^WDC", AttributeId: "5", Ignore: true})
+	require.NoError(t, err)
+
+	//test
+	overrides, err := deviceRepo.GetAttributeOverrides(ctx, device)
+	require.NoError(t, err)
+	// changes made outside the repository are not seen until the cached rules are reset
+	protocolRule := models.AttributeOverrideRule{Protocol: pkg.DeviceProtocolAta, AttributeId: "197", Ignore: true}
+	require.NoError(t, deviceRepo.gormClient.Create(&protocolRule).Error)
+	cachedOverrides, err := deviceRepo.GetAttributeOverrides(ctx, device)
+	require.NoError(t, err)
+	_, err = deviceRepo.UpdateAttributeOverrideRule(ctx, createdRule.ID, models.AttributeOverrideRule{ModelRegex: "^ST", AttributeId: "5", Ignore: true})
+	require.NoError(t, err)
+	updatedOverrides, err := deviceRepo.GetAttributeOverrides(ctx, device)
+	require.NoError(t, err)
+	require.NoError(t, deviceRepo.DeleteAttributeOverrideRule(ctx, protocolRule.ID))
+	deletedOverrides, err := deviceRepo.GetAttributeOverrides(ctx, device)
+	require.NoError(t, err)
+
+	//assert
+	require.Len(t, overrides, 1)
+	require.Contains(t, overrides, "5")
+	require.Equal(t, overrides, cachedOverrides)
+	require.Len(t, updatedOverrides, 1)
+	require.Contains(t, updatedOverrides, "197")
+	require.Empty(t, deletedOverrides)
+}
@@ -0,0 +1,91 @@
+package database
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/analogj/scrutiny/webapp/backend/pkg/models"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Collector Tokens
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+const collectorTokenPrefix = "scrutiny_"
+
+// CreateCollectorToken generates a new random token bound to the specified host.
+// The token value is returned once, only its hash is stored.
+func (sr *scrutinyRepository) CreateCollectorToken(ctx context.Context, hostId string, description string) (models.CollectorToken, string, error) {
+	tokenValue, err := generateToken(collectorTokenPrefix)
+	if err != nil {
+		return models.CollectorToken{}, "", err
+	}
+
+	collectorToken := models.CollectorToken{
+		HostId:      hostId,
+		Description: description,
+		TokenPrefix: tokenValue[:len(collectorTokenPrefix)+8],
+		TokenHash:   hashToken(tokenValue),
+	}
+	if err := sr.gormClient.WithContext(ctx).Create(&collectorToken).Error; err != nil {
+		return models.CollectorToken{}, "", fmt.Errorf("could not create collector token: %w", err)
+	}
+	return collectorToken, tokenValue, nil
+}
+
+func (sr *scrutinyRepository) GetCollectorTokens(ctx context.Context) ([]models.CollectorToken, error) {
+	collectorTokens := []models.CollectorToken{}
+	if err := sr.gormClient.WithContext(ctx).Order("host_id, id").Find(&collectorTokens).Error; err != nil {
+		return nil, fmt.Errorf("could not get collector tokens from DB: %w", err)
+	}
+	return collectorTokens, nil
+}
+
+func (sr *scrutinyRepository) DeleteCollectorToken(ctx context.Context, id uint) error {
+	result := sr.gormClient.WithContext(ctx).Unscoped().Delete(&models.CollectorToken{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("collector token %d not found", id)
+	}
+	return nil
+}
+
+// how often the last used time of a collector token is updated
+const collectorTokenUsageInterval = time.Hour
+
+// ValidateCollectorToken finds the collector token matching the provided token value, and records when it was last used.
+// The last used time is only updated once per collectorTokenUsageInterval, rather than on every collector request.
+func (sr *scrutinyRepository) ValidateCollectorToken(ctx context.Context, tokenValue string) (models.CollectorToken, error) {
+	var collectorToken models.CollectorToken
+	if err := sr.gormClient.WithContext(ctx).Where("token_hash = ?", hashToken(tokenValue)).First(&collectorToken).Error; err != nil {
+		return models.CollectorToken{}, fmt.Errorf("invalid collector token: %w", err)
+	}
+
+	now := time.Now()
+	if collectorToken.LastUsedAt != nil && now.Sub(*collectorToken.LastUsedAt) < collectorTokenUsageInterval {
+		return collectorToken, nil
+	}
+	collectorToken.LastUsedAt = &now
+	return collectorToken, sr.gormClient.WithContext(ctx).Model(&collectorToken).Update("last_used_at", now).Error
+}
+
+// generateToken returns a random token value, with the specified prefix.
+func generateToken(prefix string) (string, error) {
+	randomBytes := make([]byte, 32)
+	if _, err := rand.Read(randomBytes); err != nil {
+		return "", err
+	}
+	return prefix + hex.EncodeToString(randomBytes), nil
+}
+
+// tokens are long random values, so a fast hash is sufficient (unlike passwords, they cannot be brute forced)
+func hashToken(tokenValue string) string {
+	hash := sha256.Sum256([]byte(tokenValue))
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,74 @@
+package database
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/analogj/scrutiny/webapp/backend/pkg/models"
+	"github.com/glebarez/sqlite"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func newTestSqliteRepository(t *testing.T, tables ...interface{}) *scrutinyRepository {
+	database, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "scrutiny_test.db")), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, database.AutoMigrate(tables...))
+
+	return &scrutinyRepository{
+		gormClient: database,
+		logger:     logrus.WithFields(logrus.Fields{}),
+	}
+}
+
+func Test_CollectorTokens(t *testing.T) {
+	//setup
+	deviceRepo := newTestSqliteRepository(t, &models.CollectorToken{})
+	ctx := context.Background()
+
+	//test
+	collectorToken, tokenValue, err := deviceRepo.CreateCollectorToken(ctx, "nas01", "basement nas")
+	require.NoError(t, err)
+	validatedToken, validateErr := deviceRepo.ValidateCollectorToken(ctx, tokenValue)
+	revalidatedToken, revalidateErr := deviceRepo.ValidateCollectorToken(ctx, tokenValue)
+	_, invalidErr := deviceRepo.ValidateCollectorToken(ctx, tokenValue+"0")
+	collectorTokens, listErr := deviceRepo.GetCollectorTokens(ctx)
+
+	//assert
+	require.True(t, strings.HasPrefix(tokenValue, "scrutiny_"))
+	require.True(t, strings.HasPrefix(tokenValue, collectorToken.TokenPrefix))
+	require.NotContains(t, collectorToken.TokenHash, tokenValue)
+
+	require.NoError(t, validateErr)
+	require.Equal(t, collectorToken.ID, validatedToken.ID)
+	require.Equal(t, "nas01", validatedToken.HostId)
+	require.NotNil(t, validatedToken.LastUsedAt)
+	// the last used time is only updated once per hour
+	require.NoError(t, revalidateErr)
+	require.True(t, validatedToken.LastUsedAt.Equal(*revalidatedToken.LastUsedAt))
+	require.Error(t, invalidErr)
+
+	require.NoError(t, listErr)
+	require.Len(t, collectorTokens, 1)
+	require.NotNil(t, collectorTokens[0].LastUsedAt)
+}
+
+func Test_DeleteCollectorToken(t *testing.T) {
+	//setup
+	deviceRepo := newTestSqliteRepository(t, &models.CollectorToken{})
+	ctx := context.Background()
+	collectorToken, tokenValue, err := deviceRepo.CreateCollectorToken(ctx, "nas01", "")
+	require.NoError(t, err)
+
+	//test
+	err = deviceRepo.DeleteCollectorToken(ctx, collectorToken.ID)
+
+	//assert
+	require.NoError(t, err)
+	_, err = deviceRepo.ValidateCollectorToken(ctx, tokenValue)
+	require.Error(t, err)
+	require.Error(t, deviceRepo.DeleteCollectorToken(ctx, collectorToken.ID))
+}
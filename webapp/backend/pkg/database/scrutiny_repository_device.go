@@ -6,9 +6,11 @@ import (
 	"time"
 
 	"github.com/analogj/scrutiny/webapp/backend/pkg"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/analysis"
 	"github.com/analogj/scrutiny/webapp/backend/pkg/models"
 	"github.com/analogj/scrutiny/webapp/backend/pkg/models/collector"
 	"github.com/gofrs/uuid/v5"
+	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
 
@@ -19,9 +21,18 @@ import (
 // insert device into DB (and update specified columns if device is already registered)
 // update device fields that may change: (DeviceType, HostID)
 func (sr *scrutinyRepository) RegisterDevice(ctx context.Context, dev models.Device) error {
+	// new devices of an archived host are archived as well. The archived state of registered devices is not updated, so a
+	// device can be unarchived individually.
+	if len(dev.HostId) > 0 && !dev.Archived {
+		var archivedHosts int64
+		if err := sr.gormClient.WithContext(ctx).Model(&models.Host{}).Where("host_id = ? AND archived = ?", dev.HostId, true).Count(&archivedHosts).Error; err != nil {
+			return err
+		}
+		dev.Archived = archivedHosts > 0
+	}
 	if err := sr.gormClient.WithContext(ctx).Clauses(clause.OnConflict{
 		Columns:   []clause.Column{{Name: "scrutiny_uuid"}},
-		DoUpdates: clause.AssignmentColumns([]string{"host_id", "device_name", "device_type", "device_uuid", "device_serial_id", "device_label"}),
+		DoUpdates: clause.AssignmentColumns([]string{"host_id", "device_name", "device_type", "device_uuid", "device_serial_id", "device_label", "device_by_path", "enclosure_id", "enclosure_slot", "controller_pci_address"}),
 	}).Create(&dev).Error; err != nil {
 		return err
 	}
@@ -50,7 +61,23 @@ func (sr *scrutinyRepository) UpdateDevice(ctx context.Context, scrutiny_uuid uu
 	if err != nil {
 		return device, err
 	}
-	return device, sr.gormClient.Model(&device).Updates(device).Error
+	if err := sr.gormClient.Model(&device).Updates(device).Error; err != nil {
+		return device, err
+	}
+
+	// the device is reporting data, so it is no longer stale (the zero value must be updated explicitly)
+	lastSeenAt := time.Now()
+	device.LastSeenAt = &lastSeenAt
+	device.Stale = false
+	return device, sr.gormClient.WithContext(ctx).Model(&device).Where("scrutiny_uuid = ?", scrutiny_uuid.String()).Updates(map[string]interface{}{
+		"last_seen_at": device.LastSeenAt,
+		"stale":        false,
+	}).Error
+}
+
+// UpdateDeviceStale marks a device which stopped reporting data as stale, see monitor.CheckStale
+func (sr *scrutinyRepository) UpdateDeviceStale(ctx context.Context, scrutiny_uuid uuid.UUID, stale bool) error {
+	return sr.gormClient.WithContext(ctx).Model(&models.Device{}).Where("scrutiny_uuid = ?", scrutiny_uuid.String()).Update("stale", stale).Error
 }
 
 // Update Device Status
@@ -68,6 +95,44 @@ func (sr *scrutinyRepository) UpdateDeviceStatus(ctx context.Context, scrutiny_u
 	return device, sr.gormClient.Model(&device).Where("scrutiny_uuid = ?", scrutiny_uuid.String()).Update("device_status", status).Error
 }
 
+// Update Device Failure Prediction
+// Like the device status, the prediction replaces the previous value, so the columns are updated explicitly.
+func (sr *scrutinyRepository) UpdateDeviceFailurePrediction(ctx context.Context, scrutiny_uuid uuid.UUID, prediction analysis.FailurePrediction) (models.Device, error) {
+	var device models.Device
+	if err := sr.gormClient.WithContext(ctx).Where("scrutiny_uuid = ?", scrutiny_uuid.String()).First(&device).Error; err != nil {
+		return device, fmt.Errorf("could not get device from DB: %v", err)
+	}
+
+	device.FailureScore = prediction.Score
+	device.FailureScoreAttributeId = prediction.AttributeId
+	device.EstimatedFailureDate = prediction.EstimatedFailureDate
+	return device, sr.gormClient.WithContext(ctx).Model(&device).Where("scrutiny_uuid = ?", scrutiny_uuid.String()).Updates(map[string]interface{}{
+		"failure_score":              prediction.Score,
+		"failure_score_attribute_id": prediction.AttributeId,
+		"estimated_failure_date":     prediction.EstimatedFailureDate,
+	}).Error
+}
+
+// Update Device Endurance
+// The endurance summary replaces the previous value, so the columns are updated explicitly.
+func (sr *scrutinyRepository) UpdateDeviceEndurance(ctx context.Context, scrutiny_uuid uuid.UUID, endurance analysis.Endurance) (models.Device, error) {
+	var device models.Device
+	if err := sr.gormClient.WithContext(ctx).Where("scrutiny_uuid = ?", scrutiny_uuid.String()).First(&device).Error; err != nil {
+		return device, fmt.Errorf("could not get device from DB: %v", err)
+	}
+
+	device.DailyBytesWritten = endurance.DailyBytesWritten
+	device.WearPercentUsed = endurance.WearPercentUsed
+	device.EndurancePercentUsed = endurance.EndurancePercentUsed
+	device.EstimatedWearOutDate = endurance.EstimatedWearOutDate
+	return device, sr.gormClient.WithContext(ctx).Model(&device).Where("scrutiny_uuid = ?", scrutiny_uuid.String()).Updates(map[string]interface{}{
+		"daily_bytes_written":     endurance.DailyBytesWritten,
+		"wear_percent_used":       endurance.WearPercentUsed,
+		"endurance_percent_used":  endurance.EndurancePercentUsed,
+		"estimated_wear_out_date": endurance.EstimatedWearOutDate,
+	}).Error
+}
+
 func (sr *scrutinyRepository) GetDeviceDetails(ctx context.Context, scrutiny_uuid uuid.UUID) (models.Device, error) {
 	var device models.Device
 
@@ -91,31 +156,30 @@ func (sr *scrutinyRepository) UpdateDeviceArchived(ctx context.Context, scrutiny
 }
 
 func (sr *scrutinyRepository) DeleteDevice(ctx context.Context, scrutiny_uuid uuid.UUID) error {
-	if err := sr.gormClient.WithContext(ctx).Where("scrutiny_uuid = ?", scrutiny_uuid.String()).Delete(&models.Device{}).Error; err != nil {
+	err := sr.gormClient.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return deleteDeviceRecords(tx, []string{scrutiny_uuid.String()})
+	})
+	if err != nil {
 		return err
 	}
 
-	//delete data from influxdb.
-	buckets := []string{
-		sr.appConfig.GetString("web.influxdb.bucket"),
-		fmt.Sprintf("%s_weekly", sr.appConfig.GetString("web.influxdb.bucket")),
-		fmt.Sprintf("%s_monthly", sr.appConfig.GetString("web.influxdb.bucket")),
-		fmt.Sprintf("%s_yearly", sr.appConfig.GetString("web.influxdb.bucket")),
-	}
+	sr.analysisHistoryMu.Lock()
+	delete(sr.analysisHistory, scrutiny_uuid)
+	sr.analysisHistoryMu.Unlock()
 
-	for _, bucket := range buckets {
-		sr.logger.Infof("Deleting data for %s in bucket: %s", scrutiny_uuid.String(), bucket)
-		if err := sr.influxClient.DeleteAPI().DeleteWithName(
-			ctx,
-			sr.appConfig.GetString("web.influxdb.org"),
-			bucket,
-			time.Now().AddDate(-10, 0, 0),
-			time.Now(),
-			fmt.Sprintf(`scrutiny_uuid="%s"`, scrutiny_uuid.String()),
-		); err != nil {
-			return err
-		}
-	}
+	return sr.timeSeries.DeleteDeviceData(ctx, scrutiny_uuid)
+}
 
-	return nil
+// deleteDeviceRecords deletes the devices, and the records which reference them. The time-series data is not deleted.
+func deleteDeviceRecords(tx *gorm.DB, scrutinyUUIDs []string) error {
+	if err := tx.Where("scrutiny_uuid IN ?", scrutinyUUIDs).Delete(&models.Device{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("scrutiny_uuid IN ?", scrutinyUUIDs).Delete(&models.NotificationState{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("replaced_scrutiny_uuid IN ? OR replacement_scrutiny_uuid IN ?", scrutinyUUIDs, scrutinyUUIDs).Delete(&models.DeviceReplacement{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("scrutiny_uuid IN ?", scrutinyUUIDs).Delete(&models.DeviceMetadataChange{}).Error
 }
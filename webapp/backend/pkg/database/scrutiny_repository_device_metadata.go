@@ -0,0 +1,53 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/analogj/scrutiny/webapp/backend/pkg/models"
+	"github.com/gofrs/uuid/v5"
+	"gorm.io/gorm"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Device Metadata
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// UpdateDeviceMetadata saves the user provided metadata of the device (see models.DeviceMetadataPatch), and records
+// the changes in the audit log.
+func (sr *scrutinyRepository) UpdateDeviceMetadata(ctx context.Context, device models.Device, changes []models.DeviceMetadataChange) error {
+	return sr.gormClient.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// a map is used so that cleared fields (zero values & nil pointers) are written
+		err := tx.Model(&models.Device{}).Where("scrutiny_uuid = ?", device.ScrutinyUUID.String()).Updates(map[string]interface{}{
+			"label":                device.Label,
+			"location":             device.Location,
+			"purchase_date":        device.PurchaseDate,
+			"warranty_expiry_date": device.WarrantyExpiryDate,
+			"rma_url":              device.RmaUrl,
+			"purchase_cost":        device.PurchaseCost,
+			"rated_tbw":            device.RatedTbw,
+			"notes":                device.Notes,
+		}).Error
+		if err != nil {
+			return fmt.Errorf("could not update metadata for device %s: %w", device.ScrutinyUUID, err)
+		}
+		if len(changes) == 0 {
+			return nil
+		}
+		return tx.Create(&changes).Error
+	})
+}
+
+// GetDeviceMetadataChanges returns the most recent metadata changes for the device, newest first.
+func (sr *scrutinyRepository) GetDeviceMetadataChanges(ctx context.Context, scrutiny_uuid uuid.UUID, limit int) ([]models.DeviceMetadataChange, error) {
+	changes := []models.DeviceMetadataChange{}
+	err := sr.gormClient.WithContext(ctx).
+		Where("scrutiny_uuid = ?", scrutiny_uuid.String()).
+		Order("id desc").
+		Limit(limit).
+		Find(&changes).Error
+	if err != nil {
+		return nil, fmt.Errorf("could not get metadata changes for device %s: %w", scrutiny_uuid, err)
+	}
+	return changes, nil
+}
@@ -0,0 +1,292 @@
+package database
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/analogj/scrutiny/webapp/backend/pkg"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// deviceQueryCursor identifies the last device of a page. Pages are keyset paginated on (sort column, scrutiny_uuid), so
+// devices added or removed between requests don't cause duplicated or skipped devices.
+// The sort column value is stored in the field matching its type, so it is compared with the same type after decoding.
+type deviceQueryCursor struct {
+	Sort         string     `json:"s"`
+	String       *string    `json:"vs,omitempty"`
+	Int          *int64     `json:"vi,omitempty"`
+	Float        *float64   `json:"vf,omitempty"`
+	Bool         *bool      `json:"vb,omitempty"`
+	Time         *time.Time `json:"vt,omitempty"`
+	Null         bool       `json:"n,omitempty"`
+	ScrutinyUUID string     `json:"id"`
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Device Query
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// QueryDevices returns a page of devices matching the query, and the cursor of the next page (empty for the last page).
+// When the query has a sparse fieldset, only the requested columns are populated.
+func (sr *scrutinyRepository) QueryDevices(ctx context.Context, query models.DeviceQuery) ([]models.Device, string, error) {
+	if err := query.Validate(); err != nil {
+		return nil, "", err
+	}
+	deviceFields, err := sr.deviceQueryFields()
+	if err != nil {
+		return nil, "", err
+	}
+	sortFieldName, descending := query.SortField()
+	sortField := deviceFields[sortFieldName]
+
+	tx := sr.gormClient.WithContext(ctx).Model(&models.Device{})
+	tx = applyDeviceQueryFilters(tx, query)
+
+	if len(query.Cursor) > 0 {
+		cursor, err := decodeDeviceQueryCursor(query.Cursor, sortField)
+		if err != nil {
+			return nil, "", err
+		}
+		if cursor.Sort != query.Sort {
+			return nil, "", fmt.Errorf("invalid cursor: the cursor was created with a different sort")
+		}
+		operator := ">"
+		if descending {
+			operator = "<"
+		}
+		// NULL values are sorted last, in both directions
+		column := sortField.DBName
+		if cursor.Null {
+			tx = tx.Where(fmt.Sprintf("%s IS NULL AND scrutiny_uuid %s ?", column, operator), cursor.ScrutinyUUID)
+		} else {
+			value := cursor.value()
+			tx = tx.Where(
+				fmt.Sprintf("((%[1]s IS NOT NULL AND (%[1]s %[2]s ? OR (%[1]s = ? AND scrutiny_uuid %[2]s ?))) OR %[1]s IS NULL)", column, operator),
+				value, value, cursor.ScrutinyUUID,
+			)
+		}
+	}
+
+	if len(query.Fields) > 0 {
+		// the sort column is needed for the cursor
+		columns := []string{"scrutiny_uuid", sortField.DBName}
+		for _, fieldName := range query.Fields {
+			if column := deviceFields[fieldName].DBName; !slices.Contains(columns, column) {
+				columns = append(columns, column)
+			}
+		}
+		tx = tx.Select(columns)
+	}
+
+	direction := "ASC"
+	if descending {
+		direction = "DESC"
+	}
+	devices := []models.Device{}
+	err = tx.
+		Order(fmt.Sprintf("%[1]s IS NULL, %[1]s %[2]s, scrutiny_uuid %[2]s", sortField.DBName, direction)).
+		Limit(query.Limit + 1).
+		Find(&devices).Error
+	if err != nil {
+		return nil, "", fmt.Errorf("could not query devices from DB: %w", err)
+	}
+	if len(devices) <= query.Limit {
+		return devices, "", nil
+	}
+
+	devices = devices[:query.Limit]
+	nextCursor, err := encodeDeviceQueryCursor(ctx, query.Sort, sortField, devices[len(devices)-1])
+	if err != nil {
+		return nil, "", err
+	}
+	return devices, nextCursor, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Helper Methods
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// deviceQueryFields maps the json field names of models.Device to the database columns
+func (sr *scrutinyRepository) deviceQueryFields() (map[string]*schema.Field, error) {
+	stmt := &gorm.Statement{DB: sr.gormClient}
+	if err := stmt.Parse(&models.Device{}); err != nil {
+		return nil, fmt.Errorf("could not parse device schema: %w", err)
+	}
+	deviceFields := map[string]*schema.Field{}
+	for _, field := range stmt.Schema.Fields {
+		if len(field.DBName) > 0 {
+			deviceFields[models.DeviceFieldName(field.StructField)] = field
+		}
+	}
+	return deviceFields, nil
+}
+
+func applyDeviceQueryFilters(tx *gorm.DB, query models.DeviceQuery) *gorm.DB {
+	if len(query.HostIds) > 0 {
+		tx = tx.Where("host_id IN ?", query.HostIds)
+	}
+	if len(query.Protocols) > 0 {
+		tx = tx.Where("device_protocol IN ?", query.Protocols)
+	}
+	if len(query.Statuses) > 0 {
+		// bitwise conditions can't use the device_status index, so the filters are expanded to the matching status values
+		matchingStatuses := []pkg.DeviceStatus{}
+		for deviceStatus := pkg.DeviceStatus(0); deviceStatus <= allDeviceStatuses; deviceStatus++ {
+			for _, status := range query.Statuses {
+				if deviceStatusMatches(deviceStatus, status) {
+					matchingStatuses = append(matchingStatuses, deviceStatus)
+					break
+				}
+			}
+		}
+		tx = tx.Where("device_status IN ?", matchingStatuses)
+	}
+	if len(query.Model) > 0 {
+		tx = tx.Where(`model_name LIKE ? ESCAPE '\'`, "%"+escapeLike(query.Model)+"%")
+	}
+	if len(query.Label) > 0 {
+		tx = tx.Where(`label LIKE ? ESCAPE '\'`, "%"+escapeLike(query.Label)+"%")
+	}
+	if query.Archived != nil {
+		tx = tx.Where("archived = ?", *query.Archived)
+	}
+	if query.CapacityMin != nil {
+		tx = tx.Where("capacity >= ?", *query.CapacityMin)
+	}
+	if query.CapacityMax != nil {
+		tx = tx.Where("capacity <= ?", *query.CapacityMax)
+	}
+	return tx
+}
+
+// every combination of the device status flags
+var allDeviceStatuses = pkg.DeviceStatusSet(pkg.DeviceStatusSet(pkg.DeviceStatusFailedSmart, pkg.DeviceStatusFailedScrutiny), pkg.DeviceStatusWarningScrutiny)
+
+func deviceStatusMatches(deviceStatus pkg.DeviceStatus, status string) bool {
+	failedStatus := pkg.DeviceStatusSet(pkg.DeviceStatusFailedSmart, pkg.DeviceStatusFailedScrutiny)
+	switch status {
+	case models.DeviceQueryStatusPassed:
+		return !pkg.DeviceStatusHas(deviceStatus, failedStatus)
+	case models.DeviceQueryStatusFailed:
+		return pkg.DeviceStatusHas(deviceStatus, failedStatus)
+	case models.DeviceQueryStatusFailedSmart:
+		return pkg.DeviceStatusHas(deviceStatus, pkg.DeviceStatusFailedSmart)
+	case models.DeviceQueryStatusFailedScrutiny:
+		return pkg.DeviceStatusHas(deviceStatus, pkg.DeviceStatusFailedScrutiny)
+	case models.DeviceQueryStatusWarning:
+		return pkg.DeviceStatusHas(deviceStatus, pkg.DeviceStatusWarningScrutiny)
+	}
+	return false
+}
+
+// LIKE is case-insensitive (for ASCII characters) in SQLite, so only the wildcards need to be escaped
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
+
+func encodeDeviceQueryCursor(ctx context.Context, sort string, sortField *schema.Field, device models.Device) (string, error) {
+	cursor := deviceQueryCursor{Sort: sort, ScrutinyUUID: device.ScrutinyUUID.String()}
+	value, _ := sortField.ValueOf(ctx, reflect.ValueOf(&device).Elem())
+	reflectValue := reflect.ValueOf(value)
+	if reflectValue.Kind() == reflect.Ptr {
+		if reflectValue.IsNil() {
+			cursor.Null = true
+		} else {
+			reflectValue = reflectValue.Elem()
+		}
+	}
+	if !cursor.Null {
+		if err := cursor.setValue(reflectValue); err != nil {
+			return "", err
+		}
+	}
+
+	encoded, err := json.Marshal(cursor)
+	if err != nil {
+		return "", fmt.Errorf("could not encode cursor: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(encoded), nil
+}
+
+func decodeDeviceQueryCursor(encoded string, sortField *schema.Field) (deviceQueryCursor, error) {
+	var cursor deviceQueryCursor
+	decoded, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return cursor, fmt.Errorf("invalid cursor")
+	}
+	if err := json.Unmarshal(decoded, &cursor); err != nil || len(cursor.ScrutinyUUID) == 0 {
+		return cursor, fmt.Errorf("invalid cursor")
+	}
+
+	// the value must have been encoded from the same sort column type
+	fieldType := sortField.FieldType
+	if fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+	expected := deviceQueryCursor{}
+	if err := expected.setValue(reflect.Zero(fieldType)); err != nil {
+		return cursor, err
+	}
+	if !cursor.Null && (cursor.value() == nil || reflect.TypeOf(cursor.value()) != reflect.TypeOf(expected.value())) {
+		return cursor, fmt.Errorf("invalid cursor")
+	}
+	return cursor, nil
+}
+
+// setValue stores the sort column value in the cursor field matching its type
+func (cursor *deviceQueryCursor) setValue(value reflect.Value) error {
+	switch {
+	case value.Type() == reflect.TypeOf(time.Time{}):
+		timeValue := value.Interface().(time.Time)
+		cursor.Time = &timeValue
+	case value.Kind() == reflect.String:
+		stringValue := value.String()
+		cursor.String = &stringValue
+	case value.CanInt():
+		intValue := value.Int()
+		cursor.Int = &intValue
+	case value.CanUint():
+		intValue := int64(value.Uint())
+		cursor.Int = &intValue
+	case value.CanFloat():
+		floatValue := value.Float()
+		cursor.Float = &floatValue
+	case value.Kind() == reflect.Bool:
+		boolValue := value.Bool()
+		cursor.Bool = &boolValue
+	default:
+		// eg. uuid.UUID, which is stored as a string
+		stringer, ok := value.Interface().(fmt.Stringer)
+		if !ok {
+			return fmt.Errorf("could not encode cursor: unsupported sort field type %s", value.Type())
+		}
+		stringValue := stringer.String()
+		cursor.String = &stringValue
+	}
+	return nil
+}
+
+// value returns the sort column value, nil if the cursor doesn't have a value
+func (cursor *deviceQueryCursor) value() interface{} {
+	switch {
+	case cursor.Time != nil:
+		return *cursor.Time
+	case cursor.String != nil:
+		return *cursor.String
+	case cursor.Int != nil:
+		return *cursor.Int
+	case cursor.Float != nil:
+		return *cursor.Float
+	case cursor.Bool != nil:
+		return *cursor.Bool
+	}
+	return nil
+}
@@ -0,0 +1,226 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/analogj/scrutiny/webapp/backend/pkg"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/models"
+	"github.com/gofrs/uuid/v5"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func newTestQueryRepository(t *testing.T, devices ...models.Device) *scrutinyRepository {
+	deviceRepo := newTestSqliteRepository(t, &models.Device{})
+	for ndx := range devices {
+		devices[ndx].ScrutinyUUID = uuid.Must(uuid.NewV4())
+		require.NoError(t, deviceRepo.gormClient.Create(&devices[ndx]).Error)
+	}
+	return deviceRepo
+}
+
+// queryAllDevices follows the cursors until the last page, and returns the device names in order
+func queryAllDevices(t *testing.T, deviceRepo *scrutinyRepository, query models.DeviceQuery) []string {
+	deviceNames := []string{}
+	for {
+		devices, nextCursor, err := deviceRepo.QueryDevices(context.Background(), query)
+		require.NoError(t, err)
+		require.LessOrEqual(t, len(devices), query.Limit)
+		for _, device := range devices {
+			deviceNames = append(deviceNames, device.DeviceName)
+		}
+		if len(nextCursor) == 0 {
+			return deviceNames
+		}
+		query.Cursor = nextCursor
+	}
+}
+
+func Test_QueryDevices_Filters(t *testing.T) {
+	//setup
+	archived := true
+	capacityMin := int64(4000000000000)
+	deviceRepo := newTestQueryRepository(t,
+		models.Device{DeviceName: "sda", HostId: "nas01", DeviceProtocol: pkg.DeviceProtocolAta, ModelName: "WDC WD40EFRX", Capacity: 4000000000000},
+		models.Device{DeviceName: "sdb", HostId: "nas01", DeviceProtocol: pkg.DeviceProtocolAta, ModelName: "WDC WD140EDGZ", Capacity: 14000000000000, DeviceStatus: pkg.DeviceStatusFailedSmart},
+		models.Device{DeviceName: "sdc", HostId: "nas02", DeviceProtocol: pkg.DeviceProtocolAta, ModelName: "ST4000VN008", Capacity: 4000000000000, DeviceStatus: pkg.DeviceStatusWarningScrutiny, Label: "Parity_1"},
+		models.Device{DeviceName: "nvme0", HostId: "nas02", DeviceProtocol: pkg.DeviceProtocolNvme, ModelName: "Samsung SSD 970", Capacity: 1000000000000, Archived: true},
+		models.Device{DeviceName: "sdd", HostId: "nas03", DeviceProtocol: pkg.DeviceProtocolAta, ModelName: "ST8000VN004", Capacity: 8000000000000, DeviceStatus: pkg.DeviceStatusSet(pkg.DeviceStatusFailedScrutiny, pkg.DeviceStatusWarningScrutiny)},
+	)
+
+	for _, tc := range []struct {
+		query    models.DeviceQuery
+		expected []string
+	}{
+		{models.DeviceQuery{}, []string{"nvme0", "sda", "sdb", "sdc", "sdd"}},
+		{models.DeviceQuery{HostIds: []string{"nas02"}}, []string{"nvme0", "sdc"}},
+		{models.DeviceQuery{Protocols: []string{"nvme"}}, []string{"nvme0"}},
+		{models.DeviceQuery{Statuses: []string{models.DeviceQueryStatusFailed}}, []string{"sdb", "sdd"}},
+		// warnings are not failures
+		{models.DeviceQuery{Statuses: []string{models.DeviceQueryStatusPassed}}, []string{"nvme0", "sda", "sdc"}},
+		{models.DeviceQuery{Statuses: []string{models.DeviceQueryStatusWarning, models.DeviceQueryStatusFailedSmart}}, []string{"sdb", "sdc", "sdd"}},
+		{models.DeviceQuery{Statuses: []string{models.DeviceQueryStatusFailedScrutiny}}, []string{"sdd"}},
+		{models.DeviceQuery{Model: "wdc"}, []string{"sda", "sdb"}},
+		{models.DeviceQuery{Label: "parity_"}, []string{"sdc"}},
+		{models.DeviceQuery{Label: "%"}, []string{}},
+		{models.DeviceQuery{Archived: &archived}, []string{"nvme0"}},
+		{models.DeviceQuery{CapacityMin: &capacityMin, HostIds: []string{"nas01"}}, []string{"sda", "sdb"}},
+	} {
+		//test
+		tc.query.Limit = 10
+		deviceNames := queryAllDevices(t, deviceRepo, tc.query)
+
+		//assert
+		require.Equal(t, tc.expected, deviceNames, tc.query)
+	}
+}
+
+func Test_QueryDevices_StatusIndex(t *testing.T) {
+	//setup
+	deviceRepo := newTestQueryRepository(t)
+	query := models.DeviceQuery{Statuses: []string{models.DeviceQueryStatusFailed}}
+
+	//test
+	querySql := deviceRepo.gormClient.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		return applyDeviceQueryFilters(tx.Model(&models.Device{}), query).Find(&[]models.Device{})
+	})
+	var queryPlan []struct {
+		Detail string
+	}
+	err := deviceRepo.gormClient.Raw("EXPLAIN QUERY PLAN " + querySql).Scan(&queryPlan).Error
+
+	//assert
+	require.NoError(t, err)
+	require.Contains(t, querySql, "device_status IN (1,2,3,5,6,7)")
+	require.NotEmpty(t, queryPlan)
+	require.Contains(t, queryPlan[0].Detail, "idx_devices_device_status")
+}
+
+func Test_QueryDevices_Pagination(t *testing.T) {
+	//setup
+	lastSeenAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	laterSeenAt := lastSeenAt.Add(time.Hour)
+	deviceRepo := newTestQueryRepository(t,
+		models.Device{DeviceName: "sda", Capacity: 4000000000000, LastSeenAt: &lastSeenAt},
+		models.Device{DeviceName: "sdb", Capacity: 14000000000000},
+		models.Device{DeviceName: "sdc", Capacity: 4000000000000, LastSeenAt: &laterSeenAt},
+		models.Device{DeviceName: "sdd", Capacity: 4000000000000},
+		models.Device{DeviceName: "sde", Capacity: 8000000000000, LastSeenAt: &lastSeenAt},
+	)
+
+	//test
+	byCapacity := queryAllDevices(t, deviceRepo, models.DeviceQuery{Sort: "-capacity", Limit: 2})
+	byLastSeen := queryAllDevices(t, deviceRepo, models.DeviceQuery{Sort: "last_seen_at", Limit: 2})
+	byLastSeenDescending := queryAllDevices(t, deviceRepo, models.DeviceQuery{Sort: "-last_seen_at", Limit: 1})
+
+	//assert
+	require.Len(t, byCapacity, 5)
+	require.Equal(t, []string{"sdb", "sde"}, byCapacity[:2])
+	require.ElementsMatch(t, []string{"sda", "sdc", "sdd"}, byCapacity[2:])
+	// devices without a value are sorted last
+	require.ElementsMatch(t, []string{"sda", "sde"}, byLastSeen[:2])
+	require.Equal(t, "sdc", byLastSeen[2])
+	require.ElementsMatch(t, []string{"sdb", "sdd"}, byLastSeen[3:])
+	require.Equal(t, "sdc", byLastSeenDescending[0])
+	require.ElementsMatch(t, []string{"sda", "sde"}, byLastSeenDescending[1:3])
+	require.ElementsMatch(t, []string{"sdb", "sdd"}, byLastSeenDescending[3:])
+}
+
+func Test_QueryDevices_Fields(t *testing.T) {
+	//setup
+	deviceRepo := newTestQueryRepository(t,
+		models.Device{DeviceName: "sda", HostId: "nas01", ModelName: "WDC WD40EFRX", Capacity: 4000000000000},
+	)
+
+	//test
+	devices, nextCursor, err := deviceRepo.QueryDevices(context.Background(), models.DeviceQuery{Fields: []string{"host_id", "capacity"}})
+
+	//assert
+	require.NoError(t, err)
+	require.Empty(t, nextCursor)
+	require.Len(t, devices, 1)
+	require.NotEqual(t, uuid.Nil, devices[0].ScrutinyUUID)
+	require.Equal(t, "nas01", devices[0].HostId)
+	require.Equal(t, int64(4000000000000), devices[0].Capacity)
+	require.Empty(t, devices[0].ModelName)
+}
+
+func Test_QueryDevices_Invalid(t *testing.T) {
+	//setup
+	deviceRepo := newTestQueryRepository(t,
+		models.Device{DeviceName: "sda"},
+		models.Device{DeviceName: "sdb"},
+	)
+	ctx := context.Background()
+	_, nextCursor, err := deviceRepo.QueryDevices(ctx, models.DeviceQuery{Limit: 1})
+	require.NoError(t, err)
+	require.NotEmpty(t, nextCursor)
+
+	for _, invalidQuery := range []models.DeviceQuery{
+		{Sort: "unknown"},
+		{Fields: []string{"device_name", "unknown"}},
+		{Protocols: []string{"sas"}},
+		{Statuses: []string{"failing"}},
+		{Limit: models.DeviceQueryMaxLimit + 1},
+		{Cursor: "invalid"},
+		// latest SMART data is only available from the summary
+		{Sort: "-temp"},
+		{Fields: []string{"device_name", "power_on_hours"}},
+		// the cursor must be used with the same sort
+		{Cursor: nextCursor, Sort: "-device_name"},
+	} {
+		//test
+		_, _, err := deviceRepo.QueryDevices(ctx, invalidQuery)
+
+		//assert
+		require.Error(t, err, invalidQuery)
+	}
+}
+
+func Test_DeviceQueryCursor_TypedValue(t *testing.T) {
+	//setup
+	deviceRepo := newTestQueryRepository(t)
+	deviceFields, err := deviceRepo.deviceQueryFields()
+	require.NoError(t, err)
+	lastSeenAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	device := models.Device{ScrutinyUUID: uuid.Must(uuid.NewV4()), Capacity: 14000000000000, DeviceStatus: pkg.DeviceStatusFailedSmart, LastSeenAt: &lastSeenAt}
+
+	for sort, expected := range map[string]interface{}{
+		"-capacity":     int64(14000000000000),
+		"device_status": int64(pkg.DeviceStatusFailedSmart),
+		"last_seen_at":  lastSeenAt,
+		"archived":      false,
+	} {
+		sortFieldName := sort
+		if sort[0] == '-' {
+			sortFieldName = sort[1:]
+		}
+
+		//test
+		encoded, err := encodeDeviceQueryCursor(context.Background(), sort, deviceFields[sortFieldName], device)
+		require.NoError(t, err)
+		cursor, err := decodeDeviceQueryCursor(encoded, deviceFields[sortFieldName])
+
+		//assert
+		require.NoError(t, err, sort)
+		require.Equal(t, sort, cursor.Sort)
+		require.Equal(t, expected, cursor.value(), sort)
+	}
+}
+
+func Test_DeviceQueryCursor_InvalidValueType(t *testing.T) {
+	//setup
+	deviceRepo := newTestQueryRepository(t)
+	deviceFields, err := deviceRepo.deviceQueryFields()
+	require.NoError(t, err)
+	encoded, err := encodeDeviceQueryCursor(context.Background(), "device_name", deviceFields["device_name"], models.Device{ScrutinyUUID: uuid.Must(uuid.NewV4()), DeviceName: "sda"})
+	require.NoError(t, err)
+
+	//test
+	_, err = decodeDeviceQueryCursor(encoded, deviceFields["capacity"])
+
+	//assert
+	require.Error(t, err)
+}
@@ -0,0 +1,94 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/analogj/scrutiny/webapp/backend/pkg/models"
+	"github.com/gofrs/uuid/v5"
+	"gorm.io/gorm"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Device Replacements
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// CreateDeviceReplacement records that the replaced device was replaced by another device. A device can only be
+// replaced once, the replacement must be removed before the device can be linked to another replacement.
+func (sr *scrutinyRepository) CreateDeviceReplacement(ctx context.Context, replacement models.DeviceReplacement) (models.DeviceReplacement, error) {
+	replacement.ID = 0
+	if replacement.ReplacedScrutinyUUID.IsNil() || replacement.ReplacementScrutinyUUID.IsNil() {
+		return models.DeviceReplacement{}, fmt.Errorf("replaced and replacement device are required")
+	}
+	if replacement.ReplacedScrutinyUUID == replacement.ReplacementScrutinyUUID {
+		return models.DeviceReplacement{}, fmt.Errorf("a device cannot replace itself")
+	}
+
+	var existing models.DeviceReplacement
+	err := sr.gormClient.WithContext(ctx).Where("replaced_scrutiny_uuid = ?", replacement.ReplacedScrutinyUUID.String()).First(&existing).Error
+	if err == nil {
+		return models.DeviceReplacement{}, fmt.Errorf("device %s was already replaced by %s", replacement.ReplacedScrutinyUUID, existing.ReplacementScrutinyUUID)
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.DeviceReplacement{}, err
+	}
+
+	if err := sr.gormClient.WithContext(ctx).Create(&replacement).Error; err != nil {
+		return models.DeviceReplacement{}, fmt.Errorf("could not create device replacement: %w", err)
+	}
+	return replacement, nil
+}
+
+// GetDeviceReplacements returns the history of the slot the device is installed in: every replacement linked to the
+// device, directly or through earlier & later replacements, oldest first.
+func (sr *scrutinyRepository) GetDeviceReplacements(ctx context.Context, scrutiny_uuid uuid.UUID) ([]models.DeviceReplacement, error) {
+	lineage := []models.DeviceReplacement{}
+	visitedReplacements := map[uint]bool{}
+	visitedDevices := map[uuid.UUID]bool{scrutiny_uuid: true}
+	pendingDevices := []string{scrutiny_uuid.String()}
+
+	for len(pendingDevices) > 0 {
+		replacements := []models.DeviceReplacement{}
+		err := sr.gormClient.WithContext(ctx).
+			Where("replaced_scrutiny_uuid IN ? OR replacement_scrutiny_uuid IN ?", pendingDevices, pendingDevices).
+			Find(&replacements).Error
+		if err != nil {
+			return nil, fmt.Errorf("could not get device replacements from DB: %w", err)
+		}
+
+		pendingDevices = []string{}
+		for _, replacement := range replacements {
+			if visitedReplacements[replacement.ID] {
+				continue
+			}
+			visitedReplacements[replacement.ID] = true
+			lineage = append(lineage, replacement)
+			for _, linkedDevice := range []uuid.UUID{replacement.ReplacedScrutinyUUID, replacement.ReplacementScrutinyUUID} {
+				if !visitedDevices[linkedDevice] {
+					visitedDevices[linkedDevice] = true
+					pendingDevices = append(pendingDevices, linkedDevice.String())
+				}
+			}
+		}
+	}
+
+	slices.SortFunc(lineage, func(a, b models.DeviceReplacement) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+	return lineage, nil
+}
+
+// DeleteDeviceReplacement deletes the replacement, only when the device is the replaced or the replacement device.
+func (sr *scrutinyRepository) DeleteDeviceReplacement(ctx context.Context, scrutiny_uuid uuid.UUID, id uint) error {
+	result := sr.gormClient.WithContext(ctx).
+		Where("replaced_scrutiny_uuid = ? OR replacement_scrutiny_uuid = ?", scrutiny_uuid.String(), scrutiny_uuid.String()).
+		Delete(&models.DeviceReplacement{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("device replacement %d not found for device %s: %w", id, scrutiny_uuid, gorm.ErrRecordNotFound)
+	}
+	return nil
+}
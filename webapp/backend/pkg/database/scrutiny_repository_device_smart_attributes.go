@@ -19,16 +19,13 @@ import (
 // //////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 func (sr *scrutinyRepository) SaveSmartAttributes(ctx context.Context, scrutiny_uuid uuid.UUID, collectorSmartData collector.SmartInfo) (measurements.Smart, error) {
 	deviceSmartData := measurements.Smart{}
-	err := deviceSmartData.FromCollectorSmartInfo(scrutiny_uuid, collectorSmartData)
+	err := deviceSmartData.FromCollectorSmartInfo(scrutiny_uuid, collectorSmartData, sr.getDeviceAttributeOverrides(ctx, scrutiny_uuid))
 	if err != nil {
 		sr.logger.Errorln("Could not process SMART metrics", err)
 		return measurements.Smart{}, err
 	}
 
-	tags, fields := deviceSmartData.Flatten()
-
-	// write point immediately
-	return deviceSmartData, sr.saveDatapoint(sr.influxWriteApi, "smart", tags, fields, deviceSmartData.Date, ctx)
+	return deviceSmartData, sr.timeSeries.WriteSmart(ctx, deviceSmartData)
 }
 
 // GetSmartAttributeHistory MUST return in sorted order, where newest entries are at the beginning of the list, and oldest are at the end.
@@ -36,18 +33,71 @@ func (sr *scrutinyRepository) SaveSmartAttributes(ctx context.Context, scrutiny_
 // For example, with selectEntries = 5, selectEntries = 0, the most recent 5 are returned. With selectEntries = 3, selectEntries = 2, entries
 // 2 to 4 are returned (2 being the third newest, since it is zero-indexed)
 func (sr *scrutinyRepository) GetSmartAttributeHistory(ctx context.Context, scrutiny_uuid uuid.UUID, durationKey string, selectEntries int, selectEntriesOffset int, attributes []string) ([]measurements.Smart, error) {
+	return sr.timeSeries.GetSmartAttributeHistory(ctx, scrutiny_uuid, durationKey, selectEntries, selectEntriesOffset, attributes)
+}
+
+// GetAnalysisSmartHistory returns the last year of attribute history (newest first), used by the failure prediction &
+// endurance analysis after each upload. The history is read from the down-sampled buckets once a day, and cached. The
+// latest smart data replaces the cached entries which are not older than it.
+func (sr *scrutinyRepository) GetAnalysisSmartHistory(ctx context.Context, scrutiny_uuid uuid.UUID, latest measurements.Smart) ([]measurements.Smart, error) {
+	sr.analysisHistoryMu.Lock()
+	cached, ok := sr.analysisHistory[scrutiny_uuid]
+	sr.analysisHistoryMu.Unlock()
+
+	if !ok || time.Since(cached.loadedAt) >= analysisHistoryCacheTTL {
+		history, err := sr.GetSmartAttributeHistory(ctx, scrutiny_uuid, DURATION_KEY_YEAR, 0, 0, nil)
+		if err != nil {
+			return nil, err
+		}
+		cached = cachedSmartHistory{loadedAt: time.Now(), history: history}
+
+		sr.analysisHistoryMu.Lock()
+		if sr.analysisHistory == nil {
+			sr.analysisHistory = map[uuid.UUID]cachedSmartHistory{}
+		}
+		sr.analysisHistory[scrutiny_uuid] = cached
+		sr.analysisHistoryMu.Unlock()
+	}
+
+	history := []measurements.Smart{latest}
+	for _, smart := range cached.history {
+		if smart.Date.Before(latest.Date) {
+			history = append(history, smart)
+		}
+	}
+	return history, nil
+}
+
+// GetLatestSmartAttributes returns the most recent smart data (including attributes) for each device, using a single
+// query across all buckets.
+func (sr *scrutinyRepository) GetLatestSmartAttributes(ctx context.Context) (map[uuid.UUID]measurements.Smart, error) {
+	return sr.timeSeries.GetLatestSmartAttributes(ctx)
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// InfluxDB
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func (ir *influxTimeSeriesRepository) WriteSmart(ctx context.Context, smart measurements.Smart) error {
+	tags, fields := smart.Flatten()
+
+	// write point immediately
+	return ir.saveDatapoint(ir.influxWriteApi, "smart", tags, fields, smart.Date, ctx)
+}
+
+func (ir *influxTimeSeriesRepository) GetSmartAttributeHistory(ctx context.Context, scrutiny_uuid uuid.UUID, durationKey string, selectEntries int, selectEntriesOffset int, attributes []string) ([]measurements.Smart, error) {
 	// Get SMartResults from InfluxDB
 
 	//TODO: change the filter startrange to a real number.
 
 	// Get parser flux query result
 	//appConfig.GetString("web.influxdb.bucket")
-	queryStr := sr.aggregateSmartAttributesQuery(scrutiny_uuid, durationKey, selectEntries, selectEntriesOffset, attributes)
+	queryStr := ir.aggregateSmartAttributesQuery(scrutiny_uuid, durationKey, selectEntries, selectEntriesOffset, attributes)
 	log.Infoln(queryStr)
 
 	smartResults := []measurements.Smart{}
 
-	result, err := sr.influxQueryApi.Query(ctx, queryStr)
+	result, err := ir.influxQueryApi.Query(ctx, queryStr)
 	if err == nil {
 		// Use Next() to iterate over query result lines
 		for result.Next() {
@@ -90,7 +140,7 @@ func (sr *scrutinyRepository) GetSmartAttributeHistory(ctx context.Context, scru
 // Helper Methods
 ////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 
-func (sr *scrutinyRepository) saveDatapoint(influxWriteApi api.WriteAPIBlocking, measurement string, tags map[string]string, fields map[string]interface{}, date time.Time, ctx context.Context) error {
+func (ir *influxTimeSeriesRepository) saveDatapoint(influxWriteApi api.WriteAPIBlocking, measurement string, tags map[string]string, fields map[string]interface{}, date time.Time, ctx context.Context) error {
 	//sr.logger.Debugf("Storing datapoint in measurement '%s'. tags: %d fields: %d", measurement, len(tags), len(fields))
 	p := influxdb2.NewPoint(measurement,
 		tags,
@@ -101,7 +151,7 @@ func (sr *scrutinyRepository) saveDatapoint(influxWriteApi api.WriteAPIBlocking,
 	return influxWriteApi.WritePoint(ctx, p)
 }
 
-func (sr *scrutinyRepository) aggregateSmartAttributesQuery(scrutiny_uuid uuid.UUID, durationKey string, selectEntries int, selectEntriesOffset int, attributes []string) string {
+func (ir *influxTimeSeriesRepository) aggregateSmartAttributesQuery(scrutiny_uuid uuid.UUID, durationKey string, selectEntries int, selectEntriesOffset int, attributes []string) string {
 
 	/*
 
@@ -146,12 +196,12 @@ func (sr *scrutinyRepository) aggregateSmartAttributesQuery(scrutiny_uuid uuid.U
 		`import "influxdata/influxdb/schema"`,
 	}
 
-	nestedDurationKeys := sr.lookupNestedDurationKeys(durationKey)
+	nestedDurationKeys := lookupNestedDurationKeys(durationKey)
 
 	if len(nestedDurationKeys) == 1 {
 		//there's only one bucket being queried, no need to union, just aggregate the dataset and return
 		partialQueryStr = append(partialQueryStr, []string{
-			sr.generateSmartAttributesSubquery(scrutiny_uuid, nestedDurationKeys[0], selectEntries, selectEntriesOffset, attributes),
+			ir.generateSmartAttributesSubquery(scrutiny_uuid, nestedDurationKeys[0], selectEntries, selectEntriesOffset, attributes),
 			fmt.Sprintf(`%sData`, nestedDurationKeys[0]),
 			`|> sort(columns: ["_time"], desc: true)`,
 			`|> yield()`,
@@ -166,9 +216,9 @@ func (sr *scrutinyRepository) aggregateSmartAttributesQuery(scrutiny_uuid uuid.U
 		if selectEntries > 0 {
 			// We only need the last `n + offset` # of entries from each table to guarantee we can
 			// get the last `n` # of entries starting from `offset` of the union
-			subQueries = append(subQueries, sr.generateSmartAttributesSubquery(scrutiny_uuid, nestedDurationKey, selectEntries+selectEntriesOffset, 0, attributes))
+			subQueries = append(subQueries, ir.generateSmartAttributesSubquery(scrutiny_uuid, nestedDurationKey, selectEntries+selectEntriesOffset, 0, attributes))
 		} else {
-			subQueries = append(subQueries, sr.generateSmartAttributesSubquery(scrutiny_uuid, nestedDurationKey, 0, 0, attributes))
+			subQueries = append(subQueries, ir.generateSmartAttributesSubquery(scrutiny_uuid, nestedDurationKey, 0, 0, attributes))
 		}
 	}
 	partialQueryStr = append(partialQueryStr, subQueries...)
@@ -185,9 +235,9 @@ func (sr *scrutinyRepository) aggregateSmartAttributesQuery(scrutiny_uuid uuid.U
 	return strings.Join(partialQueryStr, "\n")
 }
 
-func (sr *scrutinyRepository) generateSmartAttributesSubquery(scrutiny_uuid uuid.UUID, durationKey string, selectEntries int, selectEntriesOffset int, attributes []string) string {
-	bucketName := sr.lookupBucketName(durationKey)
-	durationRange := sr.lookupDuration(durationKey)
+func (ir *influxTimeSeriesRepository) generateSmartAttributesSubquery(scrutiny_uuid uuid.UUID, durationKey string, selectEntries int, selectEntriesOffset int, attributes []string) string {
+	bucketName := ir.lookupBucketName(durationKey)
+	durationRange := ir.lookupDuration(durationKey)
 
 	partialQueryStr := []string{
 		fmt.Sprintf(`%sData = from(bucket: "%s")`, durationKey, bucketName),
@@ -0,0 +1,221 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/analogj/scrutiny/webapp/backend/pkg"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/analysis"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/models"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/models/collector"
+	"github.com/gofrs/uuid/v5"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func Test_UpdateDeviceFailurePrediction(t *testing.T) {
+	//setup
+	deviceRepo := newTestSqliteRepository(t, &models.Device{})
+	ctx := context.Background()
+	scrutinyUUID := uuid.Must(uuid.NewV4())
+	require.NoError(t, deviceRepo.RegisterDevice(ctx, models.Device{ScrutinyUUID: scrutinyUUID, WWN: "0x5000c500673e6b5f", DeviceName: "sda"}))
+	estimatedFailureDate := time.Now().AddDate(0, 1, 0).Truncate(time.Second)
+
+	//test
+	predictedDevice, err := deviceRepo.UpdateDeviceFailurePrediction(ctx, scrutinyUUID, analysis.FailurePrediction{
+		Score:                80,
+		AttributeId:          "5",
+		EstimatedFailureDate: &estimatedFailureDate,
+	})
+	require.NoError(t, err)
+	storedDevice, err := deviceRepo.GetDeviceDetails(ctx, scrutinyUUID)
+	require.NoError(t, err)
+
+	// a healthy prediction must overwrite the previous values
+	_, err = deviceRepo.UpdateDeviceFailurePrediction(ctx, scrutinyUUID, analysis.FailurePrediction{})
+	require.NoError(t, err)
+	resetDevice, err := deviceRepo.GetDeviceDetails(ctx, scrutinyUUID)
+	require.NoError(t, err)
+
+	//assert
+	require.Equal(t, 80, predictedDevice.FailureScore)
+	require.Equal(t, 80, storedDevice.FailureScore)
+	require.Equal(t, "5", storedDevice.FailureScoreAttributeId)
+	require.NotNil(t, storedDevice.EstimatedFailureDate)
+	require.True(t, estimatedFailureDate.Equal(*storedDevice.EstimatedFailureDate))
+
+	require.Equal(t, 0, resetDevice.FailureScore)
+	require.Empty(t, resetDevice.FailureScoreAttributeId)
+	require.Nil(t, resetDevice.EstimatedFailureDate)
+}
+
+func Test_NotificationState(t *testing.T) {
+	//setup
+	deviceRepo := newTestSqliteRepository(t, &models.Device{}, &models.NotificationState{}, &models.DeviceReplacement{}, &models.DeviceMetadataChange{}, &sqliteSmartPoint{}, &sqliteTemperaturePoint{}, &sqliteSelfTestPoint{})
+	deviceRepo.timeSeries = &sqliteTimeSeriesRepository{logger: deviceRepo.logger, gormClient: deviceRepo.gormClient}
+	ctx := context.Background()
+	scrutinyUUID := uuid.Must(uuid.NewV4())
+	require.NoError(t, deviceRepo.RegisterDevice(ctx, models.Device{ScrutinyUUID: scrutinyUUID, WWN: "0x5000c500673e6b5f", DeviceName: "sda"}))
+
+	//test
+	initialState, initialErr := deviceRepo.GetNotificationState(ctx, scrutinyUUID)
+	warningNotifiedAt := time.Now().Truncate(time.Second)
+	require.NoError(t, deviceRepo.SaveNotificationState(ctx, models.NotificationState{ScrutinyUUID: scrutinyUUID, DeviceStatus: pkg.DeviceStatusFailedScrutiny, NotifiedAt: time.Now()}))
+	require.NoError(t, deviceRepo.SaveNotificationState(ctx, models.NotificationState{ScrutinyUUID: scrutinyUUID, DeviceStatus: pkg.DeviceStatusFailedSmart, NotifiedAt: time.Now(), FailingAttributes: []string{"197", "5"}, WarningNotifiedAt: &warningNotifiedAt}))
+	notifiedState, notifiedErr := deviceRepo.GetNotificationState(ctx, scrutinyUUID)
+	require.NoError(t, deviceRepo.DeleteDevice(ctx, scrutinyUUID))
+	deletedState, deletedErr := deviceRepo.GetNotificationState(ctx, scrutinyUUID)
+
+	//assert
+	require.NoError(t, initialErr)
+	require.Equal(t, pkg.DeviceStatusPassed, initialState.DeviceStatus)
+	require.NoError(t, notifiedErr)
+	require.Equal(t, pkg.DeviceStatusFailedSmart, notifiedState.DeviceStatus)
+	require.False(t, notifiedState.NotifiedAt.IsZero())
+	require.Equal(t, []string{"197", "5"}, notifiedState.FailingAttributes)
+	require.True(t, warningNotifiedAt.Equal(*notifiedState.WarningNotifiedAt))
+	require.NoError(t, deletedErr)
+	require.Equal(t, pkg.DeviceStatusPassed, deletedState.DeviceStatus)
+}
+
+func Test_NotificationDeliveries(t *testing.T) {
+	//setup
+	deviceRepo := newTestSqliteRepository(t, &models.NotificationDelivery{})
+	ctx := context.Background()
+	scrutinyUUID := uuid.Must(uuid.NewV4()).String()
+
+	//test
+	require.NoError(t, deviceRepo.SaveNotificationDelivery(ctx, models.NotificationDelivery{ScrutinyUUID: scrutinyUUID, Attempt: 1, Error: "timeout"}))
+	require.NoError(t, deviceRepo.SaveNotificationDelivery(ctx, models.NotificationDelivery{ScrutinyUUID: scrutinyUUID, Attempt: 2, Success: true}))
+	require.NoError(t, deviceRepo.SaveNotificationDelivery(ctx, models.NotificationDelivery{Test: true, Attempt: 1, Success: true}))
+	allDeliveries, allErr := deviceRepo.GetNotificationDeliveries(ctx, "", 10)
+	deviceDeliveries, deviceErr := deviceRepo.GetNotificationDeliveries(ctx, scrutinyUUID, 1)
+
+	//assert
+	require.NoError(t, allErr)
+	require.Len(t, allDeliveries, 3)
+	require.True(t, allDeliveries[0].Test)
+	require.NoError(t, deviceErr)
+	require.Len(t, deviceDeliveries, 1)
+	require.Equal(t, 2, deviceDeliveries[0].Attempt)
+}
+
+func Test_PruneNotificationDeliveries(t *testing.T) {
+	//setup
+	deviceRepo := newTestSqliteRepository(t, &models.NotificationDelivery{})
+	ctx := context.Background()
+	now := time.Now()
+	require.NoError(t, deviceRepo.SaveNotificationDelivery(ctx, models.NotificationDelivery{CreatedAt: now.AddDate(0, 0, -31), Attempt: 1}))
+	require.NoError(t, deviceRepo.SaveNotificationDelivery(ctx, models.NotificationDelivery{CreatedAt: now.AddDate(0, 0, -1), Attempt: 2}))
+
+	//test
+	pruned, err := deviceRepo.PruneNotificationDeliveries(ctx, now.AddDate(0, 0, -30))
+	deliveries, deliveriesErr := deviceRepo.GetNotificationDeliveries(ctx, "", 10)
+
+	//assert
+	require.NoError(t, err)
+	require.Equal(t, int64(1), pruned)
+	require.NoError(t, deliveriesErr)
+	require.Len(t, deliveries, 1)
+	require.Equal(t, 2, deliveries[0].Attempt)
+}
+
+func Test_UpdateDeviceStale(t *testing.T) {
+	//setup
+	deviceRepo := newTestSqliteRepository(t, &models.Device{})
+	ctx := context.Background()
+	scrutinyUUID := uuid.Must(uuid.NewV4())
+	require.NoError(t, deviceRepo.RegisterDevice(ctx, models.Device{ScrutinyUUID: scrutinyUUID, WWN: "0x5000c500673e6b5f", DeviceName: "sda"}))
+
+	//test
+	require.NoError(t, deviceRepo.UpdateDeviceStale(ctx, scrutinyUUID, true))
+	staleDevice, err := deviceRepo.GetDeviceDetails(ctx, scrutinyUUID)
+	require.NoError(t, err)
+
+	// uploading data must clear the stale flag
+	_, err = deviceRepo.UpdateDevice(ctx, scrutinyUUID, collector.SmartInfo{})
+	require.NoError(t, err)
+	updatedDevice, err := deviceRepo.GetDeviceDetails(ctx, scrutinyUUID)
+	require.NoError(t, err)
+
+	//assert
+	require.True(t, staleDevice.Stale)
+	require.Nil(t, staleDevice.LastSeenAt)
+	require.False(t, updatedDevice.Stale)
+	require.NotNil(t, updatedDevice.LastSeenAt)
+	require.WithinDuration(t, time.Now(), *updatedDevice.LastSeenAt, time.Minute)
+}
+
+func Test_DeviceReplacements(t *testing.T) {
+	//setup
+	deviceRepo := newTestSqliteRepository(t, &models.DeviceReplacement{})
+	ctx := context.Background()
+	firstUUID := uuid.Must(uuid.NewV4())
+	secondUUID := uuid.Must(uuid.NewV4())
+	thirdUUID := uuid.Must(uuid.NewV4())
+	unrelatedUUID := uuid.Must(uuid.NewV4())
+
+	//test
+	firstReplacement, err := deviceRepo.CreateDeviceReplacement(ctx, models.DeviceReplacement{HostId: "nas01", DeviceName: "sda", ReplacedScrutinyUUID: firstUUID, ReplacementScrutinyUUID: secondUUID, Automatic: true})
+	require.NoError(t, err)
+	_, err = deviceRepo.CreateDeviceReplacement(ctx, models.DeviceReplacement{HostId: "nas01", DeviceName: "sda", ReplacedScrutinyUUID: secondUUID, ReplacementScrutinyUUID: thirdUUID})
+	require.NoError(t, err)
+	_, duplicateErr := deviceRepo.CreateDeviceReplacement(ctx, models.DeviceReplacement{ReplacedScrutinyUUID: firstUUID, ReplacementScrutinyUUID: unrelatedUUID})
+	_, selfErr := deviceRepo.CreateDeviceReplacement(ctx, models.DeviceReplacement{ReplacedScrutinyUUID: unrelatedUUID, ReplacementScrutinyUUID: unrelatedUUID})
+	lineage, lineageErr := deviceRepo.GetDeviceReplacements(ctx, thirdUUID)
+	unrelatedLineage, unrelatedErr := deviceRepo.GetDeviceReplacements(ctx, unrelatedUUID)
+
+	otherDeviceErr := deviceRepo.DeleteDeviceReplacement(ctx, thirdUUID, firstReplacement.ID)
+	require.NoError(t, deviceRepo.DeleteDeviceReplacement(ctx, firstUUID, firstReplacement.ID))
+	deletedLineage, deletedErr := deviceRepo.GetDeviceReplacements(ctx, thirdUUID)
+
+	//assert
+	require.Error(t, duplicateErr)
+	require.Error(t, selfErr)
+
+	require.NoError(t, lineageErr)
+	require.Len(t, lineage, 2)
+	require.Equal(t, firstUUID, lineage[0].ReplacedScrutinyUUID)
+	require.True(t, lineage[0].Automatic)
+	require.Equal(t, thirdUUID, lineage[1].ReplacementScrutinyUUID)
+
+	require.NoError(t, unrelatedErr)
+	require.Empty(t, unrelatedLineage)
+
+	require.NoError(t, deletedErr)
+	require.Len(t, deletedLineage, 1)
+	require.ErrorIs(t, otherDeviceErr, gorm.ErrRecordNotFound)
+	require.ErrorIs(t, deviceRepo.DeleteDeviceReplacement(ctx, firstUUID, firstReplacement.ID), gorm.ErrRecordNotFound)
+}
+
+func Test_UpdateDeviceMetadata(t *testing.T) {
+	//setup
+	deviceRepo := newTestSqliteRepository(t, &models.Device{}, &models.DeviceMetadataChange{})
+	ctx := context.Background()
+	scrutinyUUID := uuid.Must(uuid.NewV4())
+	purchaseCost := 249.99
+	require.NoError(t, deviceRepo.RegisterDevice(ctx, models.Device{ScrutinyUUID: scrutinyUUID, WWN: "0x5000c500673e6b5f", DeviceName: "sda", PurchaseCost: &purchaseCost}))
+	device, err := deviceRepo.GetDeviceDetails(ctx, scrutinyUUID)
+	require.NoError(t, err)
+
+	//test
+	device.Location = "rack 2"
+	device.PurchaseCost = nil
+	err = deviceRepo.UpdateDeviceMetadata(ctx, device, []models.DeviceMetadataChange{
+		{ScrutinyUUID: scrutinyUUID, Field: "location", NewValue: "rack 2", ChangedBy: "admin"},
+		{ScrutinyUUID: scrutinyUUID, Field: "purchase_cost", OldValue: "249.99"},
+	})
+	require.NoError(t, err)
+	updatedDevice, updatedErr := deviceRepo.GetDeviceDetails(ctx, scrutinyUUID)
+	changes, changesErr := deviceRepo.GetDeviceMetadataChanges(ctx, scrutinyUUID, 10)
+
+	//assert
+	require.NoError(t, updatedErr)
+	require.Equal(t, "rack 2", updatedDevice.Location)
+	require.Nil(t, updatedDevice.PurchaseCost)
+	require.NoError(t, changesErr)
+	require.Len(t, changes, 2)
+	require.Equal(t, "purchase_cost", changes[0].Field)
+	require.Equal(t, "admin", changes[1].ChangedBy)
+}
@@ -0,0 +1,181 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/analogj/scrutiny/webapp/backend/pkg/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Hosts
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// SaveHostCheckin records a collector check-in for the host (creating the host if necessary), and clears its stale
+// flag. The collector version & platform are only updated when they are reported.
+func (sr *scrutinyRepository) SaveHostCheckin(ctx context.Context, hostId string, checkinAt time.Time, collectorVersion string, platform string) error {
+	updates := map[string]interface{}{
+		"last_checkin_at": checkinAt,
+		"stale":           false,
+		"updated_at":      checkinAt,
+	}
+	if len(collectorVersion) > 0 {
+		updates["collector_version"] = collectorVersion
+	}
+	if len(platform) > 0 {
+		updates["platform"] = platform
+	}
+
+	host := models.Host{
+		HostId:           hostId,
+		Tags:             []string{},
+		LastCheckinAt:    checkinAt,
+		CollectorVersion: collectorVersion,
+		Platform:         platform,
+	}
+	err := sr.gormClient.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "host_id"}},
+		DoUpdates: clause.Assignments(updates),
+	}).Create(&host).Error
+	if err != nil {
+		return fmt.Errorf("could not save check-in for host %q: %w", hostId, err)
+	}
+	return nil
+}
+
+func (sr *scrutinyRepository) GetHosts(ctx context.Context) ([]models.Host, error) {
+	hosts := []models.Host{}
+	if err := sr.gormClient.WithContext(ctx).Order("host_id").Find(&hosts).Error; err != nil {
+		return nil, fmt.Errorf("could not get hosts: %w", err)
+	}
+	return hosts, nil
+}
+
+func (sr *scrutinyRepository) GetHost(ctx context.Context, hostId string) (models.Host, error) {
+	var host models.Host
+	if err := sr.gormClient.WithContext(ctx).Where("host_id = ?", hostId).First(&host).Error; err != nil {
+		return models.Host{}, fmt.Errorf("could not get host %q: %w", hostId, err)
+	}
+	return host, nil
+}
+
+// GetHostSummaries returns all hosts, with the aggregate health of their devices
+func (sr *scrutinyRepository) GetHostSummaries(ctx context.Context) ([]models.HostSummary, error) {
+	hosts, err := sr.GetHosts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	devices, err := sr.GetDevices(ctx)
+	if err != nil {
+		return nil, err
+	}
+	hostDevices := map[string][]models.Device{}
+	for _, device := range devices {
+		hostDevices[device.HostId] = append(hostDevices[device.HostId], device)
+	}
+
+	summaries := []models.HostSummary{}
+	for _, host := range hosts {
+		summaries = append(summaries, models.HostSummary{
+			Host:   host,
+			Health: models.NewHostHealth(hostDevices[host.HostId]),
+		})
+	}
+	return summaries, nil
+}
+
+// GetHostSummary returns the host, its devices, and their aggregate health
+func (sr *scrutinyRepository) GetHostSummary(ctx context.Context, hostId string) (models.HostSummary, error) {
+	host, err := sr.GetHost(ctx, hostId)
+	if err != nil {
+		return models.HostSummary{}, err
+	}
+	devices := []models.Device{}
+	if err := sr.gormClient.WithContext(ctx).Where("host_id = ?", hostId).Order("device_name").Find(&devices).Error; err != nil {
+		return models.HostSummary{}, fmt.Errorf("could not get devices for host %q: %w", hostId, err)
+	}
+	return models.HostSummary{
+		Host:    host,
+		Health:  models.NewHostHealth(devices),
+		Devices: devices,
+	}, nil
+}
+
+// GetHostDevices returns the devices of the hosts (only device metadata, no SMART data)
+func (sr *scrutinyRepository) GetHostDevices(ctx context.Context, hostIds []string) ([]models.Device, error) {
+	devices := []models.Device{}
+	if err := sr.gormClient.WithContext(ctx).Where("host_id IN ?", hostIds).Find(&devices).Error; err != nil {
+		return nil, fmt.Errorf("could not get devices for hosts %v: %w", hostIds, err)
+	}
+	return devices, nil
+}
+
+// UpdateHostStale marks a host whose collector stopped checking in as stale, see monitor.CheckStale
+func (sr *scrutinyRepository) UpdateHostStale(ctx context.Context, hostId string, stale bool) error {
+	return sr.gormClient.WithContext(ctx).Model(&models.Host{}).Where("host_id = ?", hostId).Update("stale", stale).Error
+}
+
+// UpdateHostMetadata saves the user provided metadata of the host, see models.HostMetadataPatch
+func (sr *scrutinyRepository) UpdateHostMetadata(ctx context.Context, host models.Host) error {
+	// the fields are selected so that cleared fields (zero values) are written
+	err := sr.gormClient.WithContext(ctx).Model(&host).Select("name", "description", "tags").Updates(&host).Error
+	if err != nil {
+		return fmt.Errorf("could not update metadata for host %q: %w", host.HostId, err)
+	}
+	return nil
+}
+
+// UpdateHostArchived archives (or unarchives) the host, and all of its devices
+func (sr *scrutinyRepository) UpdateHostArchived(ctx context.Context, hostId string, archived bool) error {
+	return sr.gormClient.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Host{}).Where("host_id = ?", hostId).Update("archived", archived)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("host %q not found", hostId)
+		}
+		return tx.Model(&models.Device{}).Where("host_id = ?", hostId).Update("archived", archived).Error
+	})
+}
+
+// DeleteHost deletes the host, and all of its devices (including their SMART data, see DeleteDevice). The host is
+// created again if its collector checks in.
+// The host & devices are deleted in a single transaction, the time-series data is deleted once it is committed.
+func (sr *scrutinyRepository) DeleteHost(ctx context.Context, hostId string) error {
+	if _, err := sr.GetHost(ctx, hostId); err != nil {
+		return err
+	}
+	devices := []models.Device{}
+	if err := sr.gormClient.WithContext(ctx).Where("host_id = ?", hostId).Find(&devices).Error; err != nil {
+		return fmt.Errorf("could not get devices for host %q: %w", hostId, err)
+	}
+	scrutinyUUIDs := []string{}
+	for _, device := range devices {
+		scrutinyUUIDs = append(scrutinyUUIDs, device.ScrutinyUUID.String())
+	}
+
+	err := sr.gormClient.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if len(scrutinyUUIDs) > 0 {
+			if err := deleteDeviceRecords(tx, scrutinyUUIDs); err != nil {
+				return fmt.Errorf("could not delete devices of host %q: %w", hostId, err)
+			}
+		}
+		return tx.Where("host_id = ?", hostId).Delete(&models.Host{}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	errs := []error{}
+	for _, device := range devices {
+		if err := sr.timeSeries.DeleteDeviceData(ctx, device.ScrutinyUUID); err != nil {
+			errs = append(errs, fmt.Errorf("could not delete SMART data for device %s of host %q: %w", device.ScrutinyUUID, hostId, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,193 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/analogj/scrutiny/webapp/backend/pkg"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/models"
+	"github.com/gofrs/uuid/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_HostCheckins(t *testing.T) {
+	//setup
+	deviceRepo := newTestSqliteRepository(t, &models.Host{})
+	ctx := context.Background()
+	firstCheckin := time.Now().Add(-48 * time.Hour).Truncate(time.Second)
+	secondCheckin := time.Now().Truncate(time.Second)
+
+	//test
+	require.NoError(t, deviceRepo.SaveHostCheckin(ctx, "nas01", firstCheckin, "0.9.2", "x86_64-linux-6.1.0-13-amd64"))
+	require.NoError(t, deviceRepo.SaveHostCheckin(ctx, "nas02", firstCheckin, "", ""))
+	require.NoError(t, deviceRepo.UpdateHostStale(ctx, "nas01", true))
+	staleHosts, staleErr := deviceRepo.GetHosts(ctx)
+
+	// checking in again must clear the stale flag, and only update the reported collector version & platform
+	require.NoError(t, deviceRepo.SaveHostCheckin(ctx, "nas01", secondCheckin, "0.9.3", ""))
+	hosts, err := deviceRepo.GetHosts(ctx)
+
+	//assert
+	require.NoError(t, staleErr)
+	require.Len(t, staleHosts, 2)
+	require.Equal(t, "nas01", staleHosts[0].HostId)
+	require.True(t, staleHosts[0].Stale)
+	require.False(t, staleHosts[1].Stale)
+
+	require.NoError(t, err)
+	require.Len(t, hosts, 2)
+	require.False(t, hosts[0].Stale)
+	require.True(t, secondCheckin.Equal(hosts[0].LastCheckinAt))
+	require.Equal(t, "0.9.3", hosts[0].CollectorVersion)
+	require.Equal(t, "x86_64-linux-6.1.0-13-amd64", hosts[0].Platform)
+	require.Empty(t, hosts[1].CollectorVersion)
+	require.Equal(t, []string{}, hosts[1].Tags)
+}
+
+func Test_HostMetadata(t *testing.T) {
+	//setup
+	deviceRepo := newTestSqliteRepository(t, &models.Host{})
+	ctx := context.Background()
+	require.NoError(t, deviceRepo.SaveHostCheckin(ctx, "nas01", time.Now(), "0.9.3", ""))
+	host, err := deviceRepo.GetHost(ctx, "nas01")
+	require.NoError(t, err)
+
+	//test
+	host.Name = "Basement NAS"
+	host.Tags = []string{"backup", "zfs"}
+	require.NoError(t, deviceRepo.UpdateHostMetadata(ctx, host))
+	updatedHost, updatedErr := deviceRepo.GetHost(ctx, "nas01")
+
+	host.Name = ""
+	host.Tags = []string{}
+	require.NoError(t, deviceRepo.UpdateHostMetadata(ctx, host))
+	clearedHost, clearedErr := deviceRepo.GetHost(ctx, "nas01")
+
+	_, missingErr := deviceRepo.GetHost(ctx, "nas02")
+
+	//assert
+	require.NoError(t, updatedErr)
+	require.Equal(t, "Basement NAS", updatedHost.Name)
+	require.Equal(t, []string{"backup", "zfs"}, updatedHost.Tags)
+	require.Equal(t, "0.9.3", updatedHost.CollectorVersion)
+	require.NoError(t, clearedErr)
+	require.Empty(t, clearedHost.Name)
+	require.Empty(t, clearedHost.Tags)
+	require.Error(t, missingErr)
+}
+
+func Test_HostSummaries(t *testing.T) {
+	//setup
+	deviceRepo := newTestSqliteRepository(t, &models.Host{}, &models.Device{})
+	ctx := context.Background()
+	require.NoError(t, deviceRepo.SaveHostCheckin(ctx, "nas01", time.Now(), "", ""))
+	require.NoError(t, deviceRepo.SaveHostCheckin(ctx, "nas02", time.Now(), "", ""))
+	for _, device := range []models.Device{
+		{DeviceName: "sda", HostId: "nas01", Capacity: 4000000000000},
+		{DeviceName: "sdb", HostId: "nas01", Capacity: 4000000000000, DeviceStatus: pkg.DeviceStatusFailedSmart},
+		{DeviceName: "sdc", HostId: "nas01", Capacity: 4000000000000, DeviceStatus: pkg.DeviceStatusWarningScrutiny, Stale: true},
+		{DeviceName: "sdd", HostId: "nas01", Capacity: 4000000000000, DeviceStatus: pkg.DeviceStatusFailedScrutiny, Archived: true},
+		{DeviceName: "sda", HostId: "nas02", Capacity: 1000000000000},
+	} {
+		device.ScrutinyUUID = uuid.Must(uuid.NewV4())
+		require.NoError(t, deviceRepo.RegisterDevice(ctx, device))
+	}
+
+	//test
+	summaries, err := deviceRepo.GetHostSummaries(ctx)
+	require.NoError(t, err)
+	summary, summaryErr := deviceRepo.GetHostSummary(ctx, "nas01")
+
+	//assert
+	require.Len(t, summaries, 2)
+	require.Equal(t, "nas01", summaries[0].HostId)
+	require.Equal(t, models.HostHealth{
+		DeviceStatus:  pkg.DeviceStatusFailedSmart | pkg.DeviceStatusWarningScrutiny,
+		DeviceCount:   3,
+		PassedCount:   1,
+		FailedCount:   1,
+		WarningCount:  1,
+		StaleCount:    1,
+		ArchivedCount: 1,
+		Capacity:      12000000000000,
+	}, summaries[0].Health)
+	require.Empty(t, summaries[0].Devices)
+	require.Equal(t, 1, summaries[1].Health.DeviceCount)
+
+	require.NoError(t, summaryErr)
+	require.Equal(t, summaries[0].Health, summary.Health)
+	require.Len(t, summary.Devices, 4)
+	require.Equal(t, "sda", summary.Devices[0].DeviceName)
+}
+
+func Test_GetHostDevices(t *testing.T) {
+	//setup
+	deviceRepo := newTestSqliteRepository(t, &models.Host{}, &models.Device{})
+	ctx := context.Background()
+	for _, device := range []models.Device{
+		{DeviceName: "sda", HostId: "nas01"},
+		{DeviceName: "sdb", HostId: "nas01", Archived: true},
+		{DeviceName: "sda", HostId: "nas02"},
+		{DeviceName: "sda"},
+	} {
+		device.ScrutinyUUID = uuid.Must(uuid.NewV4())
+		require.NoError(t, deviceRepo.RegisterDevice(ctx, device))
+	}
+
+	//test
+	devices, err := deviceRepo.GetHostDevices(ctx, []string{"nas01"})
+
+	//assert
+	require.NoError(t, err)
+	require.Len(t, devices, 2)
+	for _, device := range devices {
+		require.Equal(t, "nas01", device.HostId)
+	}
+}
+
+func Test_HostArchiveAndDelete(t *testing.T) {
+	//setup
+	deviceRepo := newTestSqliteRepository(t, &models.Host{}, &models.Device{}, &models.NotificationState{}, &models.DeviceReplacement{}, &models.DeviceMetadataChange{}, &sqliteSmartPoint{}, &sqliteTemperaturePoint{}, &sqliteSelfTestPoint{})
+	deviceRepo.timeSeries = &sqliteTimeSeriesRepository{logger: deviceRepo.logger, gormClient: deviceRepo.gormClient}
+	ctx := context.Background()
+	require.NoError(t, deviceRepo.SaveHostCheckin(ctx, "nas01", time.Now(), "", ""))
+	require.NoError(t, deviceRepo.SaveHostCheckin(ctx, "nas02", time.Now(), "", ""))
+	for _, device := range []models.Device{
+		{DeviceName: "sda", HostId: "nas01"},
+		{DeviceName: "sdb", HostId: "nas01"},
+		{DeviceName: "sda", HostId: "nas02"},
+	} {
+		device.ScrutinyUUID = uuid.Must(uuid.NewV4())
+		require.NoError(t, deviceRepo.RegisterDevice(ctx, device))
+	}
+
+	//test
+	require.NoError(t, deviceRepo.UpdateHostArchived(ctx, "nas01", true))
+	// new devices of an archived host are archived
+	require.NoError(t, deviceRepo.RegisterDevice(ctx, models.Device{ScrutinyUUID: uuid.Must(uuid.NewV4()), DeviceName: "sdc", HostId: "nas01"}))
+	archivedSummary, archivedErr := deviceRepo.GetHostSummary(ctx, "nas01")
+	otherSummary, otherErr := deviceRepo.GetHostSummary(ctx, "nas02")
+	missingArchiveErr := deviceRepo.UpdateHostArchived(ctx, "nas03", true)
+
+	require.NoError(t, deviceRepo.DeleteHost(ctx, "nas01"))
+	_, deletedErr := deviceRepo.GetHost(ctx, "nas01")
+	devices, devicesErr := deviceRepo.GetDevices(ctx)
+	missingDeleteErr := deviceRepo.DeleteHost(ctx, "nas03")
+
+	//assert
+	require.NoError(t, archivedErr)
+	require.True(t, archivedSummary.Archived)
+	require.Equal(t, 3, archivedSummary.Health.ArchivedCount)
+	require.Equal(t, 0, archivedSummary.Health.DeviceCount)
+	require.NoError(t, otherErr)
+	require.False(t, otherSummary.Archived)
+	require.Equal(t, 1, otherSummary.Health.DeviceCount)
+	require.Error(t, missingArchiveErr)
+
+	require.Error(t, deletedErr)
+	require.NoError(t, devicesErr)
+	require.Len(t, devices, 1)
+	require.Equal(t, "nas02", devices[0].HostId)
+	require.Error(t, missingDeleteErr)
+}
@@ -16,6 +16,23 @@ import (
 	"github.com/analogj/scrutiny/webapp/backend/pkg/database/migrations/m20220716214900"
 	"github.com/analogj/scrutiny/webapp/backend/pkg/database/migrations/m20250221084400"
 	"github.com/analogj/scrutiny/webapp/backend/pkg/database/migrations/m20260216155600"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/database/migrations/m20261018120000"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/database/migrations/m20261018130000"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/database/migrations/m20261018140000"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/database/migrations/m20261018150000"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/database/migrations/m20261018160000"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/database/migrations/m20261018170000"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/database/migrations/m20261018180000"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/database/migrations/m20261018190000"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/database/migrations/m20261018200000"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/database/migrations/m20261018210000"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/database/migrations/m20261018220000"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/database/migrations/m20261018230000"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/database/migrations/m20261018240000"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/database/migrations/m20261018250000"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/database/migrations/m20261018260000"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/database/migrations/m20261018270000"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/database/migrations/m20261018280000"
 	"github.com/analogj/scrutiny/webapp/backend/pkg/models"
 	"github.com/analogj/scrutiny/webapp/backend/pkg/models/collector"
 	"github.com/analogj/scrutiny/webapp/backend/pkg/models/measurements"
@@ -93,6 +110,15 @@ func (sr *scrutinyRepository) Migrate(ctx context.Context) error {
 					return err
 				}
 
+				// pre-v0.4.0 smart data can only be migrated into influxdb
+				influxRepo, isInfluxDB := sr.timeSeries.(*influxTimeSeriesRepository)
+				if !isInfluxDB {
+					if len(preDevices) > 0 {
+						sr.logger.Warnf("Skipping migration of pre-v0.4.0 smart data for %d devices, this requires the influxdb time-series backend", len(preDevices))
+					}
+					return nil
+				}
+
 				//calculate bucket oldest dates
 				today := time.Now()
 				dailyBucketMax := today.Add(-RETENTION_PERIOD_15_DAYS_IN_SECONDS * time.Second)     //15 days
@@ -135,8 +161,8 @@ func (sr *scrutinyRepository) Migrate(ctx context.Context) error {
 						if postSmartResults.Date.After(dailyBucketMax) {
 							sr.logger.Debugf("device (%s) smart data added to bucket: daily", preDevice.WWN)
 							// write point immediately
-							err = sr.saveDatapoint(
-								sr.influxClient.WriteAPIBlocking(sr.appConfig.GetString("web.influxdb.org"), sr.appConfig.GetString("web.influxdb.bucket")),
+							err = influxRepo.saveDatapoint(
+								influxRepo.influxClient.WriteAPIBlocking(sr.appConfig.GetString("web.influxdb.org"), sr.appConfig.GetString("web.influxdb.bucket")),
 								"smart",
 								smartTags,
 								smartFields,
@@ -145,8 +171,8 @@ func (sr *scrutinyRepository) Migrate(ctx context.Context) error {
 								return err
 							}
 
-							err = sr.saveDatapoint(
-								sr.influxClient.WriteAPIBlocking(sr.appConfig.GetString("web.influxdb.org"), sr.appConfig.GetString("web.influxdb.bucket")),
+							err = influxRepo.saveDatapoint(
+								influxRepo.influxClient.WriteAPIBlocking(sr.appConfig.GetString("web.influxdb.org"), sr.appConfig.GetString("web.influxdb.bucket")),
 								"temp",
 								tempTags,
 								tempFields,
@@ -163,8 +189,8 @@ func (sr *scrutinyRepository) Migrate(ctx context.Context) error {
 							//this week/year pair has not been processed
 							weeklyLookup[yearWeekStr] = true
 							// write point immediately
-							err = sr.saveDatapoint(
-								sr.influxClient.WriteAPIBlocking(sr.appConfig.GetString("web.influxdb.org"), fmt.Sprintf("%s_weekly", sr.appConfig.GetString("web.influxdb.bucket"))),
+							err = influxRepo.saveDatapoint(
+								influxRepo.influxClient.WriteAPIBlocking(sr.appConfig.GetString("web.influxdb.org"), fmt.Sprintf("%s_weekly", sr.appConfig.GetString("web.influxdb.bucket"))),
 								"smart",
 								smartTags,
 								smartFields,
@@ -174,8 +200,8 @@ func (sr *scrutinyRepository) Migrate(ctx context.Context) error {
 								return err
 							}
 
-							err = sr.saveDatapoint(
-								sr.influxClient.WriteAPIBlocking(sr.appConfig.GetString("web.influxdb.org"), fmt.Sprintf("%s_weekly", sr.appConfig.GetString("web.influxdb.bucket"))),
+							err = influxRepo.saveDatapoint(
+								influxRepo.influxClient.WriteAPIBlocking(sr.appConfig.GetString("web.influxdb.org"), fmt.Sprintf("%s_weekly", sr.appConfig.GetString("web.influxdb.bucket"))),
 								"temp",
 								tempTags,
 								tempFields,
@@ -192,8 +218,8 @@ func (sr *scrutinyRepository) Migrate(ctx context.Context) error {
 							//this month/year pair has not been processed
 							monthlyLookup[yearMonthStr] = true
 							// write point immediately
-							err = sr.saveDatapoint(
-								sr.influxClient.WriteAPIBlocking(sr.appConfig.GetString("web.influxdb.org"), fmt.Sprintf("%s_monthly", sr.appConfig.GetString("web.influxdb.bucket"))),
+							err = influxRepo.saveDatapoint(
+								influxRepo.influxClient.WriteAPIBlocking(sr.appConfig.GetString("web.influxdb.org"), fmt.Sprintf("%s_monthly", sr.appConfig.GetString("web.influxdb.bucket"))),
 								"smart",
 								smartTags,
 								smartFields,
@@ -202,8 +228,8 @@ func (sr *scrutinyRepository) Migrate(ctx context.Context) error {
 								return err
 							}
 
-							err = sr.saveDatapoint(
-								sr.influxClient.WriteAPIBlocking(sr.appConfig.GetString("web.influxdb.org"), fmt.Sprintf("%s_monthly", sr.appConfig.GetString("web.influxdb.bucket"))),
+							err = influxRepo.saveDatapoint(
+								influxRepo.influxClient.WriteAPIBlocking(sr.appConfig.GetString("web.influxdb.org"), fmt.Sprintf("%s_monthly", sr.appConfig.GetString("web.influxdb.bucket"))),
 								"temp",
 								tempTags,
 								tempFields,
@@ -219,8 +245,8 @@ func (sr *scrutinyRepository) Migrate(ctx context.Context) error {
 							//this year has not been processed
 							yearlyLookup[yearStr] = true
 							// write point immediately
-							err = sr.saveDatapoint(
-								sr.influxClient.WriteAPIBlocking(sr.appConfig.GetString("web.influxdb.org"), fmt.Sprintf("%s_yearly", sr.appConfig.GetString("web.influxdb.bucket"))),
+							err = influxRepo.saveDatapoint(
+								influxRepo.influxClient.WriteAPIBlocking(sr.appConfig.GetString("web.influxdb.org"), fmt.Sprintf("%s_yearly", sr.appConfig.GetString("web.influxdb.bucket"))),
 								"smart",
 								smartTags,
 								smartFields,
@@ -229,8 +255,8 @@ func (sr *scrutinyRepository) Migrate(ctx context.Context) error {
 								return err
 							}
 
-							err = sr.saveDatapoint(
-								sr.influxClient.WriteAPIBlocking(sr.appConfig.GetString("web.influxdb.org"), fmt.Sprintf("%s_yearly", sr.appConfig.GetString("web.influxdb.bucket"))),
+							err = influxRepo.saveDatapoint(
+								influxRepo.influxClient.WriteAPIBlocking(sr.appConfig.GetString("web.influxdb.org"), fmt.Sprintf("%s_yearly", sr.appConfig.GetString("web.influxdb.bucket"))),
 								"temp",
 								tempTags,
 								tempFields,
@@ -478,14 +504,222 @@ func (sr *scrutinyRepository) Migrate(ctx context.Context) error {
 				}
 
 				// Migrate WWN -> UUID in influxdb
-				err := m20260216155600_ChangeInfluxDBTags(sr, ctx, wwnToUUID)
-				if ignorePastRetentionPolicyError(err) != nil {
-					return err
+				if influxRepo, isInfluxDB := sr.timeSeries.(*influxTimeSeriesRepository); isInfluxDB {
+					err := m20260216155600_ChangeInfluxDBTags(influxRepo, ctx, wwnToUUID)
+					if ignorePastRetentionPolicyError(err) != nil {
+						return err
+					}
 				}
 
 				return nil
 			},
 		},
+		{
+			ID: "m20261018120000", // add collector_tokens table.
+			Migrate: func(tx *gorm.DB) error {
+				return tx.AutoMigrate(m20261018120000.CollectorToken{})
+			},
+		},
+		{
+			ID: "m20261018130000", // add users & user_sessions tables.
+			Migrate: func(tx *gorm.DB) error {
+				return tx.AutoMigrate(m20261018130000.User{}, m20261018130000.UserSession{})
+			},
+		},
+		{
+			ID: "m20261018140000", // add failure prediction to device data, and the notify_failure_score setting.
+			Migrate: func(tx *gorm.DB) error {
+
+				//migrate the device database.
+				// adding columns (failure_score, failure_score_attribute_id, estimated_failure_date)
+				err := tx.AutoMigrate(m20261018140000.Device{})
+				if err != nil {
+					return err
+				}
+
+				//add notify_failure_score setting default (disabled).
+				var defaultSettings = []m20220716214900.Setting{
+					{
+						SettingKeyName:        "metrics.notify_failure_score",
+						SettingKeyDescription: "Send a notification when the predicted failure score reaches this value (1-100, 0 to disable)",
+						SettingDataType:       "numeric",
+						SettingValueNumeric:   0,
+					},
+				}
+				return tx.Create(&defaultSettings).Error
+			},
+		},
+		{
+			ID: "m20261018150000", // add attribute_override_rules table.
+			Migrate: func(tx *gorm.DB) error {
+				return tx.AutoMigrate(m20261018150000.AttributeOverrideRule{})
+			},
+		},
+		{
+			ID: "m20261018160000", // add notification_states table.
+			Migrate: func(tx *gorm.DB) error {
+				return tx.AutoMigrate(m20261018160000.NotificationState{})
+			},
+		},
+		{
+			ID: "m20261018170000", // add warning notification state, and the warning_notify_interval_hours setting.
+			Migrate: func(tx *gorm.DB) error {
+
+				// adding column (warning_notified_at)
+				err := tx.AutoMigrate(m20261018170000.NotificationState{})
+				if err != nil {
+					return err
+				}
+
+				//add warning_notify_interval_hours setting default (once per day).
+				var defaultSettings = []m20220716214900.Setting{
+					{
+						SettingKeyName:        "metrics.warning_notify_interval_hours",
+						SettingKeyDescription: "Minimum number of hours between warning notifications for a device (only used when notify_level is 'warn')",
+						SettingDataType:       "numeric",
+						SettingValueNumeric:   24,
+					},
+				}
+				return tx.Create(&defaultSettings).Error
+			},
+		},
+		{
+			ID: "m20261018180000", // add notification delivery log.
+			Migrate: func(tx *gorm.DB) error {
+				return tx.AutoMigrate(m20261018180000.NotificationDelivery{})
+			},
+		},
+		{
+			ID: "m20261018190000", // add missing device & host detection, and the stale_interval_hours setting.
+			Migrate: func(tx *gorm.DB) error {
+
+				//migrate the device database.
+				// adding columns (last_seen_at, stale)
+				err := tx.AutoMigrate(m20261018190000.Device{}, m20261018190000.HostCheckin{})
+				if err != nil {
+					return err
+				}
+
+				//existing devices were last seen when they were last updated
+				err = tx.Model(&m20261018190000.Device{}).Where("last_seen_at IS NULL").Update("last_seen_at", gorm.Expr("updated_at")).Error
+				if err != nil {
+					return err
+				}
+
+				//add stale_interval_hours setting default (disabled).
+				var defaultSettings = []m20220716214900.Setting{
+					{
+						SettingKeyName:        "metrics.stale_interval_hours",
+						SettingKeyDescription: "Notify when a device or host has not reported data for this many hours (0 to disable)",
+						SettingDataType:       "numeric",
+						SettingValueNumeric:   0,
+					},
+				}
+				return tx.Create(&defaultSettings).Error
+			},
+		},
+		{
+			ID: "m20261018200000", // add device replacement lineage.
+			Migrate: func(tx *gorm.DB) error {
+				return tx.AutoMigrate(m20261018200000.DeviceReplacement{})
+			},
+		},
+		{
+			ID: "m20261018210000", // add device topology (by-path, enclosure slot & controller).
+			Migrate: func(tx *gorm.DB) error {
+
+				//migrate the device database.
+				// adding columns (device_by_path, enclosure_id, enclosure_slot, controller_pci_address)
+				return tx.AutoMigrate(m20261018210000.Device{})
+			},
+		},
+		{
+			ID: "m20261018220000", // add user provided inventory metadata, and the metadata audit log.
+			Migrate: func(tx *gorm.DB) error {
+
+				//migrate the device database.
+				// adding columns (location, purchase_date, warranty_expiry_date, rma_url, purchase_cost, notes)
+				return tx.AutoMigrate(m20261018220000.Device{}, m20261018220000.DeviceMetadataChange{})
+			},
+		},
+		{
+			ID: "m20261018230000", // add warranty rules, and track warranty & endurance notifications.
+			Migrate: func(tx *gorm.DB) error {
+
+				//migrate the device database.
+				// adding columns (rated_tbw)
+				return tx.AutoMigrate(m20261018230000.Device{}, m20261018230000.WarrantyRule{}, m20261018230000.NotificationState{})
+			},
+		},
+		{
+			ID: "m20261018240000", // add SSD endurance analytics to the device summary.
+			Migrate: func(tx *gorm.DB) error {
+
+				//migrate the device database.
+				// adding columns (daily_bytes_written, wear_percent_used, endurance_percent_used, estimated_wear_out_date)
+				return tx.AutoMigrate(m20261018240000.Device{})
+			},
+		},
+		{
+			ID: "m20261018250000", // index the columns used to filter devices (GET /api/devices).
+			Migrate: func(tx *gorm.DB) error {
+
+				//migrate the device database.
+				// adding indexes (archived, capacity, device_protocol, host_id, device_status)
+				return tx.AutoMigrate(m20261018250000.Device{})
+			},
+		},
+		{
+			ID: "m20261018260000", // replace host check-ins with hosts (name, description, tags, collector version & platform).
+			Migrate: func(tx *gorm.DB) error {
+				err := tx.AutoMigrate(m20261018260000.Host{})
+				if err != nil {
+					return err
+				}
+
+				//copy the existing host check-ins
+				err = tx.Exec(
+					`INSERT INTO hosts (host_id, created_at, updated_at, name, description, tags, last_checkin_at, collector_version, platform, stale, archived)
+					SELECT host_id, last_checkin_at, last_checkin_at, '', '', '[]', last_checkin_at, '', '', stale, ? FROM host_checkins WHERE host_id != ''`,
+					false,
+				).Error
+				if err != nil {
+					return err
+				}
+
+				//devices registered before check-ins were recorded: the migration counts as the first check-in of their host,
+				//so the host is not immediately reported as stale
+				migratedAt := time.Now()
+				err = tx.Exec(
+					`INSERT INTO hosts (host_id, created_at, updated_at, name, description, tags, last_checkin_at, collector_version, platform, stale, archived)
+					SELECT DISTINCT host_id, ?, ?, '', '', '[]', ?, '', '', ?, ? FROM devices WHERE host_id != '' AND host_id NOT IN (SELECT host_id FROM hosts)`,
+					migratedAt, migratedAt, migratedAt, false, false,
+				).Error
+				if err != nil {
+					return err
+				}
+
+				return tx.Migrator().DropTable("host_checkins")
+			},
+		},
+		{
+			ID: "m20261018270000", // store the logical block size of devices, and the ATA 241 unit in warranty rules (used to calculate bytes written).
+			Migrate: func(tx *gorm.DB) error {
+
+				//migrate the device database.
+				// adding columns (logical_block_size, attribute_241_unit_bytes)
+				return tx.AutoMigrate(m20261018270000.Device{}, m20261018270000.WarrantyRule{})
+			},
+		},
+		{
+			ID: "m20261018280000", // store the failing attributes included in the last failure notification (used to detect worsened failures).
+			Migrate: func(tx *gorm.DB) error {
+
+				//migrate the notification state database.
+				// adding column (failing_attributes)
+				return tx.AutoMigrate(m20261018280000.NotificationState{})
+			},
+		},
 	})
 
 	if err := m.Migrate(); err != nil {
@@ -535,9 +769,9 @@ func ignorePastRetentionPolicyError(err error) error {
 	return err
 }
 
-func m20260216155600_ChangeInfluxDBTags(sr *scrutinyRepository, ctx context.Context, wwnToUUID map[string]string) error {
-	bucket := sr.appConfig.GetString("web.influxdb.bucket")
-	org := sr.appConfig.GetString("web.influxdb.org")
+func m20260216155600_ChangeInfluxDBTags(ir *influxTimeSeriesRepository, ctx context.Context, wwnToUUID map[string]string) error {
+	bucket := ir.appConfig.GetString("web.influxdb.bucket")
+	org := ir.appConfig.GetString("web.influxdb.org")
 	bucketNames := []string{
 		bucket,
 		fmt.Sprintf("%s_weekly", bucket),
@@ -546,13 +780,13 @@ func m20260216155600_ChangeInfluxDBTags(sr *scrutinyRepository, ctx context.Cont
 	}
 
 	const batchSize = 1000
-	bucketsAPI := sr.influxClient.BucketsAPI()
+	bucketsAPI := ir.influxClient.BucketsAPI()
 
 	for _, bucketName := range bucketNames {
 		newBucketName := fmt.Sprintf("%s_new", bucketName)
 
 		// Step 1: Create the new bucket. Copy retention rules from the original.
-		sr.logger.Debugf("Creating temporary bucket %s...", newBucketName)
+		ir.logger.Debugf("Creating temporary bucket %s...", newBucketName)
 		oldBucket, err := bucketsAPI.FindBucketByName(ctx, bucketName)
 		if err != nil {
 			return fmt.Errorf("Failed to find bucket %s: %w", bucketName, err)
@@ -560,13 +794,13 @@ func m20260216155600_ChangeInfluxDBTags(sr *scrutinyRepository, ctx context.Cont
 
 		// Delete leftover _new bucket from a previous failed migration attempt.
 		if existingNew, _ := bucketsAPI.FindBucketByName(ctx, newBucketName); existingNew != nil {
-			sr.logger.Debugf("Found leftover bucket %s from previous migration, deleting...", newBucketName)
+			ir.logger.Debugf("Found leftover bucket %s from previous migration, deleting...", newBucketName)
 			if err := bucketsAPI.DeleteBucket(ctx, existingNew); err != nil {
 				return fmt.Errorf("Failed to delete leftover bucket %s: %w", newBucketName, err)
 			}
 		}
 
-		orgObj, err := sr.influxClient.OrganizationsAPI().FindOrganizationByName(ctx, org)
+		orgObj, err := ir.influxClient.OrganizationsAPI().FindOrganizationByName(ctx, org)
 		if err != nil {
 			return fmt.Errorf("failed to find organization %s: %w", org, err)
 		}
@@ -577,7 +811,7 @@ func m20260216155600_ChangeInfluxDBTags(sr *scrutinyRepository, ctx context.Cont
 		}
 
 		for wwn, scrutinyUUID := range wwnToUUID {
-			sr.logger.Debugf("Copying points from %s to %s for wwn %s...", bucketName, newBucketName, wwn)
+			ir.logger.Debugf("Copying points from %s to %s for wwn %s...", bucketName, newBucketName, wwn)
 
 			offset := 0
 			for ; ; offset += batchSize {
@@ -592,7 +826,7 @@ func m20260216155600_ChangeInfluxDBTags(sr *scrutinyRepository, ctx context.Cont
 					|> to(bucket: "%s")
 				`, bucketName, wwn, batchSize, offset, scrutinyUUID, newBucketName)
 
-				result, err := sr.influxQueryApi.Query(ctx, queryStr)
+				result, err := ir.influxQueryApi.Query(ctx, queryStr)
 				if err != nil {
 					return fmt.Errorf("failed to copy points from %s to %s for wwn %s (offset %d): %w", bucketName, newBucketName, wwn, offset, err)
 				}
@@ -601,10 +835,10 @@ func m20260216155600_ChangeInfluxDBTags(sr *scrutinyRepository, ctx context.Cont
 					break
 				}
 			}
-			sr.logger.Debugf("Copied approx. %d points for wwn %s", offset, wwn)
+			ir.logger.Debugf("Copied approx. %d points for wwn %s", offset, wwn)
 		}
 
-		sr.logger.Debugf("Replacing bucket %s with %s...", bucketName, newBucketName)
+		ir.logger.Debugf("Replacing bucket %s with %s...", bucketName, newBucketName)
 		if err := bucketsAPI.DeleteBucket(ctx, oldBucket); err != nil {
 			return fmt.Errorf("Failed to delete old bucket %s: %w", bucketName, err)
 		}
@@ -614,7 +848,7 @@ func m20260216155600_ChangeInfluxDBTags(sr *scrutinyRepository, ctx context.Cont
 			return fmt.Errorf("Failed to rename bucket %s to %s: %w", newBucketName, bucketName, err)
 		}
 
-		sr.logger.Debugf("Bucket %s migrated successfully", bucketName)
+		ir.logger.Debugf("Bucket %s migrated successfully", bucketName)
 	}
 
 	return nil
@@ -691,7 +925,7 @@ func m20201107210306_FromPreInfluxDBSmartResultsCreatePostInfluxDBSmartResults(d
 			})
 		}
 
-		postDeviceSmartData.ProcessAtaSmartInfo(preAtaSmartAttributesTable)
+		postDeviceSmartData.ProcessAtaSmartInfo(preAtaSmartAttributesTable, nil)
 
 	} else if preDevice.IsNvme() {
 		//info collector.SmartInfo
@@ -736,7 +970,7 @@ func m20201107210306_FromPreInfluxDBSmartResultsCreatePostInfluxDBSmartResults(d
 			}
 		}
 
-		postDeviceSmartData.ProcessNvmeSmartInfo(postNvmeSmartHealthInformation)
+		postDeviceSmartData.ProcessNvmeSmartInfo(postNvmeSmartHealthInformation, nil)
 
 	} else if preDevice.IsScsi() {
 		//info collector.SmartInfo
@@ -792,7 +1026,7 @@ func m20201107210306_FromPreInfluxDBSmartResultsCreatePostInfluxDBSmartResults(d
 				postScsiErrorCounterLog.Write.TotalUncorrectedErrors = int64(preScsiAttribute.Value)
 			}
 		}
-		postDeviceSmartData.ProcessScsiSmartInfo(postScsiGrownDefectList, postScsiErrorCounterLog)
+		postDeviceSmartData.ProcessScsiSmartInfo(postScsiGrownDefectList, postScsiErrorCounterLog, nil)
 	} else {
 		return fmt.Errorf("unknown device protocol: %s", preDevice.DeviceProtocol), postDeviceSmartData
 	}
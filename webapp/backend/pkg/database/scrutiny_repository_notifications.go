@@ -0,0 +1,76 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/analogj/scrutiny/webapp/backend/pkg"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/models"
+	"github.com/gofrs/uuid/v5"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Notification State
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// GetNotificationState returns the state of the last notification sent for the device. Devices which have never been
+// notified have a passing state.
+func (sr *scrutinyRepository) GetNotificationState(ctx context.Context, scrutiny_uuid uuid.UUID) (models.NotificationState, error) {
+	var state models.NotificationState
+	err := sr.gormClient.WithContext(ctx).Where("scrutiny_uuid = ?", scrutiny_uuid.String()).First(&state).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.NotificationState{ScrutinyUUID: scrutiny_uuid, DeviceStatus: pkg.DeviceStatusPassed}, nil
+	} else if err != nil {
+		return models.NotificationState{}, fmt.Errorf("could not get notification state for device %s: %w", scrutiny_uuid, err)
+	}
+	return state, nil
+}
+
+// SaveNotificationState creates or replaces the notification state for the device, after a notification is sent.
+func (sr *scrutinyRepository) SaveNotificationState(ctx context.Context, state models.NotificationState) error {
+	err := sr.gormClient.WithContext(ctx).Clauses(clause.OnConflict{UpdateAll: true}).Create(&state).Error
+	if err != nil {
+		return fmt.Errorf("could not save notification state for device %s: %w", state.ScrutinyUUID, err)
+	}
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Notification Delivery Log
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// SaveNotificationDelivery records a notification delivery attempt.
+func (sr *scrutinyRepository) SaveNotificationDelivery(ctx context.Context, delivery models.NotificationDelivery) error {
+	if err := sr.gormClient.WithContext(ctx).Create(&delivery).Error; err != nil {
+		return fmt.Errorf("could not save notification delivery: %w", err)
+	}
+	return nil
+}
+
+// GetNotificationDeliveries returns the most recent notification delivery attempts (newest first), optionally filtered
+// to a single device.
+func (sr *scrutinyRepository) GetNotificationDeliveries(ctx context.Context, scrutinyUUID string, limit int) ([]models.NotificationDelivery, error) {
+	deliveries := []models.NotificationDelivery{}
+	query := sr.gormClient.WithContext(ctx).Order("id desc").Limit(limit)
+	if len(scrutinyUUID) > 0 {
+		query = query.Where("scrutiny_uuid = ?", scrutinyUUID)
+	}
+	if err := query.Find(&deliveries).Error; err != nil {
+		return nil, fmt.Errorf("could not get notification deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+// PruneNotificationDeliveries deletes the notification delivery attempts recorded before the given time, and returns
+// the number of deleted attempts.
+func (sr *scrutinyRepository) PruneNotificationDeliveries(ctx context.Context, before time.Time) (int64, error) {
+	result := sr.gormClient.WithContext(ctx).Where("created_at < ?", before).Delete(&models.NotificationDelivery{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("could not prune notification deliveries: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
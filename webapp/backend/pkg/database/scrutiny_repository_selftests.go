@@ -0,0 +1,142 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/analogj/scrutiny/webapp/backend/pkg/models/collector"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/models/measurements"
+	"github.com/gofrs/uuid/v5"
+)
+
+// //////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Self-Tests
+// //////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// SaveSmartSelfTests stores every entry in the uploaded self-test log, skipping the entries which were stored by a
+// previous upload. All entries in the log are returned, with the most recent test first.
+func (sr *scrutinyRepository) SaveSmartSelfTests(ctx context.Context, scrutiny_uuid uuid.UUID, deviceProtocol string, collectorSmartData collector.SmartInfo) ([]measurements.SmartSelfTest, error) {
+	selfTests, err := measurements.NewSmartSelfTestsFromCollectorSmartInfo(deviceProtocol, collectorSmartData)
+	if err != nil {
+		sr.logger.Errorln("Could not process self-test results", err)
+		return nil, err
+	}
+
+	storedSelfTests, err := sr.timeSeries.GetSmartSelfTestHistory(ctx, scrutiny_uuid, DURATION_KEY_FOREVER)
+	if err != nil {
+		return nil, fmt.Errorf("could not get self-test history: %w", err)
+	}
+	storedKeys := map[string]bool{}
+	for _, storedSelfTest := range storedSelfTests {
+		storedKeys[storedSelfTest.Key()] = true
+	}
+
+	for _, selfTest := range selfTests {
+		if storedKeys[selfTest.Key()] {
+			continue
+		}
+		if err := sr.timeSeries.WriteSelfTest(ctx, scrutiny_uuid, selfTest); err != nil {
+			return nil, err
+		}
+		storedKeys[selfTest.Key()] = true
+	}
+	return selfTests, nil
+}
+
+// GetSmartSelfTestHistory returns the self-test results for a device, with the newest entries at the beginning of the list.
+// Entries collected at the same time are sorted by their power-on hours.
+func (sr *scrutinyRepository) GetSmartSelfTestHistory(ctx context.Context, scrutiny_uuid uuid.UUID, durationKey string) ([]measurements.SmartSelfTest, error) {
+	return sr.timeSeries.GetSmartSelfTestHistory(ctx, scrutiny_uuid, durationKey)
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// InfluxDB
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func (ir *influxTimeSeriesRepository) WriteSelfTest(ctx context.Context, scrutiny_uuid uuid.UUID, selfTest measurements.SmartSelfTest) error {
+	tags, fields := selfTest.Flatten()
+	tags["scrutiny_uuid"] = scrutiny_uuid.String()
+
+	return ir.saveDatapoint(ir.influxWriteApi, "self_test", tags, fields, selfTest.Date, ctx)
+}
+
+func (ir *influxTimeSeriesRepository) GetSmartSelfTestHistory(ctx context.Context, scrutiny_uuid uuid.UUID, durationKey string) ([]measurements.SmartSelfTest, error) {
+	queryStr := ir.aggregateSelfTestQuery(scrutiny_uuid, durationKey)
+
+	selfTests := []measurements.SmartSelfTest{}
+
+	result, err := ir.influxQueryApi.Query(ctx, queryStr)
+	if err != nil {
+		return nil, err
+	}
+	for result.Next() {
+		selfTest := measurements.SmartSelfTest{}
+		for key, val := range result.Record().Values() {
+			selfTest.Inflate(key, val)
+		}
+		selfTest.Date = result.Record().Values()["_time"].(time.Time)
+		selfTests = append(selfTests, selfTest)
+	}
+	if result.Err() != nil {
+		fmt.Printf("Query error: %s\n", result.Err().Error())
+	}
+
+	return selfTests, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Helper Methods
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func (ir *influxTimeSeriesRepository) aggregateSelfTestQuery(scrutiny_uuid uuid.UUID, durationKey string) string {
+
+	/*
+		import "influxdata/influxdb/schema"
+		weekData = from(bucket: "metrics")
+		|> range(start: -1w, stop: now())
+		|> filter(fn: (r) => r["_measurement"] == "self_test" )
+		|> filter(fn: (r) => r["scrutiny_uuid"] == "32bda933-15be-56a3-902f-9f3674b03d59" )
+		|> schema.fieldsAsCols()
+
+		monthData = from(bucket: "metrics_weekly")
+		|> range(start: -1mo, stop: -1w)
+		|> filter(fn: (r) => r["_measurement"] == "self_test" )
+		|> filter(fn: (r) => r["scrutiny_uuid"] == "32bda933-15be-56a3-902f-9f3674b03d59" )
+		|> schema.fieldsAsCols()
+
+		union(tables: [weekData, monthData])
+		|> group()
+		|> sort(columns: ["_time", "lifetime_hours"], desc: true)
+		|> yield()
+	*/
+
+	partialQueryStr := []string{
+		`import "influxdata/influxdb/schema"`,
+	}
+
+	subQueryNames := []string{}
+	for _, nestedDurationKey := range lookupNestedDurationKeys(durationKey) {
+		bucketName := ir.lookupBucketName(nestedDurationKey)
+		durationRange := ir.lookupDuration(nestedDurationKey)
+
+		subQueryNames = append(subQueryNames, fmt.Sprintf(`%sData`, nestedDurationKey))
+		partialQueryStr = append(partialQueryStr, []string{
+			fmt.Sprintf(`%sData = from(bucket: "%s")`, nestedDurationKey, bucketName),
+			fmt.Sprintf(`|> range(start: %s, stop: %s)`, durationRange[0], durationRange[1]),
+			`|> filter(fn: (r) => r["_measurement"] == "self_test" )`,
+			fmt.Sprintf(`|> filter(fn: (r) => r["scrutiny_uuid"] == "%s" )`, scrutiny_uuid.String()),
+			"|> schema.fieldsAsCols()",
+			"",
+		}...)
+	}
+
+	partialQueryStr = append(partialQueryStr, []string{
+		fmt.Sprintf("union(tables: [%s])", strings.Join(subQueryNames, ", ")),
+		`|> group()`,
+		`|> sort(columns: ["_time", "lifetime_hours"], desc: true)`,
+		`|> yield()`,
+	}...)
+
+	return strings.Join(partialQueryStr, "\n")
+}
@@ -0,0 +1,81 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/analogj/scrutiny/webapp/backend/pkg"
+	mock_config "github.com/analogj/scrutiny/webapp/backend/pkg/config/mock"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/models/collector"
+	"github.com/gofrs/uuid/v5"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func Test_aggregateSelfTestQuery_Month(t *testing.T) {
+	t.Parallel()
+
+	//setup
+	mockCtrl := gomock.NewController(t)
+	fakeConfig := mock_config.NewMockInterface(mockCtrl)
+	fakeConfig.EXPECT().GetString("web.influxdb.bucket").Return("metrics").AnyTimes()
+
+	deviceRepo := influxTimeSeriesRepository{
+		appConfig: fakeConfig,
+	}
+
+	//test
+	influxDbScript := deviceRepo.aggregateSelfTestQuery(uuid.Must(uuid.FromString("32bda933-15be-56a3-902f-9f3674b03d59")), DURATION_KEY_MONTH)
+
+	//assert
+	require.Equal(t, `import "influxdata/influxdb/schema"
+weekData = from(bucket: "metrics")
+|> range(start: -1w, stop: now())
+|> filter(fn: (r) => r["_measurement"] == "self_test" )
+|> filter(fn: (r) => r["scrutiny_uuid"] == "32bda933-15be-56a3-902f-9f3674b03d59" )
+|> schema.fieldsAsCols()
+
+monthData = from(bucket: "metrics_weekly")
+|> range(start: -1mo, stop: -1w)
+|> filter(fn: (r) => r["_measurement"] == "self_test" )
+|> filter(fn: (r) => r["scrutiny_uuid"] == "32bda933-15be-56a3-902f-9f3674b03d59" )
+|> schema.fieldsAsCols()
+
+union(tables: [weekData, monthData])
+|> group()
+|> sort(columns: ["_time", "lifetime_hours"], desc: true)
+|> yield()`, influxDbScript)
+}
+
+func Test_SaveSmartSelfTests(t *testing.T) {
+	//setup
+	timeSeries := newTestSqliteTimeSeriesRepository(t)
+	deviceRepo := &scrutinyRepository{logger: timeSeries.logger, gormClient: timeSeries.gormClient, timeSeries: timeSeries}
+	ctx := context.Background()
+	scrutinyUUID := uuid.Must(uuid.NewV4())
+	smartDataBytes, err := os.ReadFile("../models/testdata/smart-nvme-selftest.json")
+	require.NoError(t, err)
+	var smartJson collector.SmartInfo
+	require.NoError(t, json.Unmarshal(smartDataBytes, &smartJson))
+	smartJson.LocalTime.TimeT = time.Now().Unix()
+
+	//test
+	savedSelfTests, err := deviceRepo.SaveSmartSelfTests(ctx, scrutinyUUID, pkg.DeviceProtocolNvme, smartJson)
+	require.NoError(t, err)
+	// the log is uploaded again, an hour later
+	smartJson.LocalTime.TimeT += 3600
+	resavedSelfTests, err := deviceRepo.SaveSmartSelfTests(ctx, scrutinyUUID, pkg.DeviceProtocolNvme, smartJson)
+	require.NoError(t, err)
+	selfTests, err := deviceRepo.GetSmartSelfTestHistory(ctx, scrutinyUUID, DURATION_KEY_FOREVER)
+
+	//assert
+	require.NoError(t, err)
+	require.Len(t, savedSelfTests, 2)
+	require.Len(t, resavedSelfTests, 2)
+	require.Len(t, selfTests, 2)
+	require.Equal(t, int64(5321), selfTests[0].LifetimeHours)
+	require.Equal(t, int64(5000), selfTests[1].LifetimeHours)
+}
@@ -10,12 +10,12 @@ import (
 // //////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 // Tasks
 // //////////////////////////////////////////////////////////////////////////////////////////////////////////////////
-func (sr *scrutinyRepository) EnsureTasks(ctx context.Context, orgID string) error {
+func (ir *influxTimeSeriesRepository) EnsureTasks(ctx context.Context, orgID string) error {
 	weeklyTaskName := "tsk-weekly-aggr"
-	weeklyTaskScript := sr.DownsampleScript("weekly", weeklyTaskName, "0 1 * * 0")
-	if found, findErr := sr.influxTaskApi.FindTasks(ctx, &api.TaskFilter{Name: weeklyTaskName}); findErr == nil && len(found) == 0 {
+	weeklyTaskScript := ir.DownsampleScript("weekly", weeklyTaskName, "0 1 * * 0")
+	if found, findErr := ir.influxTaskApi.FindTasks(ctx, &api.TaskFilter{Name: weeklyTaskName}); findErr == nil && len(found) == 0 {
 		//weekly on Sunday at 1:00am
-		_, err := sr.influxTaskApi.CreateTaskByFlux(ctx, weeklyTaskScript, orgID)
+		_, err := ir.influxTaskApi.CreateTaskByFlux(ctx, weeklyTaskScript, orgID)
 		if err != nil {
 			return err
 		}
@@ -23,9 +23,9 @@ func (sr *scrutinyRepository) EnsureTasks(ctx context.Context, orgID string) err
 		//check if we should update
 		task := &found[0]
 		if weeklyTaskScript != task.Flux {
-			sr.logger.Infoln("updating weekly task script")
+			ir.logger.Infoln("updating weekly task script")
 			task.Flux = weeklyTaskScript
-			_, err := sr.influxTaskApi.UpdateTask(ctx, task)
+			_, err := ir.influxTaskApi.UpdateTask(ctx, task)
 			if err != nil {
 				return err
 			}
@@ -33,10 +33,10 @@ func (sr *scrutinyRepository) EnsureTasks(ctx context.Context, orgID string) err
 	}
 
 	monthlyTaskName := "tsk-monthly-aggr"
-	monthlyTaskScript := sr.DownsampleScript("monthly", monthlyTaskName, "30 1 1 * *")
-	if found, findErr := sr.influxTaskApi.FindTasks(ctx, &api.TaskFilter{Name: monthlyTaskName}); findErr == nil && len(found) == 0 {
+	monthlyTaskScript := ir.DownsampleScript("monthly", monthlyTaskName, "30 1 1 * *")
+	if found, findErr := ir.influxTaskApi.FindTasks(ctx, &api.TaskFilter{Name: monthlyTaskName}); findErr == nil && len(found) == 0 {
 		//monthly on first day of the month at 1:30am
-		_, err := sr.influxTaskApi.CreateTaskByFlux(ctx, monthlyTaskScript, orgID)
+		_, err := ir.influxTaskApi.CreateTaskByFlux(ctx, monthlyTaskScript, orgID)
 		if err != nil {
 			return err
 		}
@@ -44,9 +44,9 @@ func (sr *scrutinyRepository) EnsureTasks(ctx context.Context, orgID string) err
 		//check if we should update
 		task := &found[0]
 		if monthlyTaskScript != task.Flux {
-			sr.logger.Infoln("updating monthly task script")
+			ir.logger.Infoln("updating monthly task script")
 			task.Flux = monthlyTaskScript
-			_, err := sr.influxTaskApi.UpdateTask(ctx, task)
+			_, err := ir.influxTaskApi.UpdateTask(ctx, task)
 			if err != nil {
 				return err
 			}
@@ -54,10 +54,10 @@ func (sr *scrutinyRepository) EnsureTasks(ctx context.Context, orgID string) err
 	}
 
 	yearlyTaskName := "tsk-yearly-aggr"
-	yearlyTaskScript := sr.DownsampleScript("yearly", yearlyTaskName, "0 2 1 1 *")
-	if found, findErr := sr.influxTaskApi.FindTasks(ctx, &api.TaskFilter{Name: yearlyTaskName}); findErr == nil && len(found) == 0 {
+	yearlyTaskScript := ir.DownsampleScript("yearly", yearlyTaskName, "0 2 1 1 *")
+	if found, findErr := ir.influxTaskApi.FindTasks(ctx, &api.TaskFilter{Name: yearlyTaskName}); findErr == nil && len(found) == 0 {
 		//yearly on the first day of the year at 2:00am
-		_, err := sr.influxTaskApi.CreateTaskByFlux(ctx, yearlyTaskScript, orgID)
+		_, err := ir.influxTaskApi.CreateTaskByFlux(ctx, yearlyTaskScript, orgID)
 		if err != nil {
 			return err
 		}
@@ -65,9 +65,9 @@ func (sr *scrutinyRepository) EnsureTasks(ctx context.Context, orgID string) err
 		//check if we should update
 		task := &found[0]
 		if yearlyTaskScript != task.Flux {
-			sr.logger.Infoln("updating yearly task script")
+			ir.logger.Infoln("updating yearly task script")
 			task.Flux = yearlyTaskScript
-			_, err := sr.influxTaskApi.UpdateTask(ctx, task)
+			_, err := ir.influxTaskApi.UpdateTask(ctx, task)
 			if err != nil {
 				return err
 			}
@@ -76,7 +76,7 @@ func (sr *scrutinyRepository) EnsureTasks(ctx context.Context, orgID string) err
 	return nil
 }
 
-func (sr *scrutinyRepository) DownsampleScript(aggregationType string, name string, cron string) string {
+func (ir *influxTimeSeriesRepository) DownsampleScript(aggregationType string, name string, cron string) string {
 	var sourceBucket string // the source of the data
 	var destBucket string   // the destination for the aggregated data
 	var rangeStart string
@@ -84,20 +84,20 @@ func (sr *scrutinyRepository) DownsampleScript(aggregationType string, name stri
 	var aggWindow string
 	switch aggregationType {
 	case "weekly":
-		sourceBucket = sr.appConfig.GetString("web.influxdb.bucket")
-		destBucket = fmt.Sprintf("%s_weekly", sr.appConfig.GetString("web.influxdb.bucket"))
+		sourceBucket = ir.appConfig.GetString("web.influxdb.bucket")
+		destBucket = fmt.Sprintf("%s_weekly", ir.appConfig.GetString("web.influxdb.bucket"))
 		rangeStart = "-2w"
 		rangeEnd = "-1w"
 		aggWindow = "1w"
 	case "monthly":
-		sourceBucket = fmt.Sprintf("%s_weekly", sr.appConfig.GetString("web.influxdb.bucket"))
-		destBucket = fmt.Sprintf("%s_monthly", sr.appConfig.GetString("web.influxdb.bucket"))
+		sourceBucket = fmt.Sprintf("%s_weekly", ir.appConfig.GetString("web.influxdb.bucket"))
+		destBucket = fmt.Sprintf("%s_monthly", ir.appConfig.GetString("web.influxdb.bucket"))
 		rangeStart = "-2mo"
 		rangeEnd = "-1mo"
 		aggWindow = "1mo"
 	case "yearly":
-		sourceBucket = fmt.Sprintf("%s_monthly", sr.appConfig.GetString("web.influxdb.bucket"))
-		destBucket = fmt.Sprintf("%s_yearly", sr.appConfig.GetString("web.influxdb.bucket"))
+		sourceBucket = fmt.Sprintf("%s_monthly", ir.appConfig.GetString("web.influxdb.bucket"))
+		destBucket = fmt.Sprintf("%s_yearly", ir.appConfig.GetString("web.influxdb.bucket"))
 		rangeStart = "-2y"
 		rangeEnd = "-1y"
 		aggWindow = "1y"
@@ -124,6 +124,8 @@ func (sr *scrutinyRepository) DownsampleScript(aggregationType string, name stri
 
 	*/
 
+	// self-tests are infrequent, so they are copied to the destination bucket as-is, rather than aggregated.
+
 	return fmt.Sprintf(`
 option task = { 
   name: "%s",
@@ -152,6 +154,11 @@ from(bucket: sourceBucket)
 |> aggregateWindow(fn: mean, every: aggWindow, createEmpty: false)
 |> set(key: "_measurement", value: "temp")
 |> set(key: "_field", value: "temp")
+|> to(bucket: destBucket, org: destOrg)
+
+from(bucket: sourceBucket)
+|> range(start: rangeStart, stop: rangeEnd)
+|> filter(fn: (r) => r["_measurement"] == "self_test")
 |> to(bucket: destBucket, org: destOrg)`,
 		name,
 		cron,
@@ -160,6 +167,6 @@ from(bucket: sourceBucket)
 		rangeEnd,
 		aggWindow,
 		destBucket,
-		sr.appConfig.GetString("web.influxdb.org"),
+		ir.appConfig.GetString("web.influxdb.org"),
 	)
 }
@@ -17,7 +17,7 @@ func Test_DownsampleScript_Weekly(t *testing.T) {
 	fakeConfig.EXPECT().GetString("web.influxdb.bucket").Return("metrics").AnyTimes()
 	fakeConfig.EXPECT().GetString("web.influxdb.org").Return("scrutiny").AnyTimes()
 
-	deviceRepo := scrutinyRepository{
+	deviceRepo := influxTimeSeriesRepository{
 		appConfig: fakeConfig,
 	}
 
@@ -55,6 +55,11 @@ from(bucket: sourceBucket)
 |> aggregateWindow(fn: mean, every: aggWindow, createEmpty: false)
 |> set(key: "_measurement", value: "temp")
 |> set(key: "_field", value: "temp")
+|> to(bucket: destBucket, org: destOrg)
+
+from(bucket: sourceBucket)
+|> range(start: rangeStart, stop: rangeEnd)
+|> filter(fn: (r) => r["_measurement"] == "self_test")
 |> to(bucket: destBucket, org: destOrg)`, influxDbScript)
 }
 
@@ -67,7 +72,7 @@ func Test_DownsampleScript_Monthly(t *testing.T) {
 	fakeConfig.EXPECT().GetString("web.influxdb.bucket").Return("metrics").AnyTimes()
 	fakeConfig.EXPECT().GetString("web.influxdb.org").Return("scrutiny").AnyTimes()
 
-	deviceRepo := scrutinyRepository{
+	deviceRepo := influxTimeSeriesRepository{
 		appConfig: fakeConfig,
 	}
 
@@ -105,6 +110,11 @@ from(bucket: sourceBucket)
 |> aggregateWindow(fn: mean, every: aggWindow, createEmpty: false)
 |> set(key: "_measurement", value: "temp")
 |> set(key: "_field", value: "temp")
+|> to(bucket: destBucket, org: destOrg)
+
+from(bucket: sourceBucket)
+|> range(start: rangeStart, stop: rangeEnd)
+|> filter(fn: (r) => r["_measurement"] == "self_test")
 |> to(bucket: destBucket, org: destOrg)`, influxDbScript)
 }
 
@@ -117,7 +127,7 @@ func Test_DownsampleScript_Yearly(t *testing.T) {
 	fakeConfig.EXPECT().GetString("web.influxdb.bucket").Return("metrics").AnyTimes()
 	fakeConfig.EXPECT().GetString("web.influxdb.org").Return("scrutiny").AnyTimes()
 
-	deviceRepo := scrutinyRepository{
+	deviceRepo := influxTimeSeriesRepository{
 		appConfig: fakeConfig,
 	}
 
@@ -155,5 +165,10 @@ from(bucket: sourceBucket)
 |> aggregateWindow(fn: mean, every: aggWindow, createEmpty: false)
 |> set(key: "_measurement", value: "temp")
 |> set(key: "_field", value: "temp")
+|> to(bucket: destBucket, org: destOrg)
+
+from(bucket: sourceBucket)
+|> range(start: rangeStart, stop: rangeEnd)
+|> filter(fn: (r) => r["_measurement"] == "self_test")
 |> to(bucket: destBucket, org: destOrg)`, influxDbScript)
 }
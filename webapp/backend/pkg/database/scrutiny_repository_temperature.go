@@ -16,6 +16,7 @@ import (
 // Temperature Data
 // //////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 func (sr *scrutinyRepository) SaveSmartTemperature(ctx context.Context, scrutiny_uuid uuid.UUID, deviceProtocol string, collectorSmartData collector.SmartInfo, discardSCTTempHistory bool) error {
+	temperatures := []measurements.SmartTemperature{}
 	if len(collectorSmartData.AtaSctTemperatureHistory.Table) > 0 && !discardSCTTempHistory {
 
 		for ndx, temp := range collectorSmartData.AtaSctTemperatureHistory.Table {
@@ -27,48 +28,55 @@ func (sr *scrutinyRepository) SaveSmartTemperature(ctx context.Context, scrutiny
 			intervalSec := collectorSmartData.AtaSctTemperatureHistory.LoggingIntervalMinutes * 60
 			datapointTime := collectorSmartData.LocalTime.TimeT - int64(ndx)*intervalSec
 			alignedDatapointTime := datapointTime - datapointTime%intervalSec
-			smartTemp := measurements.SmartTemperature{
+			temperatures = append(temperatures, measurements.SmartTemperature{
 				Date: time.Unix(alignedDatapointTime, 0),
 				Temp: temp,
-			}
-
-			tags, fields := smartTemp.Flatten()
-			tags["scrutiny_uuid"] = scrutiny_uuid.String()
-			p := influxdb2.NewPoint("temp",
-				tags,
-				fields,
-				smartTemp.Date)
-			err := sr.influxWriteApi.WritePoint(ctx, p)
-			if err != nil {
-				return err
-			}
+			})
 		}
 	}
 
 	// Even if ata_sct_temperature_history is present, also add current temperature. See #824
-	smartTemp := measurements.SmartTemperature{
+	temperatures = append(temperatures, measurements.SmartTemperature{
 		Date: time.Unix(collectorSmartData.LocalTime.TimeT, 0),
 		Temp: collectorSmartData.Temperature.Current,
-	}
+	})
 
-	tags, fields := smartTemp.Flatten()
-	tags["scrutiny_uuid"] = scrutiny_uuid.String()
-	p := influxdb2.NewPoint("temp",
-		tags,
-		fields,
-		smartTemp.Date)
-	return sr.influxWriteApi.WritePoint(ctx, p)
+	return sr.timeSeries.WriteTemperatures(ctx, scrutiny_uuid, temperatures)
 }
 
 func (sr *scrutinyRepository) GetSmartTemperatureHistory(ctx context.Context, durationKey string) (map[uuid.UUID][]measurements.SmartTemperature, error) {
+	return sr.timeSeries.GetSmartTemperatureHistory(ctx, durationKey)
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// InfluxDB
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func (ir *influxTimeSeriesRepository) WriteTemperatures(ctx context.Context, scrutiny_uuid uuid.UUID, temperatures []measurements.SmartTemperature) error {
+	for _, smartTemp := range temperatures {
+		tags, fields := smartTemp.Flatten()
+		tags["scrutiny_uuid"] = scrutiny_uuid.String()
+		p := influxdb2.NewPoint("temp",
+			tags,
+			fields,
+			smartTemp.Date)
+		err := ir.influxWriteApi.WritePoint(ctx, p)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ir *influxTimeSeriesRepository) GetSmartTemperatureHistory(ctx context.Context, durationKey string) (map[uuid.UUID][]measurements.SmartTemperature, error) {
 	//we can get temp history for "week", "month", DURATION_KEY_YEAR, "forever"
 
 	deviceTempHistory := map[uuid.UUID][]measurements.SmartTemperature{}
 
 	//TODO: change the query range to a variable.
-	queryStr := sr.aggregateTempQuery(durationKey)
+	queryStr := ir.aggregateTempQuery(durationKey)
 
-	result, err := sr.influxQueryApi.Query(ctx, queryStr)
+	result, err := ir.influxQueryApi.Query(ctx, queryStr)
 	if err == nil {
 		// Use Next() to iterate over query result lines
 		for result.Next() {
@@ -106,7 +114,7 @@ func (sr *scrutinyRepository) GetSmartTemperatureHistory(ctx context.Context, du
 // Helper Methods
 ////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 
-func (sr *scrutinyRepository) aggregateTempQuery(durationKey string) string {
+func (ir *influxTimeSeriesRepository) aggregateTempQuery(durationKey string) string {
 
 	/*
 		import "influxdata/influxdb/schema"
@@ -135,13 +143,13 @@ func (sr *scrutinyRepository) aggregateTempQuery(durationKey string) string {
 		`import "influxdata/influxdb/schema"`,
 	}
 
-	nestedDurationKeys := sr.lookupNestedDurationKeys(durationKey)
+	nestedDurationKeys := lookupNestedDurationKeys(durationKey)
 
 	subQueryNames := []string{}
 	for _, nestedDurationKey := range nestedDurationKeys {
-		bucketName := sr.lookupBucketName(nestedDurationKey)
-		durationRange := sr.lookupDuration(nestedDurationKey)
-		durationResolution := sr.lookupResolution(nestedDurationKey)
+		bucketName := ir.lookupBucketName(nestedDurationKey)
+		durationRange := ir.lookupDuration(nestedDurationKey)
+		durationResolution := lookupResolution(nestedDurationKey)
 
 		subQueryNames = append(subQueryNames, fmt.Sprintf(`%sData`, nestedDurationKey))
 		partialQueryStr = append(partialQueryStr, []string{
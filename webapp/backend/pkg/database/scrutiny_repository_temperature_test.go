@@ -17,7 +17,7 @@ func Test_aggregateTempQuery_Week(t *testing.T) {
 	fakeConfig.EXPECT().GetString("web.influxdb.bucket").Return("metrics").AnyTimes()
 	fakeConfig.EXPECT().GetString("web.influxdb.org").Return("scrutiny").AnyTimes()
 
-	deviceRepo := scrutinyRepository{
+	deviceRepo := influxTimeSeriesRepository{
 		appConfig: fakeConfig,
 	}
 
@@ -49,7 +49,7 @@ func Test_aggregateTempQuery_Month(t *testing.T) {
 	fakeConfig.EXPECT().GetString("web.influxdb.bucket").Return("metrics").AnyTimes()
 	fakeConfig.EXPECT().GetString("web.influxdb.org").Return("scrutiny").AnyTimes()
 
-	deviceRepo := scrutinyRepository{
+	deviceRepo := influxTimeSeriesRepository{
 		appConfig: fakeConfig,
 	}
 
@@ -89,7 +89,7 @@ func Test_aggregateTempQuery_Year(t *testing.T) {
 	fakeConfig.EXPECT().GetString("web.influxdb.bucket").Return("metrics").AnyTimes()
 	fakeConfig.EXPECT().GetString("web.influxdb.org").Return("scrutiny").AnyTimes()
 
-	deviceRepo := scrutinyRepository{
+	deviceRepo := influxTimeSeriesRepository{
 		appConfig: fakeConfig,
 	}
 
@@ -136,7 +136,7 @@ func Test_aggregateTempQuery_Forever(t *testing.T) {
 	fakeConfig.EXPECT().GetString("web.influxdb.bucket").Return("metrics").AnyTimes()
 	fakeConfig.EXPECT().GetString("web.influxdb.org").Return("scrutiny").AnyTimes()
 
-	deviceRepo := scrutinyRepository{
+	deviceRepo := influxTimeSeriesRepository{
 		appConfig: fakeConfig,
 	}
 
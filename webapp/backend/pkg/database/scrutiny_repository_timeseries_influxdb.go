@@ -0,0 +1,436 @@
+package database
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/analogj/scrutiny/webapp/backend/pkg/config"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/models"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/models/measurements"
+	"github.com/gofrs/uuid/v5"
+	influxdb2 "github.com/influxdata/influxdb-client-go/v2"
+	"github.com/influxdata/influxdb-client-go/v2/api"
+	"github.com/influxdata/influxdb-client-go/v2/domain"
+	"github.com/sirupsen/logrus"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// InfluxDB time-series backend
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// influxTimeSeriesRepository stores measurements in InfluxDB. Data is down-sampled into the weekly, monthly & yearly
+// buckets by InfluxDB tasks (see EnsureTasks)
+type influxTimeSeriesRepository struct {
+	appConfig config.Interface
+	logger    logrus.FieldLogger
+
+	influxWriteApi api.WriteAPIBlocking
+	influxQueryApi api.QueryAPI
+	influxTaskApi  api.TasksAPI
+	influxClient   influxdb2.Client
+}
+
+func newInfluxTimeSeriesRepository(ctx context.Context, appConfig config.Interface, logger logrus.FieldLogger) (*influxTimeSeriesRepository, error) {
+	// Create a new client using an InfluxDB server base URL and an authentication token
+	influxdbUrl := fmt.Sprintf("%s://%s:%s", appConfig.GetString("web.influxdb.scheme"), appConfig.GetString("web.influxdb.host"), appConfig.GetString("web.influxdb.port"))
+	logger.Debugf("InfluxDB url: %s", influxdbUrl)
+
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: appConfig.GetBool("web.influxdb.tls.insecure_skip_verify"),
+	}
+	logger.Infof("InfluxDB certificate verification: %t\n", !tlsConfig.InsecureSkipVerify)
+
+	client := influxdb2.NewClientWithOptions(
+		influxdbUrl,
+		appConfig.GetString("web.influxdb.token"),
+		influxdb2.DefaultOptions().SetTLSConfig(tlsConfig),
+	)
+
+	//if !appConfig.IsSet("web.influxdb.token") {
+	logger.Debugf("Determine Influxdb setup status...")
+	influxSetupComplete, err := InfluxSetupComplete(influxdbUrl, tlsConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check influxdb setup status - %w", err)
+	}
+
+	if !influxSetupComplete {
+		logger.Debugf("Influxdb un-initialized, running first-time setup...")
+
+		// if no token is provided, but we have a valid server, we're going to assume this is the first setup of our server.
+		// we will initialize with a predetermined username & password, that you should change.
+
+		// metrics bucket will have a retention period of 8 days (since it will be down-sampled once a week)
+		// in seconds (60seconds * 60minutes * 24hours * 15 days) = 1_296_000 (see EnsureBucket() function)
+		_, err := client.SetupWithToken(
+			ctx,
+			appConfig.GetString("web.influxdb.init_username"),
+			appConfig.GetString("web.influxdb.init_password"),
+			appConfig.GetString("web.influxdb.org"),
+			appConfig.GetString("web.influxdb.bucket"),
+			0,
+			appConfig.GetString("web.influxdb.token"),
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Use blocking write client for writes to desired bucket
+	writeAPI := client.WriteAPIBlocking(appConfig.GetString("web.influxdb.org"), appConfig.GetString("web.influxdb.bucket"))
+
+	// Get query client
+	queryAPI := client.QueryAPI(appConfig.GetString("web.influxdb.org"))
+
+	// Get task client
+	taskAPI := client.TasksAPI()
+
+	if writeAPI == nil || queryAPI == nil || taskAPI == nil {
+		return nil, fmt.Errorf("failed to connect to influxdb")
+	}
+
+	influxRepo := influxTimeSeriesRepository{
+		appConfig:      appConfig,
+		logger:         logger,
+		influxClient:   client,
+		influxWriteApi: writeAPI,
+		influxQueryApi: queryAPI,
+		influxTaskApi:  taskAPI,
+	}
+
+	orgInfo, err := client.OrganizationsAPI().FindOrganizationByName(ctx, appConfig.GetString("web.influxdb.org"))
+	if err != nil {
+		return nil, err
+	}
+
+	// Initialize Buckets (if necessary)
+	err = influxRepo.EnsureBuckets(ctx, orgInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	// Initialize Background Tasks
+	err = influxRepo.EnsureTasks(ctx, *orgInfo.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &influxRepo, nil
+}
+
+func (ir *influxTimeSeriesRepository) Close() error {
+	ir.influxClient.Close()
+	return nil
+}
+
+func (ir *influxTimeSeriesRepository) HealthCheck(ctx context.Context) error {
+	status, err := ir.influxClient.Health(ctx)
+	if err != nil {
+		return fmt.Errorf("influxdb healthcheck failed: %w", err)
+	}
+	if status.Status != "pass" {
+		return fmt.Errorf("influxdb healthcheckf failed: status=%s", status.Status)
+	}
+	return nil
+}
+
+// GetLatestSmartSummaries returns the most recent temperature & power on hours for each device, across all buckets.
+func (ir *influxTimeSeriesRepository) GetLatestSmartSummaries(ctx context.Context) (map[uuid.UUID]*models.SmartSummary, error) {
+	summaries := map[uuid.UUID]*models.SmartSummary{}
+
+	// Get parser flux query result
+	//appConfig.GetString("web.influxdb.bucket")
+	queryStr := fmt.Sprintf(`
+  	import "influxdata/influxdb/schema"
+  	bucketBaseName = "%s"
+
+	dailyData = from(bucket: bucketBaseName)
+	|> range(start: -10y, stop: now())
+	|> filter(fn: (r) => r["_measurement"] == "smart" )
+	|> filter(fn: (r) => r["_field"] == "temp" or r["_field"] == "power_on_hours" or r["_field"] == "date")
+	|> last()
+	|> schema.fieldsAsCols()
+	|> group(columns: ["scrutiny_uuid"])
+	
+	weeklyData = from(bucket: bucketBaseName + "_weekly")
+	|> range(start: -10y, stop: now())
+	|> filter(fn: (r) => r["_measurement"] == "smart" )
+	|> filter(fn: (r) => r["_field"] == "temp" or r["_field"] == "power_on_hours" or r["_field"] == "date")
+	|> last()
+	|> schema.fieldsAsCols()
+	|> group(columns: ["scrutiny_uuid"])
+	
+	monthlyData = from(bucket: bucketBaseName + "_monthly")
+	|> range(start: -10y, stop: now())
+	|> filter(fn: (r) => r["_measurement"] == "smart" )
+	|> filter(fn: (r) => r["_field"] == "temp" or r["_field"] == "power_on_hours" or r["_field"] == "date")
+	|> last()
+	|> schema.fieldsAsCols()
+	|> group(columns: ["scrutiny_uuid"])
+	
+	yearlyData = from(bucket: bucketBaseName + "_yearly")
+	|> range(start: -10y, stop: now())
+	|> filter(fn: (r) => r["_measurement"] == "smart" )
+	|> filter(fn: (r) => r["_field"] == "temp" or r["_field"] == "power_on_hours" or r["_field"] == "date")
+	|> last()
+	|> schema.fieldsAsCols()
+	|> group(columns: ["scrutiny_uuid"])
+	
+	union(tables: [dailyData, weeklyData, monthlyData, yearlyData])
+	|> sort(columns: ["_time"], desc: false)
+	|> group(columns: ["scrutiny_uuid"])
+	|> last(column: "scrutiny_uuid")
+	|> yield(name: "last")
+		`,
+		ir.appConfig.GetString("web.influxdb.bucket"),
+	)
+
+	result, err := ir.influxQueryApi.Query(ctx, queryStr)
+	if err == nil {
+		// Use Next() to iterate over query result lines
+		for result.Next() {
+			// Observe when there is new grouping key producing new table
+			if result.TableChanged() {
+				//fmt.Printf("table: %s\n", result.TableMetadata().String())
+			}
+			// read result
+
+			//get summary data from Influxdb.
+			//result.Record().Values()
+			if scrutinyUUIDString, ok := result.Record().Values()["scrutiny_uuid"]; ok {
+				scrutinyUUID := uuid.Must(uuid.FromString(scrutinyUUIDString.(string)))
+
+				summaries[scrutinyUUID] = &models.SmartSummary{
+					Temp:          result.Record().Values()["temp"].(int64),
+					PowerOnHours:  result.Record().Values()["power_on_hours"].(int64),
+					CollectorDate: result.Record().Values()["_time"].(time.Time),
+				}
+			}
+		}
+		if result.Err() != nil {
+			fmt.Printf("Query error: %s\n", result.Err().Error())
+		}
+	} else {
+		return nil, err
+	}
+	return summaries, nil
+}
+
+// GetLatestSmartAttributes returns the most recent smart data (including attributes) for each device, across all buckets.
+func (ir *influxTimeSeriesRepository) GetLatestSmartAttributes(ctx context.Context) (map[uuid.UUID]measurements.Smart, error) {
+	bucketBaseName := ir.appConfig.GetString("web.influxdb.bucket")
+	partialQueryStr := []string{
+		`import "influxdata/influxdb/schema"`,
+	}
+	subQueryNames := []string{}
+	for ndx, bucket := range []string{bucketBaseName, bucketBaseName + "_weekly", bucketBaseName + "_monthly", bucketBaseName + "_yearly"} {
+		subQueryName := fmt.Sprintf("bucket%dData", ndx)
+		subQueryNames = append(subQueryNames, subQueryName)
+		partialQueryStr = append(partialQueryStr, []string{
+			fmt.Sprintf(`%s = from(bucket: "%s")`, subQueryName, bucket),
+			`|> range(start: -10y, stop: now())`,
+			`|> filter(fn: (r) => r["_measurement"] == "smart" )`,
+			`|> schema.fieldsAsCols()`,
+			`|> group(columns: ["scrutiny_uuid"])`,
+			`|> sort(columns: ["_time"], desc: false)`,
+			`|> last(column: "_time")`,
+		}...)
+	}
+	partialQueryStr = append(partialQueryStr, []string{
+		fmt.Sprintf("union(tables: [%s])", strings.Join(subQueryNames, ", ")),
+		`|> group(columns: ["scrutiny_uuid"])`,
+		`|> sort(columns: ["_time"], desc: false)`,
+		`|> last(column: "_time")`,
+		`|> yield(name: "last")`,
+	}...)
+
+	result, err := ir.influxQueryApi.Query(ctx, strings.Join(partialQueryStr, "\n"))
+	if err != nil {
+		return nil, err
+	}
+	latestSmart := map[uuid.UUID]measurements.Smart{}
+	for result.Next() {
+		smartData, err := measurements.NewSmartFromInfluxDB(result.Record().Values())
+		if err != nil {
+			return nil, err
+		}
+		latestSmart[smartData.ScrutinyUUID] = *smartData
+	}
+	if result.Err() != nil {
+		return nil, result.Err()
+	}
+	return latestSmart, nil
+}
+
+func (ir *influxTimeSeriesRepository) DeleteDeviceData(ctx context.Context, scrutiny_uuid uuid.UUID) error {
+	buckets := []string{
+		ir.appConfig.GetString("web.influxdb.bucket"),
+		fmt.Sprintf("%s_weekly", ir.appConfig.GetString("web.influxdb.bucket")),
+		fmt.Sprintf("%s_monthly", ir.appConfig.GetString("web.influxdb.bucket")),
+		fmt.Sprintf("%s_yearly", ir.appConfig.GetString("web.influxdb.bucket")),
+	}
+
+	for _, bucket := range buckets {
+		ir.logger.Infof("Deleting data for %s in bucket: %s", scrutiny_uuid.String(), bucket)
+		if err := ir.influxClient.DeleteAPI().DeleteWithName(
+			ctx,
+			ir.appConfig.GetString("web.influxdb.org"),
+			bucket,
+			time.Now().AddDate(-10, 0, 0),
+			time.Now(),
+			fmt.Sprintf(`scrutiny_uuid="%s"`, scrutiny_uuid.String()),
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func InfluxSetupComplete(influxEndpoint string, tlsConfig *tls.Config) (bool, error) {
+	influxUri, err := url.Parse(influxEndpoint)
+	if err != nil {
+		return false, err
+	}
+	influxUri, err = influxUri.Parse("/api/v2/setup")
+	if err != nil {
+		return false, err
+	}
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}}
+	res, err := client.Get(influxUri.String())
+	if err != nil {
+		return false, err
+	}
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return false, err
+	}
+
+	type SetupStatus struct {
+		Allowed bool `json:"allowed"`
+	}
+	var data SetupStatus
+	err = json.Unmarshal(body, &data)
+	if err != nil {
+		return false, err
+	}
+	return !data.Allowed, nil
+}
+
+func (ir *influxTimeSeriesRepository) EnsureBuckets(ctx context.Context, org *domain.Organization) error {
+
+	var mainBucketRetentionRule domain.RetentionRule
+	var weeklyBucketRetentionRule domain.RetentionRule
+	var monthlyBucketRetentionRule domain.RetentionRule
+	if ir.appConfig.GetBool("web.influxdb.retention_policy") {
+
+		// in tests, we may not want to set a retention policy. If "false", we can set data with old timestamps,
+		// then manually run the down sampling scripts. This should be true for production environments.
+		mainBucketRetentionRule = domain.RetentionRule{EverySeconds: RETENTION_PERIOD_15_DAYS_IN_SECONDS}
+		weeklyBucketRetentionRule = domain.RetentionRule{EverySeconds: RETENTION_PERIOD_9_WEEKS_IN_SECONDS}
+		monthlyBucketRetentionRule = domain.RetentionRule{EverySeconds: RETENTION_PERIOD_25_MONTHS_IN_SECONDS}
+	}
+
+	mainBucket := ir.appConfig.GetString("web.influxdb.bucket")
+	if foundMainBucket, foundErr := ir.influxClient.BucketsAPI().FindBucketByName(ctx, mainBucket); foundErr != nil {
+		// metrics bucket will have a retention period of 15 days (since it will be down-sampled once a week)
+		_, err := ir.influxClient.BucketsAPI().CreateBucketWithName(ctx, org, mainBucket, mainBucketRetentionRule)
+		if err != nil {
+			return err
+		}
+	} else if ir.appConfig.GetBool("web.influxdb.retention_policy") {
+		//correctly set the retention period for the main bucket (cant do it during setup/creation)
+		foundMainBucket.RetentionRules = domain.RetentionRules{mainBucketRetentionRule}
+		ir.influxClient.BucketsAPI().UpdateBucket(ctx, foundMainBucket)
+	}
+
+	//create buckets (used for downsampling)
+	weeklyBucket := fmt.Sprintf("%s_weekly", ir.appConfig.GetString("web.influxdb.bucket"))
+	if foundWeeklyBucket, foundErr := ir.influxClient.BucketsAPI().FindBucketByName(ctx, weeklyBucket); foundErr != nil {
+		// metrics_weekly bucket will have a retention period of 8+1 weeks (since it will be down-sampled once a month)
+		_, err := ir.influxClient.BucketsAPI().CreateBucketWithName(ctx, org, weeklyBucket, weeklyBucketRetentionRule)
+		if err != nil {
+			return err
+		}
+	} else if ir.appConfig.GetBool("web.influxdb.retention_policy") {
+		//correctly set the retention period for the bucket (may not be able to do it during setup/creation)
+		foundWeeklyBucket.RetentionRules = domain.RetentionRules{weeklyBucketRetentionRule}
+		ir.influxClient.BucketsAPI().UpdateBucket(ctx, foundWeeklyBucket)
+	}
+
+	monthlyBucket := fmt.Sprintf("%s_monthly", ir.appConfig.GetString("web.influxdb.bucket"))
+	if foundMonthlyBucket, foundErr := ir.influxClient.BucketsAPI().FindBucketByName(ctx, monthlyBucket); foundErr != nil {
+		// metrics_monthly bucket will have a retention period of 24+1 months (since it will be down-sampled once a year)
+		_, err := ir.influxClient.BucketsAPI().CreateBucketWithName(ctx, org, monthlyBucket, monthlyBucketRetentionRule)
+		if err != nil {
+			return err
+		}
+	} else if ir.appConfig.GetBool("web.influxdb.retention_policy") {
+		//correctly set the retention period for the bucket (may not be able to do it during setup/creation)
+		foundMonthlyBucket.RetentionRules = domain.RetentionRules{monthlyBucketRetentionRule}
+		ir.influxClient.BucketsAPI().UpdateBucket(ctx, foundMonthlyBucket)
+	}
+
+	yearlyBucket := fmt.Sprintf("%s_yearly", ir.appConfig.GetString("web.influxdb.bucket"))
+	if _, foundErr := ir.influxClient.BucketsAPI().FindBucketByName(ctx, yearlyBucket); foundErr != nil {
+		// metrics_yearly bucket will have an infinite retention period
+		_, err := ir.influxClient.BucketsAPI().CreateBucketWithName(ctx, org, yearlyBucket)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Helper Methods
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func (ir *influxTimeSeriesRepository) lookupBucketName(durationKey string) string {
+	switch durationKey {
+	case DURATION_KEY_DAY:
+	case DURATION_KEY_WEEK:
+		//data stored in the last week
+		return ir.appConfig.GetString("web.influxdb.bucket")
+	case DURATION_KEY_MONTH:
+		// data stored in the last month (after the first week)
+		return fmt.Sprintf("%s_weekly", ir.appConfig.GetString("web.influxdb.bucket"))
+	case DURATION_KEY_YEAR:
+		// data stored in the last year (after the first month)
+		return fmt.Sprintf("%s_monthly", ir.appConfig.GetString("web.influxdb.bucket"))
+	case DURATION_KEY_FOREVER:
+		//data stored before the last year
+		return fmt.Sprintf("%s_yearly", ir.appConfig.GetString("web.influxdb.bucket"))
+	}
+	return ir.appConfig.GetString("web.influxdb.bucket")
+}
+
+func (ir *influxTimeSeriesRepository) lookupDuration(durationKey string) []string {
+	switch durationKey {
+	case DURATION_KEY_DAY:
+		//data stored in the last day
+		return []string{"-1d", "now()"}
+	case DURATION_KEY_WEEK:
+		//data stored in the last week
+		return []string{"-1w", "now()"}
+	case DURATION_KEY_MONTH:
+		// data stored in the last month (after the first week)
+		return []string{"-1mo", "-1w"}
+	case DURATION_KEY_YEAR:
+		// data stored in the last year (after the first month)
+		return []string{"-1y", "-1mo"}
+	case DURATION_KEY_FOREVER:
+		//data stored before the last year
+		return []string{"-10y", "-1y"}
+	}
+	return []string{"-1w", "now()"}
+}
@@ -0,0 +1,438 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/analogj/scrutiny/webapp/backend/pkg/models"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/models/measurements"
+	"github.com/gofrs/uuid/v5"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// SQLite time-series backend
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// the sqlite buckets are equivalent to the influxdb buckets (metrics, metrics_weekly, metrics_monthly, metrics_yearly)
+const (
+	sqliteBucketRaw     = "raw"
+	sqliteBucketWeekly  = "weekly"
+	sqliteBucketMonthly = "monthly"
+	sqliteBucketYearly  = "yearly"
+)
+
+// how often the sqlite down-sampling job runs. It only processes data that has not been down-sampled yet.
+const sqliteDownsampleInterval = time.Hour
+
+type sqliteSmartPoint struct {
+	ID              uint   `gorm:"primaryKey"`
+	ScrutinyUUID    string `gorm:"index:idx_timeseries_smart,priority:1"`
+	Bucket          string `gorm:"index:idx_timeseries_smart,priority:2"`
+	Timestamp       int64  `gorm:"index:idx_timeseries_smart,priority:3"`
+	DeviceProtocol  string
+	Temp            int64
+	PowerOnHours    int64
+	PowerCycleCount int64
+	// the `attr.*` fields generated by measurements.Smart.Flatten(), stored as JSON
+	Attributes string
+}
+
+func (sqliteSmartPoint) TableName() string {
+	return "timeseries_smart"
+}
+
+type sqliteTemperaturePoint struct {
+	ID           uint   `gorm:"primaryKey"`
+	ScrutinyUUID string `gorm:"index:idx_timeseries_temp,priority:1"`
+	Bucket       string `gorm:"index:idx_timeseries_temp,priority:2"`
+	Timestamp    int64  `gorm:"index:idx_timeseries_temp,priority:3"`
+	Temp         int64
+}
+
+func (sqliteTemperaturePoint) TableName() string {
+	return "timeseries_temp"
+}
+
+type sqliteSelfTestPoint struct {
+	ID            uint   `gorm:"primaryKey"`
+	ScrutinyUUID  string `gorm:"index:idx_timeseries_self_test,priority:1"`
+	Bucket        string `gorm:"index:idx_timeseries_self_test,priority:2"`
+	Timestamp     int64  `gorm:"index:idx_timeseries_self_test,priority:3"`
+	TestType      string
+	TestTypeValue int64
+	Status        string
+	StatusValue   int64
+	Passed        bool
+	LifetimeHours int64
+}
+
+func (sqliteSelfTestPoint) TableName() string {
+	return "timeseries_self_test"
+}
+
+// sqliteTimeSeriesRepository stores measurements in the scrutiny sqlite database, so that Scrutiny can run without an
+// InfluxDB server. Data is down-sampled into the weekly, monthly & yearly buckets by a background job (see Downsample)
+type sqliteTimeSeriesRepository struct {
+	logger     logrus.FieldLogger
+	gormClient *gorm.DB
+
+	stopDownsampling context.CancelFunc
+	downsamplingDone chan struct{}
+}
+
+func newSqliteTimeSeriesRepository(ctx context.Context, logger logrus.FieldLogger, gormClient *gorm.DB) (*sqliteTimeSeriesRepository, error) {
+	logger.Infoln("Using sqlite time-series backend")
+
+	// the time-series tables are owned by this backend, rather than the gormigrate migrations, similar to the influxdb buckets.
+	err := gormClient.WithContext(ctx).AutoMigrate(&sqliteSmartPoint{}, &sqliteTemperaturePoint{}, &sqliteSelfTestPoint{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sqlite time-series tables: %w", err)
+	}
+
+	sqliteRepo := &sqliteTimeSeriesRepository{
+		logger:     logger,
+		gormClient: gormClient,
+	}
+	sqliteRepo.startDownsampling(sqliteDownsampleInterval)
+	return sqliteRepo, nil
+}
+
+func (sqr *sqliteTimeSeriesRepository) Close() error {
+	if sqr.stopDownsampling != nil {
+		sqr.stopDownsampling()
+		<-sqr.downsamplingDone
+	}
+	return nil
+}
+
+// HealthCheck is a no-op, the sqlite database is checked by the scrutinyRepository
+func (sqr *sqliteTimeSeriesRepository) HealthCheck(ctx context.Context) error {
+	return nil
+}
+
+func (sqr *sqliteTimeSeriesRepository) WriteSmart(ctx context.Context, smart measurements.Smart) error {
+	_, fields := smart.Flatten()
+	attributes := map[string]interface{}{}
+	for key, val := range fields {
+		if strings.HasPrefix(key, "attr.") {
+			attributes[key] = val
+		}
+	}
+	attributesJson, err := json.Marshal(attributes)
+	if err != nil {
+		return err
+	}
+
+	return sqr.gormClient.WithContext(ctx).Create(&sqliteSmartPoint{
+		ScrutinyUUID:    smart.ScrutinyUUID.String(),
+		Bucket:          sqliteBucketRaw,
+		Timestamp:       smart.Date.Unix(),
+		DeviceProtocol:  smart.DeviceProtocol,
+		Temp:            smart.Temp,
+		PowerOnHours:    smart.PowerOnHours,
+		PowerCycleCount: smart.PowerCycleCount,
+		Attributes:      string(attributesJson),
+	}).Error
+}
+
+func (sqr *sqliteTimeSeriesRepository) WriteTemperatures(ctx context.Context, scrutiny_uuid uuid.UUID, temperatures []measurements.SmartTemperature) error {
+	if len(temperatures) == 0 {
+		return nil
+	}
+	points := []sqliteTemperaturePoint{}
+	for _, smartTemp := range temperatures {
+		points = append(points, sqliteTemperaturePoint{
+			ScrutinyUUID: scrutiny_uuid.String(),
+			Bucket:       sqliteBucketRaw,
+			Timestamp:    smartTemp.Date.Unix(),
+			Temp:         smartTemp.Temp,
+		})
+	}
+	return sqr.gormClient.WithContext(ctx).CreateInBatches(&points, 100).Error
+}
+
+func (sqr *sqliteTimeSeriesRepository) WriteSelfTest(ctx context.Context, scrutiny_uuid uuid.UUID, selfTest measurements.SmartSelfTest) error {
+	return sqr.gormClient.WithContext(ctx).Create(&sqliteSelfTestPoint{
+		ScrutinyUUID:  scrutiny_uuid.String(),
+		Bucket:        sqliteBucketRaw,
+		Timestamp:     selfTest.Date.Unix(),
+		TestType:      selfTest.TestType,
+		TestTypeValue: selfTest.TestTypeValue,
+		Status:        selfTest.Status,
+		StatusValue:   selfTest.StatusValue,
+		Passed:        selfTest.Passed,
+		LifetimeHours: selfTest.LifetimeHours,
+	}).Error
+}
+
+// GetSmartAttributeHistory returns the newest entries first, with (at most) one entry per day, matching the influxdb implementation.
+func (sqr *sqliteTimeSeriesRepository) GetSmartAttributeHistory(ctx context.Context, scrutiny_uuid uuid.UUID, durationKey string, selectEntries int, selectEntriesOffset int, attributes []string) ([]measurements.Smart, error) {
+	now := time.Now()
+	smartResults := []measurements.Smart{}
+
+	for _, nestedDurationKey := range lookupNestedDurationKeys(durationKey) {
+		points := []sqliteSmartPoint{}
+		err := sqr.bucketQuery(ctx, scrutiny_uuid, nestedDurationKey, now).Order("timestamp desc").Find(&points).Error
+		if err != nil {
+			return nil, err
+		}
+
+		// equivalent to `aggregateWindow(every: 1d, fn: last)`
+		bucketResults := 0
+		days := map[int64]bool{}
+		for _, point := range points {
+			day := point.Timestamp - point.Timestamp%(24*60*60)
+			if days[day] {
+				continue
+			}
+			days[day] = true
+
+			// we only need the last `n + offset` # of entries from each bucket
+			if selectEntries > 0 && bucketResults >= selectEntries+selectEntriesOffset {
+				break
+			}
+			smartData, err := point.toSmart()
+			if err != nil {
+				return nil, err
+			}
+			smartResults = append(smartResults, *smartData)
+			bucketResults++
+		}
+	}
+
+	sort.SliceStable(smartResults, func(i, j int) bool {
+		return smartResults[i].Date.After(smartResults[j].Date)
+	})
+	if selectEntries > 0 {
+		if selectEntriesOffset >= len(smartResults) {
+			return []measurements.Smart{}, nil
+		}
+		smartResults = smartResults[selectEntriesOffset:]
+		if len(smartResults) > selectEntries {
+			smartResults = smartResults[:selectEntries]
+		}
+	}
+	return smartResults, nil
+}
+
+// GetSmartTemperatureHistory returns the mean temperature for each device, using the resolution of the duration key.
+func (sqr *sqliteTimeSeriesRepository) GetSmartTemperatureHistory(ctx context.Context, durationKey string) (map[uuid.UUID][]measurements.SmartTemperature, error) {
+	now := time.Now()
+	deviceTempHistory := map[uuid.UUID][]measurements.SmartTemperature{}
+
+	for _, nestedDurationKey := range lookupNestedDurationKeys(durationKey) {
+		resolution, err := time.ParseDuration(lookupResolution(nestedDurationKey))
+		if err != nil {
+			return nil, err
+		}
+		resolutionSeconds := int64(resolution.Seconds())
+
+		points := []sqliteTemperaturePoint{}
+		err = sqr.bucketQuery(ctx, uuid.Nil, nestedDurationKey, now).Order("scrutiny_uuid, timestamp").Find(&points).Error
+		if err != nil {
+			return nil, err
+		}
+
+		// equivalent to `aggregateWindow(every: resolution, fn: mean)`, points are sorted by device & time
+		for start := 0; start < len(points); {
+			windowStart := points[start].Timestamp - points[start].Timestamp%resolutionSeconds
+			end := start
+			var sum int64
+			for end < len(points) && points[end].ScrutinyUUID == points[start].ScrutinyUUID && points[end].Timestamp < windowStart+resolutionSeconds {
+				sum += points[end].Temp
+				end++
+			}
+
+			scrutinyUUID, err := uuid.FromString(points[start].ScrutinyUUID)
+			if err != nil {
+				return nil, err
+			}
+			deviceTempHistory[scrutinyUUID] = append(deviceTempHistory[scrutinyUUID], measurements.SmartTemperature{
+				Date: time.Unix(windowStart, 0),
+				Temp: sum / int64(end-start),
+			})
+			start = end
+		}
+	}
+
+	for scrutinyUUID := range deviceTempHistory {
+		tempHistory := deviceTempHistory[scrutinyUUID]
+		sort.SliceStable(tempHistory, func(i, j int) bool {
+			return tempHistory[i].Date.Before(tempHistory[j].Date)
+		})
+	}
+	return deviceTempHistory, nil
+}
+
+func (sqr *sqliteTimeSeriesRepository) GetSmartSelfTestHistory(ctx context.Context, scrutiny_uuid uuid.UUID, durationKey string) ([]measurements.SmartSelfTest, error) {
+	now := time.Now()
+	selfTests := []measurements.SmartSelfTest{}
+
+	for _, nestedDurationKey := range lookupNestedDurationKeys(durationKey) {
+		points := []sqliteSelfTestPoint{}
+		err := sqr.bucketQuery(ctx, scrutiny_uuid, nestedDurationKey, now).Order("timestamp desc, lifetime_hours desc").Find(&points).Error
+		if err != nil {
+			return nil, err
+		}
+		for _, point := range points {
+			selfTests = append(selfTests, measurements.SmartSelfTest{
+				Date:          time.Unix(point.Timestamp, 0),
+				TestType:      point.TestType,
+				TestTypeValue: point.TestTypeValue,
+				Status:        point.Status,
+				StatusValue:   point.StatusValue,
+				Passed:        point.Passed,
+				LifetimeHours: point.LifetimeHours,
+			})
+		}
+	}
+
+	sort.SliceStable(selfTests, func(i, j int) bool {
+		return selfTests[i].Date.After(selfTests[j].Date)
+	})
+	return selfTests, nil
+}
+
+// GetLatestSmartSummaries returns the most recent temperature & power on hours for each device, across all buckets.
+func (sqr *sqliteTimeSeriesRepository) GetLatestSmartSummaries(ctx context.Context) (map[uuid.UUID]*models.SmartSummary, error) {
+	// sqlite returns the other columns from the row containing the MAX() value
+	points := []sqliteSmartPoint{}
+	err := sqr.gormClient.WithContext(ctx).
+		Raw("SELECT scrutiny_uuid, temp, power_on_hours, MAX(timestamp) AS timestamp FROM timeseries_smart GROUP BY scrutiny_uuid").
+		Scan(&points).Error
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := map[uuid.UUID]*models.SmartSummary{}
+	for _, point := range points {
+		scrutinyUUID, err := uuid.FromString(point.ScrutinyUUID)
+		if err != nil {
+			return nil, err
+		}
+		summaries[scrutinyUUID] = &models.SmartSummary{
+			CollectorDate: time.Unix(point.Timestamp, 0),
+			Temp:          point.Temp,
+			PowerOnHours:  point.PowerOnHours,
+		}
+	}
+	return summaries, nil
+}
+
+// GetLatestSmartAttributes returns the most recent smart point for each device, across all buckets.
+func (sqr *sqliteTimeSeriesRepository) GetLatestSmartAttributes(ctx context.Context) (map[uuid.UUID]measurements.Smart, error) {
+	points := []sqliteSmartPoint{}
+	err := sqr.gormClient.WithContext(ctx).
+		Raw(`SELECT p.* FROM timeseries_smart p
+			JOIN (SELECT scrutiny_uuid, MAX(timestamp) AS timestamp FROM timeseries_smart GROUP BY scrutiny_uuid) latest
+			ON p.scrutiny_uuid = latest.scrutiny_uuid AND p.timestamp = latest.timestamp`).
+		Scan(&points).Error
+	if err != nil {
+		return nil, err
+	}
+
+	latestSmart := map[uuid.UUID]measurements.Smart{}
+	for _, point := range points {
+		scrutinyUUID, err := uuid.FromString(point.ScrutinyUUID)
+		if err != nil {
+			return nil, err
+		}
+		// a down-sampled point may share the timestamp of the raw point it was created from
+		if _, found := latestSmart[scrutinyUUID]; found {
+			continue
+		}
+		smartData, err := point.toSmart()
+		if err != nil {
+			return nil, err
+		}
+		latestSmart[scrutinyUUID] = *smartData
+	}
+	return latestSmart, nil
+}
+
+func (sqr *sqliteTimeSeriesRepository) DeleteDeviceData(ctx context.Context, scrutiny_uuid uuid.UUID) error {
+	return sqr.gormClient.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, table := range []interface{}{&sqliteSmartPoint{}, &sqliteTemperaturePoint{}, &sqliteSelfTestPoint{}} {
+			sqr.logger.Infof("Deleting data for %s in table: %T", scrutiny_uuid.String(), table)
+			if err := tx.Where("scrutiny_uuid = ?", scrutiny_uuid.String()).Delete(table).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Helper Methods
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// bucketQuery filters by the bucket & time range associated with the duration key (see lookupDuration for the
+// influxdb equivalent). Points for all devices are returned when scrutiny_uuid is uuid.Nil
+func (sqr *sqliteTimeSeriesRepository) bucketQuery(ctx context.Context, scrutiny_uuid uuid.UUID, durationKey string, now time.Time) *gorm.DB {
+	var bucket string
+	var start, stop time.Time
+	switch durationKey {
+	case DURATION_KEY_DAY:
+		//data stored in the last day
+		bucket, start = sqliteBucketRaw, now.AddDate(0, 0, -1)
+	case DURATION_KEY_MONTH:
+		// data stored in the last month (after the first week)
+		bucket, start, stop = sqliteBucketWeekly, now.AddDate(0, -1, 0), now.AddDate(0, 0, -7)
+	case DURATION_KEY_YEAR:
+		// data stored in the last year (after the first month)
+		bucket, start, stop = sqliteBucketMonthly, now.AddDate(-1, 0, 0), now.AddDate(0, -1, 0)
+	case DURATION_KEY_FOREVER:
+		//data stored before the last year
+		bucket, start, stop = sqliteBucketYearly, now.AddDate(-10, 0, 0), now.AddDate(-1, 0, 0)
+	default:
+		//data stored in the last week
+		bucket, start = sqliteBucketRaw, now.AddDate(0, 0, -7)
+	}
+
+	query := sqr.gormClient.WithContext(ctx).Where("bucket = ? AND timestamp >= ?", bucket, start.Unix())
+	if !stop.IsZero() {
+		query = query.Where("timestamp < ?", stop.Unix())
+	}
+	if scrutiny_uuid != uuid.Nil {
+		query = query.Where("scrutiny_uuid = ?", scrutiny_uuid.String())
+	}
+	return query
+}
+
+func (point sqliteSmartPoint) toSmart() (*measurements.Smart, error) {
+	attrs := map[string]interface{}{
+		"scrutiny_uuid":     point.ScrutinyUUID,
+		"device_protocol":   point.DeviceProtocol,
+		"_time":             time.Unix(point.Timestamp, 0),
+		"temp":              point.Temp,
+		"power_on_hours":    point.PowerOnHours,
+		"power_cycle_count": point.PowerCycleCount,
+	}
+
+	// decode numbers as int64 where possible, to match the field types returned by influxdb
+	decoder := json.NewDecoder(bytes.NewReader([]byte(point.Attributes)))
+	decoder.UseNumber()
+	attributes := map[string]interface{}{}
+	if err := decoder.Decode(&attributes); err != nil {
+		return nil, fmt.Errorf("could not decode smart attributes: %w", err)
+	}
+	for key, val := range attributes {
+		if number, ok := val.(json.Number); ok {
+			if intVal, err := number.Int64(); err == nil {
+				val = intVal
+			} else if floatVal, err := number.Float64(); err == nil {
+				val = floatVal
+			}
+		}
+		attrs[key] = val
+	}
+
+	return measurements.NewSmartFromInfluxDB(attrs)
+}
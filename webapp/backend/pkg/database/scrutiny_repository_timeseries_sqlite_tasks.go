@@ -0,0 +1,198 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// SQLite down-sampling
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// sqliteDownsampleTier is the sqlite equivalent of the influxdb `tsk-weekly-aggr`, `tsk-monthly-aggr` & `tsk-yearly-aggr` tasks
+type sqliteDownsampleTier struct {
+	sourceBucket string
+	destBucket   string
+	// returns the start & end of the window containing the timestamp
+	window func(t time.Time) (time.Time, time.Time)
+	// returns the start & end of the source bucket window containing the timestamp, nil when the source bucket is not down-sampled.
+	// A window is only down-sampled once all the source windows it contains have been completed.
+	sourceWindow func(t time.Time) (time.Time, time.Time)
+}
+
+var sqliteDownsampleTiers = []sqliteDownsampleTier{
+	{sourceBucket: sqliteBucketRaw, destBucket: sqliteBucketWeekly, window: sqliteWeeklyWindow},
+	{sourceBucket: sqliteBucketWeekly, destBucket: sqliteBucketMonthly, window: sqliteMonthlyWindow, sourceWindow: sqliteWeeklyWindow},
+	{sourceBucket: sqliteBucketMonthly, destBucket: sqliteBucketYearly, window: sqliteYearlyWindow, sourceWindow: sqliteMonthlyWindow},
+}
+
+// the yearly bucket is kept forever, like the influxdb `metrics_yearly` bucket
+var sqliteBucketRetentionPeriods = map[string]time.Duration{
+	sqliteBucketRaw:     RETENTION_PERIOD_15_DAYS_IN_SECONDS * time.Second,
+	sqliteBucketWeekly:  RETENTION_PERIOD_9_WEEKS_IN_SECONDS * time.Second,
+	sqliteBucketMonthly: RETENTION_PERIOD_25_MONTHS_IN_SECONDS * time.Second,
+}
+
+func sqliteWeeklyWindow(t time.Time) (time.Time, time.Time) {
+	// influxdb aligns weekly windows to the unix epoch (time.Truncate uses the zero time), we do the same.
+	const weekSeconds = 7 * 24 * 60 * 60
+	start := time.Unix(t.Unix()-t.Unix()%weekSeconds, 0).UTC()
+	return start, start.AddDate(0, 0, 7)
+}
+
+func sqliteMonthlyWindow(t time.Time) (time.Time, time.Time) {
+	t = t.UTC()
+	start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return start, start.AddDate(0, 1, 0)
+}
+
+func sqliteYearlyWindow(t time.Time) (time.Time, time.Time) {
+	t = t.UTC()
+	start := time.Date(t.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+	return start, start.AddDate(1, 0, 0)
+}
+
+// startDownsampling runs Downsample immediately, and then on every interval until Close is called.
+func (sqr *sqliteTimeSeriesRepository) startDownsampling(interval time.Duration) {
+	ctx, cancel := context.WithCancel(context.Background())
+	sqr.stopDownsampling = cancel
+	sqr.downsamplingDone = make(chan struct{})
+
+	go func() {
+		defer close(sqr.downsamplingDone)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if err := sqr.Downsample(ctx, time.Now()); err != nil && ctx.Err() == nil {
+				sqr.logger.Errorf("An error occurred while down-sampling sqlite time-series data: %v", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Downsample aggregates every completed window which has not been down-sampled yet, and then removes data older than the
+// bucket retention period.
+//   - smart: the last point in each window is kept
+//   - temp: the mean temperature of each window is kept
+//   - self_test: all points are kept
+func (sqr *sqliteTimeSeriesRepository) Downsample(ctx context.Context, now time.Time) error {
+	return sqr.gormClient.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, tier := range sqliteDownsampleTiers {
+			err := downsampleTable(tx, tier, now,
+				func(point sqliteSmartPoint) int64 { return point.Timestamp },
+				func(windowStart time.Time, points []sqliteSmartPoint) []sqliteSmartPoint {
+					last := points[len(points)-1]
+					last.ID = 0
+					last.Bucket = tier.destBucket
+					return []sqliteSmartPoint{last}
+				})
+			if err != nil {
+				return err
+			}
+
+			err = downsampleTable(tx, tier, now,
+				func(point sqliteTemperaturePoint) int64 { return point.Timestamp },
+				func(windowStart time.Time, points []sqliteTemperaturePoint) []sqliteTemperaturePoint {
+					var sum int64
+					for _, point := range points {
+						sum += point.Temp
+					}
+					return []sqliteTemperaturePoint{{
+						ScrutinyUUID: points[0].ScrutinyUUID,
+						Bucket:       tier.destBucket,
+						Timestamp:    windowStart.Unix(),
+						Temp:         sum / int64(len(points)),
+					}}
+				})
+			if err != nil {
+				return err
+			}
+
+			err = downsampleTable(tx, tier, now,
+				func(point sqliteSelfTestPoint) int64 { return point.Timestamp },
+				func(windowStart time.Time, points []sqliteSelfTestPoint) []sqliteSelfTestPoint {
+					copied := []sqliteSelfTestPoint{}
+					for _, point := range points {
+						point.ID = 0
+						point.Bucket = tier.destBucket
+						copied = append(copied, point)
+					}
+					return copied
+				})
+			if err != nil {
+				return err
+			}
+		}
+
+		for bucket, retentionPeriod := range sqliteBucketRetentionPeriods {
+			for _, table := range []interface{}{&sqliteSmartPoint{}, &sqliteTemperaturePoint{}, &sqliteSelfTestPoint{}} {
+				err := tx.Where("bucket = ? AND timestamp < ?", bucket, now.Add(-retentionPeriod).Unix()).Delete(table).Error
+				if err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// downsampleTable aggregates the source bucket points of each device into the destination bucket, one window at a time.
+// Processing continues from the window after the most recent destination bucket point, so each window is only aggregated once.
+func downsampleTable[T any](tx *gorm.DB, tier sqliteDownsampleTier, now time.Time, timestampOf func(T) int64, aggregate func(windowStart time.Time, points []T) []T) error {
+	cutoff := now
+	if tier.sourceWindow != nil {
+		cutoff, _ = tier.sourceWindow(now)
+	}
+	currentWindowStart, _ := tier.window(cutoff)
+
+	var scrutinyUUIDs []string
+	if err := tx.Model(new(T)).Where("bucket = ?", tier.sourceBucket).Distinct().Pluck("scrutiny_uuid", &scrutinyUUIDs).Error; err != nil {
+		return err
+	}
+
+	for _, scrutinyUUID := range scrutinyUUIDs {
+		var lastTimestamp sql.NullInt64
+		err := tx.Model(new(T)).
+			Select("MAX(timestamp)").
+			Where("scrutiny_uuid = ? AND bucket = ?", scrutinyUUID, tier.destBucket).
+			Row().Scan(&lastTimestamp)
+		if err != nil {
+			return err
+		}
+
+		query := tx.Where("scrutiny_uuid = ? AND bucket = ? AND timestamp < ?", scrutinyUUID, tier.sourceBucket, currentWindowStart.Unix())
+		if lastTimestamp.Valid {
+			_, lastWindowEnd := tier.window(time.Unix(lastTimestamp.Int64, 0))
+			query = query.Where("timestamp >= ?", lastWindowEnd.Unix())
+		}
+		points := []T{}
+		if err := query.Order("timestamp").Find(&points).Error; err != nil {
+			return err
+		}
+
+		aggregated := []T{}
+		for start := 0; start < len(points); {
+			windowStart, windowEnd := tier.window(time.Unix(timestampOf(points[start]), 0))
+			end := start
+			for end < len(points) && timestampOf(points[end]) < windowEnd.Unix() {
+				end++
+			}
+			aggregated = append(aggregated, aggregate(windowStart, points[start:end])...)
+			start = end
+		}
+		if len(aggregated) > 0 {
+			if err := tx.CreateInBatches(&aggregated, 100).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,288 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/analogj/scrutiny/webapp/backend/pkg/models/collector"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/models/measurements"
+	"github.com/gofrs/uuid/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSqliteTimeSeriesRepository(t *testing.T) *sqliteTimeSeriesRepository {
+	deviceRepo := newTestSqliteRepository(t, &sqliteSmartPoint{}, &sqliteTemperaturePoint{}, &sqliteSelfTestPoint{})
+	return &sqliteTimeSeriesRepository{
+		logger:     deviceRepo.logger,
+		gormClient: deviceRepo.gormClient,
+	}
+}
+
+func newTestSmart(t *testing.T, fixturePath string, scrutinyUUID uuid.UUID, date time.Time) measurements.Smart {
+	smartDataFile, err := os.ReadFile(fixturePath)
+	require.NoError(t, err)
+	var smartJson collector.SmartInfo
+	require.NoError(t, json.Unmarshal(smartDataFile, &smartJson))
+
+	smart := measurements.Smart{}
+	require.NoError(t, smart.FromCollectorSmartInfo(scrutinyUUID, smartJson, nil))
+	smart.Date = date
+	return smart
+}
+
+func Test_SqliteTimeSeries_SmartAttributeHistory(t *testing.T) {
+	//setup
+	timeSeries := newTestSqliteTimeSeriesRepository(t)
+	ctx := context.Background()
+	scrutinyUUID := uuid.Must(uuid.NewV4())
+	now := time.Unix(time.Now().Unix(), 0)
+
+	for _, fixture := range []struct {
+		path string
+		date time.Time
+	}{
+		{"../models/testdata/smart-ata.json", now.Add(-1 * time.Hour)},
+		{"../models/testdata/smart-ata.json", now.Add(-2 * time.Hour)},
+		{"../models/testdata/smart-ata.json", now.Add(-50 * time.Hour)},
+		{"../models/testdata/smart-nvme.json", now.Add(-50 * time.Hour)},
+	} {
+		require.NoError(t, timeSeries.WriteSmart(ctx, newTestSmart(t, fixture.path, scrutinyUUID, fixture.date)))
+	}
+	expected := newTestSmart(t, "../models/testdata/smart-ata.json", scrutinyUUID, now.Add(-1*time.Hour))
+
+	//test
+	weekHistory, err := timeSeries.GetSmartAttributeHistory(ctx, scrutinyUUID, DURATION_KEY_WEEK, 0, 0, nil)
+	require.NoError(t, err)
+	offsetHistory, err := timeSeries.GetSmartAttributeHistory(ctx, scrutinyUUID, DURATION_KEY_WEEK, 1, 1, nil)
+	require.NoError(t, err)
+	otherDeviceHistory, err := timeSeries.GetSmartAttributeHistory(ctx, uuid.Must(uuid.NewV4()), DURATION_KEY_WEEK, 0, 0, nil)
+	require.NoError(t, err)
+
+	//assert
+	// only the last entry per day is returned, newest first
+	require.Len(t, weekHistory, 2)
+	require.Equal(t, expected.Date.Unix(), weekHistory[0].Date.Unix())
+	require.Equal(t, expected.ScrutinyUUID, weekHistory[0].ScrutinyUUID)
+	require.Equal(t, expected.DeviceProtocol, weekHistory[0].DeviceProtocol)
+	require.Equal(t, expected.Temp, weekHistory[0].Temp)
+	require.Equal(t, expected.PowerOnHours, weekHistory[0].PowerOnHours)
+	require.Equal(t, expected.PowerCycleCount, weekHistory[0].PowerCycleCount)
+	require.Equal(t, expected.Attributes, weekHistory[0].Attributes)
+	require.Equal(t, "NVMe", weekHistory[1].DeviceProtocol)
+
+	require.Len(t, offsetHistory, 1)
+	require.Equal(t, weekHistory[1].Date, offsetHistory[0].Date)
+	require.Empty(t, otherDeviceHistory)
+}
+
+func Test_SqliteTimeSeries_TemperatureHistory(t *testing.T) {
+	//setup
+	timeSeries := newTestSqliteTimeSeriesRepository(t)
+	ctx := context.Background()
+	scrutinyUUID := uuid.Must(uuid.NewV4())
+	windowStart := time.Unix(time.Now().Add(-2*time.Hour).Unix(), 0).Truncate(10 * time.Minute)
+
+	require.NoError(t, timeSeries.WriteTemperatures(ctx, scrutinyUUID, []measurements.SmartTemperature{
+		{Date: windowStart.Add(5 * time.Minute), Temp: 41},
+		{Date: windowStart.Add(1 * time.Minute), Temp: 30},
+		{Date: windowStart.Add(12 * time.Minute), Temp: 45},
+	}))
+
+	//test
+	tempHistory, err := timeSeries.GetSmartTemperatureHistory(ctx, DURATION_KEY_DAY)
+
+	//assert
+	require.NoError(t, err)
+	require.Equal(t, map[uuid.UUID][]measurements.SmartTemperature{
+		scrutinyUUID: {
+			{Date: windowStart, Temp: 35},
+			{Date: windowStart.Add(10 * time.Minute), Temp: 45},
+		},
+	}, tempHistory)
+}
+
+func Test_SqliteTimeSeries_SelfTestHistory(t *testing.T) {
+	//setup
+	timeSeries := newTestSqliteTimeSeriesRepository(t)
+	ctx := context.Background()
+	scrutinyUUID := uuid.Must(uuid.NewV4())
+	now := time.Unix(time.Now().Unix(), 0)
+
+	olderSelfTest := measurements.SmartSelfTest{Date: now.Add(-48 * time.Hour), TestType: "Short offline", TestTypeValue: 1, Status: "Completed without error", Passed: true, LifetimeHours: 100}
+	newerSelfTest := measurements.SmartSelfTest{Date: now.Add(-1 * time.Hour), TestType: "Extended offline", TestTypeValue: 2, Status: "Completed: read failure", StatusValue: 7, Passed: false, LifetimeHours: 147}
+	require.NoError(t, timeSeries.WriteSelfTest(ctx, scrutinyUUID, olderSelfTest))
+	require.NoError(t, timeSeries.WriteSelfTest(ctx, scrutinyUUID, newerSelfTest))
+
+	//test
+	selfTests, err := timeSeries.GetSmartSelfTestHistory(ctx, scrutinyUUID, DURATION_KEY_WEEK)
+
+	//assert
+	require.NoError(t, err)
+	require.Equal(t, []measurements.SmartSelfTest{newerSelfTest, olderSelfTest}, selfTests)
+}
+
+func Test_SqliteTimeSeries_LatestSmartSummariesAndDelete(t *testing.T) {
+	//setup
+	timeSeries := newTestSqliteTimeSeriesRepository(t)
+	ctx := context.Background()
+	scrutinyUUID := uuid.Must(uuid.NewV4())
+	otherUUID := uuid.Must(uuid.NewV4())
+	now := time.Unix(time.Now().Unix(), 0)
+
+	older := newTestSmart(t, "../models/testdata/smart-ata.json", scrutinyUUID, now.Add(-2*time.Hour))
+	older.Temp = 20
+	newer := newTestSmart(t, "../models/testdata/smart-ata.json", scrutinyUUID, now.Add(-1*time.Hour))
+	newer.Temp = 30
+	newer.PowerOnHours = 1234
+	require.NoError(t, timeSeries.WriteSmart(ctx, newer))
+	require.NoError(t, timeSeries.WriteSmart(ctx, older))
+	require.NoError(t, timeSeries.WriteSmart(ctx, newTestSmart(t, "../models/testdata/smart-nvme.json", otherUUID, now)))
+	require.NoError(t, timeSeries.WriteTemperatures(ctx, scrutinyUUID, []measurements.SmartTemperature{{Date: now, Temp: 30}}))
+
+	//test
+	summaries, err := timeSeries.GetLatestSmartSummaries(ctx)
+	require.NoError(t, err)
+	deleteErr := timeSeries.DeleteDeviceData(ctx, scrutinyUUID)
+	summariesAfterDelete, err := timeSeries.GetLatestSmartSummaries(ctx)
+	require.NoError(t, err)
+	tempHistoryAfterDelete, err := timeSeries.GetSmartTemperatureHistory(ctx, DURATION_KEY_WEEK)
+	require.NoError(t, err)
+
+	//assert
+	require.Len(t, summaries, 2)
+	require.Equal(t, int64(30), summaries[scrutinyUUID].Temp)
+	require.Equal(t, int64(1234), summaries[scrutinyUUID].PowerOnHours)
+	require.Equal(t, newer.Date, summaries[scrutinyUUID].CollectorDate)
+
+	require.NoError(t, deleteErr)
+	require.Len(t, summariesAfterDelete, 1)
+	require.Contains(t, summariesAfterDelete, otherUUID)
+	require.NotContains(t, tempHistoryAfterDelete, scrutinyUUID)
+}
+
+func Test_SqliteTimeSeries_LatestSmartAttributes(t *testing.T) {
+	//setup
+	timeSeries := newTestSqliteTimeSeriesRepository(t)
+	ctx := context.Background()
+	scrutinyUUID := uuid.Must(uuid.NewV4())
+	otherUUID := uuid.Must(uuid.NewV4())
+	now := time.Unix(time.Now().Unix(), 0)
+
+	older := newTestSmart(t, "../models/testdata/smart-ata.json", scrutinyUUID, now.Add(-2*time.Hour))
+	older.Temp = 20
+	newer := newTestSmart(t, "../models/testdata/smart-ata.json", scrutinyUUID, now.Add(-1*time.Hour))
+	newer.Temp = 30
+	require.NoError(t, timeSeries.WriteSmart(ctx, newer))
+	require.NoError(t, timeSeries.WriteSmart(ctx, older))
+	require.NoError(t, timeSeries.WriteSmart(ctx, newTestSmart(t, "../models/testdata/smart-nvme.json", otherUUID, now)))
+	// the down-sampled copy of the newest point has the same timestamp
+	require.NoError(t, timeSeries.gormClient.Exec("INSERT INTO timeseries_smart (scrutiny_uuid, bucket, timestamp, device_protocol, temp, power_on_hours, power_cycle_count, attributes) SELECT scrutiny_uuid, ?, timestamp, device_protocol, temp, power_on_hours, power_cycle_count, attributes FROM timeseries_smart WHERE scrutiny_uuid = ? AND timestamp = ?", sqliteBucketWeekly, scrutinyUUID.String(), newer.Date.Unix()).Error)
+
+	//test
+	latestSmart, err := timeSeries.GetLatestSmartAttributes(ctx)
+
+	//assert
+	require.NoError(t, err)
+	require.Len(t, latestSmart, 2)
+	require.Equal(t, newer.Date.Unix(), latestSmart[scrutinyUUID].Date.Unix())
+	require.Equal(t, int64(30), latestSmart[scrutinyUUID].Temp)
+	require.Equal(t, newer.Attributes, latestSmart[scrutinyUUID].Attributes)
+	require.Equal(t, "NVMe", latestSmart[otherUUID].DeviceProtocol)
+}
+
+func Test_SqliteTimeSeries_Downsample(t *testing.T) {
+	//setup
+	timeSeries := newTestSqliteTimeSeriesRepository(t)
+	ctx := context.Background()
+	scrutinyUUID := uuid.Must(uuid.NewV4())
+	now := time.Date(2026, time.March, 18, 12, 0, 0, 0, time.UTC)
+
+	// one point per day for the last 90 days
+	for day := 1; day <= 90; day++ {
+		date := now.AddDate(0, 0, -day)
+		smart := newTestSmart(t, "../models/testdata/smart-ata.json", scrutinyUUID, date)
+		smart.PowerOnHours = int64(day)
+		require.NoError(t, timeSeries.WriteSmart(ctx, smart))
+		require.NoError(t, timeSeries.WriteTemperatures(ctx, scrutinyUUID, []measurements.SmartTemperature{{Date: date, Temp: int64(day)}}))
+		require.NoError(t, timeSeries.WriteSelfTest(ctx, scrutinyUUID, measurements.SmartSelfTest{Date: date, TestType: "Short offline", LifetimeHours: int64(day)}))
+	}
+
+	countPoints := func(table interface{}, bucket string) int64 {
+		var count int64
+		require.NoError(t, timeSeries.gormClient.Model(table).Where("bucket = ?", bucket).Count(&count).Error)
+		return count
+	}
+
+	//test
+	require.NoError(t, timeSeries.Downsample(ctx, now))
+	weeklySmart := []sqliteSmartPoint{}
+	require.NoError(t, timeSeries.gormClient.Where("bucket = ?", sqliteBucketWeekly).Order("timestamp").Find(&weeklySmart).Error)
+	weeklyTemps := []sqliteTemperaturePoint{}
+	require.NoError(t, timeSeries.gormClient.Where("bucket = ?", sqliteBucketWeekly).Order("timestamp").Find(&weeklyTemps).Error)
+
+	// running again must not create duplicate points
+	require.NoError(t, timeSeries.Downsample(ctx, now))
+
+	//assert
+	// the last week is still in progress (the 2026-03-12 epoch-aligned week), the oldest 9 weeks have expired.
+	currentWeekStart, _ := sqliteWeeklyWindow(now)
+	require.Equal(t, int64(9), countPoints(&sqliteSmartPoint{}, sqliteBucketWeekly))
+	require.Len(t, weeklySmart, 9)
+	for _, point := range weeklySmart {
+		require.Less(t, point.Timestamp, currentWeekStart.Unix())
+		require.GreaterOrEqual(t, point.Timestamp, now.Add(-RETENTION_PERIOD_9_WEEKS_IN_SECONDS*time.Second).Unix())
+	}
+	// the last point of each week is kept
+	lastWeekPoint := weeklySmart[len(weeklySmart)-1]
+	require.Equal(t, currentWeekStart.AddDate(0, 0, -1).Add(12*time.Hour).Unix(), lastWeekPoint.Timestamp)
+	require.Equal(t, int64(7), lastWeekPoint.PowerOnHours)
+
+	// the mean temperature of each week is kept, using the window start as the timestamp (so the oldest week has expired)
+	require.Len(t, weeklyTemps, 8)
+	require.Equal(t, currentWeekStart.AddDate(0, 0, -7).Unix(), weeklyTemps[len(weeklyTemps)-1].Timestamp)
+	require.Equal(t, int64(10), weeklyTemps[len(weeklyTemps)-1].Temp)
+
+	// self tests are copied, the completed weeks contain days 7 to 63 (9 week retention)
+	require.Equal(t, int64(57), countPoints(&sqliteSelfTestPoint{}, sqliteBucketWeekly))
+
+	// raw data is only kept for 15 days
+	require.Equal(t, int64(15), countPoints(&sqliteSmartPoint{}, sqliteBucketRaw))
+	require.Equal(t, int64(15), countPoints(&sqliteTemperaturePoint{}, sqliteBucketRaw))
+
+	// December, January & February are complete (the weekly points are down-sampled before the retention period is applied)
+	require.Equal(t, int64(3), countPoints(&sqliteSmartPoint{}, sqliteBucketMonthly))
+	// 2025 is complete, and only contains the December point
+	require.Equal(t, int64(1), countPoints(&sqliteSmartPoint{}, sqliteBucketYearly))
+}
+
+func Test_GetAnalysisSmartHistory(t *testing.T) {
+	//setup
+	timeSeries := newTestSqliteTimeSeriesRepository(t)
+	deviceRepo := &scrutinyRepository{logger: timeSeries.logger, gormClient: timeSeries.gormClient, timeSeries: timeSeries}
+	ctx := context.Background()
+	scrutinyUUID := uuid.Must(uuid.NewV4())
+	now := time.Unix(time.Now().Unix(), 0)
+	require.NoError(t, timeSeries.WriteSmart(ctx, newTestSmart(t, "../models/testdata/smart-ata.json", scrutinyUUID, now.Add(-100*time.Hour))))
+	require.NoError(t, timeSeries.WriteSmart(ctx, newTestSmart(t, "../models/testdata/smart-ata.json", scrutinyUUID, now.Add(-50*time.Hour))))
+	latest := newTestSmart(t, "../models/testdata/smart-ata.json", scrutinyUUID, now)
+	require.NoError(t, timeSeries.WriteSmart(ctx, latest))
+
+	//test
+	history, err := deviceRepo.GetAnalysisSmartHistory(ctx, scrutinyUUID, latest)
+	require.NoError(t, err)
+	// written after the history was cached, only the latest smart data passed in is added
+	require.NoError(t, timeSeries.WriteSmart(ctx, newTestSmart(t, "../models/testdata/smart-ata.json", scrutinyUUID, now.Add(time.Minute))))
+	nextLatest := newTestSmart(t, "../models/testdata/smart-ata.json", scrutinyUUID, now.Add(time.Hour))
+	cachedHistory, err := deviceRepo.GetAnalysisSmartHistory(ctx, scrutinyUUID, nextLatest)
+	require.NoError(t, err)
+
+	//assert
+	require.Len(t, history, 3)
+	require.Equal(t, now, history[0].Date)
+	require.Len(t, cachedHistory, 4)
+	require.Equal(t, now.Add(time.Hour), cachedHistory[0].Date)
+	require.Equal(t, now, cachedHistory[1].Date)
+}
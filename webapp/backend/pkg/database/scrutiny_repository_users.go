@@ -0,0 +1,157 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/analogj/scrutiny/webapp/backend/pkg"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/models"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Users & Sessions
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+const userSessionTokenPrefix = "session_"
+const userPasswordMinLength = 8
+
+// bcrypt hash of a random password, used to prevent username enumeration using response times.
+const dummyPasswordHash = "$2a$10$NBApZYqMTVXkTntDamh/POG8u85jcZFFNd.GPMDkjynhfTASkgc0u"
+
+func (sr *scrutinyRepository) CreateUser(ctx context.Context, username string, password string, role pkg.UserRole) (models.User, error) {
+	username = strings.TrimSpace(username)
+	if len(username) == 0 {
+		return models.User{}, fmt.Errorf("username is required")
+	}
+	if len(password) < userPasswordMinLength {
+		return models.User{}, fmt.Errorf("password must be at least %d characters", userPasswordMinLength)
+	}
+	if role != pkg.UserRoleAdmin && role != pkg.UserRoleReadOnly {
+		return models.User{}, fmt.Errorf("invalid role %q", role)
+	}
+
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	user := models.User{
+		Username:     username,
+		PasswordHash: string(passwordHash),
+		Role:         role,
+	}
+	if err := sr.gormClient.WithContext(ctx).Create(&user).Error; err != nil {
+		return models.User{}, fmt.Errorf("could not create user %s: %w", username, err)
+	}
+	return user, nil
+}
+
+func (sr *scrutinyRepository) GetUsers(ctx context.Context) ([]models.User, error) {
+	users := []models.User{}
+	if err := sr.gormClient.WithContext(ctx).Order("username").Find(&users).Error; err != nil {
+		return nil, fmt.Errorf("could not get users from DB: %w", err)
+	}
+	return users, nil
+}
+
+// DeleteUser deletes the user, and logs out all of their sessions.
+func (sr *scrutinyRepository) DeleteUser(ctx context.Context, id uint) error {
+	return sr.gormClient.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Unscoped().Delete(&models.User{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("user %d not found", id)
+		}
+		return tx.Unscoped().Where("user_id = ?", id).Delete(&models.UserSession{}).Error
+	})
+}
+
+// AuthenticateUser returns the user matching the username & password.
+func (sr *scrutinyRepository) AuthenticateUser(ctx context.Context, username string, password string) (models.User, error) {
+	var user models.User
+	err := sr.gormClient.WithContext(ctx).Where("username = ?", strings.TrimSpace(username)).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// compare against a dummy hash, so that unknown usernames take as long as invalid passwords.
+		_ = bcrypt.CompareHashAndPassword([]byte(dummyPasswordHash), []byte(password))
+		return models.User{}, fmt.Errorf("invalid username or password")
+	} else if err != nil {
+		return models.User{}, err
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return models.User{}, fmt.Errorf("invalid username or password")
+	}
+	return user, nil
+}
+
+// CreateUserSession creates a session for the user, and returns the session token. Only a hash of the token is stored.
+func (sr *scrutinyRepository) CreateUserSession(ctx context.Context, userId uint, ttl time.Duration) (string, error) {
+	tokenValue, err := generateToken(userSessionTokenPrefix)
+	if err != nil {
+		return "", err
+	}
+
+	// expired sessions are cleaned up whenever a user logs in.
+	if err := sr.gormClient.WithContext(ctx).Unscoped().Where("expires_at < ?", time.Now()).Delete(&models.UserSession{}).Error; err != nil {
+		return "", err
+	}
+
+	session := models.UserSession{
+		UserID:    userId,
+		TokenHash: hashToken(tokenValue),
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	if err := sr.gormClient.WithContext(ctx).Create(&session).Error; err != nil {
+		return "", fmt.Errorf("could not create user session: %w", err)
+	}
+	return tokenValue, nil
+}
+
+// GetSessionUser returns the user that owns the session token, if the session has not expired.
+func (sr *scrutinyRepository) GetSessionUser(ctx context.Context, tokenValue string) (models.User, error) {
+	var session models.UserSession
+	if err := sr.gormClient.WithContext(ctx).Where("token_hash = ? AND expires_at > ?", hashToken(tokenValue), time.Now()).First(&session).Error; err != nil {
+		return models.User{}, fmt.Errorf("invalid session: %w", err)
+	}
+
+	var user models.User
+	if err := sr.gormClient.WithContext(ctx).First(&user, session.UserID).Error; err != nil {
+		return models.User{}, fmt.Errorf("invalid session: %w", err)
+	}
+	return user, nil
+}
+
+func (sr *scrutinyRepository) DeleteUserSession(ctx context.Context, tokenValue string) error {
+	return sr.gormClient.WithContext(ctx).Unscoped().Where("token_hash = ?", hashToken(tokenValue)).Delete(&models.UserSession{}).Error
+}
+
+// EnsureAdminUser creates the initial admin user from `web.auth.admin.username` & `web.auth.admin.password`, when
+// authentication is enabled and no users exist yet.
+func (sr *scrutinyRepository) EnsureAdminUser(ctx context.Context) error {
+	if !sr.appConfig.GetBool("web.auth.enabled") {
+		return nil
+	}
+
+	var userCount int64
+	if err := sr.gormClient.WithContext(ctx).Model(&models.User{}).Count(&userCount).Error; err != nil {
+		return err
+	}
+	if userCount > 0 {
+		return nil
+	}
+
+	if len(sr.appConfig.GetString("web.auth.admin.password")) == 0 {
+		sr.logger.Warnln("Authentication is enabled, but no users exist. Set `web.auth.admin.password` to create the initial admin user")
+		return nil
+	}
+	sr.logger.Infof("Creating initial admin user: %s", sr.appConfig.GetString("web.auth.admin.username"))
+	_, err := sr.CreateUser(ctx, sr.appConfig.GetString("web.auth.admin.username"), sr.appConfig.GetString("web.auth.admin.password"), pkg.UserRoleAdmin)
+	return err
+}
@@ -0,0 +1,121 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/analogj/scrutiny/webapp/backend/pkg"
+	mock_config "github.com/analogj/scrutiny/webapp/backend/pkg/config/mock"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/models"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func Test_AuthenticateUser(t *testing.T) {
+	//setup
+	deviceRepo := newTestSqliteRepository(t, &models.User{}, &models.UserSession{})
+	ctx := context.Background()
+	_, err := deviceRepo.CreateUser(ctx, "operator", "correct horse battery", pkg.UserRoleReadOnly)
+	require.NoError(t, err)
+
+	//test
+	user, validErr := deviceRepo.AuthenticateUser(ctx, "operator", "correct horse battery")
+	_, invalidPasswordErr := deviceRepo.AuthenticateUser(ctx, "operator", "incorrect")
+	_, unknownUserErr := deviceRepo.AuthenticateUser(ctx, "unknown", "correct horse battery")
+
+	//assert
+	require.NoError(t, validErr)
+	require.Equal(t, "operator", user.Username)
+	require.Equal(t, pkg.UserRoleReadOnly, user.Role)
+	require.NotContains(t, user.PasswordHash, "correct horse battery")
+	require.Error(t, invalidPasswordErr)
+	require.Error(t, unknownUserErr)
+}
+
+func Test_CreateUser_Invalid(t *testing.T) {
+	//setup
+	deviceRepo := newTestSqliteRepository(t, &models.User{}, &models.UserSession{})
+	ctx := context.Background()
+
+	//test
+	_, shortPasswordErr := deviceRepo.CreateUser(ctx, "operator", "short", pkg.UserRoleAdmin)
+	_, invalidRoleErr := deviceRepo.CreateUser(ctx, "operator", "correct horse battery", pkg.UserRole("superuser"))
+	_, err := deviceRepo.CreateUser(ctx, "operator", "correct horse battery", pkg.UserRoleAdmin)
+	require.NoError(t, err)
+	_, duplicateErr := deviceRepo.CreateUser(ctx, "operator", "correct horse battery", pkg.UserRoleAdmin)
+
+	//assert
+	require.Error(t, shortPasswordErr)
+	require.Error(t, invalidRoleErr)
+	require.Error(t, duplicateErr)
+}
+
+func Test_UserSessions(t *testing.T) {
+	//setup
+	deviceRepo := newTestSqliteRepository(t, &models.User{}, &models.UserSession{})
+	ctx := context.Background()
+	user, err := deviceRepo.CreateUser(ctx, "operator", "correct horse battery", pkg.UserRoleAdmin)
+	require.NoError(t, err)
+	expiredToken, err := deviceRepo.CreateUserSession(ctx, user.ID, -time.Minute)
+	require.NoError(t, err)
+	sessionToken, err := deviceRepo.CreateUserSession(ctx, user.ID, time.Hour)
+	require.NoError(t, err)
+
+	//test
+	sessionUser, sessionErr := deviceRepo.GetSessionUser(ctx, sessionToken)
+	_, expiredErr := deviceRepo.GetSessionUser(ctx, expiredToken)
+	require.NoError(t, deviceRepo.DeleteUserSession(ctx, sessionToken))
+	_, loggedOutErr := deviceRepo.GetSessionUser(ctx, sessionToken)
+
+	//assert
+	require.NoError(t, sessionErr)
+	require.Equal(t, user.ID, sessionUser.ID)
+	require.Error(t, expiredErr)
+	require.Error(t, loggedOutErr)
+}
+
+func Test_DeleteUser_DeletesSessions(t *testing.T) {
+	//setup
+	deviceRepo := newTestSqliteRepository(t, &models.User{}, &models.UserSession{})
+	ctx := context.Background()
+	user, err := deviceRepo.CreateUser(ctx, "operator", "correct horse battery", pkg.UserRoleAdmin)
+	require.NoError(t, err)
+	sessionToken, err := deviceRepo.CreateUserSession(ctx, user.ID, time.Hour)
+	require.NoError(t, err)
+
+	//test
+	err = deviceRepo.DeleteUser(ctx, user.ID)
+
+	//assert
+	require.NoError(t, err)
+	_, sessionErr := deviceRepo.GetSessionUser(ctx, sessionToken)
+	require.Error(t, sessionErr)
+	var sessionCount int64
+	require.NoError(t, deviceRepo.gormClient.Model(&models.UserSession{}).Count(&sessionCount).Error)
+	require.Zero(t, sessionCount)
+	require.Error(t, deviceRepo.DeleteUser(ctx, user.ID))
+}
+
+func Test_EnsureAdminUser(t *testing.T) {
+	//setup
+	mockCtrl := gomock.NewController(t)
+	fakeConfig := mock_config.NewMockInterface(mockCtrl)
+	fakeConfig.EXPECT().GetBool("web.auth.enabled").Return(true).AnyTimes()
+	fakeConfig.EXPECT().GetString("web.auth.admin.username").Return("admin").AnyTimes()
+	fakeConfig.EXPECT().GetString("web.auth.admin.password").Return("correct horse battery").AnyTimes()
+	deviceRepo := newTestSqliteRepository(t, &models.User{}, &models.UserSession{})
+	deviceRepo.appConfig = fakeConfig
+	ctx := context.Background()
+
+	//test
+	require.NoError(t, deviceRepo.EnsureAdminUser(ctx))
+	require.NoError(t, deviceRepo.EnsureAdminUser(ctx))
+
+	//assert
+	users, err := deviceRepo.GetUsers(ctx)
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	require.Equal(t, "admin", users[0].Username)
+	require.True(t, users[0].IsAdmin())
+}
@@ -0,0 +1,80 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/analogj/scrutiny/webapp/backend/pkg/models"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Warranty Rules
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func (sr *scrutinyRepository) GetWarrantyRules(ctx context.Context) ([]models.WarrantyRule, error) {
+	rules := []models.WarrantyRule{}
+	if err := sr.gormClient.WithContext(ctx).Order("id").Find(&rules).Error; err != nil {
+		return nil, fmt.Errorf("could not get warranty rules from DB: %w", err)
+	}
+	return rules, nil
+}
+
+func (sr *scrutinyRepository) CreateWarrantyRule(ctx context.Context, rule models.WarrantyRule) (models.WarrantyRule, error) {
+	rule.ID = 0
+	if err := validateWarrantyRule(&rule); err != nil {
+		return models.WarrantyRule{}, err
+	}
+	if err := sr.gormClient.WithContext(ctx).Create(&rule).Error; err != nil {
+		return models.WarrantyRule{}, fmt.Errorf("could not create warranty rule: %w", err)
+	}
+	return rule, nil
+}
+
+func (sr *scrutinyRepository) DeleteWarrantyRule(ctx context.Context, id uint) error {
+	result := sr.gormClient.WithContext(ctx).Unscoped().Delete(&models.WarrantyRule{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("warranty rule %d not found", id)
+	}
+	return nil
+}
+
+// GetWarrantyRule returns the rules matching the device model merged together (see models.MatchWarrantyRules), or nil
+// if no rule matches.
+func (sr *scrutinyRepository) GetWarrantyRule(ctx context.Context, device models.Device) (*models.WarrantyRule, error) {
+	rules, err := sr.GetWarrantyRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return models.MatchWarrantyRules(rules, device), nil
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Helper Methods
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func validateWarrantyRule(rule *models.WarrantyRule) error {
+	rule.ModelRegex = strings.TrimSpace(rule.ModelRegex)
+	rule.Description = strings.TrimSpace(rule.Description)
+
+	if len(rule.ModelRegex) == 0 {
+		return fmt.Errorf("model_regex is required")
+	}
+	if _, err := regexp.Compile(rule.ModelRegex); err != nil {
+		return fmt.Errorf("invalid model_regex: %w", err)
+	}
+	if rule.WarrantyYears < 0 || rule.RatedTbw < 0 || rule.RatedDwpd < 0 || rule.Attribute241UnitBytes < 0 {
+		return fmt.Errorf("warranty_years, rated_tbw, rated_dwpd and attribute_241_unit_bytes must not be negative")
+	}
+	if rule.WarrantyYears == 0 && rule.RatedTbw == 0 && rule.RatedDwpd == 0 && rule.Attribute241UnitBytes == 0 {
+		return fmt.Errorf("at least one of warranty_years, rated_tbw, rated_dwpd or attribute_241_unit_bytes is required")
+	}
+	if rule.RatedDwpd > 0 && rule.WarrantyYears == 0 {
+		return fmt.Errorf("rated_dwpd requires warranty_years")
+	}
+	return nil
+}
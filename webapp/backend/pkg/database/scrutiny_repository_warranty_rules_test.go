@@ -0,0 +1,77 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/analogj/scrutiny/webapp/backend/pkg/models"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_WarrantyRules(t *testing.T) {
+	//setup
+	deviceRepo := newTestSqliteRepository(t, &models.WarrantyRule{})
+	ctx := context.Background()
+	device := models.Device{ModelName: "Samsung SSD 870 EVO 1TB"}
+
+	//test
+	genericRule, genericErr := deviceRepo.CreateWarrantyRule(ctx, models.WarrantyRule{ModelRegex: " ^Samsung SSD ", WarrantyYears: 3})
+	specificRule, specificErr := deviceRepo.CreateWarrantyRule(ctx, models.WarrantyRule{ModelRegex: "870 EVO", WarrantyYears: 5, RatedTbw: 600})
+	unitRule, unitErr := deviceRepo.CreateWarrantyRule(ctx, models.WarrantyRule{ModelRegex: "870 EVO", Attribute241UnitBytes: 512})
+	_, unmatchedErr := deviceRepo.CreateWarrantyRule(ctx, models.WarrantyRule{ModelRegex: "^WDC", WarrantyYears: 3})
+	matchedRule, matchErr := deviceRepo.GetWarrantyRule(ctx, device)
+	require.NoError(t, deviceRepo.DeleteWarrantyRule(ctx, unitRule.ID))
+	deleteErr := deviceRepo.DeleteWarrantyRule(ctx, specificRule.ID)
+	fallbackRule, fallbackErr := deviceRepo.GetWarrantyRule(ctx, device)
+	missingDeleteErr := deviceRepo.DeleteWarrantyRule(ctx, specificRule.ID)
+	noRule, noRuleErr := deviceRepo.GetWarrantyRule(ctx, models.Device{ModelName: "ST4000VN008"})
+
+	//assert
+	require.NoError(t, genericErr)
+	require.Equal(t, "^Samsung SSD", genericRule.ModelRegex)
+	require.NoError(t, specificErr)
+	require.NoError(t, unitErr)
+	require.NoError(t, unmatchedErr)
+
+	// each term is taken from the newest matching rule which sets it
+	require.NoError(t, matchErr)
+	require.Equal(t, unitRule.ID, matchedRule.ID)
+	require.Equal(t, 5.0, matchedRule.WarrantyYears)
+	require.Equal(t, 600.0, matchedRule.RatedTbw)
+	require.Equal(t, int64(512), matchedRule.Attribute241UnitBytes)
+
+	require.NoError(t, deleteErr)
+	require.NoError(t, fallbackErr)
+	require.Equal(t, genericRule.ID, fallbackRule.ID)
+	require.Error(t, missingDeleteErr)
+
+	require.NoError(t, noRuleErr)
+	require.Nil(t, noRule)
+}
+
+func Test_CreateWarrantyRule_Invalid(t *testing.T) {
+	//setup
+	deviceRepo := newTestSqliteRepository(t, &models.WarrantyRule{})
+	ctx := context.Background()
+	invalidRules := []models.WarrantyRule{
+		// missing model regex
+		{WarrantyYears: 5},
+		// invalid model regex
+		{ModelRegex: "WDC(", WarrantyYears: 5},
+		// missing terms
+		{ModelRegex: "^WDC"},
+		// negative terms
+		{ModelRegex: "^WDC", WarrantyYears: -1},
+		{ModelRegex: "^WDC", WarrantyYears: 5, Attribute241UnitBytes: -512},
+		// dwpd requires the warranty duration
+		{ModelRegex: "^WDC", RatedDwpd: 0.3},
+	}
+
+	for _, invalidRule := range invalidRules {
+		//test
+		_, err := deviceRepo.CreateWarrantyRule(ctx, invalidRule)
+
+		//assert
+		require.Error(t, err, invalidRule)
+	}
+}
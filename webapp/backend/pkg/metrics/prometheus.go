@@ -0,0 +1,143 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/analogj/scrutiny/webapp/backend/pkg/models"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/models/measurements"
+)
+
+// PrometheusContentType is the content type of the Prometheus text exposition format
+const PrometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DeviceMetrics contains a device, and the latest smart data reported for it (if any).
+type DeviceMetrics struct {
+	Device models.Device
+	Smart  *measurements.Smart
+}
+
+type label struct {
+	name  string
+	value string
+}
+
+type sample struct {
+	labels []label
+	value  float64
+}
+
+type metricFamily struct {
+	name    string
+	help    string
+	samples []sample
+}
+
+func (mf *metricFamily) add(labels []label, value float64) {
+	mf.samples = append(mf.samples, sample{labels: labels, value: value})
+}
+
+// WritePrometheus writes the device status, and the latest smart metrics & attributes for each device, using the
+// Prometheus text exposition format. All metrics are gauges.
+func WritePrometheus(w io.Writer, devices []DeviceMetrics) error {
+	deviceStatus := &metricFamily{name: "scrutiny_device_status", help: "Device status bitfield (0: passed, 1: failed smart, 2: failed scrutiny, 4: warning scrutiny)"}
+	temperature := &metricFamily{name: "scrutiny_device_temperature_celsius", help: "Device temperature in celsius"}
+	powerOnHours := &metricFamily{name: "scrutiny_device_power_on_hours", help: "Device power on hours"}
+	powerCycleCount := &metricFamily{name: "scrutiny_device_power_cycle_count", help: "Device power cycle count"}
+	smartTimestamp := &metricFamily{name: "scrutiny_device_smart_timestamp_seconds", help: "Unix timestamp of the latest smart data for the device"}
+	attributeValue := &metricFamily{name: "scrutiny_smart_attribute_value", help: "Smart attribute normalized value (ATA), or reported value (NVMe, SCSI)"}
+	attributeRawValue := &metricFamily{name: "scrutiny_smart_attribute_raw_value", help: "Smart attribute raw value (ATA), or reported value (NVMe, SCSI)"}
+	attributeTransformedValue := &metricFamily{name: "scrutiny_smart_attribute_transformed_value", help: "Smart attribute value, transformed by Scrutiny (eg. temperature without min/max)"}
+	attributeStatus := &metricFamily{name: "scrutiny_smart_attribute_status", help: "Smart attribute status bitfield (0: passed, 1: failed smart, 2: warning scrutiny, 4: failed scrutiny)"}
+
+	for _, deviceMetrics := range devices {
+		device := deviceMetrics.Device
+		deviceLabels := []label{
+			{"scrutiny_uuid", device.ScrutinyUUID.String()},
+			{"host_id", device.HostId},
+			{"model", device.ModelName},
+			{"serial", device.SerialNumber},
+			{"protocol", device.DeviceProtocol},
+		}
+		deviceStatus.add(deviceLabels, float64(device.DeviceStatus))
+
+		smart := deviceMetrics.Smart
+		if smart == nil {
+			continue
+		}
+		temperature.add(deviceLabels, float64(smart.Temp))
+		powerOnHours.add(deviceLabels, float64(smart.PowerOnHours))
+		powerCycleCount.add(deviceLabels, float64(smart.PowerCycleCount))
+		smartTimestamp.add(deviceLabels, float64(smart.Date.Unix()))
+
+		attributeIds := make([]string, 0, len(smart.Attributes))
+		for attributeId := range smart.Attributes {
+			attributeIds = append(attributeIds, attributeId)
+		}
+		sort.Strings(attributeIds)
+
+		for _, attributeId := range attributeIds {
+			attributeLabels := append(append([]label{}, deviceLabels...), label{"attribute_id", attributeId})
+			attribute := smart.Attributes[attributeId]
+
+			switch attr := attribute.(type) {
+			case *measurements.SmartAtaAttribute:
+				attributeValue.add(attributeLabels, float64(attr.Value))
+				attributeRawValue.add(attributeLabels, float64(attr.RawValue))
+			case *measurements.SmartNvmeAttribute:
+				// NVMe & SCSI attributes only have a single (raw) value, exported as both the value and the raw value
+				attributeValue.add(attributeLabels, float64(attr.Value))
+				attributeRawValue.add(attributeLabels, float64(attr.Value))
+			case *measurements.SmartScsiAttribute:
+				attributeValue.add(attributeLabels, float64(attr.Value))
+				attributeRawValue.add(attributeLabels, float64(attr.Value))
+			}
+			attributeTransformedValue.add(attributeLabels, float64(attribute.GetTransformedValue()))
+			attributeStatus.add(attributeLabels, float64(attribute.GetStatus()))
+		}
+	}
+
+	for _, family := range []*metricFamily{
+		deviceStatus,
+		temperature,
+		powerOnHours,
+		powerCycleCount,
+		smartTimestamp,
+		attributeValue,
+		attributeRawValue,
+		attributeTransformedValue,
+		attributeStatus,
+	} {
+		if err := writeMetricFamily(w, family); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeMetricFamily(w io.Writer, family *metricFamily) error {
+	if len(family.samples) == 0 {
+		return nil
+	}
+	if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", family.name, family.help, family.name); err != nil {
+		return err
+	}
+	for _, s := range family.samples {
+		labelPairs := make([]string, len(s.labels))
+		for ndx, l := range s.labels {
+			labelPairs[ndx] = fmt.Sprintf(`%s="%s"`, l.name, escapeLabelValue(l.value))
+		}
+		if _, err := fmt.Fprintf(w, "%s{%s} %s\n", family.name, strings.Join(labelPairs, ","), strconv.FormatFloat(s.value, 'f', -1, 64)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// escapeLabelValue escapes backslashes, double-quotes and line feeds, as required by the text exposition format.
+func escapeLabelValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
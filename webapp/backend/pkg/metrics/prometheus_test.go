@@ -0,0 +1,74 @@
+package metrics_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/analogj/scrutiny/webapp/backend/pkg"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/metrics"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/models"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/models/measurements"
+	"github.com/gofrs/uuid/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWritePrometheus(t *testing.T) {
+	//setup
+	scrutinyUuid := uuid.Must(uuid.FromString("7c1a4d2e-8f3b-5a6c-9d0e-1f2a3b4c5d6e"))
+	devices := []metrics.DeviceMetrics{
+		{
+			Device: models.Device{
+				ScrutinyUUID:   scrutinyUuid,
+				HostId:         "nas01",
+				ModelName:      `WDC "Red"`,
+				SerialNumber:   "WD-123",
+				DeviceProtocol: pkg.DeviceProtocolAta,
+				DeviceStatus:   pkg.DeviceStatusFailedScrutiny,
+			},
+			Smart: &measurements.Smart{
+				Date:            time.Unix(1700000000, 0),
+				Temp:            34,
+				PowerOnHours:    12000,
+				PowerCycleCount: 42,
+				Attributes: map[string]measurements.SmartAttribute{
+					"5":   &measurements.SmartAtaAttribute{AttributeId: 5, Value: 100, RawValue: 8, TransformedValue: 8, Status: pkg.AttributeStatusFailedScrutiny},
+					"194": &measurements.SmartAtaAttribute{AttributeId: 194, Value: 34, RawValue: 171801837602, TransformedValue: 34},
+				},
+			},
+		},
+		{
+			Device: models.Device{ScrutinyUUID: uuid.Must(uuid.FromString("2b3c4d5e-6f70-5182-93a4-b5c6d7e8f901")), HostId: "nas01", DeviceProtocol: pkg.DeviceProtocolNvme},
+			Smart: &measurements.Smart{
+				Date: time.Unix(1700000000, 0),
+				Attributes: map[string]measurements.SmartAttribute{
+					"media_errors": &measurements.SmartNvmeAttribute{AttributeId: "media_errors", Value: 3, TransformedValue: 3},
+				},
+			},
+		},
+		{
+			// devices without smart data only report their status
+			Device: models.Device{ScrutinyUUID: uuid.Nil, DeviceProtocol: pkg.DeviceProtocolNvme},
+		},
+	}
+	var output bytes.Buffer
+
+	//test
+	err := metrics.WritePrometheus(&output, devices)
+
+	//assert
+	require.NoError(t, err)
+	deviceLabels := `scrutiny_uuid="7c1a4d2e-8f3b-5a6c-9d0e-1f2a3b4c5d6e",host_id="nas01",model="WDC \"Red\"",serial="WD-123",protocol="ATA"`
+	require.Contains(t, output.String(), "# TYPE scrutiny_device_status gauge\n")
+	require.Contains(t, output.String(), "scrutiny_device_status{"+deviceLabels+"} 2\n")
+	require.Contains(t, output.String(), `scrutiny_device_status{scrutiny_uuid="00000000-0000-0000-0000-000000000000",host_id="",model="",serial="",protocol="NVMe"} 0`+"\n")
+	require.Contains(t, output.String(), "scrutiny_device_temperature_celsius{"+deviceLabels+"} 34\n")
+	require.Contains(t, output.String(), "scrutiny_device_power_on_hours{"+deviceLabels+"} 12000\n")
+	require.Contains(t, output.String(), "scrutiny_device_smart_timestamp_seconds{"+deviceLabels+"} 1700000000\n")
+	require.Contains(t, output.String(), "scrutiny_smart_attribute_raw_value{"+deviceLabels+`,attribute_id="194"} 171801837602`+"\n")
+	nvmeLabels := `scrutiny_uuid="2b3c4d5e-6f70-5182-93a4-b5c6d7e8f901",host_id="nas01",model="",serial="",protocol="NVMe",attribute_id="media_errors"`
+	require.Contains(t, output.String(), "scrutiny_smart_attribute_value{"+nvmeLabels+"} 3\n")
+	require.Contains(t, output.String(), "scrutiny_smart_attribute_raw_value{"+nvmeLabels+"} 3\n")
+	require.Contains(t, output.String(), "scrutiny_smart_attribute_status{"+deviceLabels+`,attribute_id="5"} 4`+"\n")
+	require.Equal(t, 1, bytes.Count(output.Bytes(), []byte("# TYPE scrutiny_smart_attribute_value gauge")))
+}
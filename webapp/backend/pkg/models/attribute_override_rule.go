@@ -0,0 +1,42 @@
+package models
+
+import (
+	"github.com/analogj/scrutiny/webapp/backend/pkg/thresholds"
+	"gorm.io/gorm"
+)
+
+// AttributeOverrideRule is a user-defined rule which replaces the built-in thresholds for an attribute.
+// A rule is scoped to a single device, devices with a matching model name, and/or devices using a protocol. When multiple
+// rules match the same attribute, the most specific rule is used (device > model > protocol).
+type AttributeOverrideRule struct {
+	//GORM attributes, see: http://gorm.io/docs/conventions.html
+	gorm.Model
+
+	// Scope, at least one must be set. When multiple are set, all must match.
+	ScrutinyUUID string `json:"scrutiny_uuid,omitempty"`
+	ModelRegex   string `json:"model_regex,omitempty"`
+	Protocol     string `json:"protocol,omitempty"` // ATA, NVMe or SCSI
+
+	AttributeId string `json:"attribute_id" gorm:"not null"` // eg. "5" for ATA, "media_errors" for NVMe
+
+	// Overrides
+	Ignore    bool   `json:"ignore"`
+	Ideal     string `json:"ideal,omitempty"` // low or high
+	Critical  *bool  `json:"critical,omitempty"`
+	WarnLimit *int64 `json:"warn_limit,omitempty"`
+	FailLimit *int64 `json:"fail_limit,omitempty"`
+}
+
+func (r AttributeOverrideRule) TableName() string {
+	return "attribute_override_rules"
+}
+
+func (r AttributeOverrideRule) ToAttributeOverride() thresholds.AttributeOverride {
+	return thresholds.AttributeOverride{
+		Ignore:    r.Ignore,
+		Ideal:     r.Ideal,
+		Critical:  r.Critical,
+		WarnLimit: r.WarnLimit,
+		FailLimit: r.FailLimit,
+	}
+}
@@ -0,0 +1,5 @@
+package collector
+
+// VersionHeader is sent by the collector with each request to the API server, the collector version is shown for each
+// host.
+const VersionHeader = "X-Scrutiny-Collector-Version"
@@ -96,8 +96,9 @@ type SmartInfo struct {
 				RemainingPercent int    `json:"remaining_percent"`
 			} `json:"status"`
 			PollingMinutes struct {
-				Short    int `json:"short"`
-				Extended int `json:"extended"`
+				Short      int `json:"short"`
+				Extended   int `json:"extended"`
+				Conveyance int `json:"conveyance"`
 			} `json:"polling_minutes"`
 		} `json:"self_test"`
 		Capabilities struct {
@@ -235,6 +236,7 @@ type SmartInfo struct {
 		} `json:"eui64"`
 	} `json:"nvme_namespaces"`
 	NvmeSmartHealthInformationLog NvmeSmartHealthInformationLog `json:"nvme_smart_health_information_log"`
+	NvmeSelfTestLog               NvmeSelfTestLog               `json:"nvme_self_test_log"`
 
 	// SCSI Protocol Specific Fields
 	Vendor              string              `json:"vendor"`
@@ -336,6 +338,25 @@ type NvmeSmartHealthInformationLog struct {
 	CriticalCompTime        int64 `json:"critical_comp_time"`
 }
 
+type NvmeSelfTestLog struct {
+	CurrentSelfTestOperation struct {
+		Value  int    `json:"value"`
+		String string `json:"string"`
+	} `json:"current_self_test_operation"`
+	CurrentSelfTestCompletionPercent int `json:"current_self_test_completion_percent"`
+	Table                            []struct {
+		SelfTestCode struct {
+			Value  int    `json:"value"`
+			String string `json:"string"`
+		} `json:"self_test_code"`
+		SelfTestResult struct {
+			Value  int    `json:"value"`
+			String string `json:"string"`
+		} `json:"self_test_result"`
+		PowerOnHours int `json:"power_on_hours"`
+	} `json:"table"`
+}
+
 type ScsiErrorCounterLog struct {
 	Read struct {
 		ErrorsCorrectedByEccfast         int64  `json:"errors_corrected_by_eccfast"`
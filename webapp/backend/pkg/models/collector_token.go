@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CollectorToken authenticates a collector with the API server. Each token is bound to a single host, and can only be
+// used to register & upload data for devices on that host.
+// The token value is only returned when the token is created, the database only stores a hash.
+type CollectorToken struct {
+	//GORM attributes, see: http://gorm.io/docs/conventions.html
+	gorm.Model
+
+	HostId      string     `json:"host_id" gorm:"not null"`
+	Description string     `json:"description"`
+	TokenPrefix string     `json:"token_prefix"` // first characters of the token, used to identify the token in the UI
+	TokenHash   string     `json:"-" gorm:"uniqueIndex;not null"`
+	LastUsedAt  *time.Time `json:"last_used_at"`
+}
+
+func (t CollectorToken) TableName() string {
+	return "collector_tokens"
+}
@@ -16,7 +16,7 @@ type DeviceWrapper struct {
 
 type Device struct {
 	//GORM attributes, see: http://gorm.io/docs/conventions.html
-	Archived  bool `json:"archived"`
+	Archived  bool `json:"archived" gorm:"index"`
 	CreatedAt time.Time
 	UpdatedAt time.Time
 	DeletedAt *time.Time
@@ -28,6 +28,12 @@ type Device struct {
 	DeviceSerialID string `json:"device_serial_id"`
 	DeviceLabel    string `json:"device_label"`
 
+	// Physical location of the device, detected by the collector
+	DeviceByPath         string `json:"device_by_path"`         // eg. /dev/disk/by-path/pci-0000:00:1f.2-ata-1
+	EnclosureId          string `json:"enclosure_id"`           // SES enclosure logical identifier (eg. 0x500056b36789abff), or SCSI address (eg. 0:0:8:0)
+	EnclosureSlot        string `json:"enclosure_slot"`         // slot within the SES enclosure
+	ControllerPciAddress string `json:"controller_pci_address"` // eg. 0000:00:1f.2
+
 	Manufacturer   string `json:"manufacturer"`
 	ModelName      string `json:"model_name"`
 	InterfaceType  string `json:"interface_type"`
@@ -35,19 +41,49 @@ type Device struct {
 	SerialNumber   string `json:"serial_number"`
 	Firmware       string `json:"firmware"`
 	RotationSpeed  int    `json:"rotational_speed"`
-	Capacity       int64  `json:"capacity"`
+	Capacity       int64  `json:"capacity" gorm:"index"`
 	FormFactor     string `json:"form_factor"`
 	SmartSupport   bool   `json:"smart_support"`
-	DeviceProtocol string `json:"device_protocol"` //protocol determines which smart attribute types are available (ATA, NVMe, SCSI)
-	DeviceType     string `json:"device_type"`     //device type is used for querying with -d/t flag, should only be used by collector.
+	DeviceProtocol string `json:"device_protocol" gorm:"index"` //protocol determines which smart attribute types are available (ATA, NVMe, SCSI)
+	DeviceType     string `json:"device_type"`                  //device type is used for querying with -d/t flag, should only be used by collector.
+
+	// Logical block size in bytes, reported by smartctl. Zero until the device uploads SMART data.
+	LogicalBlockSize int `json:"logical_block_size,omitempty"`
 
 	// User provided metadata
 	Label  string `json:"label"`
-	HostId string `json:"host_id"`
+	HostId string `json:"host_id" gorm:"index"`
+
+	// User provided inventory metadata, see DeviceMetadataPatch
+	Location           string     `json:"location"`
+	PurchaseDate       *time.Time `json:"purchase_date,omitempty"`
+	WarrantyExpiryDate *time.Time `json:"warranty_expiry_date,omitempty"`
+	RmaUrl             string     `json:"rma_url"`
+	PurchaseCost       *float64   `json:"purchase_cost,omitempty"`
+	Notes              string     `json:"notes"`
+
+	// Rated endurance in terabytes written, overrides the matching WarrantyRule
+	RatedTbw *float64 `json:"rated_tbw,omitempty"`
 
 	// Data set by Scrutiny
-	DeviceStatus pkg.DeviceStatus `json:"device_status"`
+	DeviceStatus pkg.DeviceStatus `json:"device_status" gorm:"index"`
 	ScrutinyUUID uuid.UUID        `json:"scrutiny_uuid" gorm:"primaryKey;uniqueIndex"`
+
+	// Failure prediction, calculated from the attribute history (see analysis.PredictFailure)
+	FailureScore            int        `json:"failure_score"`
+	FailureScoreAttributeId string     `json:"failure_score_attribute_id,omitempty"`
+	EstimatedFailureDate    *time.Time `json:"estimated_failure_date,omitempty"`
+
+	// SSD endurance, calculated from the attribute history (see analysis.AnalyzeEndurance)
+	DailyBytesWritten    *float64   `json:"daily_bytes_written,omitempty"`
+	WearPercentUsed      *float64   `json:"wear_percent_used,omitempty"`
+	EndurancePercentUsed *float64   `json:"endurance_percent_used,omitempty"`
+	EstimatedWearOutDate *time.Time `json:"estimated_wear_out_date,omitempty"`
+
+	// Missing device detection. LastSeenAt is updated each time the collector uploads data for the device, and the
+	// device is marked as stale when no data is uploaded for `metrics.stale_interval_hours`.
+	LastSeenAt *time.Time `json:"last_seen_at,omitempty"`
+	Stale      bool       `json:"stale"`
 }
 
 func (dv *Device) IsAta() bool {
@@ -62,6 +98,20 @@ func (dv *Device) IsNvme() bool {
 	return dv.DeviceProtocol == pkg.DeviceProtocolNvme
 }
 
+// SameSlot returns true if both devices are installed in the same slot of the same host, used to detect replaced
+// devices (see DeviceReplacement). The most precise location reported for both devices is compared: the SES
+// enclosure slot, then the by-path symlink. Devices without a host id, or without a persistent location, are never in
+// the same slot (the device name may change between reboots, and is shared by every host).
+func (dv *Device) SameSlot(other Device) bool {
+	if len(dv.HostId) == 0 || dv.HostId != other.HostId {
+		return false
+	}
+	if len(dv.EnclosureSlot) > 0 && len(other.EnclosureSlot) > 0 {
+		return dv.EnclosureId == other.EnclosureId && dv.EnclosureSlot == other.EnclosureSlot
+	}
+	return len(dv.DeviceByPath) > 0 && dv.DeviceByPath == other.DeviceByPath
+}
+
 //
 ////This method requires a device with an array of SmartResults.
 ////It will remove all SmartResults other than the first (the latest one)
@@ -168,6 +218,9 @@ func (dv *Device) IsNvme() bool {
 func (dv *Device) UpdateFromCollectorSmartInfo(info collector.SmartInfo) error {
 	dv.Firmware = info.FirmwareVersion
 	dv.DeviceProtocol = info.Device.Protocol
+	if info.LogicalBlockSize > 0 {
+		dv.LogicalBlockSize = info.LogicalBlockSize
+	}
 
 	return nil
 }
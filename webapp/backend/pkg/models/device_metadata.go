@@ -0,0 +1,189 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+// DeviceMetadataDateFormat is used for the purchase & warranty expiry dates in DeviceMetadataPatch
+const DeviceMetadataDateFormat = "2006-01-02"
+
+// DeviceMetadataChange records a change to the user provided metadata of a device, see DeviceMetadataPatch.
+type DeviceMetadataChange struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"created_at"`
+
+	ScrutinyUUID uuid.UUID `json:"scrutiny_uuid" gorm:"index"`
+	Field        string    `json:"field"`
+	OldValue     string    `json:"old_value"`
+	NewValue     string    `json:"new_value"`
+	ChangedBy    string    `json:"changed_by,omitempty"` //username, empty when authentication is disabled
+}
+
+func (c DeviceMetadataChange) TableName() string {
+	return "device_metadata_changes"
+}
+
+// DeviceMetadataPatch is a partial update of the user provided device metadata, keyed by the json field name. Only
+// the fields included in the patch are updated, and `null` clears a field.
+type DeviceMetadataPatch map[string]json.RawMessage
+
+type deviceMetadataField struct {
+	maxLength int
+	get       func(device *Device) string
+	set       func(device *Device, raw json.RawMessage) error
+}
+
+var deviceMetadataFields = map[string]deviceMetadataField{
+	"label": {
+		maxLength: 100,
+		get:       func(device *Device) string { return device.Label },
+		set:       func(device *Device, raw json.RawMessage) error { return decodeMetadataString(raw, &device.Label) },
+	},
+	"location": {
+		maxLength: 200,
+		get:       func(device *Device) string { return device.Location },
+		set:       func(device *Device, raw json.RawMessage) error { return decodeMetadataString(raw, &device.Location) },
+	},
+	"purchase_date": {
+		get: func(device *Device) string { return formatMetadataDate(device.PurchaseDate) },
+		set: func(device *Device, raw json.RawMessage) error { return decodeMetadataDate(raw, &device.PurchaseDate) },
+	},
+	"warranty_expiry_date": {
+		get: func(device *Device) string { return formatMetadataDate(device.WarrantyExpiryDate) },
+		set: func(device *Device, raw json.RawMessage) error {
+			return decodeMetadataDate(raw, &device.WarrantyExpiryDate)
+		},
+	},
+	"rma_url": {
+		maxLength: 2048,
+		get:       func(device *Device) string { return device.RmaUrl },
+		set: func(device *Device, raw json.RawMessage) error {
+			if err := decodeMetadataString(raw, &device.RmaUrl); err != nil {
+				return err
+			}
+			if len(device.RmaUrl) == 0 {
+				return nil
+			}
+			if parsed, err := url.Parse(device.RmaUrl); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || len(parsed.Host) == 0 {
+				return fmt.Errorf("must be a http or https url")
+			}
+			return nil
+		},
+	},
+	"purchase_cost": {
+		get: func(device *Device) string { return formatMetadataNumber(device.PurchaseCost) },
+		set: func(device *Device, raw json.RawMessage) error {
+			return decodeMetadataNumber(raw, &device.PurchaseCost)
+		},
+	},
+	"rated_tbw": {
+		get: func(device *Device) string { return formatMetadataNumber(device.RatedTbw) },
+		set: func(device *Device, raw json.RawMessage) error { return decodeMetadataNumber(raw, &device.RatedTbw) },
+	},
+	"notes": {
+		maxLength: 10000,
+		get:       func(device *Device) string { return device.Notes },
+		set:       func(device *Device, raw json.RawMessage) error { return decodeMetadataString(raw, &device.Notes) },
+	},
+}
+
+// Apply validates the patch and updates the device. The changed fields are returned, in alphabetical order.
+func (patch DeviceMetadataPatch) Apply(device *Device) ([]DeviceMetadataChange, error) {
+	fieldNames := make([]string, 0, len(patch))
+	for fieldName := range patch {
+		if _, known := deviceMetadataFields[fieldName]; !known {
+			return nil, fmt.Errorf("unknown field %q", fieldName)
+		}
+		fieldNames = append(fieldNames, fieldName)
+	}
+	sort.Strings(fieldNames)
+
+	changes := []DeviceMetadataChange{}
+	for _, fieldName := range fieldNames {
+		field := deviceMetadataFields[fieldName]
+		oldValue := field.get(device)
+		if err := field.set(device, patch[fieldName]); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", fieldName, err)
+		}
+		newValue := field.get(device)
+		if field.maxLength > 0 && len(newValue) > field.maxLength {
+			return nil, fmt.Errorf("invalid %s: must be at most %d characters", fieldName, field.maxLength)
+		}
+		if newValue != oldValue {
+			changes = append(changes, DeviceMetadataChange{
+				ScrutinyUUID: device.ScrutinyUUID,
+				Field:        fieldName,
+				OldValue:     oldValue,
+				NewValue:     newValue,
+			})
+		}
+	}
+
+	if device.PurchaseDate != nil && device.WarrantyExpiryDate != nil && device.WarrantyExpiryDate.Before(*device.PurchaseDate) {
+		return nil, fmt.Errorf("invalid warranty_expiry_date: must not be before purchase_date")
+	}
+	return changes, nil
+}
+
+func decodeMetadataString(raw json.RawMessage, value *string) error {
+	var decoded *string
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return fmt.Errorf("must be a string")
+	}
+	*value = ""
+	if decoded != nil {
+		*value = *decoded
+	}
+	return nil
+}
+
+func decodeMetadataDate(raw json.RawMessage, value **time.Time) error {
+	var decoded *string
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return fmt.Errorf("must be a date (YYYY-MM-DD)")
+	}
+	*value = nil
+	if decoded == nil || len(*decoded) == 0 {
+		return nil
+	}
+	date, err := time.Parse(DeviceMetadataDateFormat, *decoded)
+	if err != nil {
+		return fmt.Errorf("must be a date (YYYY-MM-DD)")
+	}
+	*value = &date
+	return nil
+}
+
+// decodeMetadataNumber decodes a non-negative number
+func decodeMetadataNumber(raw json.RawMessage, value **float64) error {
+	var decoded *float64
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return fmt.Errorf("must be a number")
+	}
+	if decoded != nil && *decoded < 0 {
+		return fmt.Errorf("must not be negative")
+	}
+	*value = decoded
+	return nil
+}
+
+func formatMetadataNumber(value *float64) string {
+	if value == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*value, 'f', -1, 64)
+}
+
+func formatMetadataDate(date *time.Time) string {
+	if date == nil {
+		return ""
+	}
+	return date.Format(DeviceMetadataDateFormat)
+}
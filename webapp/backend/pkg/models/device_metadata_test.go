@@ -0,0 +1,68 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeviceMetadataPatch_Apply(t *testing.T) {
+	//setup
+	device := Device{Label: "parity", Notes: "bought used"}
+	var patch DeviceMetadataPatch
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"label": "parity",
+		"location": "rack 2, shelf 3",
+		"purchase_date": "2024-01-15",
+		"warranty_expiry_date": "2029-01-15",
+		"rma_url": "https://support.example.com/rma",
+		"purchase_cost": 249.99,
+		"notes": null
+	}`), &patch))
+
+	//test
+	changes, err := patch.Apply(&device)
+
+	//assert
+	require.NoError(t, err)
+	require.Equal(t, "parity", device.Label)
+	require.Equal(t, "rack 2, shelf 3", device.Location)
+	require.Equal(t, "2024-01-15", device.PurchaseDate.Format(DeviceMetadataDateFormat))
+	require.Equal(t, "2029-01-15", device.WarrantyExpiryDate.Format(DeviceMetadataDateFormat))
+	require.Equal(t, 249.99, *device.PurchaseCost)
+	require.Empty(t, device.Notes)
+
+	// unchanged fields (label) are not audited
+	require.Len(t, changes, 6)
+	require.Equal(t, "location", changes[0].Field)
+	require.Equal(t, "notes", changes[1].Field)
+	require.Equal(t, "bought used", changes[1].OldValue)
+	require.Empty(t, changes[1].NewValue)
+	require.Equal(t, "purchase_cost", changes[2].Field)
+	require.Equal(t, "249.99", changes[2].NewValue)
+}
+
+func TestDeviceMetadataPatch_Apply_Invalid(t *testing.T) {
+	for _, invalidPatch := range []string{
+		`{"serial_number": "FAKE"}`,
+		`{"label": 1}`,
+		`{"purchase_date": "15/01/2024"}`,
+		`{"purchase_date": "2024-01-15", "warranty_expiry_date": "2023-01-15"}`,
+		`{"rma_url": "javascript:alert(1)"}`,
+		`{"purchase_cost": -1}`,
+		`{"purchase_cost": "1"}`,
+		`{"rated_tbw": -600}`,
+	} {
+		//setup
+		device := Device{}
+		var patch DeviceMetadataPatch
+		require.NoError(t, json.Unmarshal([]byte(invalidPatch), &patch))
+
+		//test
+		_, err := patch.Apply(&device)
+
+		//assert
+		require.Error(t, err, invalidPatch)
+	}
+}
@@ -0,0 +1,127 @@
+package models
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/analogj/scrutiny/webapp/backend/pkg"
+)
+
+const (
+	DeviceQueryDefaultLimit = 100
+	DeviceQueryMaxLimit     = 1000
+)
+
+// device status filter values, see pkg.DeviceStatus
+const (
+	DeviceQueryStatusPassed         = "passed"
+	DeviceQueryStatusFailed         = "failed" // failed SMART or Scrutiny thresholds
+	DeviceQueryStatusFailedSmart    = "failed_smart"
+	DeviceQueryStatusFailedScrutiny = "failed_scrutiny"
+	DeviceQueryStatusWarning        = "warning"
+)
+
+// DeviceQuerySummaryFields are the latest SMART data fields of the device summary (see SmartSummary). They are stored
+// in the time series database, so devices can't be sorted by them and they can't be returned by GET /api/devices.
+var DeviceQuerySummaryFields = map[string]bool{
+	"collector_date": true,
+	"temp":           true,
+	"power_on_hours": true,
+}
+
+// DeviceQuery filters, sorts & pages the stored devices, see GET /api/devices. Field names (Sort & Fields) are the json
+// field names of Device.
+type DeviceQuery struct {
+	// Filters, all must match. Multiple values for the same filter match any value.
+	HostIds     []string
+	Protocols   []string
+	Statuses    []string
+	Model       string // case-insensitive substring of the model name
+	Label       string // case-insensitive substring of the label
+	Archived    *bool
+	CapacityMin *int64 // bytes
+	CapacityMax *int64 // bytes
+
+	// Sort field, prefixed with `-` for descending order. Devices with the same value are sorted by scrutiny_uuid.
+	Sort string
+	// Cursor returned with the previous page, must be used with the same Sort.
+	Cursor string
+	Limit  int
+
+	// Fields returned for each device (sparse fieldset), all fields when empty. scrutiny_uuid is always returned.
+	Fields []string
+}
+
+// SortField returns the json field name used to sort the devices, and the sort direction
+func (q DeviceQuery) SortField() (string, bool) {
+	if len(q.Sort) == 0 {
+		return "device_name", false
+	}
+	return strings.TrimPrefix(q.Sort, "-"), strings.HasPrefix(q.Sort, "-")
+}
+
+// Validate checks the field names & filter values, normalizes the protocols and applies the default limit.
+func (q *DeviceQuery) Validate() error {
+	fieldNames := DeviceFieldNames()
+	sortField, _ := q.SortField()
+	if DeviceQuerySummaryFields[sortField] {
+		return fmt.Errorf("invalid sort: %q is latest SMART data, only available from /api/summary", sortField)
+	} else if !fieldNames[sortField] {
+		return fmt.Errorf("invalid sort: unknown field %q", sortField)
+	}
+	for _, field := range q.Fields {
+		if DeviceQuerySummaryFields[field] {
+			return fmt.Errorf("invalid fields: %q is latest SMART data, only available from /api/summary", field)
+		} else if !fieldNames[field] {
+			return fmt.Errorf("invalid fields: unknown field %q", field)
+		}
+	}
+	for ndx, protocol := range q.Protocols {
+		switch {
+		case strings.EqualFold(protocol, pkg.DeviceProtocolAta):
+			q.Protocols[ndx] = pkg.DeviceProtocolAta
+		case strings.EqualFold(protocol, pkg.DeviceProtocolNvme):
+			q.Protocols[ndx] = pkg.DeviceProtocolNvme
+		case strings.EqualFold(protocol, pkg.DeviceProtocolScsi):
+			q.Protocols[ndx] = pkg.DeviceProtocolScsi
+		default:
+			return fmt.Errorf("invalid protocol %q (ATA, NVMe or SCSI)", protocol)
+		}
+	}
+	for _, status := range q.Statuses {
+		switch status {
+		case DeviceQueryStatusPassed, DeviceQueryStatusFailed, DeviceQueryStatusFailedSmart, DeviceQueryStatusFailedScrutiny, DeviceQueryStatusWarning:
+		default:
+			return fmt.Errorf("invalid status %q (passed, failed, failed_smart, failed_scrutiny or warning)", status)
+		}
+	}
+	if q.CapacityMin != nil && q.CapacityMax != nil && *q.CapacityMin > *q.CapacityMax {
+		return fmt.Errorf("invalid capacity range: capacity_min is greater than capacity_max")
+	}
+	if q.Limit < 0 || q.Limit > DeviceQueryMaxLimit {
+		return fmt.Errorf("invalid limit: must be between 1 and %d", DeviceQueryMaxLimit)
+	}
+	if q.Limit == 0 {
+		q.Limit = DeviceQueryDefaultLimit
+	}
+	return nil
+}
+
+// DeviceFieldNames returns the json field names of Device
+func DeviceFieldNames() map[string]bool {
+	fieldNames := map[string]bool{}
+	deviceType := reflect.TypeOf(Device{})
+	for ndx := 0; ndx < deviceType.NumField(); ndx++ {
+		fieldNames[DeviceFieldName(deviceType.Field(ndx))] = true
+	}
+	return fieldNames
+}
+
+// DeviceFieldName returns the json field name of a Device struct field (the Go name, when there's no json tag)
+func DeviceFieldName(field reflect.StructField) string {
+	if name, _, _ := strings.Cut(field.Tag.Get("json"), ","); len(name) > 0 {
+		return name
+	}
+	return field.Name
+}
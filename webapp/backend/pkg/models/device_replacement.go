@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+// DeviceReplacement links a device to the device which replaced it in the same slot (host & device name). Replacements
+// are recorded automatically when a new device is registered in the slot of a device that stopped reporting, or
+// manually using the API.
+type DeviceReplacement struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"created_at"`
+
+	HostId     string `json:"host_id"`
+	DeviceName string `json:"device_name"`
+
+	ReplacedScrutinyUUID    uuid.UUID `json:"replaced_scrutiny_uuid" gorm:"index"`
+	ReplacementScrutinyUUID uuid.UUID `json:"replacement_scrutiny_uuid" gorm:"index"`
+	Automatic               bool      `json:"automatic"` // false when recorded using the API
+}
+
+func (r DeviceReplacement) TableName() string {
+	return "device_replacements"
+}
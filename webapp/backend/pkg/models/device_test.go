@@ -0,0 +1,26 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDevice_SameSlot(t *testing.T) {
+	device := Device{HostId: "nas01", DeviceName: "sda", DeviceByPath: "pci-0000:00:1f.2-ata-1", EnclosureId: "0x5000", EnclosureSlot: "3"}
+
+	//same enclosure slot, even if the by-path changed
+	require.True(t, device.SameSlot(Device{HostId: "nas01", DeviceName: "sdb", DeviceByPath: "pci-0000:00:1f.2-ata-2", EnclosureId: "0x5000", EnclosureSlot: "3"}))
+	require.False(t, device.SameSlot(Device{HostId: "nas01", DeviceName: "sda", DeviceByPath: "pci-0000:00:1f.2-ata-1", EnclosureId: "0x5000", EnclosureSlot: "4"}))
+
+	//same by-path when the enclosure slot is unknown
+	require.True(t, device.SameSlot(Device{HostId: "nas01", DeviceName: "sdb", DeviceByPath: "pci-0000:00:1f.2-ata-1"}))
+	require.False(t, device.SameSlot(Device{HostId: "nas01", DeviceName: "sda", DeviceByPath: "pci-0000:00:1f.2-ata-2"}))
+
+	//the device name alone never identifies a slot
+	require.False(t, device.SameSlot(Device{HostId: "nas01", DeviceName: "sda"}))
+
+	//different hosts, or no host id
+	require.False(t, device.SameSlot(Device{HostId: "nas02", DeviceName: "sda", DeviceByPath: "pci-0000:00:1f.2-ata-1"}))
+	require.False(t, (&Device{DeviceName: "sda", DeviceByPath: "pci-0000:00:1f.2-ata-1"}).SameSlot(Device{DeviceName: "sda", DeviceByPath: "pci-0000:00:1f.2-ata-1"}))
+}
@@ -0,0 +1,150 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/analogj/scrutiny/webapp/backend/pkg"
+)
+
+const (
+	hostNameMaxLength        = 100
+	hostDescriptionMaxLength = 1000
+	hostTagMaxLength         = 50
+	hostTagsMaxCount         = 20
+)
+
+// Host is a server running the collector, identified by the collector `host.id`. The host is created when its collector
+// first checks in (registers its devices or uploads data), and the collector version & platform are updated on each
+// check-in. The host is marked as stale when its collector has not checked in for `metrics.stale_interval_hours`.
+type Host struct {
+	HostId    string    `json:"host_id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// User provided metadata, see HostMetadataPatch
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Tags        []string `json:"tags" gorm:"serializer:json"`
+
+	// Reported by the collector
+	LastCheckinAt    time.Time `json:"last_checkin_at"`
+	CollectorVersion string    `json:"collector_version"`
+	Platform         string    `json:"platform"` // smartctl platform_info, eg. x86_64-linux-6.1.0-13-amd64
+
+	Stale    bool `json:"stale"`
+	Archived bool `json:"archived"` // the devices of an archived host are archived as well
+}
+
+func (h Host) TableName() string {
+	return "hosts"
+}
+
+// HostHealth aggregates the status of the devices of a host. Archived devices are only counted in ArchivedCount.
+type HostHealth struct {
+	DeviceStatus  pkg.DeviceStatus `json:"device_status"` // combined status flags of the devices
+	DeviceCount   int              `json:"device_count"`
+	PassedCount   int              `json:"passed_count"`
+	FailedCount   int              `json:"failed_count"` // failed SMART or Scrutiny thresholds
+	WarningCount  int              `json:"warning_count"`
+	StaleCount    int              `json:"stale_count"`
+	ArchivedCount int              `json:"archived_count"`
+	Capacity      int64            `json:"capacity"` // bytes
+}
+
+// HostSummary is a host with the aggregate health of its devices, see GET /api/hosts. The devices are only included
+// for a single host (GET /api/host/:host_id).
+type HostSummary struct {
+	Host
+	Health  HostHealth `json:"health"`
+	Devices []Device   `json:"devices,omitempty"`
+}
+
+// NewHostHealth aggregates the status of the devices of a host
+func NewHostHealth(devices []Device) HostHealth {
+	health := HostHealth{}
+	for _, device := range devices {
+		if device.Archived {
+			health.ArchivedCount++
+			continue
+		}
+		health.DeviceCount++
+		health.DeviceStatus = pkg.DeviceStatusSet(health.DeviceStatus, device.DeviceStatus)
+		health.Capacity += device.Capacity
+		if device.Stale {
+			health.StaleCount++
+		}
+		switch {
+		case pkg.DeviceStatusHas(device.DeviceStatus, pkg.DeviceStatusFailedSmart) || pkg.DeviceStatusHas(device.DeviceStatus, pkg.DeviceStatusFailedScrutiny):
+			health.FailedCount++
+		case pkg.DeviceStatusHas(device.DeviceStatus, pkg.DeviceStatusWarningScrutiny):
+			health.WarningCount++
+		default:
+			health.PassedCount++
+		}
+	}
+	return health
+}
+
+// HostMetadataPatch is a partial update of the user provided host metadata (name, description & tags), keyed by the
+// json field name. Only the fields included in the patch are updated, and `null` clears a field.
+type HostMetadataPatch map[string]json.RawMessage
+
+// Apply validates the patch and updates the host. Tags are trimmed, and duplicate & empty tags are removed.
+func (patch HostMetadataPatch) Apply(host *Host) error {
+	fieldNames := make([]string, 0, len(patch))
+	for fieldName := range patch {
+		fieldNames = append(fieldNames, fieldName)
+	}
+	sort.Strings(fieldNames)
+
+	for _, fieldName := range fieldNames {
+		var err error
+		switch fieldName {
+		case "name":
+			err = decodeMetadataString(patch[fieldName], &host.Name)
+			if err == nil && len(host.Name) > hostNameMaxLength {
+				err = fmt.Errorf("must be at most %d characters", hostNameMaxLength)
+			}
+		case "description":
+			err = decodeMetadataString(patch[fieldName], &host.Description)
+			if err == nil && len(host.Description) > hostDescriptionMaxLength {
+				err = fmt.Errorf("must be at most %d characters", hostDescriptionMaxLength)
+			}
+		case "tags":
+			err = decodeHostTags(patch[fieldName], &host.Tags)
+		default:
+			return fmt.Errorf("unknown field %q", fieldName)
+		}
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", fieldName, err)
+		}
+	}
+	return nil
+}
+
+func decodeHostTags(raw json.RawMessage, tags *[]string) error {
+	var decoded []string
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return fmt.Errorf("must be a list of strings")
+	}
+	*tags = []string{}
+	for _, tag := range decoded {
+		tag = strings.TrimSpace(tag)
+		if len(tag) == 0 || slices.Contains(*tags, tag) {
+			continue
+		}
+		if len(tag) > hostTagMaxLength {
+			return fmt.Errorf("tags must be at most %d characters", hostTagMaxLength)
+		}
+		*tags = append(*tags, tag)
+	}
+	if len(*tags) > hostTagsMaxCount {
+		return fmt.Errorf("must be at most %d tags", hostTagsMaxCount)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHostMetadataPatch_Apply(t *testing.T) {
+	//setup
+	host := Host{HostId: "nas01", Description: "old description", Tags: []string{"backup"}}
+	var patch HostMetadataPatch
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"name": "Basement NAS",
+		"description": null,
+		"tags": [" zfs", "backup", "zfs", ""]
+	}`), &patch))
+
+	//test
+	err := patch.Apply(&host)
+
+	//assert
+	require.NoError(t, err)
+	require.Equal(t, "nas01", host.HostId)
+	require.Equal(t, "Basement NAS", host.Name)
+	require.Empty(t, host.Description)
+	require.Equal(t, []string{"zfs", "backup"}, host.Tags)
+}
+
+func TestHostMetadataPatch_Apply_Invalid(t *testing.T) {
+	for _, invalidPatch := range []string{
+		`{"host_id": "nas02"}`,
+		`{"name": 1}`,
+		`{"name": "` + strings.Repeat("a", 101) + `"}`,
+		`{"tags": "zfs"}`,
+		`{"tags": ["` + strings.Repeat("a", 51) + `"]}`,
+	} {
+		//setup
+		host := Host{}
+		var patch HostMetadataPatch
+		require.NoError(t, json.Unmarshal([]byte(invalidPatch), &patch))
+
+		//test
+		err := patch.Apply(&host)
+
+		//assert
+		require.Error(t, err, invalidPatch)
+	}
+}
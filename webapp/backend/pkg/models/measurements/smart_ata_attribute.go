@@ -33,6 +33,14 @@ func (sa *SmartAtaAttribute) GetStatus() pkg.AttributeStatus {
 	return sa.Status
 }
 
+func (sa *SmartAtaAttribute) GetStatusReason() string {
+	return sa.StatusReason
+}
+
+func (sa *SmartAtaAttribute) GetThresholdValue() int64 {
+	return sa.thresholdValue(thresholds.AtaMetadata[sa.AttributeId])
+}
+
 func (sa *SmartAtaAttribute) Flatten() map[string]interface{} {
 
 	idString := strconv.Itoa(sa.AttributeId)
@@ -86,14 +94,19 @@ func (sa *SmartAtaAttribute) Inflate(key string, val interface{}) {
 	case "status_reason":
 		sa.StatusReason = val.(string)
 	case "failure_rate":
-		sa.FailureRate = val.(float64)
+		sa.FailureRate = inflateFloat64(val)
 
 	}
 }
 
 // populate attribute status, using SMART Thresholds & Observed Metadata
 // Chainable
-func (sa *SmartAtaAttribute) PopulateAttributeStatus() *SmartAtaAttribute {
+func (sa *SmartAtaAttribute) PopulateAttributeStatus(override *thresholds.AttributeOverride) *SmartAtaAttribute {
+	if override.IsIgnored() {
+		sa.StatusReason = attributeIgnoredReason
+		return sa
+	}
+
 	if strings.ToUpper(sa.WhenFailed) == pkg.AttributeWhenFailedFailingNow {
 		//this attribute has previously failed
 		sa.Status = pkg.AttributeStatusSet(sa.Status, pkg.AttributeStatusFailedSmart)
@@ -106,13 +119,31 @@ func (sa *SmartAtaAttribute) PopulateAttributeStatus() *SmartAtaAttribute {
 		sa.StatusReason += "Attribute has previously failed manufacturer SMART threshold"
 	}
 
-	if smartMetadata, ok := thresholds.AtaMetadata[sa.AttributeId]; ok {
+	smartMetadata, hasMetadata := thresholds.AtaMetadata[sa.AttributeId]
+	if override.HasLimits() {
+		//user-defined limits replace the observed thresholds
+		status, reason := overrideLimitStatus(override, sa.thresholdValue(smartMetadata), override.IdealOr(smartMetadata.Ideal))
+		sa.Status = pkg.AttributeStatusSet(sa.Status, status)
+		sa.StatusReason += reason
+	} else if hasMetadata {
+		smartMetadata.Critical = override.CriticalOr(smartMetadata.Critical)
 		sa.ValidateThreshold(smartMetadata)
 	}
 
 	return sa
 }
 
+// the value which is compared against thresholds, depending on the attribute display type
+func (sa *SmartAtaAttribute) thresholdValue(smartMetadata thresholds.AtaAttributeMetadata) int64 {
+	if smartMetadata.DisplayType == thresholds.AtaSmartAttributeDisplayTypeNormalized {
+		return int64(sa.Value)
+	} else if smartMetadata.DisplayType == thresholds.AtaSmartAttributeDisplayTypeTransformed {
+		return sa.TransformedValue
+	} else {
+		return sa.RawValue
+	}
+}
+
 // compare the attribute (raw, normalized, transformed) value to observed thresholds, and update status if necessary
 func (sa *SmartAtaAttribute) ValidateThreshold(smartMetadata thresholds.AtaAttributeMetadata) {
 	//TODO: multiple rules
@@ -125,14 +156,7 @@ func (sa *SmartAtaAttribute) ValidateThreshold(smartMetadata thresholds.AtaAttri
 	// 		- if failure rate is above 10 but below 20 - set to warn
 
 	//update the smart attribute status based on Observed thresholds.
-	var value int64
-	if smartMetadata.DisplayType == thresholds.AtaSmartAttributeDisplayTypeNormalized {
-		value = int64(sa.Value)
-	} else if smartMetadata.DisplayType == thresholds.AtaSmartAttributeDisplayTypeTransformed {
-		value = sa.TransformedValue
-	} else {
-		value = sa.RawValue
-	}
+	value := sa.thresholdValue(smartMetadata)
 
 	for _, obsThresh := range smartMetadata.ObservedThresholds {
 
@@ -142,15 +166,15 @@ func (sa *SmartAtaAttribute) ValidateThreshold(smartMetadata thresholds.AtaAttri
 			sa.FailureRate = obsThresh.AnnualFailureRate
 
 			if smartMetadata.Critical {
-				if obsThresh.AnnualFailureRate >= 0.10 {
+				if obsThresh.AnnualFailureRate >= thresholds.ObservedFailureRateCritical {
 					sa.Status = pkg.AttributeStatusSet(sa.Status, pkg.AttributeStatusFailedScrutiny)
 					sa.StatusReason += "Observed Failure Rate for Critical Attribute is greater than 10%"
 				}
 			} else {
-				if obsThresh.AnnualFailureRate >= 0.20 {
+				if obsThresh.AnnualFailureRate >= thresholds.ObservedFailureRateNonCritical {
 					sa.Status = pkg.AttributeStatusSet(sa.Status, pkg.AttributeStatusFailedScrutiny)
 					sa.StatusReason += "Observed Failure Rate for Non-Critical Attribute is greater than 20%"
-				} else if obsThresh.AnnualFailureRate >= 0.10 {
+				} else if obsThresh.AnnualFailureRate >= thresholds.ObservedFailureRateCritical {
 					sa.Status = pkg.AttributeStatusSet(sa.Status, pkg.AttributeStatusWarningScrutiny)
 					sa.StatusReason += "Observed Failure Rate for Non-Critical Attribute is greater than 10%"
 				}
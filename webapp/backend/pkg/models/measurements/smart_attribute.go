@@ -1,10 +1,36 @@
 package measurements
 
-import "github.com/analogj/scrutiny/webapp/backend/pkg"
+import (
+	"github.com/analogj/scrutiny/webapp/backend/pkg"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/thresholds"
+)
 
 type SmartAttribute interface {
 	Flatten() (fields map[string]interface{})
 	Inflate(key string, val interface{})
 	GetStatus() pkg.AttributeStatus
+	GetStatusReason() string
 	GetTransformedValue() int64
+	// the value which is compared against thresholds (eg. the raw value for most ATA attributes)
+	GetThresholdValue() int64
+}
+
+const attributeIgnoredReason = "Attribute is ignored by a user-defined override"
+
+// compare the attribute value to the user-defined warn & fail limits of the override
+func overrideLimitStatus(override *thresholds.AttributeOverride, value int64, ideal string) (pkg.AttributeStatus, string) {
+	if thresholds.ExceedsLimit(override.FailLimit, value, ideal) {
+		return pkg.AttributeStatusFailedScrutiny, "Attribute is failing user-defined limit"
+	} else if thresholds.ExceedsLimit(override.WarnLimit, value, ideal) {
+		return pkg.AttributeStatusWarningScrutiny, "Attribute is past user-defined warning limit"
+	}
+	return pkg.AttributeStatusPassed, ""
+}
+
+// the status for an attribute failing its SMART threshold. The attribute fails unless it has been overridden as non-critical.
+func thresholdFailureStatus(override *thresholds.AttributeOverride) pkg.AttributeStatus {
+	if override.CriticalOr(true) {
+		return pkg.AttributeStatusFailedScrutiny
+	}
+	return pkg.AttributeStatusWarningScrutiny
 }
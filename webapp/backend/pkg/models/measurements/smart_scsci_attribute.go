@@ -27,6 +27,14 @@ func (sa *SmartScsiAttribute) GetStatus() pkg.AttributeStatus {
 	return sa.Status
 }
 
+func (sa *SmartScsiAttribute) GetStatusReason() string {
+	return sa.StatusReason
+}
+
+func (sa *SmartScsiAttribute) GetThresholdValue() int64 {
+	return sa.Value
+}
+
 func (sa *SmartScsiAttribute) Flatten() map[string]interface{} {
 	return map[string]interface{}{
 		fmt.Sprintf("attr.%s.attribute_id", sa.AttributeId): sa.AttributeId,
@@ -63,21 +71,34 @@ func (sa *SmartScsiAttribute) Inflate(key string, val interface{}) {
 	case "status_reason":
 		sa.StatusReason = val.(string)
 	case "failure_rate":
-		sa.FailureRate = val.(float64)
+		sa.FailureRate = inflateFloat64(val)
 	}
 }
 
 // populate attribute status, using SMART Thresholds & Observed Metadata
 // Chainable
-func (sa *SmartScsiAttribute) PopulateAttributeStatus() *SmartScsiAttribute {
+func (sa *SmartScsiAttribute) PopulateAttributeStatus(override *thresholds.AttributeOverride) *SmartScsiAttribute {
+	if override.IsIgnored() {
+		sa.StatusReason = attributeIgnoredReason
+		return sa
+	}
+
+	if override.HasLimits() {
+		//user-defined limits replace the SMART threshold
+		status, reason := overrideLimitStatus(override, sa.Value, override.IdealOr(thresholds.ScsiMetadata[sa.AttributeId].Ideal))
+		sa.Status = pkg.AttributeStatusSet(sa.Status, status)
+		sa.StatusReason += reason
+		return sa
+	}
 
 	//-1 is a special number meaning no threshold.
 	if sa.Threshold != -1 {
 		if smartMetadata, ok := thresholds.NmveMetadata[sa.AttributeId]; ok {
 			//check what the ideal is. Ideal tells us if we our recorded value needs to be above, or below the threshold
-			if (smartMetadata.Ideal == "low" && sa.Value > sa.Threshold) ||
-				(smartMetadata.Ideal == "high" && sa.Value < sa.Threshold) {
-				sa.Status = pkg.AttributeStatusSet(sa.Status, pkg.AttributeStatusFailedScrutiny)
+			ideal := override.IdealOr(smartMetadata.Ideal)
+			if (ideal == "low" && sa.Value > sa.Threshold) ||
+				(ideal == "high" && sa.Value < sa.Threshold) {
+				sa.Status = pkg.AttributeStatusSet(sa.Status, thresholdFailureStatus(override))
 				sa.StatusReason = "Attribute is failing recommended SMART threshold"
 			}
 		}
@@ -0,0 +1,128 @@
+package measurements
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/analogj/scrutiny/webapp/backend/pkg"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/models/collector"
+)
+
+// SmartSelfTest is the result of a single self-test, as reported by the device self-test log.
+type SmartSelfTest struct {
+	Date time.Time `json:"date"`
+
+	TestType      string `json:"test_type"`
+	TestTypeValue int64  `json:"test_type_value"`
+	Status        string `json:"status"`
+	StatusValue   int64  `json:"status_value"`
+	Passed        bool   `json:"passed"`
+	// power-on hours when the self-test completed
+	LifetimeHours int64 `json:"lifetime_hours"`
+}
+
+func (st *SmartSelfTest) Flatten() (tags map[string]string, fields map[string]interface{}) {
+	fields = map[string]interface{}{
+		"test_type":       st.TestType,
+		"test_type_value": st.TestTypeValue,
+		"status":          st.Status,
+		"status_value":    st.StatusValue,
+		"passed":          st.Passed,
+		"lifetime_hours":  st.LifetimeHours,
+	}
+	tags = map[string]string{
+		"log_entry": st.Key(),
+	}
+
+	return tags, fields
+}
+
+func (st *SmartSelfTest) Inflate(key string, val interface{}) {
+	if val == nil {
+		return
+	}
+
+	switch key {
+	case "test_type":
+		st.TestType, _ = val.(string)
+	case "test_type_value":
+		st.TestTypeValue = inflateInt64(val)
+	case "status":
+		st.Status, _ = val.(string)
+	case "status_value":
+		st.StatusValue = inflateInt64(val)
+	case "passed":
+		st.Passed, _ = val.(bool)
+	case "lifetime_hours":
+		st.LifetimeHours = inflateInt64(val)
+	}
+}
+
+// NewSmartSelfTestsFromCollectorSmartInfo returns every entry in the ATA or NVMe self-test log, with the most recent
+// test first. The self-test log only records the power-on hours when each test completed (LifetimeHours), so the date
+// of every entry is the time the log was collected.
+func NewSmartSelfTestsFromCollectorSmartInfo(deviceProtocol string, info collector.SmartInfo) ([]SmartSelfTest, error) {
+	collectedAt := time.Unix(info.LocalTime.TimeT, 0)
+	selfTests := []SmartSelfTest{}
+
+	switch deviceProtocol {
+	case pkg.DeviceProtocolAta:
+		//the self-test log is sorted with the most recent test first
+		for _, entry := range info.AtaSmartSelfTestLog.Standard.Table {
+			selfTests = append(selfTests, SmartSelfTest{
+				Date:          collectedAt,
+				TestType:      entry.Type.String,
+				TestTypeValue: int64(entry.Type.Value),
+				Status:        entry.Status.String,
+				StatusValue:   int64(entry.Status.Value),
+				Passed:        entry.Status.Passed,
+				LifetimeHours: int64(entry.LifetimeHours),
+			})
+		}
+	case pkg.DeviceProtocolNvme:
+		for _, entry := range info.NvmeSelfTestLog.Table {
+			selfTests = append(selfTests, SmartSelfTest{
+				Date:          collectedAt,
+				TestType:      entry.SelfTestCode.String,
+				TestTypeValue: int64(entry.SelfTestCode.Value),
+				Status:        entry.SelfTestResult.String,
+				StatusValue:   int64(entry.SelfTestResult.Value),
+				//NVMe self-test result 0h: "Operation completed without error"
+				Passed:        entry.SelfTestResult.Value == 0,
+				LifetimeHours: int64(entry.PowerOnHours),
+			})
+		}
+	default:
+		return nil, fmt.Errorf("self-tests are not supported for %s devices", deviceProtocol)
+	}
+	if len(selfTests) == 0 {
+		return nil, fmt.Errorf("%s self-test log is empty", deviceProtocol)
+	}
+	return selfTests, nil
+}
+
+// Key identifies a self-test log entry, so entries which were already stored can be skipped when the log is uploaded
+// again. It is also stored as a tag, so the entries collected at the same time are stored as separate points.
+func (st *SmartSelfTest) Key() string {
+	return fmt.Sprintf("%d-%d-%d", st.LifetimeHours, st.TestTypeValue, st.StatusValue)
+}
+
+func inflateInt64(val interface{}) int64 {
+	switch t := val.(type) {
+	case int64:
+		return t
+	case float64:
+		return int64(t)
+	}
+	return 0
+}
+
+func inflateFloat64(val interface{}) float64 {
+	switch t := val.(type) {
+	case float64:
+		return t
+	case int64:
+		return float64(t)
+	}
+	return 0
+}
@@ -0,0 +1,100 @@
+package measurements_test
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/analogj/scrutiny/webapp/backend/pkg"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/models/collector"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/models/measurements"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSmartSelfTest_Flatten_Inflate(t *testing.T) {
+	//setup
+	selfTest := measurements.SmartSelfTest{
+		TestType:      "Short offline",
+		TestTypeValue: 1,
+		Status:        "Completed without error",
+		StatusValue:   0,
+		Passed:        true,
+		LifetimeHours: 41288,
+	}
+
+	//test
+	tags, fields := selfTest.Flatten()
+	inflated := measurements.SmartSelfTest{}
+	for key, val := range fields {
+		inflated.Inflate(key, val)
+	}
+
+	//assert
+	require.Equal(t, map[string]string{"log_entry": "41288-1-0"}, tags)
+	require.Equal(t, selfTest, inflated)
+}
+
+func TestNewSmartSelfTestsFromCollectorSmartInfo_Ata(t *testing.T) {
+	//setup
+	smartDataBytes, err := os.ReadFile("../testdata/smart-ata-selftest.json")
+	require.NoError(t, err)
+	var smartJson collector.SmartInfo
+	require.NoError(t, json.Unmarshal(smartDataBytes, &smartJson))
+
+	//test
+	selfTests, err := measurements.NewSmartSelfTestsFromCollectorSmartInfo(pkg.DeviceProtocolAta, smartJson)
+
+	//assert
+	require.NoError(t, err)
+	require.Equal(t, []measurements.SmartSelfTest{
+		{
+			Date:          time.Unix(1700000130, 0),
+			TestType:      "Short offline",
+			TestTypeValue: 1,
+			Status:        "Completed without error",
+			StatusValue:   0,
+			Passed:        true,
+			LifetimeHours: 41288,
+		},
+		{
+			Date:          time.Unix(1700000130, 0),
+			TestType:      "Short offline",
+			TestTypeValue: 1,
+			Status:        "Completed without error",
+			StatusValue:   0,
+			Passed:        true,
+			LifetimeHours: 41200,
+		},
+	}, selfTests)
+}
+
+func TestNewSmartSelfTestsFromCollectorSmartInfo_Nvme(t *testing.T) {
+	//setup
+	smartDataBytes, err := os.ReadFile("../testdata/smart-nvme-selftest.json")
+	require.NoError(t, err)
+	var smartJson collector.SmartInfo
+	require.NoError(t, json.Unmarshal(smartDataBytes, &smartJson))
+
+	//test
+	selfTests, err := measurements.NewSmartSelfTestsFromCollectorSmartInfo(pkg.DeviceProtocolNvme, smartJson)
+
+	//assert
+	require.NoError(t, err)
+	require.Len(t, selfTests, 2)
+	require.Equal(t, "Short", selfTests[0].TestType)
+	require.Equal(t, "Completed: failed segments", selfTests[0].Status)
+	require.False(t, selfTests[0].Passed)
+	require.Equal(t, int64(5321), selfTests[0].LifetimeHours)
+	require.Equal(t, "Extended", selfTests[1].TestType)
+	require.True(t, selfTests[1].Passed)
+	require.Equal(t, int64(5000), selfTests[1].LifetimeHours)
+}
+
+func TestNewSmartSelfTestsFromCollectorSmartInfo_EmptyLog(t *testing.T) {
+	//test
+	_, err := measurements.NewSmartSelfTestsFromCollectorSmartInfo(pkg.DeviceProtocolAta, collector.SmartInfo{})
+
+	//assert
+	require.Error(t, err)
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/analogj/scrutiny/webapp/backend/pkg"
 	"github.com/analogj/scrutiny/webapp/backend/pkg/models/collector"
 	"github.com/analogj/scrutiny/webapp/backend/pkg/models/measurements"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/thresholds"
 	"github.com/gofrs/uuid/v5"
 	"github.com/stretchr/testify/require"
 )
@@ -329,12 +330,12 @@ func TestFromCollectorSmartInfo(t *testing.T) {
 	//test
 	smartMdl := measurements.Smart{}
 	smartUUID := uuid.Must(uuid.NewV4())
-	err = smartMdl.FromCollectorSmartInfo(smartUUID, smartJson)
+	err = smartMdl.FromCollectorSmartInfo(smartUUID, smartJson, nil)
 
 	//assert
 	require.NoError(t, err)
 	require.Equal(t, smartUUID, smartMdl.ScrutinyUUID)
-	require.Equal(t, pkg.DeviceStatusPassed, smartMdl.Status)
+	require.Equal(t, pkg.DeviceStatusWarningScrutiny, smartMdl.Status)
 	require.Equal(t, 18, len(smartMdl.Attributes))
 
 	//check that temperature was correctly parsed
@@ -362,7 +363,7 @@ func TestFromCollectorSmartInfo_Fail_Smart(t *testing.T) {
 	//test
 	smartMdl := measurements.Smart{}
 	smartUUID := uuid.Must(uuid.NewV4())
-	err = smartMdl.FromCollectorSmartInfo(smartUUID, smartJson)
+	err = smartMdl.FromCollectorSmartInfo(smartUUID, smartJson, nil)
 
 	//assert
 	require.NoError(t, err)
@@ -387,12 +388,12 @@ func TestFromCollectorSmartInfo_Fail_ScrutinySmart(t *testing.T) {
 	//test
 	smartMdl := measurements.Smart{}
 	smartUUID := uuid.Must(uuid.NewV4())
-	err = smartMdl.FromCollectorSmartInfo(smartUUID, smartJson)
+	err = smartMdl.FromCollectorSmartInfo(smartUUID, smartJson, nil)
 
 	//assert
 	require.NoError(t, err)
 	require.Equal(t, smartUUID, smartMdl.ScrutinyUUID)
-	require.Equal(t, pkg.DeviceStatusFailedScrutiny|pkg.DeviceStatusFailedSmart, smartMdl.Status)
+	require.Equal(t, pkg.DeviceStatusFailedScrutiny|pkg.DeviceStatusFailedSmart|pkg.DeviceStatusWarningScrutiny, smartMdl.Status)
 	require.Equal(t, 17, len(smartMdl.Attributes))
 }
 
@@ -412,7 +413,7 @@ func TestFromCollectorSmartInfo_Fail_ScrutinyNonCriticalFailed(t *testing.T) {
 	//test
 	smartMdl := measurements.Smart{}
 	smartUUID := uuid.Must(uuid.NewV4())
-	err = smartMdl.FromCollectorSmartInfo(smartUUID, smartJson)
+	err = smartMdl.FromCollectorSmartInfo(smartUUID, smartJson, nil)
 
 	//assert
 	require.NoError(t, err)
@@ -446,7 +447,7 @@ func TestFromCollectorSmartInfo_NVMe_Fail_Scrutiny(t *testing.T) {
 	//test
 	smartMdl := measurements.Smart{}
 	smartUUID := uuid.Must(uuid.NewV4())
-	err = smartMdl.FromCollectorSmartInfo(smartUUID, smartJson)
+	err = smartMdl.FromCollectorSmartInfo(smartUUID, smartJson, nil)
 
 	//assert
 	require.NoError(t, err)
@@ -478,7 +479,7 @@ func TestFromCollectorSmartInfo_Nvme(t *testing.T) {
 	//test
 	smartMdl := measurements.Smart{}
 	smartUUID := uuid.Must(uuid.NewV4())
-	err = smartMdl.FromCollectorSmartInfo(smartUUID, smartJson)
+	err = smartMdl.FromCollectorSmartInfo(smartUUID, smartJson, nil)
 
 	//assert
 	require.NoError(t, err)
@@ -506,7 +507,7 @@ func TestFromCollectorSmartInfo_Scsi(t *testing.T) {
 	//test
 	smartMdl := measurements.Smart{}
 	smartUUID := uuid.Must(uuid.NewV4())
-	err = smartMdl.FromCollectorSmartInfo(smartUUID, smartJson)
+	err = smartMdl.FromCollectorSmartInfo(smartUUID, smartJson, nil)
 
 	//assert
 	require.NoError(t, err)
@@ -517,3 +518,89 @@ func TestFromCollectorSmartInfo_Scsi(t *testing.T) {
 	require.Equal(t, int64(56), smartMdl.Attributes["scsi_grown_defect_list"].(*measurements.SmartScsiAttribute).Value)
 	require.Equal(t, int64(300357663), smartMdl.Attributes["read_errors_corrected_by_eccfast"].(*measurements.SmartScsiAttribute).Value) //total_errors_corrected
 }
+
+func readCollectorSmartInfo(t *testing.T, path string) collector.SmartInfo {
+	smartDataBytes, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var smartJson collector.SmartInfo
+	require.NoError(t, json.Unmarshal(smartDataBytes, &smartJson))
+	return smartJson
+}
+
+func TestFromCollectorSmartInfo_Override_Ignore(t *testing.T) {
+	//setup
+	smartJson := readCollectorSmartInfo(t, "../testdata/smart-ata-failed-scrutiny.json")
+	overrides := map[string]thresholds.AttributeOverride{
+		"199": {Ignore: true},
+	}
+
+	//test
+	smartMdl := measurements.Smart{}
+	err := smartMdl.FromCollectorSmartInfo(uuid.Must(uuid.NewV4()), smartJson, overrides)
+
+	//assert
+	require.NoError(t, err)
+	require.Equal(t, pkg.DeviceStatusPassed, smartMdl.Status)
+	require.Equal(t, pkg.AttributeStatusPassed, smartMdl.Attributes["199"].GetStatus())
+	require.Equal(t, "Attribute is ignored by a user-defined override", smartMdl.Attributes["199"].(*measurements.SmartAtaAttribute).StatusReason)
+}
+
+func TestFromCollectorSmartInfo_Override_AtaLimits(t *testing.T) {
+	//setup
+	// attribute 199 has a raw value of 108
+	smartJson := readCollectorSmartInfo(t, "../testdata/smart-ata-failed-scrutiny.json")
+	warnLimit := int64(100)
+	failLimit := int64(200)
+	overrides := map[string]thresholds.AttributeOverride{
+		"199": {WarnLimit: &warnLimit, FailLimit: &failLimit},
+	}
+
+	//test
+	smartMdl := measurements.Smart{}
+	err := smartMdl.FromCollectorSmartInfo(uuid.Must(uuid.NewV4()), smartJson, overrides)
+
+	//assert
+	require.NoError(t, err)
+	require.Equal(t, pkg.DeviceStatusWarningScrutiny, smartMdl.Status)
+	require.Equal(t, pkg.AttributeStatusWarningScrutiny, smartMdl.Attributes["199"].GetStatus())
+}
+
+func TestFromCollectorSmartInfo_Override_NvmeNonCritical(t *testing.T) {
+	//setup
+	smartJson := readCollectorSmartInfo(t, "../testdata/smart-nvme-failed.json")
+	critical := false
+	overrides := map[string]thresholds.AttributeOverride{
+		"media_errors": {Critical: &critical},
+	}
+
+	//test
+	smartMdl := measurements.Smart{}
+	err := smartMdl.FromCollectorSmartInfo(uuid.Must(uuid.NewV4()), smartJson, overrides)
+
+	//assert
+	require.NoError(t, err)
+	require.Equal(t, pkg.DeviceStatusWarningScrutiny, smartMdl.Status)
+	require.Equal(t, pkg.AttributeStatusWarningScrutiny, smartMdl.Attributes["media_errors"].GetStatus())
+}
+
+func TestFromCollectorSmartInfo_Override_NvmeFailLimit(t *testing.T) {
+	//setup
+	// media_errors is 7
+	smartJson := readCollectorSmartInfo(t, "../testdata/smart-nvme-failed.json")
+	failLimit := int64(5)
+	overrides := map[string]thresholds.AttributeOverride{
+		"media_errors":    {FailLimit: &failLimit},
+		"percentage_used": {Ideal: "high", FailLimit: &failLimit},
+	}
+
+	//test
+	smartMdl := measurements.Smart{}
+	err := smartMdl.FromCollectorSmartInfo(uuid.Must(uuid.NewV4()), smartJson, overrides)
+
+	//assert
+	require.NoError(t, err)
+	require.Equal(t, pkg.DeviceStatusFailedScrutiny, smartMdl.Status)
+	require.Equal(t, pkg.AttributeStatusFailedScrutiny, smartMdl.Attributes["media_errors"].GetStatus())
+	require.Equal(t, "Attribute is failing user-defined limit", smartMdl.Attributes["media_errors"].(*measurements.SmartNvmeAttribute).StatusReason)
+	require.Equal(t, pkg.AttributeStatusFailedScrutiny, smartMdl.Attributes["percentage_used"].GetStatus())
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+)
+
+// NotificationDelivery records a single attempt to deliver a notification to a `notify.urls` target.
+type NotificationDelivery struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+
+	//notification
+	ScrutinyUUID string `json:"scrutiny_uuid,omitempty" gorm:"index"` //empty for test notifications
+	HostId       string `json:"host_id,omitempty"`
+	FailureType  string `json:"failure_type"`
+	Subject      string `json:"subject"`
+	Test         bool   `json:"test"`
+
+	//target
+	TargetType string `json:"target_type"` // webhook, script, shoutrrr
+	Target     string `json:"target"`      // url with credentials, tokens & query parameters removed
+
+	//attempt
+	Attempt    int    `json:"attempt"` // starts at 1
+	Success    bool   `json:"success"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+func (d NotificationDelivery) TableName() string {
+	return "notification_deliveries"
+}
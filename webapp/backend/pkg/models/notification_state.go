@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"github.com/analogj/scrutiny/webapp/backend/pkg"
+	"github.com/gofrs/uuid/v5"
+)
+
+// NotificationState stores the device status included in the last notification sent for a device. It is compared
+// with the current device status to detect transitions (see notify.DetectTransition).
+type NotificationState struct {
+	ScrutinyUUID uuid.UUID        `json:"scrutiny_uuid" gorm:"primaryKey"`
+	DeviceStatus pkg.DeviceStatus `json:"device_status"` // DeviceStatusPassed once a resolved notification is sent
+	NotifiedAt   time.Time        `json:"notified_at"`
+
+	// the failing attributes included in the last failure notification, see notify.FailingAttributeIds. Empty once a
+	// resolved notification is sent.
+	FailingAttributes []string `json:"failing_attributes,omitempty" gorm:"serializer:json"`
+
+	// warning notifications are rate limited separately, see notify.ShouldNotifyWarning. Reset when the device no longer
+	// has warnings.
+	WarningNotifiedAt *time.Time `json:"warning_notified_at,omitempty"`
+
+	// the highest warranty/endurance threshold (percent) already notified, see monitor.CheckLifetime. Reset when the
+	// percentage used drops below the notified threshold (eg. when the warranty expiry date is changed).
+	WarrantyNotifiedPercent  int `json:"warranty_notified_percent"`
+	EnduranceNotifiedPercent int `json:"endurance_notified_percent"`
+}
+
+func (s NotificationState) TableName() string {
+	return "notification_states"
+}
@@ -22,9 +22,12 @@ type Settings struct {
 	} `json:"collector" mapstructure:"collector"`
 
 	Metrics struct {
-		NotifyLevel            int  `json:"notify_level" mapstructure:"notify_level"`
-		StatusFilterAttributes int  `json:"status_filter_attributes" mapstructure:"status_filter_attributes"`
-		StatusThreshold        int  `json:"status_threshold" mapstructure:"status_threshold"`
-		RepeatNotifications    bool `json:"repeat_notifications" mapstructure:"repeat_notifications"`
+		NotifyLevel                int  `json:"notify_level" mapstructure:"notify_level"`
+		WarningNotifyIntervalHours int  `json:"warning_notify_interval_hours" mapstructure:"warning_notify_interval_hours"`
+		StatusFilterAttributes     int  `json:"status_filter_attributes" mapstructure:"status_filter_attributes"`
+		StatusThreshold            int  `json:"status_threshold" mapstructure:"status_threshold"`
+		RepeatNotifications        bool `json:"repeat_notifications" mapstructure:"repeat_notifications"`
+		NotifyFailureScore         int  `json:"notify_failure_score" mapstructure:"notify_failure_score"`
+		StaleIntervalHours         int  `json:"stale_interval_hours" mapstructure:"stale_interval_hours"`
 	} `json:"metrics" mapstructure:"metrics"`
 }
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"github.com/analogj/scrutiny/webapp/backend/pkg"
+	"gorm.io/gorm"
+)
+
+// User is a local account, used to log into the dashboard when `web.auth.enabled` is set.
+type User struct {
+	//GORM attributes, see: http://gorm.io/docs/conventions.html
+	gorm.Model
+
+	Username     string       `json:"username" gorm:"uniqueIndex;not null"`
+	PasswordHash string       `json:"-"`
+	Role         pkg.UserRole `json:"role" gorm:"not null"`
+
+	// Users authenticated by a reverse proxy (forward auth) are not stored in the database.
+	External bool `json:"external" gorm:"-"`
+}
+
+func (u User) TableName() string {
+	return "users"
+}
+
+func (u User) IsAdmin() bool {
+	return u.Role == pkg.UserRoleAdmin
+}
+
+// UserSession is created when a user logs in, the session token is stored in a cookie. The database only stores a hash.
+type UserSession struct {
+	//GORM attributes, see: http://gorm.io/docs/conventions.html
+	gorm.Model
+
+	UserID    uint      `gorm:"index;not null"`
+	TokenHash string    `gorm:"uniqueIndex;not null"`
+	ExpiresAt time.Time `gorm:"not null"`
+}
+
+func (s UserSession) TableName() string {
+	return "user_sessions"
+}
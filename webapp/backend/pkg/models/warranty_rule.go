@@ -0,0 +1,66 @@
+package models
+
+import (
+	"regexp"
+
+	"gorm.io/gorm"
+)
+
+// WarrantyRule is a user-defined warranty term, applied to devices with a matching model name. When multiple rules
+// match a device, they are merged (see MatchWarrantyRules). The warranty expiry date & rated endurance set on the device
+// itself take precedence over the rule.
+type WarrantyRule struct {
+	//GORM attributes, see: http://gorm.io/docs/conventions.html
+	gorm.Model
+
+	ModelRegex  string `json:"model_regex" gorm:"not null"`
+	Description string `json:"description,omitempty"`
+
+	// Terms, at least one must be set.
+	WarrantyYears float64 `json:"warranty_years,omitempty"`
+	RatedTbw      float64 `json:"rated_tbw,omitempty"`  // rated endurance, in terabytes written
+	RatedDwpd     float64 `json:"rated_dwpd,omitempty"` // rated drive writes per day, for the duration of the warranty
+
+	// Bytes per unit of the ATA Total LBAs Written attribute (241), for models which don't report it in logical blocks
+	// (eg. 32 MiB units). Defaults to the logical block size of the device.
+	Attribute241UnitBytes int64 `json:"attribute_241_unit_bytes,omitempty"`
+}
+
+func (r WarrantyRule) TableName() string {
+	return "warranty_rules"
+}
+
+// MatchWarrantyRules merges the rules matching the device model. Each term is taken from the newest matching rule which
+// sets it, so a rule which only sets attribute_241_unit_bytes doesn't hide the warranty terms of an older rule. The
+// merged rule has the id of the newest matching rule. rules must be ordered by id, returns nil if no rule matches.
+func MatchWarrantyRules(rules []WarrantyRule, device Device) *WarrantyRule {
+	var merged *WarrantyRule
+	for i := len(rules) - 1; i >= 0; i-- {
+		// the regex is validated when the rule is saved
+		modelRegex, err := regexp.Compile(rules[i].ModelRegex)
+		if err != nil || !modelRegex.MatchString(device.ModelName) {
+			continue
+		}
+		if merged == nil {
+			rule := rules[i]
+			merged = &rule
+			continue
+		}
+		if len(merged.Description) == 0 {
+			merged.Description = rules[i].Description
+		}
+		if merged.WarrantyYears == 0 {
+			merged.WarrantyYears = rules[i].WarrantyYears
+		}
+		if merged.RatedTbw == 0 {
+			merged.RatedTbw = rules[i].RatedTbw
+		}
+		if merged.RatedDwpd == 0 {
+			merged.RatedDwpd = rules[i].RatedDwpd
+		}
+		if merged.Attribute241UnitBytes == 0 {
+			merged.Attribute241UnitBytes = rules[i].Attribute241UnitBytes
+		}
+	}
+	return merged
+}
@@ -0,0 +1,287 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/analogj/scrutiny/webapp/backend/pkg/analysis"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/config"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/database"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/models"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/models/measurements"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/notify"
+	"github.com/sirupsen/logrus"
+)
+
+// Monitor runs periodic background checks against the stored devices, for problems which can't be detected when
+// the collector uploads data (eg. a device or host which stopped reporting, or a warranty which is about to expire).
+type Monitor struct {
+	Logger       logrus.FieldLogger
+	Config       config.Interface
+	DeviceRepo   database.DeviceRepo
+	NotifyDigest *notify.Digest
+	NotifyQueue  *notify.Queue
+
+	// the last time CheckLifetime ran, see lifetimeCheckInterval
+	lifetimeCheckedAt time.Time
+}
+
+// lifetimeCheckInterval limits CheckLifetime to once a day, the elapsed warranty & the consumed endurance change slowly.
+const lifetimeCheckInterval = 24 * time.Hour
+
+func New(logger logrus.FieldLogger, appConfig config.Interface, deviceRepo database.DeviceRepo, notifyDigest *notify.Digest, notifyQueue *notify.Queue) *Monitor {
+	return &Monitor{
+		Logger:       logger,
+		Config:       appConfig,
+		DeviceRepo:   deviceRepo,
+		NotifyDigest: notifyDigest,
+		NotifyQueue:  notifyQueue,
+	}
+}
+
+// Start runs the checks every interval, until the returned stop function is called.
+func (m *Monitor) Start(interval time.Duration) (stop func()) {
+	ticker := time.NewTicker(interval)
+	done := make(chan bool)
+
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case now := <-ticker.C:
+				if err := m.CheckStale(context.Background(), now); err != nil {
+					m.Logger.Errorf("An error occurred while checking for stale devices: %v", err)
+				}
+				if now.Sub(m.lifetimeCheckedAt) >= lifetimeCheckInterval {
+					m.lifetimeCheckedAt = now
+					if err := m.CheckLifetime(context.Background(), now); err != nil {
+						m.Logger.Errorf("An error occurred while checking device warranty & endurance: %v", err)
+					}
+				}
+				if err := m.PruneNotificationDeliveries(context.Background(), now); err != nil {
+					m.Logger.Errorf("An error occurred while pruning the notification delivery log: %v", err)
+				}
+			}
+		}
+	}()
+
+	return func() {
+		ticker.Stop()
+		close(done)
+	}
+}
+
+// PruneNotificationDeliveries deletes notification delivery attempts older than `notify.delivery_retention_days`, so
+// the delivery log doesn't grow forever.
+func (m *Monitor) PruneNotificationDeliveries(ctx context.Context, now time.Time) error {
+	retentionDays := m.Config.GetInt("notify.delivery_retention_days")
+	if retentionDays <= 0 {
+		//keep the delivery log forever
+		return nil
+	}
+	pruned, err := m.DeviceRepo.PruneNotificationDeliveries(ctx, now.AddDate(0, 0, -retentionDays))
+	if err != nil {
+		return err
+	}
+	if pruned > 0 {
+		m.Logger.Debugf("Pruned %d notification delivery attempts older than %d days", pruned, retentionDays)
+	}
+	return nil
+}
+
+// CheckStale marks devices & hosts which have not reported data for `metrics.stale_interval_hours` as stale, and
+// sends a notification when they become stale. A stale host results in a single notification, rather than one
+// notification for each of its devices.
+func (m *Monitor) CheckStale(ctx context.Context, now time.Time) error {
+	staleIntervalHours := m.Config.GetInt(fmt.Sprintf("%s.metrics.stale_interval_hours", config.DB_USER_SETTINGS_SUBKEY))
+	if staleIntervalHours <= 0 {
+		//stale device detection is disabled
+		return nil
+	}
+	staleBefore := now.Add(-time.Duration(staleIntervalHours) * time.Hour)
+
+	devices, err := m.DeviceRepo.GetDevices(ctx)
+	if err != nil {
+		return err
+	}
+	hostDevices := map[string][]models.Device{}
+	for _, device := range devices {
+		if device.Archived {
+			continue
+		}
+		hostDevices[device.HostId] = append(hostDevices[device.HostId], device)
+	}
+
+	hosts, err := m.DeviceRepo.GetHosts(ctx)
+	if err != nil {
+		return err
+	}
+	staleHosts := map[string]bool{}
+	for _, host := range hosts {
+		if host.Archived || !host.LastCheckinAt.Before(staleBefore) {
+			continue
+		}
+		staleHosts[host.HostId] = true
+		if host.Stale {
+			//already notified
+			continue
+		}
+
+		m.Logger.Warnf("Collector for host %s has not checked in since %s", host.HostId, host.LastCheckinAt.Format(time.RFC3339))
+		if err := m.DeviceRepo.UpdateHostStale(ctx, host.HostId, true); err != nil {
+			return err
+		}
+		m.sendNotification(notify.NewHostStale(m.Logger, m.Config, host.HostId, host.LastCheckinAt, hostDevices[host.HostId]))
+	}
+
+	for _, device := range devices {
+		//devices which never uploaded data are stale when they were registered before the stale interval
+		lastSeenAt := device.CreatedAt
+		if device.LastSeenAt != nil {
+			lastSeenAt = *device.LastSeenAt
+		}
+		if device.Archived || device.Stale || !lastSeenAt.Before(staleBefore) {
+			continue
+		}
+
+		m.Logger.Warnf("Device %s has not reported data since %s", device.DeviceName, lastSeenAt.Format(time.RFC3339))
+		if err := m.DeviceRepo.UpdateDeviceStale(ctx, device.ScrutinyUUID, true); err != nil {
+			return err
+		}
+		if staleHosts[device.HostId] {
+			//included in the host notification
+			continue
+		}
+		m.sendNotification(notify.NewDeviceStale(m.Logger, m.Config, device))
+	}
+	return nil
+}
+
+// CheckLifetime sends a notification when the elapsed warranty period or the consumed rated endurance of a device (see
+// analysis.CalculateLifetime) crosses one of the `notify.lifetime.warranty_percentages` or
+// `notify.lifetime.endurance_percentages` thresholds. Each threshold is only notified once, the last notified threshold
+// is stored in the NotificationState of the device. The consumed endurance is calculated when the collector uploads data
+// (see analysis.AnalyzeEndurance), the SMART data is only retrieved to estimate the warranty start of devices without a
+// purchase date.
+func (m *Monitor) CheckLifetime(ctx context.Context, now time.Time) error {
+	warrantyPercentages := m.lifetimePercentages("notify.lifetime.warranty_percentages")
+	endurancePercentages := m.lifetimePercentages("notify.lifetime.endurance_percentages")
+	if len(warrantyPercentages) == 0 && len(endurancePercentages) == 0 {
+		//lifetime notifications are disabled
+		return nil
+	}
+
+	devices, err := m.DeviceRepo.GetDevices(ctx)
+	if err != nil {
+		return err
+	}
+	rules, err := m.DeviceRepo.GetWarrantyRules(ctx)
+	if err != nil {
+		return err
+	}
+	for _, device := range devices {
+		if device.Archived {
+			continue
+		}
+		rule := models.MatchWarrantyRules(rules, device)
+
+		var latest *measurements.Smart
+		if len(warrantyPercentages) > 0 && needsWarrantyStart(device, rule) {
+			history, err := m.DeviceRepo.GetSmartAttributeHistory(ctx, device.ScrutinyUUID, database.DURATION_KEY_MONTH, 1, 0, nil)
+			if err != nil {
+				m.Logger.Warnf("Could not get SMART data for device %s, the warranty start is unknown: %v", device.ScrutinyUUID, err)
+			} else if len(history) > 0 {
+				latest = &history[0]
+			}
+		}
+		lifetime := analysis.CalculateLifetime(device, rule, latest, now)
+		lifetime.EndurancePercentUsed = device.EndurancePercentUsed
+
+		notificationState, err := m.DeviceRepo.GetNotificationState(ctx, device.ScrutinyUUID)
+		if err != nil {
+			m.Logger.Errorf("Could not get notification state for device %s, skipping lifetime check: %v", device.ScrutinyUUID, err)
+			continue
+		}
+		notifications := []notify.Notify{}
+
+		// a lower threshold (eg. after the warranty expiry date is extended) resets the notified threshold, so it can be
+		// notified again later.
+		warrantyPercent := crossedPercentage(warrantyPercentages, lifetime.WarrantyPercentUsed)
+		if warrantyPercent > notificationState.WarrantyNotifiedPercent {
+			m.Logger.Infof("Warranty for device %s is %d%% elapsed", device.DeviceName, warrantyPercent)
+			notifications = append(notifications, notify.NewWarrantyExpiry(m.Logger, m.Config, device, lifetime, warrantyPercent))
+		}
+		endurancePercent := crossedPercentage(endurancePercentages, lifetime.EndurancePercentUsed)
+		if endurancePercent > notificationState.EnduranceNotifiedPercent {
+			m.Logger.Infof("Rated endurance for device %s is %d%% used", device.DeviceName, endurancePercent)
+			notifications = append(notifications, notify.NewEnduranceLimit(m.Logger, m.Config, device, lifetime, endurancePercent))
+		}
+
+		if warrantyPercent == notificationState.WarrantyNotifiedPercent && endurancePercent == notificationState.EnduranceNotifiedPercent {
+			continue
+		}
+		notificationState.WarrantyNotifiedPercent = warrantyPercent
+		notificationState.EnduranceNotifiedPercent = endurancePercent
+		if err := m.DeviceRepo.SaveNotificationState(ctx, notificationState); err != nil {
+			//don't send the notifications, they would be sent again on the next check
+			m.Logger.Errorf("Could not save notification state for device %s: %v", device.ScrutinyUUID, err)
+			continue
+		}
+		for _, notification := range notifications {
+			m.sendNotification(notification)
+		}
+	}
+	return nil
+}
+
+// lifetimePercentages returns the configured thresholds, an empty list disables the check.
+func (m *Monitor) lifetimePercentages(key string) []int {
+	percentages := []int{}
+	for _, value := range m.Config.GetStringSlice(key) {
+		percentage, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || percentage <= 0 {
+			m.Logger.Warnf("Ignoring invalid %s threshold: %q", key, value)
+			continue
+		}
+		percentages = append(percentages, percentage)
+	}
+	return percentages
+}
+
+// crossedPercentage returns the highest threshold which has been reached, or 0.
+func crossedPercentage(percentages []int, percentUsed *float64) int {
+	crossed := 0
+	if percentUsed == nil {
+		return crossed
+	}
+	for _, percentage := range percentages {
+		if *percentUsed >= float64(percentage) && percentage > crossed {
+			crossed = percentage
+		}
+	}
+	return crossed
+}
+
+// the SMART data is only needed to estimate the warranty start, when the warranty end is known but not the purchase date
+func needsWarrantyStart(device models.Device, rule *models.WarrantyRule) bool {
+	if device.PurchaseDate != nil {
+		return false
+	}
+	return device.WarrantyExpiryDate != nil || (rule != nil && rule.WarrantyYears > 0)
+}
+
+// sendNotification queues the notification to be sent in the background (see notify.Queue), or adds it to the digest
+// when `notify.digest.enabled` is set.
+func (m *Monitor) sendNotification(n notify.Notify) {
+	n.Deliveries = m.DeviceRepo
+
+	if m.NotifyDigest != nil && m.NotifyDigest.Enabled() {
+		m.NotifyDigest.Add(n)
+		return
+	}
+	m.NotifyQueue.Add(n)
+}
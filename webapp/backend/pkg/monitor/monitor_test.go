@@ -0,0 +1,289 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/analogj/scrutiny/webapp/backend/pkg"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/config"
+	mock_database "github.com/analogj/scrutiny/webapp/backend/pkg/database/mock"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/models"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/models/measurements"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/notify"
+	"github.com/gofrs/uuid/v5"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func newTestMonitor(t *testing.T, staleIntervalHours int) (*Monitor, *mock_database.MockDeviceRepo, *[]notify.WebhookPayload) {
+	var mu sync.Mutex
+	payloads := []notify.WebhookPayload{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload notify.WebhookPayload
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		mu.Lock()
+		defer mu.Unlock()
+		payloads = append(payloads, payload)
+	}))
+	t.Cleanup(server.Close)
+
+	appConfig, err := config.Create()
+	require.NoError(t, err)
+	appConfig.Set("notify.urls", []string{server.URL})
+	appConfig.Set("user.metrics.stale_interval_hours", staleIntervalHours)
+
+	mockCtrl := gomock.NewController(t)
+	fakeDeviceRepo := mock_database.NewMockDeviceRepo(mockCtrl)
+	fakeDeviceRepo.EXPECT().SaveNotificationDelivery(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+	return New(logrus.StandardLogger(), appConfig, fakeDeviceRepo, nil, notify.NewQueue(logrus.StandardLogger(), 1, 10)), fakeDeviceRepo, &payloads
+}
+
+func TestMonitor_CheckStale_Disabled(t *testing.T) {
+	t.Parallel()
+
+	//setup
+	testMonitor, _, payloads := newTestMonitor(t, 0)
+
+	//test
+	err := testMonitor.CheckStale(context.Background(), time.Now())
+	testMonitor.NotifyQueue.Close() //wait for the queued notifications to be sent
+
+	//assert
+	require.NoError(t, err)
+	require.Empty(t, *payloads)
+}
+
+func TestMonitor_CheckStale_Device(t *testing.T) {
+	t.Parallel()
+
+	//setup
+	testMonitor, fakeDeviceRepo, payloads := newTestMonitor(t, 24)
+	now := time.Now()
+	recentlySeen := now.Add(-time.Hour)
+	lastSeen := now.Add(-25 * time.Hour)
+	staleDevice := models.Device{ScrutinyUUID: uuid.Must(uuid.NewV4()), DeviceName: "sdb", HostId: "nas01", LastSeenAt: &lastSeen}
+	fakeDeviceRepo.EXPECT().GetDevices(gomock.Any()).Return([]models.Device{
+		{ScrutinyUUID: uuid.Must(uuid.NewV4()), DeviceName: "sda", HostId: "nas01", LastSeenAt: &recentlySeen},
+		staleDevice,
+		{ScrutinyUUID: uuid.Must(uuid.NewV4()), DeviceName: "sdc", HostId: "nas01", LastSeenAt: &lastSeen, Stale: true},
+		{ScrutinyUUID: uuid.Must(uuid.NewV4()), DeviceName: "sdd", HostId: "nas01", LastSeenAt: &lastSeen, Archived: true},
+		//never reported data, recently registered
+		{ScrutinyUUID: uuid.Must(uuid.NewV4()), DeviceName: "sde", HostId: "nas01", CreatedAt: recentlySeen},
+	}, nil)
+	fakeDeviceRepo.EXPECT().GetHosts(gomock.Any()).Return([]models.Host{{HostId: "nas01", LastCheckinAt: recentlySeen}}, nil)
+	fakeDeviceRepo.EXPECT().UpdateDeviceStale(gomock.Any(), staleDevice.ScrutinyUUID, true).Return(nil)
+
+	//test
+	err := testMonitor.CheckStale(context.Background(), now)
+	testMonitor.NotifyQueue.Close() //wait for the queued notifications to be sent
+
+	//assert
+	require.NoError(t, err)
+	require.Len(t, *payloads, 1)
+	require.Equal(t, notify.NotifyFailureTypeDeviceStale, (*payloads)[0].FailureType)
+	require.Equal(t, staleDevice.ScrutinyUUID.String(), (*payloads)[0].ScrutinyUUID)
+	require.Equal(t, "Scrutiny device stopped reporting on [host]device: [nas01]sdb", (*payloads)[0].Subject)
+}
+
+func TestMonitor_CheckStale_DeviceNeverSeen(t *testing.T) {
+	t.Parallel()
+
+	//setup
+	testMonitor, fakeDeviceRepo, payloads := newTestMonitor(t, 24)
+	now := time.Now()
+	registeredAt := now.Add(-25 * time.Hour)
+	neverSeenDevice := models.Device{ScrutinyUUID: uuid.Must(uuid.NewV4()), DeviceName: "sdb", HostId: "nas01", CreatedAt: registeredAt}
+	fakeDeviceRepo.EXPECT().GetDevices(gomock.Any()).Return([]models.Device{neverSeenDevice}, nil)
+	fakeDeviceRepo.EXPECT().GetHosts(gomock.Any()).Return([]models.Host{{HostId: "nas01", LastCheckinAt: now}}, nil)
+	fakeDeviceRepo.EXPECT().UpdateDeviceStale(gomock.Any(), neverSeenDevice.ScrutinyUUID, true).Return(nil)
+
+	//test
+	err := testMonitor.CheckStale(context.Background(), now)
+	testMonitor.NotifyQueue.Close() //wait for the queued notifications to be sent
+
+	//assert
+	require.NoError(t, err)
+	require.Len(t, *payloads, 1)
+	require.Equal(t, notify.NotifyFailureTypeDeviceStale, (*payloads)[0].FailureType)
+	require.Equal(t, neverSeenDevice.ScrutinyUUID.String(), (*payloads)[0].ScrutinyUUID)
+	require.Empty(t, (*payloads)[0].LastSeenAt)
+}
+
+func TestMonitor_CheckStale_Host(t *testing.T) {
+	t.Parallel()
+
+	//setup
+	testMonitor, fakeDeviceRepo, payloads := newTestMonitor(t, 24)
+	now := time.Now()
+	lastSeen := now.Add(-25 * time.Hour)
+	devices := []models.Device{
+		{ScrutinyUUID: uuid.Must(uuid.NewV4()), DeviceName: "sda", HostId: "nas01", LastSeenAt: &lastSeen},
+		{ScrutinyUUID: uuid.Must(uuid.NewV4()), DeviceName: "sdb", HostId: "nas01", Label: "parity", LastSeenAt: &lastSeen},
+	}
+	fakeDeviceRepo.EXPECT().GetDevices(gomock.Any()).Return(devices, nil)
+	fakeDeviceRepo.EXPECT().GetHosts(gomock.Any()).Return([]models.Host{
+		{HostId: "nas01", LastCheckinAt: lastSeen},
+		{HostId: "nas02", LastCheckinAt: lastSeen, Stale: true},
+		{HostId: "nas03", LastCheckinAt: lastSeen, Archived: true},
+	}, nil)
+	fakeDeviceRepo.EXPECT().UpdateHostStale(gomock.Any(), "nas01", true).Return(nil)
+	fakeDeviceRepo.EXPECT().UpdateDeviceStale(gomock.Any(), devices[0].ScrutinyUUID, true).Return(nil)
+	fakeDeviceRepo.EXPECT().UpdateDeviceStale(gomock.Any(), devices[1].ScrutinyUUID, true).Return(nil)
+
+	//test
+	err := testMonitor.CheckStale(context.Background(), now)
+	testMonitor.NotifyQueue.Close() //wait for the queued notifications to be sent
+
+	//assert
+	require.NoError(t, err)
+	require.Len(t, *payloads, 1)
+	require.Equal(t, notify.NotifyFailureTypeHostStale, (*payloads)[0].FailureType)
+	require.Equal(t, "Scrutiny collector stopped reporting for host: nas01", (*payloads)[0].Subject)
+	require.Equal(t, []string{"sda", "sdb (parity)"}, (*payloads)[0].StaleDevices)
+	require.Nil(t, (*payloads)[0].Device)
+}
+
+func TestMonitor_CheckLifetime_Warranty(t *testing.T) {
+	t.Parallel()
+
+	//setup
+	testMonitor, fakeDeviceRepo, payloads := newTestMonitor(t, 0)
+	now := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+	purchaseDate := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	device := models.Device{ScrutinyUUID: uuid.Must(uuid.NewV4()), DeviceName: "sda", HostId: "nas01", ModelName: "WDC WD40EFRX", PurchaseDate: &purchaseDate}
+	fakeDeviceRepo.EXPECT().GetDevices(gomock.Any()).Return([]models.Device{device}, nil)
+	// 5.5 of 6 years elapsed, the purchase date is known so the SMART data is not retrieved
+	fakeDeviceRepo.EXPECT().GetWarrantyRules(gomock.Any()).Return([]models.WarrantyRule{{ModelRegex: "^WDC", WarrantyYears: 6}}, nil)
+	fakeDeviceRepo.EXPECT().GetNotificationState(gomock.Any(), device.ScrutinyUUID).Return(models.NotificationState{ScrutinyUUID: device.ScrutinyUUID}, nil)
+	fakeDeviceRepo.EXPECT().SaveNotificationState(gomock.Any(), models.NotificationState{ScrutinyUUID: device.ScrutinyUUID, WarrantyNotifiedPercent: 90}).Return(nil)
+
+	//test
+	err := testMonitor.CheckLifetime(context.Background(), now)
+	testMonitor.NotifyQueue.Close() //wait for the queued notifications to be sent
+
+	//assert
+	require.NoError(t, err)
+	require.Len(t, *payloads, 1)
+	require.Equal(t, notify.NotifyFailureTypeWarrantyExpiry, (*payloads)[0].FailureType)
+	require.Equal(t, 90, (*payloads)[0].LifetimePercentUsed)
+	require.Equal(t, "2025-01-01", (*payloads)[0].WarrantyExpiryDate)
+	require.Equal(t, "Scrutiny warranty 90% elapsed on [host]device: [nas01]sda", (*payloads)[0].Subject)
+}
+
+func TestMonitor_CheckLifetime_DeviceError(t *testing.T) {
+	t.Parallel()
+
+	//setup
+	testMonitor, fakeDeviceRepo, payloads := newTestMonitor(t, 0)
+	now := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+	purchaseDate := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	brokenDevice := models.Device{ScrutinyUUID: uuid.Must(uuid.NewV4()), DeviceName: "sda", HostId: "nas01", ModelName: "WDC WD40EFRX", PurchaseDate: &purchaseDate}
+	device := models.Device{ScrutinyUUID: uuid.Must(uuid.NewV4()), DeviceName: "sdb", HostId: "nas01", ModelName: "WDC WD40EFRX", PurchaseDate: &purchaseDate}
+	fakeDeviceRepo.EXPECT().GetDevices(gomock.Any()).Return([]models.Device{brokenDevice, device}, nil)
+	fakeDeviceRepo.EXPECT().GetWarrantyRules(gomock.Any()).Return([]models.WarrantyRule{{ModelRegex: "^WDC", WarrantyYears: 6}}, nil)
+	fakeDeviceRepo.EXPECT().GetNotificationState(gomock.Any(), brokenDevice.ScrutinyUUID).Return(models.NotificationState{}, errors.New("database is locked"))
+	fakeDeviceRepo.EXPECT().GetNotificationState(gomock.Any(), device.ScrutinyUUID).Return(models.NotificationState{ScrutinyUUID: device.ScrutinyUUID}, nil)
+	fakeDeviceRepo.EXPECT().SaveNotificationState(gomock.Any(), models.NotificationState{ScrutinyUUID: device.ScrutinyUUID, WarrantyNotifiedPercent: 90}).Return(nil)
+
+	//test
+	err := testMonitor.CheckLifetime(context.Background(), now)
+	testMonitor.NotifyQueue.Close() //wait for the queued notifications to be sent
+
+	//assert
+	require.NoError(t, err)
+	require.Len(t, *payloads, 1)
+	require.Equal(t, device.ScrutinyUUID.String(), (*payloads)[0].ScrutinyUUID)
+}
+
+func TestMonitor_CheckLifetime_Endurance_AlreadyNotified(t *testing.T) {
+	t.Parallel()
+
+	//setup
+	testMonitor, fakeDeviceRepo, payloads := newTestMonitor(t, 0)
+	ratedTbw := 600.0
+	// 500TB written, 83% of the rated endurance (calculated when the collector uploaded data)
+	endurancePercentUsed := 83.3
+	device := models.Device{ScrutinyUUID: uuid.Must(uuid.NewV4()), DeviceName: "nvme0", DeviceProtocol: pkg.DeviceProtocolNvme, RatedTbw: &ratedTbw, EndurancePercentUsed: &endurancePercentUsed}
+	fakeDeviceRepo.EXPECT().GetDevices(gomock.Any()).Return([]models.Device{device}, nil).Times(2)
+	fakeDeviceRepo.EXPECT().GetWarrantyRules(gomock.Any()).Return([]models.WarrantyRule{}, nil).Times(2)
+	gomock.InOrder(
+		fakeDeviceRepo.EXPECT().GetNotificationState(gomock.Any(), device.ScrutinyUUID).Return(models.NotificationState{ScrutinyUUID: device.ScrutinyUUID}, nil),
+		fakeDeviceRepo.EXPECT().SaveNotificationState(gomock.Any(), models.NotificationState{ScrutinyUUID: device.ScrutinyUUID, EnduranceNotifiedPercent: 80}).Return(nil),
+		fakeDeviceRepo.EXPECT().GetNotificationState(gomock.Any(), device.ScrutinyUUID).Return(models.NotificationState{ScrutinyUUID: device.ScrutinyUUID, EnduranceNotifiedPercent: 80}, nil),
+	)
+
+	//test
+	require.NoError(t, testMonitor.CheckLifetime(context.Background(), time.Now()))
+	require.NoError(t, testMonitor.CheckLifetime(context.Background(), time.Now()))
+	testMonitor.NotifyQueue.Close() //wait for the queued notifications to be sent
+
+	//assert
+	require.Len(t, *payloads, 1)
+	require.Equal(t, notify.NotifyFailureTypeEnduranceLimit, (*payloads)[0].FailureType)
+	require.Equal(t, 80, (*payloads)[0].LifetimePercentUsed)
+	require.Equal(t, 600.0, (*payloads)[0].RatedTbw)
+	require.Equal(t, "Scrutiny rated endurance 80% used on device: nvme0", (*payloads)[0].Subject)
+}
+
+func TestMonitor_CheckLifetime_EstimatedWarrantyStart(t *testing.T) {
+	t.Parallel()
+
+	//setup
+	testMonitor, fakeDeviceRepo, payloads := newTestMonitor(t, 0)
+	now := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+	device := models.Device{ScrutinyUUID: uuid.Must(uuid.NewV4()), DeviceName: "sda", HostId: "nas01", ModelName: "WDC WD40EFRX", CreatedAt: now.Add(-time.Hour)}
+	fakeDeviceRepo.EXPECT().GetDevices(gomock.Any()).Return([]models.Device{device}, nil)
+	fakeDeviceRepo.EXPECT().GetWarrantyRules(gomock.Any()).Return([]models.WarrantyRule{{ModelRegex: "^WDC", WarrantyYears: 2}}, nil)
+	// powered on for 1.9 of the 2 years, even though Scrutiny only saw the device an hour ago
+	latest := measurements.Smart{Date: now, PowerOnHours: int64(1.9 * 365 * 24)}
+	fakeDeviceRepo.EXPECT().GetSmartAttributeHistory(gomock.Any(), device.ScrutinyUUID, gomock.Any(), 1, 0, nil).Return([]measurements.Smart{latest}, nil)
+	fakeDeviceRepo.EXPECT().GetNotificationState(gomock.Any(), device.ScrutinyUUID).Return(models.NotificationState{ScrutinyUUID: device.ScrutinyUUID}, nil)
+	fakeDeviceRepo.EXPECT().SaveNotificationState(gomock.Any(), models.NotificationState{ScrutinyUUID: device.ScrutinyUUID, WarrantyNotifiedPercent: 90}).Return(nil)
+
+	//test
+	err := testMonitor.CheckLifetime(context.Background(), now)
+	testMonitor.NotifyQueue.Close() //wait for the queued notifications to be sent
+
+	//assert
+	require.NoError(t, err)
+	require.Len(t, *payloads, 1)
+	require.Equal(t, notify.NotifyFailureTypeWarrantyExpiry, (*payloads)[0].FailureType)
+	require.Equal(t, 90, (*payloads)[0].LifetimePercentUsed)
+}
+
+func TestMonitor_PruneNotificationDeliveries(t *testing.T) {
+	t.Parallel()
+
+	//setup
+	testMonitor, fakeDeviceRepo, _ := newTestMonitor(t, 0)
+	now := time.Now()
+	fakeDeviceRepo.EXPECT().PruneNotificationDeliveries(gomock.Any(), now.AddDate(0, 0, -30)).Return(int64(2), nil)
+
+	//test
+	err := testMonitor.PruneNotificationDeliveries(context.Background(), now)
+
+	//assert
+	require.NoError(t, err)
+}
+
+func TestMonitor_PruneNotificationDeliveries_Disabled(t *testing.T) {
+	t.Parallel()
+
+	//setup
+	testMonitor, _, _ := newTestMonitor(t, 0)
+	testMonitor.Config.Set("notify.delivery_retention_days", 0)
+
+	//test
+	err := testMonitor.PruneNotificationDeliveries(context.Background(), time.Now())
+
+	//assert
+	require.NoError(t, err)
+}
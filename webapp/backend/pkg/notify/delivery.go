@@ -0,0 +1,131 @@
+package notify
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/analogj/scrutiny/webapp/backend/pkg/config"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/models"
+)
+
+const (
+	NotifyTargetTypeWebhook  = "webhook"
+	NotifyTargetTypeScript   = "script"
+	NotifyTargetTypeShoutrrr = "shoutrrr"
+)
+
+// DeliveryRecorder persists notification delivery attempts, implemented by database.DeviceRepo
+type DeliveryRecorder interface {
+	SaveNotificationDelivery(ctx context.Context, delivery models.NotificationDelivery) error
+}
+
+// deliver sends the notification to the target (see forTarget), and records the attempt in the delivery log. A failed
+// attempt is retried later, see retryLater.
+func (n *Notify) deliver(target Target, targetType string, send func(targetNotify *Notify, targetUrl string) error) error {
+	attempt := max(n.attempt, 1)
+	targetNotify := n.forTarget(target)
+
+	startTime := time.Now()
+	err := send(&targetNotify, target.Url)
+	targetNotify.recordDelivery(target, targetType, attempt, time.Since(startTime), err)
+	if err != nil && attempt <= target.retryCount(n.Config) {
+		n.retryLater(target, attempt)
+	}
+	return err
+}
+
+// retryLater queues the notification for the failed target again (`notify.retries`), after a backoff
+// (`notify.retry_backoff_seconds`, doubled after each retry). Only notifications sent by a Queue are retried, so the
+// sender is never blocked while waiting for a retry.
+func (n *Notify) retryLater(target Target, attempt int) {
+	if n.queue == nil {
+		n.Logger.Warnf("Not retrying notification to %s, it was not sent by the notification queue", redactTargetUrl(target.Url))
+		return
+	}
+	backoff := time.Duration(n.Config.GetInt("notify.retry_backoff_seconds")) * time.Second << (attempt - 1)
+	n.Logger.Warnf("Retrying notification to %s in %s (attempt %d of %d)", redactTargetUrl(target.Url), backoff, attempt+1, target.retryCount(n.Config)+1)
+
+	retry := *n
+	retry.attempt = attempt + 1
+	retry.targets = []Target{target}
+	n.queue.addAfter(retry, backoff)
+}
+
+func (n *Notify) recordDelivery(target Target, targetType string, attempt int, duration time.Duration, sendErr error) {
+	if n.Deliveries == nil {
+		return
+	}
+	delivery := models.NotificationDelivery{
+		ScrutinyUUID: n.Payload.ScrutinyUUID,
+		HostId:       n.Payload.HostId,
+		FailureType:  n.Payload.FailureType,
+		Subject:      n.Payload.Subject,
+		Test:         n.Payload.Test,
+		TargetType:   targetType,
+		Target:       redactTargetUrl(target.Url),
+		Attempt:      attempt,
+		Success:      sendErr == nil,
+		DurationMs:   duration.Milliseconds(),
+	}
+	if sendErr != nil {
+		delivery.Error = sendErr.Error()
+	}
+	if err := n.Deliveries.SaveNotificationDelivery(context.Background(), delivery); err != nil {
+		n.Logger.Errorf("Could not record notification delivery: %v", err)
+	}
+}
+
+// deliverWebhook, deliverScript & deliverShoutrrr deliver the notification to a target of that type, see deliver
+func (n *Notify) deliverWebhook(target Target) error {
+	return n.deliver(target, NotifyTargetTypeWebhook, (*Notify).SendWebhookNotification)
+}
+
+func (n *Notify) deliverScript(target Target) error {
+	return n.deliver(target, NotifyTargetTypeScript, (*Notify).SendScriptNotification)
+}
+
+func (n *Notify) deliverShoutrrr(target Target) error {
+	return n.deliver(target, NotifyTargetTypeShoutrrr, (*Notify).SendShoutrrrNotification)
+}
+
+// deliveryTimeout is the timeout for a single delivery attempt, set per target by forTarget
+func (n *Notify) deliveryTimeout() time.Duration {
+	if n.timeout > 0 {
+		return n.timeout
+	}
+	return time.Duration(n.Config.GetInt("notify.timeout_seconds")) * time.Second
+}
+
+func (t Target) retryCount(appconfig config.Interface) int {
+	if t.Retries != nil {
+		return max(*t.Retries, 0)
+	}
+	return max(appconfig.GetInt("notify.retries"), 0)
+}
+
+func (t Target) timeout(appconfig config.Interface) time.Duration {
+	if t.TimeoutSeconds > 0 {
+		return time.Duration(t.TimeoutSeconds) * time.Second
+	}
+	return time.Duration(appconfig.GetInt("notify.timeout_seconds")) * time.Second
+}
+
+// redactTargetUrl removes credentials, tokens & query parameters from a target url, so it can be logged & stored in the
+// delivery log. Script paths are kept as-is.
+func redactTargetUrl(targetUrl string) string {
+	if strings.HasPrefix(targetUrl, "script://") {
+		return targetUrl
+	}
+	parsedUrl, err := url.Parse(targetUrl)
+	if err != nil {
+		scheme, _, _ := strings.Cut(targetUrl, "://")
+		return scheme + "://..."
+	}
+	redacted := parsedUrl.Scheme + "://" + parsedUrl.Host
+	if len(strings.Trim(parsedUrl.Path, "/")) > 0 || len(parsedUrl.RawQuery) > 0 || parsedUrl.User != nil {
+		redacted += "/..."
+	}
+	return redacted
+}
@@ -0,0 +1,195 @@
+package notify
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/analogj/scrutiny/webapp/backend/pkg/config"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/models"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+)
+
+// Digest queues notifications when `notify.digest.enabled` is set, so a burst of notifications (eg. many disks behind a
+// failing HBA) is sent as a single notification per target. The queue is flushed `notify.digest.quiet_seconds` after
+// the last queued notification, or at most `notify.digest.interval_seconds` after the first one.
+//
+// The queue is only stored in memory, queued notifications are lost if Scrutiny is restarted before the flush.
+type Digest struct {
+	Logger logrus.FieldLogger
+	Config config.Interface
+
+	// optional, the digest notifications are sent (and retried) by the queue. Sent directly (without retries) when nil.
+	Queue *Queue
+
+	mu         sync.Mutex
+	queue      []Notify
+	maxTimer   *time.Timer
+	quietTimer *time.Timer
+}
+
+func NewDigest(logger logrus.FieldLogger, appconfig config.Interface) *Digest {
+	return &Digest{
+		Logger: logger,
+		Config: appconfig,
+	}
+}
+
+func (d *Digest) Enabled() bool {
+	return d.Config.GetBool("notify.digest.enabled")
+}
+
+// Add queues the notification. A queued notification for the same device & failure type is replaced, only the latest
+// one is included in the digest.
+func (d *Digest) Add(n Notify) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.queue = slices.DeleteFunc(d.queue, func(queued Notify) bool {
+		return len(n.Payload.ScrutinyUUID) > 0 &&
+			queued.Payload.ScrutinyUUID == n.Payload.ScrutinyUUID &&
+			queued.Payload.FailureType == n.Payload.FailureType
+	})
+	d.queue = append(d.queue, n)
+	d.Logger.Infof("Queued %s notification for %s in digest (%d queued)", n.Payload.FailureType, n.Payload.DeviceName, len(d.queue))
+
+	flush := func() {
+		if err := d.Flush(); err != nil {
+			d.Logger.Errorf("One or more digest notifications failed to send: %v", err)
+		}
+	}
+	if d.maxTimer == nil {
+		d.maxTimer = time.AfterFunc(time.Duration(d.Config.GetInt("notify.digest.interval_seconds"))*time.Second, flush)
+	}
+	if quiet := time.Duration(d.Config.GetInt("notify.digest.quiet_seconds")) * time.Second; quiet > 0 {
+		if d.quietTimer == nil {
+			d.quietTimer = time.AfterFunc(quiet, flush)
+		} else {
+			d.quietTimer.Reset(quiet)
+		}
+	}
+}
+
+// Flush sends the queued notifications. Each target receives a single notification, summarizing the queued
+// notifications matching its filters (or the original notification, if only one matches). When the digest has a Queue,
+// the notifications are added to it instead of being sent before Flush returns.
+func (d *Digest) Flush() error {
+	d.mu.Lock()
+	queue := d.queue
+	d.queue = nil
+	for _, timer := range []*time.Timer{d.maxTimer, d.quietTimer} {
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+	d.maxTimer = nil
+	d.quietTimer = nil
+	d.mu.Unlock()
+
+	if len(queue) == 0 {
+		return nil
+	}
+
+	targets, err := LoadTargets(d.Config)
+	if err != nil {
+		d.Logger.Errorf("Could not load notification endpoints: %v", err)
+		return err
+	}
+	d.Logger.Infof("Sending digest of %d notifications", len(queue))
+
+	var eg errgroup.Group
+	for _, target := range targets {
+		matching := slices.DeleteFunc(slices.Clone(queue), func(queued Notify) bool { return !target.Matches(queued.Payload) })
+		if len(matching) == 0 {
+			continue
+		}
+
+		targetNotify := matching[0]
+		if len(matching) > 1 {
+			payloads := []Payload{}
+			for _, queued := range matching {
+				payloads = append(payloads, queued.Payload)
+			}
+			targetNotify = newNotify(d.Logger, d.Config, models.Device{}, NewDigestPayload(payloads))
+			targetNotify.Deliveries = matching[0].Deliveries
+		}
+		if d.Queue != nil {
+			targetNotify.targets = []Target{target}
+			d.Queue.Add(targetNotify)
+			continue
+		}
+		_target := target
+		eg.Go(func() error { return targetNotify.sendToTargets([]Target{_target}) })
+	}
+	return eg.Wait()
+}
+
+// NewDigestPayload summarizes the payloads of multiple notifications
+func NewDigestPayload(payloads []Payload, currentTime ...time.Time) Payload {
+	payload := Payload{
+		FailureType: NotifyFailureTypeDigest,
+		Digest:      payloads,
+	}
+	sendDate := time.Now()
+	if len(currentTime) > 0 {
+		sendDate = currentTime[0]
+	}
+	payload.Date = sendDate.Format(time.RFC3339)
+	payload.Subject = payload.GenerateSubject()
+	payload.Message = payload.GenerateMessage()
+	return payload
+}
+
+// digestSummary eg. "3 notifications for 2 devices on 1 host"
+func (p *Payload) digestSummary() string {
+	devices := map[string]bool{}
+	hosts := map[string]bool{}
+	for _, digested := range p.Digest {
+		deviceKey := digested.ScrutinyUUID
+		if len(deviceKey) == 0 {
+			deviceKey = digested.HostId + digested.DeviceName
+		}
+		devices[deviceKey] = true
+		if len(digested.HostId) > 0 {
+			hosts[digested.HostId] = true
+		}
+	}
+
+	summary := fmt.Sprintf("%s for %s", pluralize(len(p.Digest), "notification"), pluralize(len(devices), "device"))
+	if len(hosts) > 0 {
+		summary += fmt.Sprintf(" on %s", pluralize(len(hosts), "host"))
+	}
+	return summary
+}
+
+// digestLine eg. "[nas-01] /dev/sda (parity): SmartFailure"
+func (p *Payload) digestLine() string {
+	var line strings.Builder
+	if len(p.HostId) > 0 {
+		line.WriteString(fmt.Sprintf("[%s] ", p.HostId))
+	}
+	line.WriteString(p.DeviceName)
+	if len(p.DeviceLabel) > 0 {
+		line.WriteString(fmt.Sprintf(" (%s)", p.DeviceLabel))
+	}
+	line.WriteString(": " + p.FailureType)
+	if p.FailureType == NotifyFailureTypePredictedFailure {
+		line.WriteString(fmt.Sprintf(" (score %d)", p.FailureScore))
+	} else if p.FailureType == NotifyFailureTypeWarrantyExpiry || p.FailureType == NotifyFailureTypeEnduranceLimit {
+		line.WriteString(fmt.Sprintf(" (%d%%)", p.LifetimePercentUsed))
+	}
+	if len(p.DashboardUrl) > 0 {
+		line.WriteString(" - " + p.DashboardUrl)
+	}
+	return line.String()
+}
+
+func pluralize(count int, noun string) string {
+	if count == 1 {
+		return fmt.Sprintf("%d %s", count, noun)
+	}
+	return fmt.Sprintf("%d %ss", count, noun)
+}
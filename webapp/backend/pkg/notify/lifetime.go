@@ -0,0 +1,77 @@
+package notify
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/analogj/scrutiny/webapp/backend/pkg/analysis"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/config"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/models"
+	"github.com/sirupsen/logrus"
+)
+
+// NewWarrantyExpiryPayload is used when the elapsed warranty period of the device crosses one of the
+// `notify.lifetime.warranty_percentages` thresholds. percentUsed is the threshold that was crossed.
+func NewWarrantyExpiryPayload(device models.Device, lifetime analysis.Lifetime, percentUsed int, currentTime ...time.Time) Payload {
+	payload := NewPayload(device, false, currentTime...)
+	payload.FailureType = NotifyFailureTypeWarrantyExpiry
+	payload.LifetimePercentUsed = percentUsed
+	if lifetime.WarrantyEnd != nil {
+		// the expiry date may be calculated from a warranty rule, rather than set on the device
+		payload.WarrantyExpiryDate = lifetime.WarrantyEnd.Format(models.DeviceMetadataDateFormat)
+	}
+	payload.Subject = payload.GenerateSubject()
+	payload.Message = payload.GenerateMessage()
+	return payload
+}
+
+// NewEnduranceLimitPayload is used when the bytes written to the device cross one of the
+// `notify.lifetime.endurance_percentages` thresholds of its rated endurance. percentUsed is the threshold that was crossed.
+func NewEnduranceLimitPayload(device models.Device, lifetime analysis.Lifetime, percentUsed int, currentTime ...time.Time) Payload {
+	payload := NewPayload(device, false, currentTime...)
+	payload.FailureType = NotifyFailureTypeEnduranceLimit
+	payload.LifetimePercentUsed = percentUsed
+	if lifetime.BytesWritten != nil {
+		payload.BytesWritten = *lifetime.BytesWritten
+	}
+	payload.RatedTbw = lifetime.RatedTbw
+	payload.Subject = payload.GenerateSubject()
+	payload.Message = payload.GenerateMessage()
+	return payload
+}
+
+func NewWarrantyExpiry(logger logrus.FieldLogger, appconfig config.Interface, device models.Device, lifetime analysis.Lifetime, percentUsed int) Notify {
+	return newNotify(logger, appconfig, device, NewWarrantyExpiryPayload(device, lifetime, percentUsed))
+}
+
+func NewEnduranceLimit(logger logrus.FieldLogger, appconfig config.Interface, device models.Device, lifetime analysis.Lifetime, percentUsed int) Notify {
+	return newNotify(logger, appconfig, device, NewEnduranceLimitPayload(device, lifetime, percentUsed))
+}
+
+func (p *Payload) lifetimeSubject() string {
+	var summary string
+	if p.FailureType == NotifyFailureTypeEnduranceLimit {
+		summary = fmt.Sprintf("rated endurance %d%% used", p.LifetimePercentUsed)
+	} else if p.LifetimePercentUsed >= 100 {
+		summary = "warranty expired"
+	} else {
+		summary = fmt.Sprintf("warranty %d%% elapsed", p.LifetimePercentUsed)
+	}
+
+	if len(p.HostId) > 0 {
+		return fmt.Sprintf("Scrutiny %s on [host]device: [%s]%s", summary, p.HostId, p.DeviceName)
+	}
+	return fmt.Sprintf("Scrutiny %s on device: %s", summary, p.DeviceName)
+}
+
+func (p *Payload) lifetimeMessage() []string {
+	if p.FailureType == NotifyFailureTypeWarrantyExpiry {
+		return []string{fmt.Sprintf("Warranty Elapsed: %d%%", p.LifetimePercentUsed)}
+	}
+	return []string{
+		fmt.Sprintf("Endurance Used: %d%%", p.LifetimePercentUsed),
+		fmt.Sprintf("Data Written: %s TB", strconv.FormatFloat(float64(p.BytesWritten)/1e12, 'f', 1, 64)),
+		fmt.Sprintf("Rated Endurance: %s TBW", strconv.FormatFloat(p.RatedTbw, 'f', -1, 64)),
+	}
+}
@@ -2,12 +2,15 @@ package notify
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
 	"os"
+	"os/exec"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -31,6 +34,28 @@ const NotifyFailureTypeEmailTest = "EmailTest"
 const NotifyFailureTypeBothFailure = "SmartFailure" //SmartFailure always takes precedence when Scrutiny & Smart failed.
 const NotifyFailureTypeSmartFailure = "SmartFailure"
 const NotifyFailureTypeScrutinyFailure = "ScrutinyFailure"
+const NotifyFailureTypeScrutinyWarning = "ScrutinyWarning"
+const NotifyFailureTypePredictedFailure = "PredictedFailure"
+const NotifyFailureTypeResolved = "Resolved"
+const NotifyFailureTypeDigest = "Digest"
+const NotifyFailureTypeDeviceStale = "DeviceStale"
+const NotifyFailureTypeHostStale = "HostStale"
+const NotifyFailureTypeWarrantyExpiry = "WarrantyExpiry"
+const NotifyFailureTypeEnduranceLimit = "EnduranceLimit"
+
+const NotifySeverityFailure = "failure"
+const NotifySeverityWarning = "warning"
+const NotifySeverityInfo = "info"
+
+// NotificationTransition describes how the device status changed since the last notification was sent for the device
+type NotificationTransition string
+
+const (
+	NotificationTransitionNone     NotificationTransition = ""
+	NotificationTransitionFailed   NotificationTransition = "failed"   // passed -> failed
+	NotificationTransitionWorsened NotificationTransition = "worsened" // failed -> failed with an additional failure type
+	NotificationTransitionResolved NotificationTransition = "resolved" // failed -> passed
+)
 
 // ShouldNotify check if the error Message should be filtered (level mismatch or filtered_attributes)
 func ShouldNotify(logger logrus.FieldLogger, device models.Device, smartAttrs measurements.Smart, scrutiny_uuid uuid.UUID, statusThreshold pkg.MetricsStatusThreshold, statusFilterAttributes pkg.MetricsStatusFilterAttributes, repeatNotifications bool, c *gin.Context, deviceRepo database.DeviceRepo) bool {
@@ -39,23 +64,10 @@ func ShouldNotify(logger logrus.FieldLogger, device models.Device, smartAttrs me
 		return false
 	}
 
-	//TODO: cannot check for warning notifyLevel yet.
+	// warnings are handled separately, see ShouldNotifyWarning
 
 	// setup constants for comparison
-	var requiredDeviceStatus pkg.DeviceStatus
-	var requiredAttrStatus pkg.AttributeStatus
-	if statusThreshold == pkg.MetricsStatusThresholdBoth {
-		// either scrutiny or smart failures should trigger an email
-		requiredDeviceStatus = pkg.DeviceStatusSet(pkg.DeviceStatusFailedSmart, pkg.DeviceStatusFailedScrutiny)
-		requiredAttrStatus = pkg.AttributeStatusSet(pkg.AttributeStatusFailedSmart, pkg.AttributeStatusFailedScrutiny)
-	} else if statusThreshold == pkg.MetricsStatusThresholdSmart {
-		//only smart failures
-		requiredDeviceStatus = pkg.DeviceStatusFailedSmart
-		requiredAttrStatus = pkg.AttributeStatusFailedSmart
-	} else {
-		requiredDeviceStatus = pkg.DeviceStatusFailedScrutiny
-		requiredAttrStatus = pkg.AttributeStatusFailedScrutiny
-	}
+	requiredDeviceStatus, requiredAttrStatus := requiredFailureStatus(statusThreshold)
 
 	// This is the only case where individual attributes need not be considered
 	if statusFilterAttributes == pkg.MetricsStatusFilterAttributesAll && repeatNotifications {
@@ -73,24 +85,9 @@ func ShouldNotify(logger logrus.FieldLogger, device models.Device, smartAttrs me
 
 		// If the user only wants to consider critical attributes, we have to check
 		// if the not-passing attribute is critical or not
-		if statusFilterAttributes == pkg.MetricsStatusFilterAttributesCritical {
-			critical := false
-			if device.IsScsi() {
-				critical = thresholds.ScsiMetadata[attrId].Critical
-			} else if device.IsNvme() {
-				critical = thresholds.NmveMetadata[attrId].Critical
-			} else {
-				//this is ATA
-				attrIdInt, err := strconv.Atoi(attrId)
-				if err != nil {
-					continue
-				}
-				critical = thresholds.AtaMetadata[attrIdInt].Critical
-			}
+		if statusFilterAttributes == pkg.MetricsStatusFilterAttributesCritical && !isCriticalAttribute(device, attrId) {
 			// Skip non-critical, non-passing attributes when this setting is on
-			if !critical {
-				continue
-			}
+			continue
 		}
 
 		// Record any attribute that doesn't get skipped by the above two checks
@@ -122,28 +119,198 @@ func ShouldNotify(logger logrus.FieldLogger, device models.Device, smartAttrs me
 	return false
 }
 
-// TODO: include user label for device.
+// ShouldNotifyWarning checks if a warning notification should be sent, when the `metrics.notify_level` setting is `warn`.
+// Warnings are rate limited separately from failures: once a warning notification is sent for a device, the next one is
+// only sent after warningInterval has passed (see models.NotificationState).
+func ShouldNotifyWarning(device models.Device, smartAttrs measurements.Smart, notifyLevel pkg.MetricsNotifyLevel, statusFilterAttributes pkg.MetricsStatusFilterAttributes, lastWarningNotifiedAt *time.Time, warningInterval time.Duration, currentTime time.Time) bool {
+	if notifyLevel != pkg.MetricsNotifyLevelWarn || device.Archived {
+		return false
+	}
+	if !pkg.DeviceStatusHas(device.DeviceStatus, pkg.DeviceStatusWarningScrutiny) {
+		return false
+	}
+	if lastWarningNotifiedAt != nil && currentTime.Sub(*lastWarningNotifiedAt) < warningInterval {
+		return false
+	}
+
+	for attrId, attrData := range smartAttrs.Attributes {
+		if !pkg.AttributeStatusHas(attrData.GetStatus(), pkg.AttributeStatusWarningScrutiny) {
+			continue
+		}
+		if statusFilterAttributes == pkg.MetricsStatusFilterAttributesCritical && !isCriticalAttribute(device, attrId) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+func isCriticalAttribute(device models.Device, attrId string) bool {
+	if device.IsScsi() {
+		return thresholds.ScsiMetadata[attrId].Critical
+	} else if device.IsNvme() {
+		return thresholds.NmveMetadata[attrId].Critical
+	} else {
+		//this is ATA
+		attrIdInt, err := strconv.Atoi(attrId)
+		if err != nil {
+			return false
+		}
+		return thresholds.AtaMetadata[attrIdInt].Critical
+	}
+}
+
+// DetectTransition compares the device status from the last notification (see models.NotificationState) with the
+// current device status. Only the failure types selected by the `metrics.status_threshold` setting are compared.
+func DetectTransition(notifiedStatus pkg.DeviceStatus, currentStatus pkg.DeviceStatus, statusThreshold pkg.MetricsStatusThreshold) NotificationTransition {
+	requiredDeviceStatus, _ := requiredFailureStatus(statusThreshold)
+	notifiedStatus = notifiedStatus & requiredDeviceStatus
+	currentStatus = currentStatus & requiredDeviceStatus
+
+	if notifiedStatus == pkg.DeviceStatusPassed && currentStatus != pkg.DeviceStatusPassed {
+		return NotificationTransitionFailed
+	} else if notifiedStatus != pkg.DeviceStatusPassed && currentStatus == pkg.DeviceStatusPassed {
+		return NotificationTransitionResolved
+	} else if pkg.DeviceStatusClear(currentStatus, notifiedStatus) != pkg.DeviceStatusPassed {
+		return NotificationTransitionWorsened
+	}
+	return NotificationTransitionNone
+}
+
+// FailingAttributeIds returns the (sorted) ids of the attributes with a failure selected by the `metrics.status_threshold`
+// and `metrics.status_filter_attributes` settings. Stored with the notification state, to detect worsened failures (see
+// HasNewFailingAttributes).
+func FailingAttributeIds(device models.Device, smartAttrs measurements.Smart, statusThreshold pkg.MetricsStatusThreshold, statusFilterAttributes pkg.MetricsStatusFilterAttributes) []string {
+	_, requiredAttrStatus := requiredFailureStatus(statusThreshold)
+
+	failingAttributes := []string{}
+	for attrId, attrData := range smartAttrs.Attributes {
+		if !pkg.AttributeStatusHas(attrData.GetStatus(), requiredAttrStatus) {
+			continue
+		}
+		if statusFilterAttributes == pkg.MetricsStatusFilterAttributesCritical && !isCriticalAttribute(device, attrId) {
+			continue
+		}
+		failingAttributes = append(failingAttributes, attrId)
+	}
+	slices.Sort(failingAttributes)
+	return failingAttributes
+}
+
+// HasNewFailingAttributes returns true if an attribute is failing which was not included in the last failure
+// notification. Always false when the notified attributes are unknown (notifications sent before they were stored).
+func HasNewFailingAttributes(notifiedAttributes []string, failingAttributes []string) bool {
+	if len(notifiedAttributes) == 0 {
+		return false
+	}
+	for _, attrId := range failingAttributes {
+		if !slices.Contains(notifiedAttributes, attrId) {
+			return true
+		}
+	}
+	return false
+}
+
+// requiredFailureStatus returns the device & attribute statuses which should trigger a notification
+func requiredFailureStatus(statusThreshold pkg.MetricsStatusThreshold) (pkg.DeviceStatus, pkg.AttributeStatus) {
+	if statusThreshold == pkg.MetricsStatusThresholdBoth {
+		// either scrutiny or smart failures should trigger an email
+		return pkg.DeviceStatusSet(pkg.DeviceStatusFailedSmart, pkg.DeviceStatusFailedScrutiny),
+			pkg.AttributeStatusSet(pkg.AttributeStatusFailedSmart, pkg.AttributeStatusFailedScrutiny)
+	} else if statusThreshold == pkg.MetricsStatusThresholdSmart {
+		//only smart failures
+		return pkg.DeviceStatusFailedSmart, pkg.AttributeStatusFailedSmart
+	} else {
+		return pkg.DeviceStatusFailedScrutiny, pkg.AttributeStatusFailedScrutiny
+	}
+}
+
+// ShouldNotifyFailurePrediction checks if the failure score has reached the `metrics.notify_failure_score` setting (0 disables
+// these notifications). A notification is only sent when the score crosses the threshold, not on every upload.
+func ShouldNotifyFailurePrediction(previousScore int, device models.Device, scoreThreshold int) bool {
+	if scoreThreshold <= 0 || device.Archived {
+		return false
+	}
+	return device.FailureScore >= scoreThreshold && previousScore < scoreThreshold
+}
+
+// Payload is sent to webhooks as JSON, and is the data available to subject & message templates (see Target).
 type Payload struct {
-	HostId       string `json:"host_id,omitempty"` //host id (optional)
-	DeviceType   string `json:"device_type"`       //ATA/SCSI/NVMe
-	DeviceName   string `json:"device_name"`       //dev/sda
-	DeviceSerial string `json:"device_serial"`     //WDDJ324KSO
-	Test         bool   `json:"test"`              // false
+	HostId         string `json:"host_id,omitempty"`         //host id (optional)
+	DeviceType     string `json:"device_type"`               //ATA/SCSI/NVMe
+	DeviceName     string `json:"device_name"`               //dev/sda
+	DeviceSerial   string `json:"device_serial"`             //WDDJ324KSO
+	DeviceLabel    string `json:"device_label,omitempty"`    //user provided label (optional)
+	DeviceModel    string `json:"device_model,omitempty"`    //WDC WD140EDGZ-11B1PA0
+	DeviceCapacity int64  `json:"device_capacity,omitempty"` //bytes
+	DeviceLocation string `json:"device_location,omitempty"` //enclosure slot or by-path, when detected by the collector
+	ScrutinyUUID   string `json:"scrutiny_uuid,omitempty"`
+	Test           bool   `json:"test"` // false
+
+	//user provided inventory metadata (optional)
+	Location           string `json:"location,omitempty"`
+	PurchaseDate       string `json:"purchase_date,omitempty"`        //YYYY-MM-DD
+	WarrantyExpiryDate string `json:"warranty_expiry_date,omitempty"` //YYYY-MM-DD
+	RmaUrl             string `json:"rma_url,omitempty"`
+	Notes              string `json:"notes,omitempty"`
+
+	DeviceStatus         pkg.DeviceStatus  `json:"device_status"`
+	PreviousDeviceStatus *pkg.DeviceStatus `json:"previous_device_status,omitempty"` //status before the upload which triggered the notification
 
 	//private, populated during init (marked as Public for JSON serialization)
-	Date        string `json:"date"`         //populated by Send function.
-	FailureType string `json:"failure_type"` //EmailTest, BothFail, SmartFail, ScrutinyFail
-	Subject     string `json:"subject"`
-	Message     string `json:"message"`
+	Date        string                 `json:"date"`                 //populated by Send function.
+	FailureType string                 `json:"failure_type"`         //EmailTest, BothFail, SmartFail, ScrutinyFail, ScrutinyWarning, PredictedFailure, Resolved, Digest, DeviceStale, HostStale, WarrantyExpiry, EnduranceLimit
+	Transition  NotificationTransition `json:"transition,omitempty"` //failed, worsened, resolved (empty for repeated & test notifications)
+	Subject     string                 `json:"subject"`
+	Message     string                 `json:"message"`
+
+	//populated for PredictedFailure notifications
+	FailureScore         int    `json:"failure_score,omitempty"`
+	FailureAttributeId   string `json:"failure_attribute_id,omitempty"`
+	EstimatedFailureDate string `json:"estimated_failure_date,omitempty"`
+
+	//populated when the SMART data is available, see AddFailingAttributes
+	FailingAttributes []FailingAttribute `json:"failing_attributes,omitempty"`
+	//link to the device details page, populated when `notify.dashboard_url` is set
+	DashboardUrl string `json:"dashboard_url,omitempty"`
+
+	//populated for Digest notifications, see NewDigestPayload
+	Digest []Payload `json:"digest,omitempty"`
+
+	//populated for DeviceStale & HostStale notifications, see NewDeviceStalePayload & NewHostStalePayload
+	LastSeenAt   string   `json:"last_seen_at,omitempty"`
+	StaleDevices []string `json:"stale_devices,omitempty"`
+
+	//populated for WarrantyExpiry & EnduranceLimit notifications, see NewWarrantyExpiryPayload & NewEnduranceLimitPayload
+	LifetimePercentUsed int     `json:"lifetime_percent_used,omitempty"` //the threshold which was crossed
+	BytesWritten        int64   `json:"bytes_written,omitempty"`
+	RatedTbw            float64 `json:"rated_tbw,omitempty"`
 }
 
 func NewPayload(device models.Device, test bool, currentTime ...time.Time) Payload {
 	payload := Payload{
-		HostId:       strings.TrimSpace(device.HostId),
-		DeviceType:   device.DeviceType,
-		DeviceName:   device.DeviceName,
-		DeviceSerial: device.SerialNumber,
-		Test:         test,
+		HostId:         strings.TrimSpace(device.HostId),
+		DeviceType:     device.DeviceType,
+		DeviceName:     device.DeviceName,
+		DeviceSerial:   device.SerialNumber,
+		DeviceLabel:    strings.TrimSpace(device.Label),
+		DeviceModel:    device.ModelName,
+		DeviceCapacity: device.Capacity,
+		DeviceLocation: deviceLocation(device),
+		Location:       strings.TrimSpace(device.Location),
+		RmaUrl:         device.RmaUrl,
+		Notes:          device.Notes,
+		DeviceStatus:   device.DeviceStatus,
+		Test:           test,
+	}
+	if device.PurchaseDate != nil {
+		payload.PurchaseDate = device.PurchaseDate.Format(models.DeviceMetadataDateFormat)
+	}
+	if device.WarrantyExpiryDate != nil {
+		payload.WarrantyExpiryDate = device.WarrantyExpiryDate.Format(models.DeviceMetadataDateFormat)
+	}
+	if device.ScrutinyUUID != uuid.Nil {
+		payload.ScrutinyUUID = device.ScrutinyUUID.String()
 	}
 
 	//validate that the Payload is populated
@@ -161,6 +328,14 @@ func NewPayload(device models.Device, test bool, currentTime ...time.Time) Paylo
 	return payload
 }
 
+// deviceLocation describes where the device is physically installed, eg. "enclosure 0:0:8:0 slot 2"
+func deviceLocation(device models.Device) string {
+	if len(device.EnclosureSlot) > 0 {
+		return fmt.Sprintf("enclosure %s slot %s", device.EnclosureId, device.EnclosureSlot)
+	}
+	return device.DeviceByPath
+}
+
 func (p *Payload) GenerateFailureType(deviceStatus pkg.DeviceStatus) string {
 	//generate a failure type, given Test and DeviceStatus
 	if p.Test {
@@ -175,9 +350,107 @@ func (p *Payload) GenerateFailureType(deviceStatus pkg.DeviceStatus) string {
 	}
 }
 
+// Severity groups the failure types, so notification targets can be filtered without listing every failure type.
+func (p *Payload) Severity() string {
+	switch p.FailureType {
+	case NotifyFailureTypeSmartFailure, NotifyFailureTypeScrutinyFailure:
+		return NotifySeverityFailure
+	case NotifyFailureTypeResolved:
+		//sent to the same targets as the failure notification it resolves
+		return NotifySeverityFailure
+	case NotifyFailureTypeScrutinyWarning, NotifyFailureTypePredictedFailure, NotifyFailureTypeDeviceStale, NotifyFailureTypeHostStale,
+		NotifyFailureTypeWarrantyExpiry, NotifyFailureTypeEnduranceLimit:
+		return NotifySeverityWarning
+	case NotifyFailureTypeDigest:
+		//the most severe of the digested notifications
+		severity := NotifySeverityInfo
+		for ndx := range p.Digest {
+			switch p.Digest[ndx].Severity() {
+			case NotifySeverityFailure:
+				return NotifySeverityFailure
+			case NotifySeverityWarning:
+				severity = NotifySeverityWarning
+			}
+		}
+		return severity
+	default:
+		return NotifySeverityInfo
+	}
+}
+
+// NewPredictedFailurePayload is used when the failure score of the device reaches the configured threshold, even though
+// the device may not be failing yet.
+func NewPredictedFailurePayload(device models.Device, currentTime ...time.Time) Payload {
+	payload := NewPayload(device, false, currentTime...)
+	payload.FailureType = NotifyFailureTypePredictedFailure
+	payload.FailureScore = device.FailureScore
+	payload.FailureAttributeId = device.FailureScoreAttributeId
+	if device.EstimatedFailureDate != nil {
+		payload.EstimatedFailureDate = device.EstimatedFailureDate.Format(time.RFC3339)
+	}
+	payload.Subject = payload.GenerateSubject()
+	payload.Message = payload.GenerateMessage()
+	return payload
+}
+
+// NewWarningPayload is used when the device has attributes with warnings, and the `metrics.notify_level` setting is `warn`.
+func NewWarningPayload(device models.Device, currentTime ...time.Time) Payload {
+	payload := NewPayload(device, false, currentTime...)
+	payload.FailureType = NotifyFailureTypeScrutinyWarning
+	payload.Subject = payload.GenerateSubject()
+	payload.Message = payload.GenerateMessage()
+	return payload
+}
+
+// NewResolvedPayload is used when a device which was included in a failure notification is no longer failing.
+func NewResolvedPayload(device models.Device, currentTime ...time.Time) Payload {
+	payload := NewPayload(device, false, currentTime...)
+	payload.FailureType = NotifyFailureTypeResolved
+	payload.Transition = NotificationTransitionResolved
+	payload.Subject = payload.GenerateSubject()
+	payload.Message = payload.GenerateMessage()
+	return payload
+}
+
 func (p *Payload) GenerateSubject() string {
 	//generate a detailed failure message
 	var subject string
+	if p.FailureType == NotifyFailureTypeDigest {
+		return fmt.Sprintf("Scrutiny SMART digest: %s", p.digestSummary())
+	}
+	if p.FailureType == NotifyFailureTypeHostStale {
+		if len(p.HostId) > 0 {
+			return fmt.Sprintf("Scrutiny collector stopped reporting for host: %s", p.HostId)
+		}
+		return "Scrutiny collector stopped reporting"
+	}
+	if p.FailureType == NotifyFailureTypeWarrantyExpiry || p.FailureType == NotifyFailureTypeEnduranceLimit {
+		return p.lifetimeSubject()
+	}
+	if p.FailureType == NotifyFailureTypeDeviceStale {
+		if len(p.HostId) > 0 {
+			return fmt.Sprintf("Scrutiny device stopped reporting on [host]device: [%s]%s", p.HostId, p.DeviceName)
+		}
+		return fmt.Sprintf("Scrutiny device stopped reporting: %s", p.DeviceName)
+	}
+	if p.FailureType == NotifyFailureTypeScrutinyWarning {
+		if len(p.HostId) > 0 {
+			return fmt.Sprintf("Scrutiny SMART warning detected on [host]device: [%s]%s", p.HostId, p.DeviceName)
+		}
+		return fmt.Sprintf("Scrutiny SMART warning detected on device: %s", p.DeviceName)
+	}
+	if p.FailureType == NotifyFailureTypeResolved {
+		if len(p.HostId) > 0 {
+			return fmt.Sprintf("Scrutiny SMART error resolved on [host]device: [%s]%s", p.HostId, p.DeviceName)
+		}
+		return fmt.Sprintf("Scrutiny SMART error resolved on device: %s", p.DeviceName)
+	}
+	if p.FailureType == NotifyFailureTypePredictedFailure {
+		if len(p.HostId) > 0 {
+			return fmt.Sprintf("Scrutiny predicted failure (score %d) for [host]device: [%s]%s", p.FailureScore, p.HostId, p.DeviceName)
+		}
+		return fmt.Sprintf("Scrutiny predicted failure (score %d) for device: %s", p.FailureScore, p.DeviceName)
+	}
 	if len(p.HostId) > 0 {
 		subject = fmt.Sprintf("Scrutiny SMART error (%s) detected on [host]device: [%s]%s", p.FailureType, p.HostId, p.DeviceName)
 	} else {
@@ -191,7 +464,30 @@ func (p *Payload) GenerateMessage() string {
 
 	messageParts := []string{}
 
-	messageParts = append(messageParts, fmt.Sprintf("Scrutiny SMART error notification for device: %s", p.DeviceName))
+	if p.FailureType == NotifyFailureTypeDigest {
+		messageParts = append(messageParts, fmt.Sprintf("Scrutiny SMART digest: %s", p.digestSummary()))
+		for ndx := range p.Digest {
+			messageParts = append(messageParts, fmt.Sprintf("- %s", p.Digest[ndx].digestLine()))
+		}
+		messageParts = append(messageParts, "", fmt.Sprintf("Date: %s", p.Date))
+		return strings.Join(messageParts, "\n")
+	}
+
+	if p.FailureType == NotifyFailureTypeHostStale {
+		return strings.Join(p.hostStaleMessage(), "\n")
+	}
+
+	if p.FailureType == NotifyFailureTypeResolved {
+		messageParts = append(messageParts, fmt.Sprintf("Scrutiny SMART error resolved for device: %s", p.DeviceName))
+	} else if p.FailureType == NotifyFailureTypeDeviceStale {
+		messageParts = append(messageParts, fmt.Sprintf("Scrutiny has not received data for device: %s", p.DeviceName))
+	} else if p.FailureType == NotifyFailureTypeWarrantyExpiry || p.FailureType == NotifyFailureTypeEnduranceLimit {
+		messageParts = append(messageParts, fmt.Sprintf("Scrutiny lifetime notification for device: %s", p.DeviceName))
+	} else if p.FailureType == NotifyFailureTypeScrutinyWarning {
+		messageParts = append(messageParts, fmt.Sprintf("Scrutiny SMART warning notification for device: %s", p.DeviceName))
+	} else {
+		messageParts = append(messageParts, fmt.Sprintf("Scrutiny SMART error notification for device: %s", p.DeviceName))
+	}
 	if len(p.HostId) > 0 {
 		messageParts = append(messageParts, fmt.Sprintf("Host Id: %s", p.HostId))
 	}
@@ -201,9 +497,55 @@ func (p *Payload) GenerateMessage() string {
 		fmt.Sprintf("Device Name: %s", p.DeviceName),
 		fmt.Sprintf("Device Serial: %s", p.DeviceSerial),
 		fmt.Sprintf("Device Type: %s", p.DeviceType),
+	)
+	if len(p.DeviceLabel) > 0 {
+		messageParts = append(messageParts, fmt.Sprintf("Device Label: %s", p.DeviceLabel))
+	}
+	if len(p.DeviceLocation) > 0 {
+		messageParts = append(messageParts, fmt.Sprintf("Device Location: %s", p.DeviceLocation))
+	}
+	if len(p.Location) > 0 {
+		messageParts = append(messageParts, fmt.Sprintf("Location: %s", p.Location))
+	}
+	if len(p.WarrantyExpiryDate) > 0 {
+		messageParts = append(messageParts, fmt.Sprintf("Warranty Expires: %s", p.WarrantyExpiryDate))
+	}
+	if len(p.RmaUrl) > 0 {
+		messageParts = append(messageParts, fmt.Sprintf("RMA: %s", p.RmaUrl))
+	}
+
+	if p.FailureType == NotifyFailureTypePredictedFailure {
+		messageParts = append(messageParts,
+			fmt.Sprintf("Failure Score: %d", p.FailureScore),
+			fmt.Sprintf("Failure Attribute: %s", p.FailureAttributeId),
+		)
+		if len(p.EstimatedFailureDate) > 0 {
+			messageParts = append(messageParts, fmt.Sprintf("Estimated Failure Date: %s", p.EstimatedFailureDate))
+		}
+	}
+
+	if p.FailureType == NotifyFailureTypeWarrantyExpiry || p.FailureType == NotifyFailureTypeEnduranceLimit {
+		messageParts = append(messageParts, p.lifetimeMessage()...)
+	}
+
+	if len(p.LastSeenAt) > 0 {
+		messageParts = append(messageParts, fmt.Sprintf("Last Seen: %s", p.LastSeenAt))
+	}
+
+	if len(p.FailingAttributes) > 0 {
+		messageParts = append(messageParts, "", "Failing Attributes:")
+		for _, attr := range p.FailingAttributes {
+			messageParts = append(messageParts, fmt.Sprintf("- %s", attr.String()))
+		}
+	}
+
+	messageParts = append(messageParts,
 		"",
 		fmt.Sprintf("Date: %s", p.Date),
 	)
+	if len(p.DashboardUrl) > 0 {
+		messageParts = append(messageParts, fmt.Sprintf("Details: %s", p.DashboardUrl))
+	}
 
 	if p.Test {
 		messageParts = append([]string{"TEST NOTIFICATION:"}, messageParts...)
@@ -213,10 +555,32 @@ func (p *Payload) GenerateMessage() string {
 }
 
 func New(logger logrus.FieldLogger, appconfig config.Interface, device models.Device, test bool) Notify {
+	return newNotify(logger, appconfig, device, NewPayload(device, test))
+}
+
+func NewPredictedFailure(logger logrus.FieldLogger, appconfig config.Interface, device models.Device) Notify {
+	return newNotify(logger, appconfig, device, NewPredictedFailurePayload(device))
+}
+
+func NewWarning(logger logrus.FieldLogger, appconfig config.Interface, device models.Device) Notify {
+	return newNotify(logger, appconfig, device, NewWarningPayload(device))
+}
+
+func NewResolved(logger logrus.FieldLogger, appconfig config.Interface, device models.Device) Notify {
+	return newNotify(logger, appconfig, device, NewResolvedPayload(device))
+}
+
+// newNotify links the payload to the device details page when `notify.dashboard_url` is set
+func newNotify(logger logrus.FieldLogger, appconfig config.Interface, device models.Device, payload Payload) Notify {
+	dashboardUrl := strings.TrimSuffix(appconfig.GetString("notify.dashboard_url"), "/")
+	if len(dashboardUrl) > 0 && device.ScrutinyUUID != uuid.Nil {
+		payload.DashboardUrl = fmt.Sprintf("%s/web/device/%s", dashboardUrl, device.ScrutinyUUID.String())
+		payload.Message = payload.GenerateMessage()
+	}
 	return Notify{
 		Logger:  logger,
 		Config:  appconfig,
-		Payload: NewPayload(device, test),
+		Payload: payload,
 	}
 }
 
@@ -224,31 +588,63 @@ type Notify struct {
 	Logger  logrus.FieldLogger
 	Config  config.Interface
 	Payload Payload
+
+	// optional, records every delivery attempt
+	Deliveries DeliveryRecorder
+
+	// per target settings, see forTarget
+	timeout       time.Duration
+	webhookSecret string
+
+	// set when the notification is sent by a Queue, so failed deliveries are retried (see retryLater)
+	queue *Queue
+	// a retry is only sent to the target which failed, see retryLater
+	attempt int
+	targets []Target
 }
 
 func (n *Notify) Send() error {
+	if len(n.targets) > 0 {
+		//a retry, the target filters were already checked
+		return n.sendToTargets(n.targets)
+	}
 
 	//retrieve list of notification endpoints from config file
-	configUrls := n.Config.GetStringSlice("notify.urls")
-	n.Logger.Debugf("Configured notification services: %v", configUrls)
+	targets, err := LoadTargets(n.Config)
+	if err != nil {
+		n.Logger.Errorf("Could not load notification endpoints: %v", err)
+		return err
+	}
+	n.Logger.Debugf("Configured notification services: %v", targets)
 
-	if len(configUrls) == 0 {
+	if len(targets) == 0 {
 		n.Logger.Infof("No notification endpoints configured. Skipping failure notification.")
 		return nil
 	}
 
+	//only send the notification to the targets with matching filters
+	targets = slices.DeleteFunc(targets, func(target Target) bool { return !target.Matches(n.Payload) })
+	if len(targets) == 0 {
+		n.Logger.Infof("No notification endpoints match this notification (%s). Skipping notification.", n.Payload.FailureType)
+		return nil
+	}
+	return n.sendToTargets(targets)
+}
+
+// sendToTargets sends the notification to the targets, without checking the target filters.
+func (n *Notify) sendToTargets(targets []Target) error {
 	//remove http:// https:// and script:// prefixed urls
-	notifyWebhooks := []string{}
-	notifyScripts := []string{}
-	notifyShoutrrr := []string{}
-
-	for ndx := range configUrls {
-		if strings.HasPrefix(configUrls[ndx], "https://") || strings.HasPrefix(configUrls[ndx], "http://") {
-			notifyWebhooks = append(notifyWebhooks, configUrls[ndx])
-		} else if strings.HasPrefix(configUrls[ndx], "script://") {
-			notifyScripts = append(notifyScripts, configUrls[ndx])
+	notifyWebhooks := []Target{}
+	notifyScripts := []Target{}
+	notifyShoutrrr := []Target{}
+
+	for ndx := range targets {
+		if strings.HasPrefix(targets[ndx].Url, "https://") || strings.HasPrefix(targets[ndx].Url, "http://") {
+			notifyWebhooks = append(notifyWebhooks, targets[ndx])
+		} else if strings.HasPrefix(targets[ndx].Url, "script://") {
+			notifyScripts = append(notifyScripts, targets[ndx])
 		} else {
-			notifyShoutrrr = append(notifyShoutrrr, configUrls[ndx])
+			notifyShoutrrr = append(notifyShoutrrr, targets[ndx])
 		}
 	}
 
@@ -260,20 +656,20 @@ func (n *Notify) Send() error {
 	//var wg sync.WaitGroup
 	var eg errgroup.Group
 
-	for _, url := range notifyWebhooks {
-		// execute collection in parallel go-routines
-		_url := url
-		eg.Go(func() error { return n.SendWebhookNotification(_url) })
+	for _, target := range notifyWebhooks {
+		// execute collection in parallel go-routines, each target may render its own subject & message
+		_target := target
+		eg.Go(func() error { return n.deliverWebhook(_target) })
 	}
-	for _, url := range notifyScripts {
+	for _, target := range notifyScripts {
 		// execute collection in parallel go-routines
-		_url := url
-		eg.Go(func() error { return n.SendScriptNotification(_url) })
+		_target := target
+		eg.Go(func() error { return n.deliverScript(_target) })
 	}
-	for _, url := range notifyShoutrrr {
+	for _, target := range notifyShoutrrr {
 		// execute collection in parallel go-routines
-		_url := url
-		eg.Go(func() error { return n.SendShoutrrrNotification(_url) })
+		_target := target
+		eg.Go(func() error { return n.deliverShoutrrr(_target) })
 	}
 
 	//and wait for completion, error or timeout.
@@ -296,19 +692,37 @@ func (n *Notify) Send() error {
 
 func (n *Notify) SendWebhookNotification(webhookUrl string) error {
 	n.Logger.Infof("Sending Webhook to %s", webhookUrl)
-	requestBody, err := json.Marshal(n.Payload)
+	requestBody, err := json.Marshal(NewWebhookPayload(n.Payload))
+	if err != nil {
+		n.Logger.Errorf("An error occurred while sending Webhook to %s: %v", webhookUrl, err)
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, webhookUrl, bytes.NewBuffer(requestBody))
 	if err != nil {
 		n.Logger.Errorf("An error occurred while sending Webhook to %s: %v", webhookUrl, err)
 		return err
 	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookHeaderSchemaVersion, strconv.Itoa(WebhookSchemaVersion))
+	if len(n.webhookSecret) > 0 {
+		timestamp := time.Now().Unix()
+		req.Header.Set(WebhookHeaderTimestamp, strconv.FormatInt(timestamp, 10))
+		req.Header.Set(WebhookHeaderSignature, SignWebhookPayload(n.webhookSecret, timestamp, requestBody))
+	}
 
-	resp, err := http.Post(webhookUrl, "application/json", bytes.NewBuffer(requestBody))
+	client := &http.Client{Timeout: n.deliveryTimeout()}
+	resp, err := client.Do(req)
 	if err != nil {
 		n.Logger.Errorf("An error occurred while sending Webhook to %s: %v", webhookUrl, err)
 		return err
 	}
 	defer resp.Body.Close()
 	//we don't care about resp body content, but maybe we should log it?
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		n.Logger.Errorf("Webhook %s responded with status %s", webhookUrl, resp.Status)
+		return fmt.Errorf("webhook responded with status %s", resp.Status)
+	}
 	return nil
 }
 
@@ -326,6 +740,7 @@ func (n *Notify) SendScriptNotification(scriptUrl string) error {
 	copyEnv = append(copyEnv, fmt.Sprintf("SCRUTINY_SUBJECT=%s", n.Payload.Subject))
 	copyEnv = append(copyEnv, fmt.Sprintf("SCRUTINY_DATE=%s", n.Payload.Date))
 	copyEnv = append(copyEnv, fmt.Sprintf("SCRUTINY_FAILURE_TYPE=%s", n.Payload.FailureType))
+	copyEnv = append(copyEnv, fmt.Sprintf("SCRUTINY_TRANSITION=%s", n.Payload.Transition))
 	copyEnv = append(copyEnv, fmt.Sprintf("SCRUTINY_DEVICE_NAME=%s", n.Payload.DeviceName))
 	copyEnv = append(copyEnv, fmt.Sprintf("SCRUTINY_DEVICE_TYPE=%s", n.Payload.DeviceType))
 	copyEnv = append(copyEnv, fmt.Sprintf("SCRUTINY_DEVICE_SERIAL=%s", n.Payload.DeviceSerial))
@@ -333,7 +748,24 @@ func (n *Notify) SendScriptNotification(scriptUrl string) error {
 	if len(n.Payload.HostId) > 0 {
 		copyEnv = append(copyEnv, fmt.Sprintf("SCRUTINY_HOST_ID=%s", n.Payload.HostId))
 	}
-	err := utils.CmdExec(scriptPath, []string{}, "", copyEnv, "")
+	if len(n.Payload.DeviceLabel) > 0 {
+		copyEnv = append(copyEnv, fmt.Sprintf("SCRUTINY_DEVICE_LABEL=%s", n.Payload.DeviceLabel))
+	}
+	if len(n.Payload.DashboardUrl) > 0 {
+		copyEnv = append(copyEnv, fmt.Sprintf("SCRUTINY_DASHBOARD_URL=%s", n.Payload.DashboardUrl))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), n.deliveryTimeout())
+	defer cancel()
+	cmd := exec.CommandContext(ctx, scriptPath)
+	cmd.Env = copyEnv
+	output, err := cmd.CombinedOutput()
+	if len(output) > 0 {
+		n.Logger.Infof("Script %s output:\n%s", scriptPath, output)
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		err = fmt.Errorf("script timed out after %s", n.deliveryTimeout())
+	}
 	if err != nil {
 		n.Logger.Errorf("An error occurred while executing script %s: %v", scriptPath, err)
 		return err
@@ -352,6 +784,7 @@ func (n *Notify) SendShoutrrrNotification(shoutrrrUrl string) error {
 		return err
 	}
 
+	sender.Timeout = n.deliveryTimeout()
 	//sender.SetLogger(n.Logger.)
 	serviceName, params, err := n.GenShoutrrrNotificationParams(shoutrrrUrl)
 	n.Logger.Debugf("notification data for %s: (%s)\n%v", serviceName, shoutrrrUrl, params)
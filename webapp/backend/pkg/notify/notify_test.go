@@ -1,12 +1,26 @@
 package notify
 
 import (
+	"context"
+	"crypto/hmac"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/analogj/scrutiny/webapp/backend/pkg"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/analysis"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/config"
+	mock_config "github.com/analogj/scrutiny/webapp/backend/pkg/config/mock"
 	"github.com/analogj/scrutiny/webapp/backend/pkg/database"
 	mock_database "github.com/analogj/scrutiny/webapp/backend/pkg/database/mock"
 	"github.com/analogj/scrutiny/webapp/backend/pkg/models"
@@ -339,3 +353,854 @@ Device Type: ATA
 
 Date: %s`, currentTime.Format(time.RFC3339)), payload.Message)
 }
+
+func TestNewPredictedFailurePayload(t *testing.T) {
+	t.Parallel()
+
+	//setup
+	estimatedFailureDate := time.Date(2027, time.January, 2, 3, 4, 5, 0, time.UTC)
+	device := models.Device{
+		SerialNumber:            "FAKEWDDJ324KSO",
+		DeviceType:              pkg.DeviceProtocolAta,
+		DeviceName:              "/dev/sda",
+		DeviceStatus:            pkg.DeviceStatusPassed,
+		FailureScore:            72,
+		FailureScoreAttributeId: "5",
+		EstimatedFailureDate:    &estimatedFailureDate,
+	}
+	currentTime := time.Now()
+	//test
+
+	payload := NewPredictedFailurePayload(device, currentTime)
+
+	//assert
+	require.Equal(t, NotifyFailureTypePredictedFailure, payload.FailureType)
+	require.Equal(t, "Scrutiny predicted failure (score 72) for device: /dev/sda", payload.Subject)
+	require.Equal(t, fmt.Sprintf(`Scrutiny SMART error notification for device: /dev/sda
+Failure Type: PredictedFailure
+Device Name: /dev/sda
+Device Serial: FAKEWDDJ324KSO
+Device Type: ATA
+Failure Score: 72
+Failure Attribute: 5
+Estimated Failure Date: 2027-01-02T03:04:05Z
+
+Date: %s`, currentTime.Format(time.RFC3339)), payload.Message)
+}
+
+func TestShouldNotifyFailurePrediction(t *testing.T) {
+	t.Parallel()
+
+	//setup
+	device := models.Device{FailureScore: 80}
+	archivedDevice := models.Device{FailureScore: 80, Archived: true}
+
+	//assert
+	require.True(t, ShouldNotifyFailurePrediction(10, device, 75), "score crossed the threshold")
+	require.False(t, ShouldNotifyFailurePrediction(78, device, 75), "score was already above the threshold")
+	require.False(t, ShouldNotifyFailurePrediction(10, device, 90), "score below the threshold")
+	require.False(t, ShouldNotifyFailurePrediction(10, device, 0), "notifications disabled")
+	require.False(t, ShouldNotifyFailurePrediction(10, archivedDevice, 75), "archived device")
+}
+
+func TestNewResolvedPayload(t *testing.T) {
+	t.Parallel()
+
+	//setup
+	device := models.Device{
+		HostId:       "custom-host",
+		SerialNumber: "FAKEWDDJ324KSO",
+		DeviceType:   pkg.DeviceProtocolAta,
+		DeviceName:   "/dev/sda",
+		DeviceStatus: pkg.DeviceStatusPassed,
+	}
+	currentTime := time.Now()
+	//test
+
+	payload := NewResolvedPayload(device, currentTime)
+
+	//assert
+	require.Equal(t, NotifyFailureTypeResolved, payload.FailureType)
+	require.Equal(t, NotificationTransitionResolved, payload.Transition)
+	require.Equal(t, NotifySeverityFailure, payload.Severity(), "sent to the targets which received the failure")
+	require.Equal(t, "Scrutiny SMART error resolved on [host]device: [custom-host]/dev/sda", payload.Subject)
+	require.Equal(t, fmt.Sprintf(`Scrutiny SMART error resolved for device: /dev/sda
+Host Id: custom-host
+Failure Type: Resolved
+Device Name: /dev/sda
+Device Serial: FAKEWDDJ324KSO
+Device Type: ATA
+
+Date: %s`, currentTime.Format(time.RFC3339)), payload.Message)
+}
+
+func TestNewDeviceStalePayload(t *testing.T) {
+	t.Parallel()
+
+	//setup
+	lastSeenAt := time.Now().Add(-48 * time.Hour)
+	device := models.Device{
+		HostId:       "custom-host",
+		SerialNumber: "FAKEWDDJ324KSO",
+		DeviceType:   pkg.DeviceProtocolAta,
+		DeviceName:   "/dev/sda",
+		LastSeenAt:   &lastSeenAt,
+	}
+	currentTime := time.Now()
+
+	//test
+	payload := NewDeviceStalePayload(device, currentTime)
+
+	//assert
+	require.Equal(t, NotifyFailureTypeDeviceStale, payload.FailureType)
+	require.Equal(t, NotifySeverityWarning, payload.Severity())
+	require.Equal(t, "Scrutiny device stopped reporting on [host]device: [custom-host]/dev/sda", payload.Subject)
+	require.Equal(t, fmt.Sprintf(`Scrutiny has not received data for device: /dev/sda
+Host Id: custom-host
+Failure Type: DeviceStale
+Device Name: /dev/sda
+Device Serial: FAKEWDDJ324KSO
+Device Type: ATA
+Last Seen: %s
+
+Date: %s`, lastSeenAt.Format(time.RFC3339), currentTime.Format(time.RFC3339)), payload.Message)
+}
+
+func TestNewHostStalePayload(t *testing.T) {
+	t.Parallel()
+
+	//setup
+	lastCheckinAt := time.Now().Add(-48 * time.Hour)
+	devices := []models.Device{
+		{HostId: "custom-host", DeviceName: "/dev/sda"},
+		{HostId: "custom-host", DeviceName: "/dev/sdb", Label: "parity"},
+	}
+	currentTime := time.Now()
+
+	//test
+	payload := NewHostStalePayload("custom-host", lastCheckinAt, devices, currentTime)
+
+	//assert
+	require.Equal(t, NotifyFailureTypeHostStale, payload.FailureType)
+	require.Equal(t, NotifySeverityWarning, payload.Severity())
+	require.Equal(t, "Scrutiny collector stopped reporting for host: custom-host", payload.Subject)
+	require.Equal(t, fmt.Sprintf(`Scrutiny has not received data from the collector for host: custom-host
+Failure Type: HostStale
+Last Check-in: %s
+
+Devices:
+- /dev/sda
+- /dev/sdb (parity)
+
+Date: %s`, lastCheckinAt.Format(time.RFC3339), currentTime.Format(time.RFC3339)), payload.Message)
+}
+
+func TestNewWarrantyExpiryPayload(t *testing.T) {
+	t.Parallel()
+
+	//setup
+	device := models.Device{
+		HostId:       "custom-host",
+		SerialNumber: "FAKEWDDJ324KSO",
+		DeviceType:   pkg.DeviceProtocolAta,
+		DeviceName:   "/dev/sda",
+	}
+	warrantyEnd := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	currentTime := time.Now()
+
+	//test
+	payload := NewWarrantyExpiryPayload(device, analysis.Lifetime{WarrantyEnd: &warrantyEnd}, 100, currentTime)
+
+	//assert
+	require.Equal(t, NotifyFailureTypeWarrantyExpiry, payload.FailureType)
+	require.Equal(t, NotifySeverityWarning, payload.Severity())
+	require.Equal(t, "Scrutiny warranty expired on [host]device: [custom-host]/dev/sda", payload.Subject)
+	require.Equal(t, fmt.Sprintf(`Scrutiny lifetime notification for device: /dev/sda
+Host Id: custom-host
+Failure Type: WarrantyExpiry
+Device Name: /dev/sda
+Device Serial: FAKEWDDJ324KSO
+Device Type: ATA
+Warranty Expires: 2025-01-01
+Warranty Elapsed: 100%%
+
+Date: %s`, currentTime.Format(time.RFC3339)), payload.Message)
+}
+
+func TestNewEnduranceLimitPayload(t *testing.T) {
+	t.Parallel()
+
+	//setup
+	device := models.Device{
+		SerialNumber: "FAKES4EWNX0R",
+		DeviceType:   pkg.DeviceProtocolNvme,
+		DeviceName:   "/dev/nvme0",
+	}
+	bytesWritten := int64(540_000_000_000_000)
+	currentTime := time.Now()
+
+	//test
+	payload := NewEnduranceLimitPayload(device, analysis.Lifetime{BytesWritten: &bytesWritten, RatedTbw: 600}, 90, currentTime)
+
+	//assert
+	require.Equal(t, NotifyFailureTypeEnduranceLimit, payload.FailureType)
+	require.Equal(t, NotifySeverityWarning, payload.Severity())
+	require.Equal(t, "Scrutiny rated endurance 90% used on device: /dev/nvme0", payload.Subject)
+	require.Equal(t, fmt.Sprintf(`Scrutiny lifetime notification for device: /dev/nvme0
+Failure Type: EnduranceLimit
+Device Name: /dev/nvme0
+Device Serial: FAKES4EWNX0R
+Device Type: NVMe
+Endurance Used: 90%%
+Data Written: 540.0 TB
+Rated Endurance: 600 TBW
+
+Date: %s`, currentTime.Format(time.RFC3339)), payload.Message)
+}
+
+func TestDetectTransition(t *testing.T) {
+	t.Parallel()
+
+	//setup
+	bothFailed := pkg.DeviceStatusSet(pkg.DeviceStatusFailedSmart, pkg.DeviceStatusFailedScrutiny)
+
+	//assert
+	require.Equal(t, NotificationTransitionNone, DetectTransition(pkg.DeviceStatusPassed, pkg.DeviceStatusPassed, pkg.MetricsStatusThresholdBoth))
+	require.Equal(t, NotificationTransitionFailed, DetectTransition(pkg.DeviceStatusPassed, pkg.DeviceStatusFailedScrutiny, pkg.MetricsStatusThresholdBoth))
+	require.Equal(t, NotificationTransitionNone, DetectTransition(pkg.DeviceStatusFailedScrutiny, pkg.DeviceStatusFailedScrutiny, pkg.MetricsStatusThresholdBoth))
+	require.Equal(t, NotificationTransitionWorsened, DetectTransition(pkg.DeviceStatusFailedScrutiny, bothFailed, pkg.MetricsStatusThresholdBoth))
+	require.Equal(t, NotificationTransitionNone, DetectTransition(bothFailed, pkg.DeviceStatusFailedSmart, pkg.MetricsStatusThresholdBoth), "partial recovery")
+	require.Equal(t, NotificationTransitionResolved, DetectTransition(bothFailed, pkg.DeviceStatusPassed, pkg.MetricsStatusThresholdBoth))
+
+	// failure types excluded by the status threshold are ignored
+	require.Equal(t, NotificationTransitionNone, DetectTransition(pkg.DeviceStatusPassed, pkg.DeviceStatusFailedScrutiny, pkg.MetricsStatusThresholdSmart))
+	require.Equal(t, NotificationTransitionResolved, DetectTransition(bothFailed, pkg.DeviceStatusFailedScrutiny, pkg.MetricsStatusThresholdSmart))
+}
+
+func TestFailingAttributeIds(t *testing.T) {
+	t.Parallel()
+
+	//setup
+	device := models.Device{DeviceProtocol: pkg.DeviceProtocolAta, DeviceStatus: pkg.DeviceStatusFailedScrutiny}
+	smartAttrs := measurements.Smart{Attributes: map[string]measurements.SmartAttribute{
+		"5":   &measurements.SmartAtaAttribute{Status: pkg.AttributeStatusFailedScrutiny},
+		"10":  &measurements.SmartAtaAttribute{Status: pkg.AttributeStatusPassed},
+		"194": &measurements.SmartAtaAttribute{Status: pkg.AttributeStatusFailedScrutiny},
+		"197": &measurements.SmartAtaAttribute{Status: pkg.AttributeStatusWarningScrutiny},
+	}}
+
+	//assert
+	require.Equal(t, []string{"194", "5"}, FailingAttributeIds(device, smartAttrs, pkg.MetricsStatusThresholdBoth, pkg.MetricsStatusFilterAttributesAll))
+	require.Equal(t, []string{"5"}, FailingAttributeIds(device, smartAttrs, pkg.MetricsStatusThresholdBoth, pkg.MetricsStatusFilterAttributesCritical))
+	require.Empty(t, FailingAttributeIds(device, smartAttrs, pkg.MetricsStatusThresholdSmart, pkg.MetricsStatusFilterAttributesAll))
+}
+
+func TestHasNewFailingAttributes(t *testing.T) {
+	t.Parallel()
+
+	//assert
+	require.True(t, HasNewFailingAttributes([]string{"5"}, []string{"197", "5"}))
+	require.False(t, HasNewFailingAttributes([]string{"197", "5"}, []string{"5"}), "partial recovery")
+	require.False(t, HasNewFailingAttributes([]string{"5"}, []string{"5"}))
+	require.False(t, HasNewFailingAttributes(nil, []string{"5"}), "notified attributes unknown")
+}
+
+func TestShouldNotifyWarning(t *testing.T) {
+	t.Parallel()
+
+	//setup
+	currentTime := time.Now()
+	recentlyNotified := currentTime.Add(-1 * time.Hour)
+	previouslyNotified := currentTime.Add(-48 * time.Hour)
+	device := models.Device{
+		DeviceProtocol: pkg.DeviceProtocolAta,
+		DeviceStatus:   pkg.DeviceStatusWarningScrutiny,
+	}
+	smartAttrs := measurements.Smart{Attributes: map[string]measurements.SmartAttribute{
+		// Power_Cycle_Count is not critical
+		"12": &measurements.SmartAtaAttribute{AttributeId: 12, Status: pkg.AttributeStatusWarningScrutiny},
+	}}
+	passingDevice := models.Device{DeviceProtocol: pkg.DeviceProtocolAta}
+
+	//assert
+	require.True(t, ShouldNotifyWarning(device, smartAttrs, pkg.MetricsNotifyLevelWarn, pkg.MetricsStatusFilterAttributesAll, nil, 24*time.Hour, currentTime))
+	require.True(t, ShouldNotifyWarning(device, smartAttrs, pkg.MetricsNotifyLevelWarn, pkg.MetricsStatusFilterAttributesAll, &previouslyNotified, 24*time.Hour, currentTime))
+	require.False(t, ShouldNotifyWarning(device, smartAttrs, pkg.MetricsNotifyLevelWarn, pkg.MetricsStatusFilterAttributesAll, &recentlyNotified, 24*time.Hour, currentTime), "rate limited")
+	require.False(t, ShouldNotifyWarning(device, smartAttrs, pkg.MetricsNotifyLevelFail, pkg.MetricsStatusFilterAttributesAll, nil, 24*time.Hour, currentTime), "notify level is fail")
+	require.False(t, ShouldNotifyWarning(device, smartAttrs, pkg.MetricsNotifyLevelWarn, pkg.MetricsStatusFilterAttributesCritical, nil, 24*time.Hour, currentTime), "non-critical attribute")
+	require.False(t, ShouldNotifyWarning(passingDevice, smartAttrs, pkg.MetricsNotifyLevelWarn, pkg.MetricsStatusFilterAttributesAll, nil, 24*time.Hour, currentTime), "device has no warnings")
+}
+
+func TestNewWarningPayload(t *testing.T) {
+	t.Parallel()
+
+	//setup
+	device := models.Device{
+		SerialNumber: "FAKEWDDJ324KSO",
+		DeviceType:   pkg.DeviceProtocolAta,
+		DeviceName:   "/dev/sda",
+		DeviceStatus: pkg.DeviceStatusWarningScrutiny,
+	}
+	currentTime := time.Now()
+	//test
+
+	payload := NewWarningPayload(device, currentTime)
+
+	//assert
+	require.Equal(t, NotifyFailureTypeScrutinyWarning, payload.FailureType)
+	require.Equal(t, "Scrutiny SMART warning detected on device: /dev/sda", payload.Subject)
+	require.Equal(t, fmt.Sprintf(`Scrutiny SMART warning notification for device: /dev/sda
+Failure Type: ScrutinyWarning
+Device Name: /dev/sda
+Device Serial: FAKEWDDJ324KSO
+Device Type: ATA
+
+Date: %s`, currentTime.Format(time.RFC3339)), payload.Message)
+}
+
+func TestShouldNotify_MustSkipWarningDevices(t *testing.T) {
+	t.Parallel()
+	//setup
+	device := models.Device{
+		DeviceStatus: pkg.DeviceStatusWarningScrutiny,
+	}
+	smartAttrs := measurements.Smart{}
+	scrutinyUUID := uuid.Must(uuid.NewV4())
+
+	mockCtrl := gomock.NewController(t)
+	fakeDatabase := mock_database.NewMockDeviceRepo(mockCtrl)
+	//assert
+	require.False(t, ShouldNotify(logrus.StandardLogger(), device, smartAttrs, scrutinyUUID, pkg.MetricsStatusThresholdBoth, pkg.MetricsStatusFilterAttributesAll, true, &gin.Context{}, fakeDatabase))
+}
+
+func TestLoadTargets(t *testing.T) {
+	t.Parallel()
+
+	//setup
+	appConfig, err := config.Create()
+	require.NoError(t, err)
+	// yaml lists are decoded as []interface{}, plain urls can be mixed with target objects
+	appConfig.Set("notify.urls", []interface{}{
+		"discord://token@id",
+		map[string]interface{}{
+			"url":              "ntfy://ntfy.sh/storage",
+			"subject_template": "{{.DeviceLabel}} failed",
+		},
+	})
+
+	//test
+	targets, err := LoadTargets(appConfig)
+
+	//assert
+	require.NoError(t, err)
+	require.Equal(t, []Target{
+		{Url: "discord://token@id"},
+		{Url: "ntfy://ntfy.sh/storage", SubjectTemplate: "{{.DeviceLabel}} failed"},
+	}, targets)
+}
+
+func TestLoadTargets_EnvironmentVariable(t *testing.T) {
+	t.Parallel()
+
+	//setup
+	appConfig, err := config.Create()
+	require.NoError(t, err)
+	appConfig.Set("notify.urls", "discord://token@id  https://example.com/webhook")
+
+	//test
+	targets, err := LoadTargets(appConfig)
+
+	//assert
+	require.NoError(t, err)
+	require.Equal(t, []Target{{Url: "discord://token@id"}, {Url: "https://example.com/webhook"}}, targets)
+}
+
+func TestLoadTargets_MissingUrl(t *testing.T) {
+	t.Parallel()
+
+	//setup
+	appConfig, err := config.Create()
+	require.NoError(t, err)
+	appConfig.Set("notify.urls", []interface{}{map[string]interface{}{"subject_template": "{{.Subject}}"}})
+
+	//test
+	_, err = LoadTargets(appConfig)
+
+	//assert
+	require.Error(t, err)
+}
+
+func TestRenderTemplate(t *testing.T) {
+	t.Parallel()
+
+	//setup
+	payload := Payload{
+		HostId:         "nas01",
+		DeviceName:     "/dev/sda",
+		DeviceLabel:    "parity",
+		DeviceCapacity: 14000519643136,
+		FailureType:    NotifyFailureTypeScrutinyFailure,
+		FailingAttributes: []FailingAttribute{
+			{AttributeId: "5", DisplayName: "Reallocated Sectors Count", Status: "failed", Value: 8},
+		},
+	}
+
+	//test
+	subject, subjectErr := RenderTemplate("subject", "[{{upper .HostId}}] {{.DeviceLabel}} ({{capacity .DeviceCapacity}}): {{.FailureType}}", payload)
+	message, messageErr := RenderTemplate("message", "{{range .FailingAttributes}}{{.DisplayName}}={{.Value}}{{end}}", payload)
+	_, missingErr := RenderTemplate("subject", "{{.MissingField}}", payload)
+	_, parseErr := RenderTemplate("subject", "{{.DeviceLabel", payload)
+
+	//assert
+	require.NoError(t, subjectErr)
+	require.Equal(t, "[NAS01] parity (14.0 TB): ScrutinyFailure", subject)
+	require.NoError(t, messageErr)
+	require.Equal(t, "Reallocated Sectors Count=8", message)
+	require.Error(t, missingErr)
+	require.Error(t, parseErr)
+}
+
+func TestNotify_ForTarget(t *testing.T) {
+	t.Parallel()
+
+	//setup
+	mockCtrl := gomock.NewController(t)
+	fakeConfig := mock_config.NewMockInterface(mockCtrl)
+	fakeConfig.EXPECT().GetString("notify.templates.subject").Return("Global: {{.DeviceName}}").AnyTimes()
+	fakeConfig.EXPECT().GetString("notify.templates.message").Return("{{.Invalid").AnyTimes()
+	fakeConfig.EXPECT().GetString("notify.dashboard_url").Return("").AnyTimes()
+	fakeConfig.EXPECT().GetInt("notify.timeout_seconds").Return(30).AnyTimes()
+	device := models.Device{DeviceName: "/dev/sda", DeviceStatus: pkg.DeviceStatusFailedScrutiny}
+	testNotify := New(logrus.StandardLogger(), fakeConfig, device, false)
+
+	//test
+	globalNotify := testNotify.forTarget(Target{Url: "discord://token@id"})
+	targetNotify := testNotify.forTarget(Target{Url: "ntfy://ntfy.sh/storage", SubjectTemplate: "Target: {{.DeviceName}}"})
+
+	//assert
+	require.Equal(t, "Global: /dev/sda", globalNotify.Payload.Subject)
+	require.Equal(t, "Target: /dev/sda", targetNotify.Payload.Subject)
+	// invalid templates fall back to the default message
+	require.Equal(t, testNotify.Payload.Message, globalNotify.Payload.Message)
+	// the original payload is unchanged
+	require.Equal(t, "Scrutiny SMART error (ScrutinyFailure) detected on device: /dev/sda", testNotify.Payload.Subject)
+}
+
+func TestPayload_AddFailingAttributes(t *testing.T) {
+	t.Parallel()
+
+	//setup
+	device := models.Device{
+		ScrutinyUUID:  uuid.Must(uuid.NewV4()),
+		SerialNumber:  "FAKEWDDJ324KSO",
+		DeviceType:    pkg.DeviceProtocolAta,
+		DeviceName:    "/dev/sda",
+		DeviceStatus:  pkg.DeviceStatusFailedScrutiny,
+		Label:         "parity",
+		EnclosureId:   "0:0:8:0",
+		EnclosureSlot: "2",
+		Location:      "rack 2, shelf 3",
+		RmaUrl:        "https://support.example.com/rma",
+	}
+	currentTime := time.Now()
+	smartAttrs := measurements.Smart{Attributes: map[string]measurements.SmartAttribute{
+		"5":   &measurements.SmartAtaAttribute{AttributeId: 5, RawValue: 8, Status: pkg.AttributeStatusFailedScrutiny, StatusReason: "Attribute is failing user-defined limit"},
+		"197": &measurements.SmartAtaAttribute{AttributeId: 197, RawValue: 2, Status: pkg.AttributeStatusWarningScrutiny},
+		"9":   &measurements.SmartAtaAttribute{AttributeId: 9, RawValue: 1000, Status: pkg.AttributeStatusPassed},
+	}}
+	previousSmartAttrs := measurements.Smart{Attributes: map[string]measurements.SmartAttribute{
+		"5": &measurements.SmartAtaAttribute{AttributeId: 5, RawValue: 0, Status: pkg.AttributeStatusPassed},
+	}}
+	payload := NewPayload(device, false, currentTime)
+	payload.DashboardUrl = "http://scrutiny.example.com/web/device/" + device.ScrutinyUUID.String()
+
+	//test
+	payload.AddFailingAttributes(device, smartAttrs, &previousSmartAttrs)
+
+	//assert
+	require.Len(t, payload.FailingAttributes, 2)
+	require.Equal(t, "5", payload.FailingAttributes[0].AttributeId)
+	require.Equal(t, int64(0), *payload.FailingAttributes[0].PreviousValue)
+	require.Equal(t, "197", payload.FailingAttributes[1].AttributeId)
+	require.Nil(t, payload.FailingAttributes[1].PreviousValue)
+	require.Equal(t, fmt.Sprintf(`Scrutiny SMART error notification for device: /dev/sda
+Failure Type: ScrutinyFailure
+Device Name: /dev/sda
+Device Serial: FAKEWDDJ324KSO
+Device Type: ATA
+Device Label: parity
+Device Location: enclosure 0:0:8:0 slot 2
+Location: rack 2, shelf 3
+RMA: https://support.example.com/rma
+
+Failing Attributes:
+- Reallocated Sectors Count (5): 0 -> 8, failed - Attribute is failing user-defined limit
+- Current Pending Sector Count (197): 2, warning
+
+Date: %s
+Details: %s`, currentTime.Format(time.RFC3339), payload.DashboardUrl), payload.Message)
+}
+
+func TestTarget_Matches(t *testing.T) {
+	t.Parallel()
+
+	//setup
+	scrutinyUUID := uuid.Must(uuid.NewV4())
+	failure := Payload{HostId: "nas-01", DeviceLabel: "parity", ScrutinyUUID: scrutinyUUID.String(), FailureType: NotifyFailureTypeSmartFailure}
+	warning := Payload{HostId: "provider-01", DeviceLabel: "cache", FailureType: NotifyFailureTypeScrutinyWarning}
+	resolved := Payload{HostId: "nas-02", FailureType: NotifyFailureTypeResolved}
+	test := Payload{FailureType: NotifyFailureTypeEmailTest, Test: true}
+
+	testCases := []struct {
+		target   Target
+		expected []bool // failure, warning, resolved, test
+	}{
+		{Target{}, []bool{true, true, true, true}},
+		{Target{HostIds: []string{"nas-*"}}, []bool{true, false, true, true}},
+		{Target{Devices: []string{"cache", strings.ToUpper(scrutinyUUID.String())}}, []bool{true, true, false, true}},
+		{Target{FailureTypes: []string{"smartfailure"}}, []bool{true, false, false, true}},
+		{Target{Severities: []string{NotifySeverityFailure}}, []bool{true, false, true, true}},
+		{Target{HostIds: []string{"nas-*"}, Severities: []string{NotifySeverityInfo}}, []bool{false, false, false, true}},
+		{Target{TestOnly: true}, []bool{false, false, false, true}},
+	}
+
+	for _, tc := range testCases {
+		//test
+		actual := []bool{tc.target.Matches(failure), tc.target.Matches(warning), tc.target.Matches(resolved), tc.target.Matches(test)}
+
+		//assert
+		require.Equal(t, tc.expected, actual, "target: %+v", tc.target)
+	}
+}
+
+func TestLoadTargets_InvalidFilters(t *testing.T) {
+	t.Parallel()
+
+	for _, target := range []map[string]interface{}{
+		{"url": "discord://token@id", "host_ids": []interface{}{"nas-["}},
+		{"url": "discord://token@id", "failure_types": []interface{}{"BothFailure"}},
+		{"url": "discord://token@id", "severities": []interface{}{"critical"}},
+	} {
+		//setup
+		appConfig, err := config.Create()
+		require.NoError(t, err)
+		appConfig.Set("notify.urls", []interface{}{target})
+
+		//test
+		_, err = LoadTargets(appConfig)
+
+		//assert
+		require.Error(t, err, "target should be invalid: %v", target)
+	}
+}
+
+type fakeDeliveryRecorder struct {
+	mu         sync.Mutex
+	deliveries []models.NotificationDelivery
+}
+
+func (r *fakeDeliveryRecorder) SaveNotificationDelivery(ctx context.Context, delivery models.NotificationDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.deliveries = append(r.deliveries, delivery)
+	return nil
+}
+
+func TestQueue_RetriesFailedWebhook(t *testing.T) {
+	t.Parallel()
+
+	//setup
+	var requestCount, hookRequestCount atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestCount.Add(1)
+		if r.URL.Path == "/hook" && hookRequestCount.Add(1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer server.Close()
+
+	appConfig, err := config.Create()
+	require.NoError(t, err)
+	appConfig.Set("notify.urls", []string{server.URL + "/hook?token=secret", server.URL + "/other"})
+	appConfig.Set("notify.retry_backoff_seconds", 0)
+	recorder := &fakeDeliveryRecorder{}
+	device := models.Device{ScrutinyUUID: uuid.Must(uuid.NewV4()), DeviceName: "/dev/sda", DeviceStatus: pkg.DeviceStatusFailedSmart}
+	testNotify := New(logrus.StandardLogger(), appConfig, device, false)
+	testNotify.Deliveries = recorder
+	queue := NewQueue(logrus.StandardLogger(), 1, 10)
+
+	//test
+	require.True(t, queue.Add(testNotify))
+	require.Eventually(t, func() bool { return requestCount.Load() == 3 }, 5*time.Second, 10*time.Millisecond)
+	queue.Close()
+
+	//assert (only the failed target is retried)
+	require.Equal(t, int32(2), hookRequestCount.Load())
+	require.Len(t, recorder.deliveries, 3)
+	// the other target succeeded on the first attempt
+	recorder.deliveries = slices.DeleteFunc(recorder.deliveries, func(delivery models.NotificationDelivery) bool {
+		return delivery.Attempt == 1 && delivery.Success
+	})
+	require.Len(t, recorder.deliveries, 2)
+	require.False(t, recorder.deliveries[0].Success)
+	require.Contains(t, recorder.deliveries[0].Error, "502")
+	require.True(t, recorder.deliveries[1].Success)
+	require.Equal(t, 2, recorder.deliveries[1].Attempt)
+	require.Equal(t, NotifyTargetTypeWebhook, recorder.deliveries[1].TargetType)
+	require.Equal(t, device.ScrutinyUUID.String(), recorder.deliveries[1].ScrutinyUUID)
+	require.NotContains(t, recorder.deliveries[1].Target, "secret")
+}
+
+func TestNotify_Send_DoesNotRetry(t *testing.T) {
+	t.Parallel()
+
+	//setup
+	var requestCount atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestCount.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	appConfig, err := config.Create()
+	require.NoError(t, err)
+	appConfig.Set("notify.urls", []string{server.URL})
+	appConfig.Set("notify.retry_backoff_seconds", 60)
+	testNotify := New(logrus.StandardLogger(), appConfig, models.Device{DeviceName: "/dev/sda"}, true)
+
+	//test
+	startTime := time.Now()
+	err = testNotify.Send()
+
+	//assert (sent outside of a queue, eg. test notifications, the sender doesn't wait for retries)
+	require.Error(t, err)
+	require.Equal(t, int32(1), requestCount.Load())
+	require.Less(t, time.Since(startTime), 10*time.Second)
+}
+
+func TestNotify_Send_WebhookTimeout(t *testing.T) {
+	t.Parallel()
+
+	//setup
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(2 * time.Second)
+	}))
+	defer server.Close()
+
+	appConfig, err := config.Create()
+	require.NoError(t, err)
+	appConfig.Set("notify.urls", []interface{}{map[string]interface{}{"url": server.URL, "timeout_seconds": 1, "retries": 0}})
+	recorder := &fakeDeliveryRecorder{}
+	testNotify := New(logrus.StandardLogger(), appConfig, models.Device{DeviceName: "/dev/sda"}, true)
+	testNotify.Deliveries = recorder
+
+	//test
+	err = testNotify.Send()
+
+	//assert
+	require.Error(t, err)
+	require.Len(t, recorder.deliveries, 1)
+	require.False(t, recorder.deliveries[0].Success)
+	require.True(t, recorder.deliveries[0].Test)
+}
+
+func TestQueue_SendsInBackground(t *testing.T) {
+	t.Parallel()
+
+	//setup
+	release := make(chan bool)
+	var requestCount atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		requestCount.Add(1)
+	}))
+	defer server.Close()
+
+	appConfig, err := config.Create()
+	require.NoError(t, err)
+	appConfig.Set("notify.urls", []string{server.URL + "/hook"})
+	recorder := &fakeDeliveryRecorder{}
+	device := models.Device{ScrutinyUUID: uuid.Must(uuid.NewV4()), DeviceName: "/dev/sda", DeviceStatus: pkg.DeviceStatusFailedSmart}
+	queue := NewQueue(logrus.StandardLogger(), 1, 1)
+
+	//test
+	firstNotify := New(logrus.StandardLogger(), appConfig, device, false)
+	firstNotify.Deliveries = recorder
+	queued := queue.Add(firstNotify)
+	// the worker is blocked by the first notification, so the second one fills the queue
+	require.Eventually(t, func() bool { return len(queue.notifications) == 0 }, time.Second, 10*time.Millisecond)
+	secondQueued := queue.Add(firstNotify)
+	thirdQueued := queue.Add(firstNotify)
+	close(release)
+	queue.Close()
+
+	//assert
+	require.True(t, queued)
+	require.True(t, secondQueued)
+	require.False(t, thirdQueued)
+	require.Equal(t, int32(2), requestCount.Load())
+	require.Len(t, recorder.deliveries, 2)
+	require.True(t, recorder.deliveries[0].Success)
+}
+
+func TestRedactTargetUrl(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "discord://id/...", redactTargetUrl("discord://token@id"))
+	require.Equal(t, "https://discord.com/...", redactTargetUrl("https://discord.com/api/webhooks/123/token"))
+	require.Equal(t, "https://example.com", redactTargetUrl("https://example.com/"))
+	require.Equal(t, "script:///opt/notify.sh", redactTargetUrl("script:///opt/notify.sh"))
+}
+
+func TestNewDigestPayload(t *testing.T) {
+	t.Parallel()
+
+	//setup
+	currentTime := time.Now()
+	payloads := []Payload{
+		NewPayload(models.Device{ScrutinyUUID: uuid.Must(uuid.NewV4()), HostId: "nas-01", DeviceName: "/dev/sda", Label: "parity", DeviceStatus: pkg.DeviceStatusFailedSmart}, false, currentTime),
+		NewWarningPayload(models.Device{ScrutinyUUID: uuid.Must(uuid.NewV4()), HostId: "nas-01", DeviceName: "/dev/sdb"}, currentTime),
+		NewResolvedPayload(models.Device{ScrutinyUUID: uuid.Must(uuid.NewV4()), HostId: "nas-02", DeviceName: "/dev/sdc"}, currentTime),
+	}
+
+	//test
+	payload := NewDigestPayload(payloads, currentTime)
+
+	//assert
+	require.Equal(t, NotifyFailureTypeDigest, payload.FailureType)
+	require.Equal(t, NotifySeverityFailure, payload.Severity())
+	require.Equal(t, "Scrutiny SMART digest: 3 notifications for 3 devices on 2 hosts", payload.Subject)
+	require.Equal(t, fmt.Sprintf(`Scrutiny SMART digest: 3 notifications for 3 devices on 2 hosts
+- [nas-01] /dev/sda (parity): SmartFailure
+- [nas-01] /dev/sdb: ScrutinyWarning
+- [nas-02] /dev/sdc: Resolved
+
+Date: %s`, currentTime.Format(time.RFC3339)), payload.Message)
+}
+
+func TestDigest_Flush(t *testing.T) {
+	t.Parallel()
+
+	//setup
+	var mu sync.Mutex
+	received := map[string][]Payload{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload Payload
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		mu.Lock()
+		defer mu.Unlock()
+		received[r.URL.Path] = append(received[r.URL.Path], payload)
+	}))
+	defer server.Close()
+
+	appConfig, err := config.Create()
+	require.NoError(t, err)
+	appConfig.Set("notify.urls", []interface{}{
+		server.URL + "/all",
+		map[string]interface{}{"url": server.URL + "/nas-02", "host_ids": []interface{}{"nas-02"}},
+		map[string]interface{}{"url": server.URL + "/none", "host_ids": []interface{}{"provider-*"}},
+	})
+	appConfig.Set("notify.digest.interval_seconds", 3600)
+	appConfig.Set("notify.digest.quiet_seconds", 3600)
+	digest := NewDigest(logrus.StandardLogger(), appConfig)
+	repeatedDevice := models.Device{ScrutinyUUID: uuid.Must(uuid.NewV4()), HostId: "nas-01", DeviceName: "/dev/sda", DeviceStatus: pkg.DeviceStatusFailedSmart}
+
+	//test
+	digest.Add(New(logrus.StandardLogger(), appConfig, repeatedDevice, false))
+	digest.Add(New(logrus.StandardLogger(), appConfig, repeatedDevice, false))
+	digest.Add(NewWarning(logrus.StandardLogger(), appConfig, models.Device{ScrutinyUUID: uuid.Must(uuid.NewV4()), HostId: "nas-02", DeviceName: "/dev/sdb"}))
+	err = digest.Flush()
+	emptyErr := digest.Flush()
+
+	//assert
+	require.NoError(t, err)
+	require.NoError(t, emptyErr)
+	require.Len(t, received["/all"], 1)
+	require.Equal(t, NotifyFailureTypeDigest, received["/all"][0].FailureType)
+	require.Len(t, received["/all"][0].Digest, 2)
+	// a single matching notification is sent as-is
+	require.Len(t, received["/nas-02"], 1)
+	require.Equal(t, NotifyFailureTypeScrutinyWarning, received["/nas-02"][0].FailureType)
+	require.Empty(t, received["/none"])
+}
+
+func TestNotify_SendWebhookNotification_Signed(t *testing.T) {
+	t.Parallel()
+
+	//setup
+	secret := "webhook-secret"
+	var requestHeaders http.Header
+	var requestBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestHeaders = r.Header.Clone()
+		requestBody, _ = io.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	appConfig, err := config.Create()
+	require.NoError(t, err)
+	appConfig.Set("notify.urls", []interface{}{map[string]interface{}{"url": server.URL, "secret": secret}})
+	previousStatus := pkg.DeviceStatusPassed
+	device := models.Device{ScrutinyUUID: uuid.Must(uuid.NewV4()), DeviceName: "/dev/sda", ModelName: "WDC WD140EDGZ-11B1PA0", DeviceStatus: pkg.DeviceStatusFailedSmart}
+	testNotify := New(logrus.StandardLogger(), appConfig, device, false)
+	testNotify.Payload.PreviousDeviceStatus = &previousStatus
+
+	//test
+	err = testNotify.Send()
+
+	//assert
+	require.NoError(t, err)
+	require.Equal(t, "2", requestHeaders.Get(WebhookHeaderSchemaVersion))
+	require.NoError(t, verifyWebhookSignature(secret, requestHeaders.Get(WebhookHeaderTimestamp), requestHeaders.Get(WebhookHeaderSignature), requestBody, time.Minute, time.Now()))
+	require.Error(t, verifyWebhookSignature("wrong-secret", requestHeaders.Get(WebhookHeaderTimestamp), requestHeaders.Get(WebhookHeaderSignature), requestBody, time.Minute, time.Now()))
+	require.Error(t, verifyWebhookSignature(secret, requestHeaders.Get(WebhookHeaderTimestamp), requestHeaders.Get(WebhookHeaderSignature), requestBody, time.Minute, time.Now().Add(time.Hour)))
+
+	var webhookPayload WebhookPayload
+	require.NoError(t, json.Unmarshal(requestBody, &webhookPayload))
+	require.Equal(t, WebhookSchemaVersion, webhookPayload.SchemaVersion)
+	require.Equal(t, NotifySeverityFailure, webhookPayload.Severity)
+	require.Equal(t, "/dev/sda", webhookPayload.DeviceName) // version 1 fields
+	require.Equal(t, "WDC WD140EDGZ-11B1PA0", webhookPayload.Device.Model)
+	require.Equal(t, pkg.DeviceStatusFailedSmart, webhookPayload.Device.Status)
+	require.Equal(t, pkg.DeviceStatusPassed, *webhookPayload.Device.PreviousStatus)
+}
+
+// verifyWebhookSignature checks the signature & timestamp headers of a webhook request, the way a receiver would (see
+// docs/TROUBLESHOOTING_NOTIFICATIONS.md). Requests with a timestamp outside the tolerance are rejected.
+func verifyWebhookSignature(secret string, timestampHeader string, signatureHeader string, body []byte, tolerance time.Duration, currentTime time.Time) error {
+	timestamp, err := strconv.ParseInt(timestampHeader, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid %s header: %w", WebhookHeaderTimestamp, err)
+	}
+	if age := currentTime.Sub(time.Unix(timestamp, 0)); age > tolerance || age < -tolerance {
+		return fmt.Errorf("%s header is outside the allowed tolerance (%s)", WebhookHeaderTimestamp, tolerance)
+	}
+	if !strings.HasPrefix(signatureHeader, "sha256=") || !hmac.Equal([]byte(signatureHeader), []byte(SignWebhookPayload(secret, timestamp, body))) {
+		return errors.New("webhook signature does not match")
+	}
+	return nil
+}
+
+func TestNotify_SendWebhookNotification_Unsigned(t *testing.T) {
+	t.Parallel()
+
+	//setup
+	var requestHeaders http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestHeaders = r.Header.Clone()
+	}))
+	defer server.Close()
+
+	appConfig, err := config.Create()
+	require.NoError(t, err)
+	appConfig.Set("notify.urls", []string{server.URL})
+	testNotify := New(logrus.StandardLogger(), appConfig, models.Device{DeviceName: "/dev/sda"}, true)
+
+	//test
+	err = testNotify.Send()
+
+	//assert
+	require.NoError(t, err)
+	require.Empty(t, requestHeaders.Get(WebhookHeaderSignature))
+	require.Empty(t, requestHeaders.Get(WebhookHeaderTimestamp))
+}
@@ -0,0 +1,84 @@
+package notify
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Queue sends notifications in the background, so that a slow or unreachable target doesn't block the collector upload
+// (or the monitor check) which triggered the notification. Notifications are sent by a fixed number of workers, and are
+// dropped (with an error) when the queue is full. Failed deliveries are added to the queue again after a backoff (see
+// retryLater), workers don't wait for retries.
+//
+// The queue is only stored in memory, queued notifications (and pending retries) are lost if Scrutiny is restarted
+// before they are sent.
+type Queue struct {
+	Logger logrus.FieldLogger
+
+	notifications chan Notify
+	workers       sync.WaitGroup
+
+	// guards notifications, retries may be added after the queue is closed
+	mu     sync.Mutex
+	closed bool
+}
+
+// NewQueue starts the queue workers, stop them using Close.
+func NewQueue(logger logrus.FieldLogger, workers int, size int) *Queue {
+	q := &Queue{
+		Logger:        logger,
+		notifications: make(chan Notify, size),
+	}
+	for range max(workers, 1) {
+		q.workers.Add(1)
+		go q.run()
+	}
+	return q
+}
+
+// Add queues the notification, without waiting for it to be sent. Returns false if the queue is full or closed.
+func (q *Queue) Add(n Notify) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		q.Logger.Errorf("Notification queue is closed, dropping %s notification for %s", n.Payload.FailureType, n.Payload.DeviceName)
+		return false
+	}
+	select {
+	case q.notifications <- n:
+		return true
+	default:
+		q.Logger.Errorf("Notification queue is full, dropping %s notification for %s", n.Payload.FailureType, n.Payload.DeviceName)
+		return false
+	}
+}
+
+// addAfter queues the notification once the delay has passed, see retryLater
+func (q *Queue) addAfter(n Notify, delay time.Duration) {
+	time.AfterFunc(delay, func() { q.Add(n) })
+}
+
+// Close stops accepting notifications, and waits for the queued notifications to be sent. Pending retries are dropped.
+func (q *Queue) Close() {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.notifications)
+	}
+	q.mu.Unlock()
+
+	q.workers.Wait()
+}
+
+func (q *Queue) run() {
+	defer q.workers.Done()
+	for n := range q.notifications {
+		n.queue = q
+		if err := n.Send(); err != nil {
+			q.Logger.Errorf("An error occurred while sending %s notification: %v", n.Payload.FailureType, err)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package notify
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/analogj/scrutiny/webapp/backend/pkg/config"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/models"
+	"github.com/sirupsen/logrus"
+)
+
+// NewDeviceStalePayload is used when a device has not reported data for `metrics.stale_interval_hours`, eg. because it
+// died completely or dropped off the bus, and is no longer detected by the collector.
+func NewDeviceStalePayload(device models.Device, currentTime ...time.Time) Payload {
+	payload := NewPayload(device, false, currentTime...)
+	payload.FailureType = NotifyFailureTypeDeviceStale
+	if device.LastSeenAt != nil {
+		payload.LastSeenAt = device.LastSeenAt.Format(time.RFC3339)
+	}
+	payload.Subject = payload.GenerateSubject()
+	payload.Message = payload.GenerateMessage()
+	return payload
+}
+
+// NewHostStalePayload is used when the collector for a host has not checked in for `metrics.stale_interval_hours`. A
+// single notification is sent for the host, instead of one for each of its devices.
+func NewHostStalePayload(hostId string, lastCheckinAt time.Time, devices []models.Device, currentTime ...time.Time) Payload {
+	payload := Payload{
+		HostId:      hostId,
+		FailureType: NotifyFailureTypeHostStale,
+		LastSeenAt:  lastCheckinAt.Format(time.RFC3339),
+	}
+	for _, device := range devices {
+		staleDevice := device.DeviceName
+		if len(device.Label) > 0 {
+			staleDevice = fmt.Sprintf("%s (%s)", device.DeviceName, device.Label)
+		}
+		payload.StaleDevices = append(payload.StaleDevices, staleDevice)
+	}
+
+	sendDate := time.Now()
+	if len(currentTime) > 0 {
+		sendDate = currentTime[0]
+	}
+	payload.Date = sendDate.Format(time.RFC3339)
+	payload.Subject = payload.GenerateSubject()
+	payload.Message = payload.GenerateMessage()
+	return payload
+}
+
+func NewDeviceStale(logger logrus.FieldLogger, appconfig config.Interface, device models.Device) Notify {
+	return newNotify(logger, appconfig, device, NewDeviceStalePayload(device))
+}
+
+func NewHostStale(logger logrus.FieldLogger, appconfig config.Interface, hostId string, lastCheckinAt time.Time, devices []models.Device) Notify {
+	return newNotify(logger, appconfig, models.Device{}, NewHostStalePayload(hostId, lastCheckinAt, devices))
+}
+
+func (p *Payload) hostStaleMessage() []string {
+	messageParts := []string{}
+	if len(p.HostId) > 0 {
+		messageParts = append(messageParts, fmt.Sprintf("Scrutiny has not received data from the collector for host: %s", p.HostId))
+	} else {
+		messageParts = append(messageParts, "Scrutiny has not received data from the collector")
+	}
+	messageParts = append(messageParts,
+		fmt.Sprintf("Failure Type: %s", p.FailureType),
+		fmt.Sprintf("Last Check-in: %s", p.LastSeenAt),
+	)
+	if len(p.StaleDevices) > 0 {
+		messageParts = append(messageParts, "", "Devices:")
+		for _, staleDevice := range p.StaleDevices {
+			messageParts = append(messageParts, fmt.Sprintf("- %s", staleDevice))
+		}
+	}
+	return append(messageParts, "", fmt.Sprintf("Date: %s", p.Date))
+}
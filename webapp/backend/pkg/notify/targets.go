@@ -0,0 +1,160 @@
+package notify
+
+import (
+	"fmt"
+	"path"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/analogj/scrutiny/webapp/backend/pkg/config"
+	"github.com/spf13/viper"
+)
+
+// Target is a notification endpoint configured in `notify.urls`. Each entry is either a url string, or an object with
+// additional settings for the endpoint:
+//
+//	notify:
+//	  urls:
+//	    - "discord://token@id"
+//	    - url: "ntfy://ntfy.sh/storage-team"
+//	      subject_template: "{{.DeviceLabel}} on {{.HostId}}: {{.FailureType}}"
+//	      host_ids: ["nas-*"]
+//	      severities: ["failure"]
+//
+// Filters are combined, a notification is only sent to the target when it matches every configured filter. An empty
+// filter matches all notifications.
+type Target struct {
+	Url string `mapstructure:"url"`
+
+	// override the global `notify.templates.subject` & `notify.templates.message` templates for this target
+	SubjectTemplate string `mapstructure:"subject_template"`
+	MessageTemplate string `mapstructure:"message_template"`
+
+	// override the global `notify.timeout_seconds` & `notify.retries` options for this target
+	TimeoutSeconds int  `mapstructure:"timeout_seconds"`
+	Retries        *int `mapstructure:"retries"`
+
+	// webhook targets only, used to sign the request body (see SignWebhookPayload)
+	Secret string `mapstructure:"secret"`
+
+	//Filters
+	HostIds      []string `mapstructure:"host_ids"`      // glob patterns, eg. "nas-*"
+	Devices      []string `mapstructure:"devices"`       // device labels or scrutiny uuids
+	FailureTypes []string `mapstructure:"failure_types"` // SmartFailure, ScrutinyFailure, ScrutinyWarning, PredictedFailure, Resolved, DeviceStale, HostStale, WarrantyExpiry, EnduranceLimit
+	Severities   []string `mapstructure:"severities"`    // failure, warning, info
+	TestOnly     bool     `mapstructure:"test_only"`     // only receive test notifications
+}
+
+// String is used when logging the configured targets, the secret & templates are omitted
+func (t Target) String() string {
+	return t.Url
+}
+
+// Matches checks if the notification should be sent to the target. Test notifications are sent to every target (so the
+// endpoint can be verified), and are the only notifications sent to test only targets.
+func (t Target) Matches(payload Payload) bool {
+	if payload.Test {
+		return true
+	} else if t.TestOnly {
+		return false
+	}
+
+	if len(t.HostIds) > 0 && !slices.ContainsFunc(t.HostIds, func(pattern string) bool {
+		matched, _ := path.Match(pattern, payload.HostId)
+		return matched
+	}) {
+		return false
+	}
+	if len(t.Devices) > 0 && !slices.ContainsFunc(t.Devices, func(device string) bool {
+		return (len(payload.DeviceLabel) > 0 && device == payload.DeviceLabel) ||
+			(len(payload.ScrutinyUUID) > 0 && strings.EqualFold(device, payload.ScrutinyUUID))
+	}) {
+		return false
+	}
+	if len(t.FailureTypes) > 0 && !slices.ContainsFunc(t.FailureTypes, func(failureType string) bool {
+		return strings.EqualFold(failureType, payload.FailureType)
+	}) {
+		return false
+	}
+	if len(t.Severities) > 0 && !slices.ContainsFunc(t.Severities, func(severity string) bool {
+		return strings.EqualFold(severity, payload.Severity())
+	}) {
+		return false
+	}
+	return true
+}
+
+func (t Target) validate() error {
+	for _, pattern := range t.HostIds {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid host_ids pattern %q: %w", pattern, err)
+		}
+	}
+	for _, failureType := range t.FailureTypes {
+		if !slices.ContainsFunc([]string{
+			NotifyFailureTypeSmartFailure,
+			NotifyFailureTypeScrutinyFailure,
+			NotifyFailureTypeScrutinyWarning,
+			NotifyFailureTypePredictedFailure,
+			NotifyFailureTypeResolved,
+			NotifyFailureTypeDeviceStale,
+			NotifyFailureTypeHostStale,
+			NotifyFailureTypeWarrantyExpiry,
+			NotifyFailureTypeEnduranceLimit,
+		}, func(known string) bool { return strings.EqualFold(known, failureType) }) {
+			return fmt.Errorf("unknown failure type %q", failureType)
+		}
+	}
+	for _, severity := range t.Severities {
+		if !slices.ContainsFunc([]string{NotifySeverityFailure, NotifySeverityWarning, NotifySeverityInfo}, func(known string) bool {
+			return strings.EqualFold(known, severity)
+		}) {
+			return fmt.Errorf("unknown severity %q", severity)
+		}
+	}
+	return nil
+}
+
+// LoadTargets parses the `notify.urls` configuration.
+func LoadTargets(appconfig config.Interface) ([]Target, error) {
+	targets := []Target{}
+
+	switch configUrls := appconfig.Get("notify.urls").(type) {
+	case nil:
+		return targets, nil
+	case string:
+		// set via the SCRUTINY_NOTIFY_URLS environmental variable, whitespace separated
+		for _, targetUrl := range strings.Fields(configUrls) {
+			targets = append(targets, Target{Url: targetUrl})
+		}
+	case []string:
+		for _, targetUrl := range configUrls {
+			targets = append(targets, Target{Url: targetUrl})
+		}
+	default:
+		err := appconfig.UnmarshalKey("notify.urls", &targets, viper.DecodeHook(stringToTargetHook))
+		if err != nil {
+			return nil, fmt.Errorf("invalid notify.urls configuration: %w", err)
+		}
+	}
+
+	for ndx := range targets {
+		targets[ndx].Url = strings.TrimSpace(targets[ndx].Url)
+		if len(targets[ndx].Url) == 0 {
+			return nil, fmt.Errorf("invalid notify.urls configuration: entry %d is missing a url", ndx)
+		}
+		if err := targets[ndx].validate(); err != nil {
+			return nil, fmt.Errorf("invalid notify.urls configuration: entry %d: %w", ndx, err)
+		}
+	}
+	return targets, nil
+}
+
+// stringToTargetHook allows plain url strings to be mixed with Target objects in `notify.urls`
+func stringToTargetHook(from reflect.Type, to reflect.Type, data interface{}) (interface{}, error) {
+	if from.Kind() == reflect.String && to == reflect.TypeOf(Target{}) {
+		return Target{Url: data.(string)}, nil
+	}
+	return data, nil
+}
@@ -0,0 +1,178 @@
+package notify
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/analogj/scrutiny/webapp/backend/pkg"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/models"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/models/measurements"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/thresholds"
+)
+
+// FailingAttribute is a non-passing attribute, included in failure & warning notifications.
+type FailingAttribute struct {
+	AttributeId   string `json:"attribute_id"`
+	DisplayName   string `json:"display_name,omitempty"`
+	Status        string `json:"status"` // failed or warning
+	StatusReason  string `json:"status_reason,omitempty"`
+	Value         int64  `json:"value"`
+	PreviousValue *int64 `json:"previous_value,omitempty"` // value from the previous upload, when available
+}
+
+// String is used in the default notification message, eg. "Reallocated Sectors Count (5): 0 -> 8, failed - reason"
+func (a FailingAttribute) String() string {
+	name := a.AttributeId
+	if len(a.DisplayName) > 0 {
+		name = fmt.Sprintf("%s (%s)", a.DisplayName, a.AttributeId)
+	}
+	value := strconv.FormatInt(a.Value, 10)
+	if a.PreviousValue != nil && *a.PreviousValue != a.Value {
+		value = fmt.Sprintf("%d -> %d", *a.PreviousValue, a.Value)
+	}
+
+	line := fmt.Sprintf("%s: %s, %s", name, value, a.Status)
+	if len(a.StatusReason) > 0 {
+		line += " - " + a.StatusReason
+	}
+	return line
+}
+
+// NewFailingAttributes returns the non-passing attributes of the SMART data, sorted by attribute id. previous is the
+// SMART data from the previous upload, and may be nil.
+func NewFailingAttributes(device models.Device, smartData measurements.Smart, previous *measurements.Smart) []FailingAttribute {
+	failingAttributes := []FailingAttribute{}
+	for attrId, attrData := range smartData.Attributes {
+		status := attrData.GetStatus()
+		if status == pkg.AttributeStatusPassed {
+			continue
+		}
+
+		failingAttribute := FailingAttribute{
+			AttributeId:  attrId,
+			DisplayName:  attributeDisplayName(device, attrId),
+			Status:       "failed",
+			StatusReason: attrData.GetStatusReason(),
+			Value:        attrData.GetThresholdValue(),
+		}
+		if !pkg.AttributeStatusHas(status, pkg.AttributeStatusFailedSmart) && !pkg.AttributeStatusHas(status, pkg.AttributeStatusFailedScrutiny) {
+			failingAttribute.Status = "warning"
+		}
+		if previous != nil {
+			if previousAttr, ok := previous.Attributes[attrId]; ok {
+				previousValue := previousAttr.GetThresholdValue()
+				failingAttribute.PreviousValue = &previousValue
+			}
+		}
+		failingAttributes = append(failingAttributes, failingAttribute)
+	}
+
+	sort.Slice(failingAttributes, func(i, j int) bool {
+		// ATA attribute ids are numeric
+		left, leftErr := strconv.Atoi(failingAttributes[i].AttributeId)
+		right, rightErr := strconv.Atoi(failingAttributes[j].AttributeId)
+		if leftErr == nil && rightErr == nil {
+			return left < right
+		}
+		return failingAttributes[i].AttributeId < failingAttributes[j].AttributeId
+	})
+	return failingAttributes
+}
+
+// AddFailingAttributes adds the non-passing attributes to the payload (see NewFailingAttributes), and regenerates the
+// default message.
+func (p *Payload) AddFailingAttributes(device models.Device, smartData measurements.Smart, previous *measurements.Smart) {
+	p.FailingAttributes = NewFailingAttributes(device, smartData, previous)
+	p.Message = p.GenerateMessage()
+}
+
+func attributeDisplayName(device models.Device, attrId string) string {
+	if device.IsScsi() {
+		return thresholds.ScsiMetadata[attrId].DisplayName
+	} else if device.IsNvme() {
+		return thresholds.NmveMetadata[attrId].DisplayName
+	} else {
+		attrIdInt, err := strconv.Atoi(attrId)
+		if err != nil {
+			return ""
+		}
+		return thresholds.AtaMetadata[attrIdInt].DisplayName
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Templates
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+var templateFuncs = template.FuncMap{
+	"upper":    strings.ToUpper,
+	"lower":    strings.ToLower,
+	"join":     strings.Join,
+	"capacity": formatCapacity,
+}
+
+// RenderTemplate executes a subject or message template (Go text/template syntax) with the payload as its data.
+func RenderTemplate(name string, templateText string, payload Payload) (string, error) {
+	tmpl, err := template.New(name).Funcs(templateFuncs).Option("missingkey=error").Parse(templateText)
+	if err != nil {
+		return "", fmt.Errorf("could not parse %s template: %w", name, err)
+	}
+	var rendered bytes.Buffer
+	if err := tmpl.Execute(&rendered, payload); err != nil {
+		return "", fmt.Errorf("could not render %s template: %w", name, err)
+	}
+	return rendered.String(), nil
+}
+
+// forTarget returns a copy of the notification, with the subject & message rendered using the target templates, or the
+// global `notify.templates.subject` & `notify.templates.message` templates. The default subject & message are kept
+// when no template is configured, or when the template is invalid (the notification is still sent).
+func (n *Notify) forTarget(target Target) Notify {
+	targetNotify := *n
+	targetNotify.timeout = target.timeout(n.Config)
+	targetNotify.webhookSecret = target.Secret
+
+	subjectTemplate := target.SubjectTemplate
+	if len(subjectTemplate) == 0 {
+		subjectTemplate = n.Config.GetString("notify.templates.subject")
+	}
+	messageTemplate := target.MessageTemplate
+	if len(messageTemplate) == 0 {
+		messageTemplate = n.Config.GetString("notify.templates.message")
+	}
+
+	if len(subjectTemplate) > 0 {
+		if subject, err := RenderTemplate("subject", subjectTemplate, n.Payload); err != nil {
+			n.Logger.Errorf("Using the default notification subject: %v", err)
+		} else {
+			targetNotify.Payload.Subject = subject
+		}
+	}
+	if len(messageTemplate) > 0 {
+		if message, err := RenderTemplate("message", messageTemplate, n.Payload); err != nil {
+			n.Logger.Errorf("Using the default notification message: %v", err)
+		} else {
+			targetNotify.Payload.Message = message
+		}
+	}
+	return targetNotify
+}
+
+// formatCapacity formats a size in bytes using SI units, eg. 14000519643136 -> "14.0 TB"
+func formatCapacity(capacity int64) string {
+	units := []string{"B", "KB", "MB", "GB", "TB", "PB"}
+	size := float64(capacity)
+	unit := 0
+	for size >= 1000 && unit < len(units)-1 {
+		size /= 1000
+		unit++
+	}
+	if unit == 0 {
+		return fmt.Sprintf("%d B", capacity)
+	}
+	return fmt.Sprintf("%.1f %s", size, units[unit])
+}
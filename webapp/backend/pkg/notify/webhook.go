@@ -0,0 +1,76 @@
+package notify
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+
+	"github.com/analogj/scrutiny/webapp/backend/pkg"
+)
+
+// WebhookSchemaVersion is incremented when fields are removed or changed in WebhookPayload. The unversioned payload
+// sent by older releases (the bare Payload) is version 1.
+const WebhookSchemaVersion = 2
+
+const (
+	WebhookHeaderSignature     = "X-Scrutiny-Signature"
+	WebhookHeaderTimestamp     = "X-Scrutiny-Timestamp"
+	WebhookHeaderSchemaVersion = "X-Scrutiny-Schema-Version"
+)
+
+// WebhookPayload is the JSON document posted to webhook targets. The Payload fields are still included at the top
+// level, so receivers of the version 1 payload keep working.
+type WebhookPayload struct {
+	SchemaVersion int    `json:"schema_version"`
+	Severity      string `json:"severity"` //failure, warning, info
+	Payload
+
+	Device *WebhookDevice `json:"device,omitempty"` //empty for Digest & HostStale notifications
+}
+
+type WebhookDevice struct {
+	ScrutinyUUID   string            `json:"scrutiny_uuid,omitempty"`
+	HostId         string            `json:"host_id,omitempty"`
+	Name           string            `json:"name"`
+	Type           string            `json:"type"`
+	Serial         string            `json:"serial"`
+	Label          string            `json:"label,omitempty"`
+	Model          string            `json:"model,omitempty"`
+	Capacity       int64             `json:"capacity,omitempty"`
+	Status         pkg.DeviceStatus  `json:"status"`
+	PreviousStatus *pkg.DeviceStatus `json:"previous_status,omitempty"` //status before the upload which triggered the notification
+}
+
+func NewWebhookPayload(payload Payload) WebhookPayload {
+	webhookPayload := WebhookPayload{
+		SchemaVersion: WebhookSchemaVersion,
+		Severity:      payload.Severity(),
+		Payload:       payload,
+	}
+	if payload.FailureType != NotifyFailureTypeDigest && payload.FailureType != NotifyFailureTypeHostStale {
+		webhookPayload.Device = &WebhookDevice{
+			ScrutinyUUID:   payload.ScrutinyUUID,
+			HostId:         payload.HostId,
+			Name:           payload.DeviceName,
+			Type:           payload.DeviceType,
+			Serial:         payload.DeviceSerial,
+			Label:          payload.DeviceLabel,
+			Model:          payload.DeviceModel,
+			Capacity:       payload.DeviceCapacity,
+			Status:         payload.DeviceStatus,
+			PreviousStatus: payload.PreviousDeviceStatus,
+		}
+	}
+	return webhookPayload
+}
+
+// SignWebhookPayload returns the X-Scrutiny-Signature header value: the hex encoded HMAC-SHA256 of
+// "<timestamp>.<request body>", using the webhook secret as the key.
+func SignWebhookPayload(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -33,6 +33,31 @@ type ObservedThreshold struct {
 	ErrorInterval     []float64 `json:"error_interval"`
 }
 
+// the observed annual failure rate at which an attribute is failed (critical attributes) or warned (non-critical)
+const ObservedFailureRateCritical = 0.10
+
+// the observed annual failure rate at which a non-critical attribute is failed
+const ObservedFailureRateNonCritical = 0.20
+
+// FailureLimit returns the lowest value which falls in an observed threshold bucket with a failing annual failure
+// rate. false if none of the buckets are failing.
+func (m AtaAttributeMetadata) FailureLimit(critical bool) (int64, bool) {
+	failureRate := ObservedFailureRateNonCritical
+	if critical {
+		failureRate = ObservedFailureRateCritical
+	}
+	for _, obsThresh := range m.ObservedThresholds {
+		if obsThresh.AnnualFailureRate < failureRate {
+			continue
+		}
+		if obsThresh.Low == obsThresh.High {
+			return obsThresh.Low, true
+		}
+		return obsThresh.Low + 1, true
+	}
+	return 0, false
+}
+
 var AtaMetadata = map[int]AtaAttributeMetadata{
 	1: {
 		ID:          1,
@@ -0,0 +1,52 @@
+package thresholds
+
+// AttributeOverride replaces the built-in metadata for a single attribute. Overrides are user-defined rules stored in the
+// database (see models.AttributeOverrideRule), and are resolved for each device before its SMART data is processed.
+type AttributeOverride struct {
+	// the attribute status is always passing
+	Ignore bool
+	// "low" or "high", replaces the metadata Ideal direction when not empty
+	Ideal string
+	// replaces the metadata Critical flag when not nil
+	Critical *bool
+	// when either limit is set, the attribute value is compared against the limits instead of the built-in thresholds.
+	// Values past the limit (above for a "low" ideal, below for a "high" ideal) are warned/failed.
+	WarnLimit *int64
+	FailLimit *int64
+}
+
+func (o *AttributeOverride) IsIgnored() bool {
+	return o != nil && o.Ignore
+}
+
+func (o *AttributeOverride) HasLimits() bool {
+	return o != nil && (o.WarnLimit != nil || o.FailLimit != nil)
+}
+
+// IdealOr returns the overridden ideal direction, or the provided metadata value
+func (o *AttributeOverride) IdealOr(ideal string) string {
+	if o != nil && len(o.Ideal) > 0 {
+		return o.Ideal
+	}
+	return ideal
+}
+
+// CriticalOr returns the overridden critical flag, or the provided metadata value
+func (o *AttributeOverride) CriticalOr(critical bool) bool {
+	if o != nil && o.Critical != nil {
+		return *o.Critical
+	}
+	return critical
+}
+
+// ExceedsLimit checks if the value is past the limit, in the opposite direction of the ideal.
+// Attributes without an ideal direction are treated as "low" (eg. error counters)
+func ExceedsLimit(limit *int64, value int64, ideal string) bool {
+	if limit == nil {
+		return false
+	}
+	if ideal == ObservedThresholdIdealHigh {
+		return value < *limit
+	}
+	return value > *limit
+}
@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/analogj/scrutiny/webapp/backend/pkg/database"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// CreateAttributeOverrideRule stores a new user-defined threshold override. The rule is applied to SMART data
+// uploaded after it is created.
+func CreateAttributeOverrideRule(c *gin.Context) {
+	logger := c.MustGet("LOGGER").(*logrus.Entry)
+	deviceRepo := c.MustGet("DEVICE_REPOSITORY").(database.DeviceRepo)
+
+	var rule models.AttributeOverrideRule
+	err := c.BindJSON(&rule)
+	if err != nil {
+		logger.Errorln("Cannot parse attribute override rule", err)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false})
+		return
+	}
+
+	createdRule, err := deviceRepo.CreateAttributeOverrideRule(c, rule)
+	if err != nil {
+		logger.Errorln("An error occurred while creating attribute override rule", err)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "errors": []string{err.Error()}})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    createdRule,
+	})
+}
@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/analogj/scrutiny/webapp/backend/pkg/database"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+type createCollectorTokenRequest struct {
+	HostId      string `json:"host_id"`
+	Description string `json:"description"`
+}
+
+// CreateCollectorToken creates a new token for the collector running on the specified host.
+// The token value is only included in this response.
+func CreateCollectorToken(c *gin.Context) {
+	logger := c.MustGet("LOGGER").(*logrus.Entry)
+	deviceRepo := c.MustGet("DEVICE_REPOSITORY").(database.DeviceRepo)
+
+	var tokenRequest createCollectorTokenRequest
+	err := c.BindJSON(&tokenRequest)
+	if err != nil {
+		logger.Errorln("Cannot parse collector token request", err)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false})
+		return
+	}
+	if len(strings.TrimSpace(tokenRequest.HostId)) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "errors": []string{"host_id is required"}})
+		return
+	}
+
+	collectorToken, tokenValue, err := deviceRepo.CreateCollectorToken(c, strings.TrimSpace(tokenRequest.HostId), tokenRequest.Description)
+	if err != nil {
+		logger.Errorln("An error occurred while creating collector token", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    collectorToken,
+		"token":   tokenValue,
+	})
+}
@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/analogj/scrutiny/webapp/backend/pkg/database"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/gofrs/uuid/v5"
+	"github.com/sirupsen/logrus"
+)
+
+// CreateDeviceReplacement manually records that the device replaced another device, eg. when the replacement was
+// installed in a different slot, or was not detected automatically.
+func CreateDeviceReplacement(c *gin.Context) {
+	logger := c.MustGet("LOGGER").(*logrus.Entry)
+	deviceRepo := c.MustGet("DEVICE_REPOSITORY").(database.DeviceRepo)
+
+	scrutiny_uuid, err := uuid.FromString(c.Param("scrutiny_uuid"))
+	if err != nil {
+		logger.Errorln("Invalid scrutiny uuid", err)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false})
+		return
+	}
+
+	var request struct {
+		ReplacedScrutinyUUID uuid.UUID `json:"replaced_scrutiny_uuid"`
+	}
+	if err := c.BindJSON(&request); err != nil {
+		logger.Errorln("Cannot parse device replacement", err)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false})
+		return
+	}
+
+	device, err := deviceRepo.GetDeviceDetails(c, scrutiny_uuid)
+	if err != nil {
+		logger.Errorln("An error occurred while retrieving device details", err)
+		c.JSON(http.StatusNotFound, gin.H{"success": false})
+		return
+	}
+	if _, err := deviceRepo.GetDeviceDetails(c, request.ReplacedScrutinyUUID); err != nil {
+		logger.Errorln("An error occurred while retrieving replaced device details", err)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "errors": []string{"replaced device not found"}})
+		return
+	}
+
+	replacement, err := deviceRepo.CreateDeviceReplacement(c, models.DeviceReplacement{
+		HostId:                  device.HostId,
+		DeviceName:              device.DeviceName,
+		ReplacedScrutinyUUID:    request.ReplacedScrutinyUUID,
+		ReplacementScrutinyUUID: device.ScrutinyUUID,
+	})
+	if err != nil {
+		logger.Errorln("An error occurred while creating device replacement", err)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "errors": []string{err.Error()}})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    replacement,
+	})
+}
@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/analogj/scrutiny/webapp/backend/pkg"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/database"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+type createUserRequest struct {
+	Username string       `json:"username"`
+	Password string       `json:"password"`
+	Role     pkg.UserRole `json:"role"`
+}
+
+func CreateUser(c *gin.Context) {
+	logger := c.MustGet("LOGGER").(*logrus.Entry)
+	deviceRepo := c.MustGet("DEVICE_REPOSITORY").(database.DeviceRepo)
+
+	var userRequest createUserRequest
+	err := c.BindJSON(&userRequest)
+	if err != nil {
+		logger.Errorln("Cannot parse user request", err)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false})
+		return
+	}
+	if len(userRequest.Role) == 0 {
+		userRequest.Role = pkg.UserRoleReadOnly
+	}
+
+	user, err := deviceRepo.CreateUser(c, userRequest.Username, userRequest.Password, userRequest.Role)
+	if err != nil {
+		logger.Errorln("An error occurred while creating user", err)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "errors": []string{err.Error()}})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    user,
+	})
+}
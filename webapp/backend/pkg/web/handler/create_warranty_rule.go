@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/analogj/scrutiny/webapp/backend/pkg/database"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// CreateWarrantyRule stores a new warranty term for a device model. The rule is used by the next warranty & endurance
+// check (see monitor.CheckLifetime).
+func CreateWarrantyRule(c *gin.Context) {
+	logger := c.MustGet("LOGGER").(*logrus.Entry)
+	deviceRepo := c.MustGet("DEVICE_REPOSITORY").(database.DeviceRepo)
+
+	var rule models.WarrantyRule
+	err := c.BindJSON(&rule)
+	if err != nil {
+		logger.Errorln("Cannot parse warranty rule", err)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false})
+		return
+	}
+
+	createdRule, err := deviceRepo.CreateWarrantyRule(c, rule)
+	if err != nil {
+		logger.Errorln("An error occurred while creating warranty rule", err)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "errors": []string{err.Error()}})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    createdRule,
+	})
+}
@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/analogj/scrutiny/webapp/backend/pkg/database"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+func DeleteAttributeOverrideRule(c *gin.Context) {
+	logger := c.MustGet("LOGGER").(*logrus.Entry)
+	deviceRepo := c.MustGet("DEVICE_REPOSITORY").(database.DeviceRepo)
+
+	ruleId, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		logger.Errorln("Invalid attribute override rule id", err)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false})
+		return
+	}
+
+	err = deviceRepo.DeleteAttributeOverrideRule(c, uint(ruleId))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logger.Errorln("Attribute override rule not found", err)
+		c.JSON(http.StatusNotFound, gin.H{"success": false})
+		return
+	} else if err != nil {
+		logger.Errorln("An error occurred while deleting attribute override rule", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
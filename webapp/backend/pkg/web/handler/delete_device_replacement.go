@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/analogj/scrutiny/webapp/backend/pkg/database"
+	"github.com/gin-gonic/gin"
+	"github.com/gofrs/uuid/v5"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// DeleteDeviceReplacement removes an incorrectly recorded replacement of the device. The devices themselves are not
+// modified.
+func DeleteDeviceReplacement(c *gin.Context) {
+	logger := c.MustGet("LOGGER").(*logrus.Entry)
+	deviceRepo := c.MustGet("DEVICE_REPOSITORY").(database.DeviceRepo)
+
+	scrutiny_uuid, err := uuid.FromString(c.Param("scrutiny_uuid"))
+	if err != nil {
+		logger.Errorln("Invalid scrutiny uuid", err)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false})
+		return
+	}
+
+	replacementId, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		logger.Errorln("Invalid device replacement id", err)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false})
+		return
+	}
+
+	err = deviceRepo.DeleteDeviceReplacement(c, scrutiny_uuid, uint(replacementId))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logger.Errorln("Device replacement not found", err)
+		c.JSON(http.StatusNotFound, gin.H{"success": false})
+		return
+	} else if err != nil {
+		logger.Errorln("An error occurred while deleting device replacement", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
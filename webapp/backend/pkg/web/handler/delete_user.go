@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/analogj/scrutiny/webapp/backend/pkg/database"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+func DeleteUser(c *gin.Context) {
+	logger := c.MustGet("LOGGER").(*logrus.Entry)
+	deviceRepo := c.MustGet("DEVICE_REPOSITORY").(database.DeviceRepo)
+
+	userId, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		logger.Errorln("Invalid user id", err)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false})
+		return
+	}
+
+	// prevent admins from locking themselves out.
+	if authUser, exists := c.Get("AUTH_USER"); exists && authUser.(models.User).ID == uint(userId) {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "errors": []string{"cannot delete the logged in user"}})
+		return
+	}
+
+	err = deviceRepo.DeleteUser(c, uint(userId))
+	if err != nil {
+		logger.Errorln("An error occurred while deleting user", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
@@ -0,0 +1,29 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/analogj/scrutiny/webapp/backend/pkg/config"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+// GetCurrentUser returns the logged-in user, so that the frontend can hide actions the user is not allowed to perform.
+func GetCurrentUser(c *gin.Context) {
+	appConfig := c.MustGet("CONFIG").(config.Interface)
+
+	authUser, exists := c.Get("AUTH_USER")
+	if !exists {
+		c.JSON(http.StatusOK, gin.H{
+			"success":      true,
+			"auth_enabled": appConfig.GetBool("web.auth.enabled"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":      true,
+		"auth_enabled": true,
+		"data":         authUser.(models.User),
+	})
+}
@@ -38,6 +38,27 @@ func GetDeviceDetails(c *gin.Context) {
 		return
 	}
 
+	selfTests, err := deviceRepo.GetSmartSelfTestHistory(c, scrutiny_uuid, durationKey)
+	if err != nil {
+		logger.Errorln("An error occurred while retrieving device self-test results", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false})
+		return
+	}
+
+	replacements, err := deviceRepo.GetDeviceReplacements(c, scrutiny_uuid)
+	if err != nil {
+		logger.Errorln("An error occurred while retrieving device replacements", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false})
+		return
+	}
+
+	metadataChanges, err := deviceRepo.GetDeviceMetadataChanges(c, scrutiny_uuid, 50)
+	if err != nil {
+		logger.Errorln("An error occurred while retrieving device metadata changes", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false})
+		return
+	}
+
 	var deviceMetadata interface{}
 	if device.IsAta() {
 		deviceMetadata = thresholds.AtaMetadata
@@ -47,5 +68,5 @@ func GetDeviceDetails(c *gin.Context) {
 		deviceMetadata = thresholds.ScsiMetadata
 	}
 
-	c.JSON(http.StatusOK, gin.H{"success": true, "data": map[string]interface{}{"device": device, "smart_results": smartResults}, "metadata": deviceMetadata})
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": map[string]interface{}{"device": device, "smart_results": smartResults, "self_tests": selfTests, "replacements": replacements, "metadata_changes": metadataChanges}, "metadata": deviceMetadata})
 }
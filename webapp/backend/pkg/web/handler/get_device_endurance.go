@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/analogj/scrutiny/webapp/backend/pkg/analysis"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/database"
+	"github.com/gin-gonic/gin"
+	"github.com/gofrs/uuid/v5"
+	"github.com/sirupsen/logrus"
+)
+
+// GetDeviceEndurance returns the SSD endurance analytics for the device (see analysis.AnalyzeEndurance), calculated
+// from the downsampled attribute history of the requested duration (default: year).
+func GetDeviceEndurance(c *gin.Context) {
+	logger := c.MustGet("LOGGER").(*logrus.Entry)
+	deviceRepo := c.MustGet("DEVICE_REPOSITORY").(database.DeviceRepo)
+	scrutiny_uuid, err := uuid.FromString(c.Param("scrutiny_uuid"))
+	if err != nil {
+		logger.Errorln("Invalid scrutiny uuid", err)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false})
+		return
+	}
+	device, err := deviceRepo.GetDeviceDetails(c, scrutiny_uuid)
+	if err != nil {
+		logger.Errorln("An error occurred while retrieving device details", err)
+		c.JSON(http.StatusNotFound, gin.H{"success": false})
+		return
+	}
+
+	durationKey, exists := c.GetQuery("duration_key")
+	if !exists {
+		durationKey = database.DURATION_KEY_YEAR
+	}
+
+	smartHistory, err := deviceRepo.GetSmartAttributeHistory(c, scrutiny_uuid, durationKey, 0, 0, nil)
+	if err != nil {
+		logger.Errorln("An error occurred while retrieving device smart results", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false})
+		return
+	}
+
+	warrantyRule, err := deviceRepo.GetWarrantyRule(c, device)
+	if err != nil {
+		logger.Errorln("An error occurred while retrieving warranty rule", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    analysis.AnalyzeEndurance(device, warrantyRule, smartHistory, time.Now()),
+	})
+}
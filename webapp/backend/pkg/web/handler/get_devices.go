@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/analogj/scrutiny/webapp/backend/pkg/database"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// GetDevices returns a page of devices, filtered by the `host_id`, `protocol`, `status`, `model`, `label`, `archived`,
+// `capacity_min` & `capacity_max` query parameters (see models.DeviceQuery). Devices are sorted by `sort`, and the
+// `next_cursor` returned with each page is passed as `cursor` to get the next page. `fields` limits the returned fields.
+// List parameters can be repeated, or comma separated. Invalid parameters return a 400, including sorting by or selecting
+// the latest SMART data fields (`collector_date`, `temp` & `power_on_hours`), which are only returned by /api/summary.
+func GetDevices(c *gin.Context) {
+	logger := c.MustGet("LOGGER").(*logrus.Entry)
+	deviceRepo := c.MustGet("DEVICE_REPOSITORY").(database.DeviceRepo)
+
+	query, err := parseDeviceQuery(c)
+	if err == nil {
+		err = query.Validate()
+	}
+	if err != nil {
+		logger.Errorln("Invalid device query", err)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "errors": []string{err.Error()}})
+		return
+	}
+
+	devices, nextCursor, err := deviceRepo.QueryDevices(c, query)
+	if err != nil {
+		logger.Errorln("An error occurred while querying devices", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false})
+		return
+	}
+
+	var data interface{} = devices
+	if len(query.Fields) > 0 {
+		sparseDevices, err := sparseDeviceFields(devices, query.Fields)
+		if err != nil {
+			logger.Errorln("An error occurred while selecting device fields", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false})
+			return
+		}
+		data = sparseDevices
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"devices":     data,
+			"next_cursor": nextCursor,
+		},
+	})
+}
+
+func parseDeviceQuery(c *gin.Context) (models.DeviceQuery, error) {
+	query := models.DeviceQuery{
+		HostIds:   queryList(c, "host_id"),
+		Protocols: queryList(c, "protocol"),
+		Statuses:  queryList(c, "status"),
+		Model:     c.Query("model"),
+		Label:     c.Query("label"),
+		Sort:      c.Query("sort"),
+		Cursor:    c.Query("cursor"),
+		Fields:    queryList(c, "fields"),
+	}
+
+	if archivedParam, exists := c.GetQuery("archived"); exists {
+		archived, err := strconv.ParseBool(archivedParam)
+		if err != nil {
+			return query, fmt.Errorf("invalid archived: must be true or false")
+		}
+		query.Archived = &archived
+	}
+	for param, value := range map[string]**int64{"capacity_min": &query.CapacityMin, "capacity_max": &query.CapacityMax} {
+		if capacityParam, exists := c.GetQuery(param); exists {
+			capacity, err := strconv.ParseInt(capacityParam, 10, 64)
+			if err != nil {
+				return query, fmt.Errorf("invalid %s: must be a number of bytes", param)
+			}
+			*value = &capacity
+		}
+	}
+	if limitParam, exists := c.GetQuery("limit"); exists {
+		limit, err := strconv.Atoi(limitParam)
+		if err != nil || limit < 1 {
+			return query, fmt.Errorf("invalid limit: must be between 1 and %d", models.DeviceQueryMaxLimit)
+		}
+		query.Limit = limit
+	}
+	return query, nil
+}
+
+// queryList supports both repeated (?status=failed&status=warning) and comma separated (?status=failed,warning) values
+func queryList(c *gin.Context, key string) []string {
+	values := []string{}
+	for _, param := range c.QueryArray(key) {
+		for _, value := range strings.Split(param, ",") {
+			if value = strings.TrimSpace(value); len(value) > 0 {
+				values = append(values, value)
+			}
+		}
+	}
+	return values
+}
+
+// sparseDeviceFields returns only the requested json fields of each device (and the scrutiny_uuid)
+func sparseDeviceFields(devices []models.Device, fields []string) ([]map[string]json.RawMessage, error) {
+	sparseDevices := []map[string]json.RawMessage{}
+	for _, device := range devices {
+		encoded, err := json.Marshal(device)
+		if err != nil {
+			return nil, err
+		}
+		var allFields map[string]json.RawMessage
+		if err := json.Unmarshal(encoded, &allFields); err != nil {
+			return nil, err
+		}
+
+		sparseDevice := map[string]json.RawMessage{"scrutiny_uuid": allFields["scrutiny_uuid"]}
+		for _, field := range fields {
+			if value, ok := allFields[field]; ok {
+				sparseDevice[field] = value
+			}
+		}
+		sparseDevices = append(sparseDevices, sparseDevice)
+	}
+	return sparseDevices, nil
+}
@@ -0,0 +1,27 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/analogj/scrutiny/webapp/backend/pkg/database"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// GetHost returns the host, its devices, and their aggregate health
+func GetHost(c *gin.Context) {
+	logger := c.MustGet("LOGGER").(*logrus.Entry)
+	deviceRepo := c.MustGet("DEVICE_REPOSITORY").(database.DeviceRepo)
+
+	host, err := deviceRepo.GetHostSummary(c, c.Param("host_id"))
+	if err != nil {
+		logger.Errorln("An error occurred while retrieving host", err)
+		c.JSON(http.StatusNotFound, gin.H{"success": false})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    host,
+	})
+}
@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/analogj/scrutiny/webapp/backend/pkg/database"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+const notificationHistoryDefaultLimit = 100
+const notificationHistoryMaxLimit = 1000
+
+// GetNotificationHistory returns the most recent notification delivery attempts. Supports the optional `scrutiny_uuid`
+// and `limit` query parameters.
+func GetNotificationHistory(c *gin.Context) {
+	logger := c.MustGet("LOGGER").(*logrus.Entry)
+	deviceRepo := c.MustGet("DEVICE_REPOSITORY").(database.DeviceRepo)
+
+	limit := notificationHistoryDefaultLimit
+	if limitParam, exists := c.GetQuery("limit"); exists {
+		parsedLimit, err := strconv.Atoi(limitParam)
+		if err != nil || parsedLimit < 1 {
+			logger.Errorln("Invalid notification history limit", limitParam)
+			c.JSON(http.StatusBadRequest, gin.H{"success": false})
+			return
+		}
+		limit = min(parsedLimit, notificationHistoryMaxLimit)
+	}
+
+	deliveries, err := deviceRepo.GetNotificationDeliveries(c, c.Query("scrutiny_uuid"), limit)
+	if err != nil {
+		logger.Errorln("An error occurred while retrieving notification history", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    deliveries,
+	})
+}
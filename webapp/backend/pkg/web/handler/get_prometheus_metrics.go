@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/analogj/scrutiny/webapp/backend/pkg/database"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/metrics"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// GetPrometheusMetrics exposes the latest smart data for each (non-archived) device in the Prometheus text format.
+func GetPrometheusMetrics(c *gin.Context) {
+	logger := c.MustGet("LOGGER").(*logrus.Entry)
+	deviceRepo := c.MustGet("DEVICE_REPOSITORY").(database.DeviceRepo)
+
+	devices, err := deviceRepo.GetDevices(c)
+	if err != nil {
+		logger.Errorln("An error occurred while retrieving devices", err)
+		c.String(http.StatusInternalServerError, "could not retrieve devices")
+		return
+	}
+
+	latestSmart, err := deviceRepo.GetLatestSmartAttributes(c)
+	if err != nil {
+		logger.Errorln("An error occurred while retrieving latest smart data", err)
+		c.String(http.StatusInternalServerError, "could not retrieve smart data")
+		return
+	}
+
+	deviceMetrics := []metrics.DeviceMetrics{}
+	for _, device := range devices {
+		if device.Archived {
+			continue
+		}
+
+		latest := metrics.DeviceMetrics{Device: device}
+		if smartData, found := latestSmart[device.ScrutinyUUID]; found {
+			latest.Smart = &smartData
+		}
+		deviceMetrics = append(deviceMetrics, latest)
+	}
+
+	var output bytes.Buffer
+	if err := metrics.WritePrometheus(&output, deviceMetrics); err != nil {
+		logger.Errorln("An error occurred while generating metrics", err)
+		c.String(http.StatusInternalServerError, "could not generate metrics")
+		return
+	}
+	c.Data(http.StatusOK, metrics.PrometheusContentType, output.Bytes())
+}
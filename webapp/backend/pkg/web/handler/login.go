@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/analogj/scrutiny/webapp/backend/pkg/config"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/database"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/web/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+type loginRequest struct {
+	Username string `json:"username" form:"username"`
+	Password string `json:"password" form:"password"`
+}
+
+// Login authenticates a local user, and sets the session cookie.
+func Login(c *gin.Context) {
+	logger := c.MustGet("LOGGER").(*logrus.Entry)
+	appConfig := c.MustGet("CONFIG").(config.Interface)
+	deviceRepo := c.MustGet("DEVICE_REPOSITORY").(database.DeviceRepo)
+
+	var credentials loginRequest
+	err := c.ShouldBind(&credentials)
+	if err != nil {
+		logger.Errorln("Cannot parse login request", err)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false})
+		return
+	}
+
+	user, err := deviceRepo.AuthenticateUser(c, credentials.Username, credentials.Password)
+	if err != nil {
+		logger.Warnf("Failed login for user %q from %s", credentials.Username, c.ClientIP())
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "errors": []string{"invalid username or password"}})
+		return
+	}
+
+	sessionTtl := time.Duration(appConfig.GetInt("web.auth.session_ttl_hours")) * time.Hour
+	sessionToken, err := deviceRepo.CreateUserSession(c, user.ID, sessionTtl)
+	if err != nil {
+		logger.Errorln("An error occurred while creating user session", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false})
+		return
+	}
+
+	setSessionCookie(c, appConfig, sessionToken, int(sessionTtl.Seconds()))
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": user})
+}
+
+// Logout deletes the current session, and clears the session cookie.
+func Logout(c *gin.Context) {
+	logger := c.MustGet("LOGGER").(*logrus.Entry)
+	appConfig := c.MustGet("CONFIG").(config.Interface)
+	deviceRepo := c.MustGet("DEVICE_REPOSITORY").(database.DeviceRepo)
+
+	if sessionToken, err := c.Cookie(middleware.SessionCookieName); err == nil && len(sessionToken) > 0 {
+		if err := deviceRepo.DeleteUserSession(c, sessionToken); err != nil {
+			logger.Errorln("An error occurred while deleting user session", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false})
+			return
+		}
+	}
+
+	setSessionCookie(c, appConfig, "", -1)
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+func setSessionCookie(c *gin.Context, appConfig config.Interface, sessionToken string, maxAge int) {
+	cookiePath := appConfig.GetString("web.listen.basepath")
+	if len(cookiePath) == 0 {
+		cookiePath = "/"
+	}
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(middleware.SessionCookieName, sessionToken, maxAge, cookiePath, "", appConfig.GetBool("web.auth.cookie_secure"), true)
+}
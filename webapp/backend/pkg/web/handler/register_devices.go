@@ -2,9 +2,13 @@ package handler
 
 import (
 	"net/http"
+	"slices"
+	"time"
 
 	"github.com/analogj/scrutiny/webapp/backend/pkg/database"
 	"github.com/analogj/scrutiny/webapp/backend/pkg/models"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/models/collector"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/web/middleware"
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 )
@@ -33,6 +37,39 @@ func RegisterDevices(c *gin.Context) {
 		detectedStorageDevices = append(detectedStorageDevices, dev)
 	}
 
+	// when collector authentication is enabled, a collector may only register devices for the host its token is bound to.
+	if collectorToken, exists := c.Get("COLLECTOR_TOKEN"); exists {
+		for ndx := range detectedStorageDevices {
+			dev := &detectedStorageDevices[ndx]
+			// collectors without a configured host id are assigned the host of their token
+			if len(dev.HostId) == 0 {
+				dev.HostId = collectorToken.(models.CollectorToken).HostId
+			}
+			if !middleware.CollectorHostAllowed(c, dev.HostId) || !middleware.CollectorDeviceAllowed(c, deviceRepo, dev.ScrutinyUUID) {
+				logger.Errorf("Collector token is not allowed to register device %s for host %q", dev.DeviceName, dev.HostId)
+				c.JSON(http.StatusForbidden, gin.H{"success": false})
+				return
+			}
+		}
+	}
+
+	// the devices registered on these hosts before this collector run, used to detect replaced devices
+	replacementHostIds := []string{}
+	for _, dev := range detectedStorageDevices {
+		if len(dev.HostId) > 0 && !slices.Contains(replacementHostIds, dev.HostId) {
+			replacementHostIds = append(replacementHostIds, dev.HostId)
+		}
+	}
+	registeredDevices := []models.Device{}
+	if len(replacementHostIds) > 0 {
+		registeredDevices, err = deviceRepo.GetHostDevices(c, replacementHostIds)
+		if err != nil {
+			logger.Errorln("An error occurred while retrieving registered devices", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false})
+			return
+		}
+	}
+
 	errs := []error{}
 	for _, dev := range detectedStorageDevices {
 		//insert devices into DB (and update specified columns if device is already registered)
@@ -42,6 +79,20 @@ func RegisterDevices(c *gin.Context) {
 		}
 	}
 
+	recordDeviceReplacements(c, logger, deviceRepo, registeredDevices, detectedStorageDevices)
+
+	// record the collector check-in for each host, used to detect collectors which stopped reporting
+	hostIds := map[string]bool{}
+	for _, dev := range detectedStorageDevices {
+		if len(dev.HostId) == 0 || hostIds[dev.HostId] {
+			continue
+		}
+		hostIds[dev.HostId] = true
+		if err := deviceRepo.SaveHostCheckin(c, dev.HostId, time.Now(), c.GetHeader(collector.VersionHeader), ""); err != nil {
+			logger.Errorln("An error occurred while saving host check-in", err)
+		}
+	}
+
 	if len(errs) > 0 {
 		logger.Errorln("An error occurred while registering devices", errs)
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -56,3 +107,53 @@ func RegisterDevices(c *gin.Context) {
 		return
 	}
 }
+
+// recordDeviceReplacements links each newly detected device to the device it replaced: a stale device in the same slot
+// (see models.Device.SameSlot) which was not detected by the collector. When more than one device previously used the
+// slot, the most recently seen one is replaced.
+func recordDeviceReplacements(c *gin.Context, logger *logrus.Entry, deviceRepo database.DeviceRepo, registeredDevices []models.Device, detectedDevices []models.Device) {
+	for _, dev := range detectedDevices {
+		if slices.ContainsFunc(registeredDevices, func(registered models.Device) bool { return registered.ScrutinyUUID == dev.ScrutinyUUID }) {
+			//not a new device
+			continue
+		}
+
+		var replacedDevice *models.Device
+		for ndx := range registeredDevices {
+			registered := &registeredDevices[ndx]
+			if registered.Archived || !registered.Stale || !registered.SameSlot(dev) {
+				continue
+			}
+			if slices.ContainsFunc(detectedDevices, func(detected models.Device) bool { return detected.ScrutinyUUID == registered.ScrutinyUUID }) {
+				//still reporting, eg. the device names were reordered
+				continue
+			}
+			if replacedDevice == nil || lastSeen(*registered).After(lastSeen(*replacedDevice)) {
+				replacedDevice = registered
+			}
+		}
+		if replacedDevice == nil {
+			continue
+		}
+
+		_, err := deviceRepo.CreateDeviceReplacement(c, models.DeviceReplacement{
+			HostId:                  dev.HostId,
+			DeviceName:              dev.DeviceName,
+			ReplacedScrutinyUUID:    replacedDevice.ScrutinyUUID,
+			ReplacementScrutinyUUID: dev.ScrutinyUUID,
+			Automatic:               true,
+		})
+		if err != nil {
+			logger.Warnf("Could not record replacement of device %s by %s: %v", replacedDevice.ScrutinyUUID, dev.ScrutinyUUID, err)
+			continue
+		}
+		logger.Infof("Device %s (%s) was replaced by %s", replacedDevice.ScrutinyUUID, dev.DeviceName, dev.ScrutinyUUID)
+	}
+}
+
+func lastSeen(device models.Device) time.Time {
+	if device.LastSeenAt != nil {
+		return *device.LastSeenAt
+	}
+	return device.UpdatedAt
+}
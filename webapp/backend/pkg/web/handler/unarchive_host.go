@@ -0,0 +1,30 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/analogj/scrutiny/webapp/backend/pkg/database"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// UnarchiveHost unarchives the host, and all of its devices
+func UnarchiveHost(c *gin.Context) {
+	logger := c.MustGet("LOGGER").(*logrus.Entry)
+	deviceRepo := c.MustGet("DEVICE_REPOSITORY").(database.DeviceRepo)
+	hostId := c.Param("host_id")
+
+	if _, err := deviceRepo.GetHost(c, hostId); err != nil {
+		logger.Errorln("An error occurred while retrieving host", err)
+		c.JSON(http.StatusNotFound, gin.H{"success": false})
+		return
+	}
+
+	if err := deviceRepo.UpdateHostArchived(c, hostId, false); err != nil {
+		logger.Errorln("An error occurred while unarchiving host", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
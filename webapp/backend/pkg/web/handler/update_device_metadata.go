@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/analogj/scrutiny/webapp/backend/pkg/database"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/gofrs/uuid/v5"
+	"github.com/sirupsen/logrus"
+)
+
+// UpdateDeviceMetadata updates the user provided metadata of a device (label, location, purchase & warranty details,
+// notes). Only the fields included in the request are updated, see models.DeviceMetadataPatch.
+func UpdateDeviceMetadata(c *gin.Context) {
+	logger := c.MustGet("LOGGER").(*logrus.Entry)
+	deviceRepo := c.MustGet("DEVICE_REPOSITORY").(database.DeviceRepo)
+
+	scrutiny_uuid, err := uuid.FromString(c.Param("scrutiny_uuid"))
+	if err != nil {
+		logger.Errorln("Invalid scrutiny uuid", err)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false})
+		return
+	}
+
+	var patch models.DeviceMetadataPatch
+	if err := c.BindJSON(&patch); err != nil {
+		logger.Errorln("Cannot parse device metadata", err)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false})
+		return
+	}
+
+	device, err := deviceRepo.GetDeviceDetails(c, scrutiny_uuid)
+	if err != nil {
+		logger.Errorln("An error occurred while retrieving device details", err)
+		c.JSON(http.StatusNotFound, gin.H{"success": false})
+		return
+	}
+
+	changes, err := patch.Apply(&device)
+	if err != nil {
+		logger.Errorln("Invalid device metadata", err)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "errors": []string{err.Error()}})
+		return
+	}
+	if authUser, exists := c.Get("AUTH_USER"); exists {
+		for ndx := range changes {
+			changes[ndx].ChangedBy = authUser.(models.User).Username
+		}
+	}
+
+	if err := deviceRepo.UpdateDeviceMetadata(c, device, changes); err != nil {
+		logger.Errorln("An error occurred while updating device metadata", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    device,
+	})
+}
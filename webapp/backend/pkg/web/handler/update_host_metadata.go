@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/analogj/scrutiny/webapp/backend/pkg/database"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// UpdateHostMetadata updates the user provided metadata of a host (name, description & tags). Only the fields
+// included in the request are updated, see models.HostMetadataPatch.
+func UpdateHostMetadata(c *gin.Context) {
+	logger := c.MustGet("LOGGER").(*logrus.Entry)
+	deviceRepo := c.MustGet("DEVICE_REPOSITORY").(database.DeviceRepo)
+
+	var patch models.HostMetadataPatch
+	if err := c.BindJSON(&patch); err != nil {
+		logger.Errorln("Cannot parse host metadata", err)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false})
+		return
+	}
+
+	host, err := deviceRepo.GetHost(c, c.Param("host_id"))
+	if err != nil {
+		logger.Errorln("An error occurred while retrieving host", err)
+		c.JSON(http.StatusNotFound, gin.H{"success": false})
+		return
+	}
+
+	if err := patch.Apply(&host); err != nil {
+		logger.Errorln("Invalid host metadata", err)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "errors": []string{err.Error()}})
+		return
+	}
+
+	if err := deviceRepo.UpdateHostMetadata(c, host); err != nil {
+		logger.Errorln("An error occurred while updating host metadata", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    host,
+	})
+}
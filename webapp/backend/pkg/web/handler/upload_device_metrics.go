@@ -3,12 +3,17 @@ package handler
 import (
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/analogj/scrutiny/webapp/backend/pkg"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/analysis"
 	"github.com/analogj/scrutiny/webapp/backend/pkg/config"
 	"github.com/analogj/scrutiny/webapp/backend/pkg/database"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/models"
 	"github.com/analogj/scrutiny/webapp/backend/pkg/models/collector"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/models/measurements"
 	"github.com/analogj/scrutiny/webapp/backend/pkg/notify"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/web/middleware"
 	"github.com/gin-gonic/gin"
 	"github.com/gofrs/uuid/v5"
 	"github.com/sirupsen/logrus"
@@ -30,6 +35,12 @@ func UploadDeviceMetrics(c *gin.Context) {
 		return
 	}
 
+	if !middleware.CollectorDeviceAllowed(c, deviceRepo, scrutiny_uuid) {
+		logger.Errorf("Collector token is not allowed to upload data for device %s", scrutiny_uuid)
+		c.JSON(http.StatusForbidden, gin.H{"success": false})
+		return
+	}
+
 	var collectorSmartData collector.SmartInfo
 	err = c.BindJSON(&collectorSmartData)
 	if err != nil {
@@ -45,6 +56,14 @@ func UploadDeviceMetrics(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"success": false})
 		return
 	}
+	//the device status before this upload, included in notifications
+	previousDeviceStatus := updatedDevice.DeviceStatus
+
+	if len(updatedDevice.HostId) > 0 {
+		if err := deviceRepo.SaveHostCheckin(c, updatedDevice.HostId, time.Now(), c.GetHeader(collector.VersionHeader), collectorSmartData.Smartctl.PlatformInfo); err != nil {
+			logger.Errorln("An error occurred while saving host check-in", err)
+		}
+	}
 
 	// insert smart info
 	smartData, err := deviceRepo.SaveSmartAttributes(c, scrutiny_uuid, collectorSmartData)
@@ -72,14 +91,76 @@ func UploadDeviceMetrics(c *gin.Context) {
 		return
 	}
 
-	//check for error
-	if notify.ShouldNotify(
+	// update the failure prediction using the attribute history (ignore failures, the prediction is informational)
+	previousFailureScore := updatedDevice.FailureScore
+	smartHistory, err := deviceRepo.GetAnalysisSmartHistory(c, scrutiny_uuid, smartData)
+	if err != nil {
+		logger.Errorln("An error occurred while retrieving smart history for failure prediction", err)
+	} else {
+		attributeOverrides, err := deviceRepo.GetAttributeOverrides(c, updatedDevice)
+		if err != nil {
+			logger.Errorln("An error occurred while retrieving attribute overrides for failure prediction", err)
+		}
+		prediction := analysis.PredictFailure(updatedDevice.DeviceProtocol, smartHistory, attributeOverrides, time.Now())
+		predictedDevice, err := deviceRepo.UpdateDeviceFailurePrediction(c, scrutiny_uuid, prediction)
+		if err != nil {
+			logger.Errorln("An error occurred while updating device failure prediction", err)
+		} else {
+			updatedDevice = predictedDevice
+		}
+
+		// the endurance summary is shown on the dashboard, the full analysis is available from /api/device/:uuid/endurance
+		warrantyRule, err := deviceRepo.GetWarrantyRule(c, updatedDevice)
+		if err != nil {
+			logger.Errorln("An error occurred while retrieving warranty rule for endurance analysis", err)
+		}
+		endurance := analysis.AnalyzeEndurance(updatedDevice, warrantyRule, smartHistory, time.Now())
+		enduranceDevice, err := deviceRepo.UpdateDeviceEndurance(c, scrutiny_uuid, endurance)
+		if err != nil {
+			logger.Errorln("An error occurred while updating device endurance", err)
+		} else {
+			updatedDevice = enduranceDevice
+		}
+	}
+
+	if notify.ShouldNotifyFailurePrediction(
+		previousFailureScore,
+		updatedDevice,
+		appConfig.GetInt(fmt.Sprintf("%s.metrics.notify_failure_score", config.DB_USER_SETTINGS_SUBKEY)),
+	) {
+		predictionNotify := notify.NewPredictedFailure(
+			logger,
+			appConfig,
+			updatedDevice,
+		)
+		predictionNotify.Payload.PreviousDeviceStatus = &previousDeviceStatus
+		sendNotification(c, logger, deviceRepo, predictionNotify)
+	}
+
+	//compare the device status with the last notification sent for this device (ignore failures, the device is treated as
+	//not previously notified)
+	statusThreshold := pkg.MetricsStatusThreshold(appConfig.GetInt(fmt.Sprintf("%s.metrics.status_threshold", config.DB_USER_SETTINGS_SUBKEY)))
+	statusFilterAttributes := pkg.MetricsStatusFilterAttributes(appConfig.GetInt(fmt.Sprintf("%s.metrics.status_filter_attributes", config.DB_USER_SETTINGS_SUBKEY)))
+	notificationState, err := deviceRepo.GetNotificationState(c, scrutiny_uuid)
+	if err != nil {
+		logger.Errorln("An error occurred while retrieving notification state", err)
+		notificationState = models.NotificationState{ScrutinyUUID: scrutiny_uuid}
+	}
+	transition := notify.DetectTransition(notificationState.DeviceStatus, updatedDevice.DeviceStatus, statusThreshold)
+	failingAttributes := notify.FailingAttributeIds(updatedDevice, smartData, statusThreshold, statusFilterAttributes)
+	if transition == notify.NotificationTransitionNone && notify.HasNewFailingAttributes(notificationState.FailingAttributes, failingAttributes) {
+		transition = notify.NotificationTransitionWorsened
+	}
+
+	//check for error. Worsened failures are always notified, even when `metrics.repeat_notifications` is disabled
+	failureNotified := false
+	if transition == notify.NotificationTransitionWorsened || notify.ShouldNotify(
 		logger,
 		updatedDevice,
 		smartData,
 		scrutiny_uuid,
-		pkg.MetricsStatusThreshold(appConfig.GetInt(fmt.Sprintf("%s.metrics.status_threshold", config.DB_USER_SETTINGS_SUBKEY))),
-		pkg.MetricsStatusFilterAttributes(appConfig.GetInt(fmt.Sprintf("%s.metrics.status_filter_attributes", config.DB_USER_SETTINGS_SUBKEY))),
+		statusThreshold,
+		statusFilterAttributes,
 		appConfig.GetBool(fmt.Sprintf("%s.metrics.repeat_notifications", config.DB_USER_SETTINGS_SUBKEY)),
 		c,
 		deviceRepo,
@@ -92,8 +173,95 @@ func UploadDeviceMetrics(c *gin.Context) {
 			updatedDevice,
 			false,
 		)
-		_ = liveNotify.Send() //we ignore error message when sending notifications.
+		liveNotify.Payload.Transition = transition
+		liveNotify.Payload.AddFailingAttributes(updatedDevice, smartData, previousSmartData(c, logger, deviceRepo, scrutiny_uuid))
+		liveNotify.Payload.PreviousDeviceStatus = &previousDeviceStatus
+		sendNotification(c, logger, deviceRepo, liveNotify)
+
+		failureNotified = true
+		notificationState.DeviceStatus = updatedDevice.DeviceStatus
+		notificationState.FailingAttributes = failingAttributes
+		notificationState.NotifiedAt = time.Now()
+		saveNotificationState(c, logger, deviceRepo, notificationState)
+	} else if transition == notify.NotificationTransitionResolved {
+		//the device was included in a failure notification, and is no longer failing
+		resolvedNotify := notify.NewResolved(
+			logger,
+			appConfig,
+			updatedDevice,
+		)
+		resolvedNotify.Payload.PreviousDeviceStatus = &previousDeviceStatus
+		sendNotification(c, logger, deviceRepo, resolvedNotify)
+
+		notificationState.DeviceStatus = pkg.DeviceStatusPassed
+		notificationState.FailingAttributes = nil
+		notificationState.NotifiedAt = time.Now()
+		saveNotificationState(c, logger, deviceRepo, notificationState)
+	}
+
+	//warnings are rate limited separately from failures, and are skipped when a failure notification was just sent
+	if !failureNotified && notify.ShouldNotifyWarning(
+		updatedDevice,
+		smartData,
+		pkg.MetricsNotifyLevel(appConfig.GetInt(fmt.Sprintf("%s.metrics.notify_level", config.DB_USER_SETTINGS_SUBKEY))),
+		statusFilterAttributes,
+		notificationState.WarningNotifiedAt,
+		time.Duration(appConfig.GetInt(fmt.Sprintf("%s.metrics.warning_notify_interval_hours", config.DB_USER_SETTINGS_SUBKEY)))*time.Hour,
+		time.Now(),
+	) {
+		warningNotify := notify.NewWarning(
+			logger,
+			appConfig,
+			updatedDevice,
+		)
+		warningNotify.Payload.AddFailingAttributes(updatedDevice, smartData, previousSmartData(c, logger, deviceRepo, scrutiny_uuid))
+		warningNotify.Payload.PreviousDeviceStatus = &previousDeviceStatus
+		sendNotification(c, logger, deviceRepo, warningNotify)
+
+		warningNotifiedAt := time.Now()
+		notificationState.WarningNotifiedAt = &warningNotifiedAt
+		saveNotificationState(c, logger, deviceRepo, notificationState)
+	} else if notificationState.WarningNotifiedAt != nil && !pkg.DeviceStatusHas(updatedDevice.DeviceStatus, pkg.DeviceStatusWarningScrutiny) {
+		//the warnings have cleared, the next warning should be sent immediately
+		notificationState.WarningNotifiedAt = nil
+		saveNotificationState(c, logger, deviceRepo, notificationState)
 	}
 
 	c.JSON(http.StatusOK, gin.H{"success": true})
 }
+
+// sendNotification queues the notification to be sent in the background (see notify.Queue), so the upload doesn't wait
+// for slow targets & retries. The notification is added to the digest instead when `notify.digest.enabled` is set.
+func sendNotification(c *gin.Context, logger *logrus.Entry, deviceRepo database.DeviceRepo, deviceNotify notify.Notify) {
+	deviceNotify.Deliveries = deviceRepo
+
+	notifyDigest := c.MustGet("NOTIFY_DIGEST").(*notify.Digest)
+	if notifyDigest.Enabled() {
+		notifyDigest.Add(deviceNotify)
+		return
+	}
+	notifyQueue := c.MustGet("NOTIFY_QUEUE").(*notify.Queue)
+	if notifyQueue.Add(deviceNotify) {
+		logger.Debugf("Queued %s notification for %s", deviceNotify.Payload.FailureType, deviceNotify.Payload.DeviceName)
+	}
+}
+
+func saveNotificationState(c *gin.Context, logger *logrus.Entry, deviceRepo database.DeviceRepo, notificationState models.NotificationState) {
+	if err := deviceRepo.SaveNotificationState(c, notificationState); err != nil {
+		logger.Errorln("An error occurred while saving notification state", err)
+	}
+}
+
+// previousSmartData returns the SMART data uploaded before the current upload, used to include the previous attribute
+// values in notifications. Returns nil if there is no previous upload.
+func previousSmartData(c *gin.Context, logger *logrus.Entry, deviceRepo database.DeviceRepo, scrutinyUUID uuid.UUID) *measurements.Smart {
+	history, err := deviceRepo.GetSmartAttributeHistory(c, scrutinyUUID, database.DURATION_KEY_FOREVER, 1, 1, nil)
+	if err != nil {
+		logger.Errorln("An error occurred while retrieving previous smart data", err)
+		return nil
+	}
+	if len(history) == 0 {
+		return nil
+	}
+	return &history[0]
+}
@@ -1,7 +1,60 @@
 package handler
 
-import "github.com/gin-gonic/gin"
+import (
+	"net/http"
+
+	"github.com/analogj/scrutiny/webapp/backend/pkg/database"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/models/collector"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/web/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/gofrs/uuid/v5"
+	"github.com/sirupsen/logrus"
+)
 
 func UploadDeviceSelfTests(c *gin.Context) {
+	logger := c.MustGet("LOGGER").(*logrus.Entry)
+	deviceRepo := c.MustGet("DEVICE_REPOSITORY").(database.DeviceRepo)
+
+	scrutiny_uuid, err := uuid.FromString(c.Param("scrutiny_uuid"))
+	if err != nil {
+		logger.Errorln("Invalid scrutiny uuid", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false})
+		return
+	}
+
+	if !middleware.CollectorDeviceAllowed(c, deviceRepo, scrutiny_uuid) {
+		logger.Errorf("Collector token is not allowed to upload data for device %s", scrutiny_uuid)
+		c.JSON(http.StatusForbidden, gin.H{"success": false})
+		return
+	}
+
+	var collectorSmartData collector.SmartInfo
+	err = c.BindJSON(&collectorSmartData)
+	if err != nil {
+		logger.Errorln("Cannot parse self-test data", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false})
+		return
+	}
+
+	device, err := deviceRepo.GetDeviceDetails(c, scrutiny_uuid)
+	if err != nil {
+		logger.Errorln("An error occurred while retrieving device details", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false})
+		return
+	}
+
+	//the device protocol is only known after the first SMART metrics upload, so prefer the protocol reported by smartctl
+	deviceProtocol := collectorSmartData.Device.Protocol
+	if len(deviceProtocol) == 0 {
+		deviceProtocol = device.DeviceProtocol
+	}
+
+	selfTests, err := deviceRepo.SaveSmartSelfTests(c, scrutiny_uuid, deviceProtocol, collectorSmartData)
+	if err != nil {
+		logger.Errorln("An error occurred while saving self-test results", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false})
+		return
+	}
 
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": selfTests})
 }
@@ -0,0 +1,136 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/analogj/scrutiny/webapp/backend/pkg"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/config"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/database"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+const SessionCookieName = "scrutiny_session"
+
+// AuthMiddleware requires a logged-in user when `web.auth.enabled` is set. Users are authenticated using the session
+// cookie, or the headers set by a trusted reverse proxy (forward auth). The user is stored in the context as "AUTH_USER".
+func AuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		appConfig := c.MustGet("CONFIG").(config.Interface)
+		if !appConfig.GetBool("web.auth.enabled") {
+			c.Next()
+			return
+		}
+
+		user, authenticated := AuthenticatedUser(c)
+		if !authenticated {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"success": false, "errors": []string{"authentication required"}})
+			return
+		}
+
+		c.Set("AUTH_USER", user)
+		c.Next()
+	}
+}
+
+// RequireRole only allows users with the specified role. All users are allowed when `web.auth.enabled` is not set.
+func RequireRole(role pkg.UserRole) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authUser, exists := c.Get("AUTH_USER")
+		if !exists {
+			// AuthMiddleware only skips authentication when it is disabled.
+			c.Next()
+			return
+		}
+
+		if user := authUser.(models.User); user.Role != role {
+			logger := c.MustGet("LOGGER").(*logrus.Entry)
+			logger.Warnf("User %s (%s) is not allowed to access %s %s", user.Username, user.Role, c.Request.Method, c.Request.URL.Path)
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"success": false, "errors": []string{"insufficient permissions"}})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireAuthenticatedUser rejects requests without an authenticated user, even when `web.auth.enabled` is not set.
+// Used by routes which must never be public (eg. managing collector tokens).
+func RequireAuthenticatedUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, exists := c.Get("AUTH_USER"); !exists {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"success": false, "errors": []string{"user authentication (web.auth.enabled) is required"}})
+			return
+		}
+		c.Next()
+	}
+}
+
+// AuthenticatedUser returns the user authenticated by the reverse proxy headers, or by the session cookie.
+func AuthenticatedUser(c *gin.Context) (models.User, bool) {
+	appConfig := c.MustGet("CONFIG").(config.Interface)
+	logger := c.MustGet("LOGGER").(*logrus.Entry)
+
+	if appConfig.GetBool("web.auth.forward_auth.enabled") {
+		if user, ok := forwardAuthUser(c, appConfig, logger); ok {
+			return user, true
+		}
+	}
+
+	sessionToken, err := c.Cookie(SessionCookieName)
+	if err != nil || len(sessionToken) == 0 {
+		return models.User{}, false
+	}
+	deviceRepo := c.MustGet("DEVICE_REPOSITORY").(database.DeviceRepo)
+	user, err := deviceRepo.GetSessionUser(c, sessionToken)
+	if err != nil {
+		logger.Debugln("Invalid user session", err)
+		return models.User{}, false
+	}
+	return user, true
+}
+
+// forwardAuthUser trusts the user & group headers set by an authenticating reverse proxy (eg. Authelia, Authentik,
+// oauth2-proxy), but only for requests sent directly by one of the `web.auth.forward_auth.trusted_proxies`.
+func forwardAuthUser(c *gin.Context, appConfig config.Interface, logger *logrus.Entry) (models.User, bool) {
+	username := strings.TrimSpace(c.GetHeader(appConfig.GetString("web.auth.forward_auth.user_header")))
+	if len(username) == 0 {
+		return models.User{}, false
+	}
+
+	remoteHost, _, err := net.SplitHostPort(c.Request.RemoteAddr)
+	if err != nil {
+		remoteHost = c.Request.RemoteAddr
+	}
+	remoteIp := net.ParseIP(remoteHost)
+	trusted := false
+	for _, trustedProxy := range appConfig.GetStringSlice("web.auth.forward_auth.trusted_proxies") {
+		_, trustedNetwork, err := net.ParseCIDR(trustedProxy)
+		if err != nil {
+			logger.Warnf("Ignoring invalid trusted proxy %q: %v", trustedProxy, err)
+			continue
+		}
+		if remoteIp != nil && trustedNetwork.Contains(remoteIp) {
+			trusted = true
+			break
+		}
+	}
+	if !trusted {
+		logger.Warnf("Ignoring forward auth headers from untrusted address %s", remoteHost)
+		return models.User{}, false
+	}
+
+	role := pkg.UserRoleReadOnly
+	groups := strings.Split(c.GetHeader(appConfig.GetString("web.auth.forward_auth.groups_header")), ",")
+	for ndx := range groups {
+		groups[ndx] = strings.TrimSpace(groups[ndx])
+	}
+	if slices.Contains(groups, appConfig.GetString("web.auth.forward_auth.admin_group")) {
+		role = pkg.UserRoleAdmin
+	}
+
+	return models.User{Username: username, Role: role, External: true}, true
+}
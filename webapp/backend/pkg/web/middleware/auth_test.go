@@ -0,0 +1,157 @@
+package middleware_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/analogj/scrutiny/webapp/backend/pkg"
+	mock_config "github.com/analogj/scrutiny/webapp/backend/pkg/config/mock"
+	mock_database "github.com/analogj/scrutiny/webapp/backend/pkg/database/mock"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/models"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/web/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func setupAuthRouter(t *testing.T, authEnabled bool, forwardAuthEnabled bool) (*gin.Engine, *mock_database.MockDeviceRepo) {
+	mockCtrl := gomock.NewController(t)
+	fakeConfig := mock_config.NewMockInterface(mockCtrl)
+	fakeConfig.EXPECT().GetBool("web.auth.enabled").Return(authEnabled).AnyTimes()
+	fakeConfig.EXPECT().GetBool("web.auth.forward_auth.enabled").Return(forwardAuthEnabled).AnyTimes()
+	fakeConfig.EXPECT().GetString("web.auth.forward_auth.user_header").Return("Remote-User").AnyTimes()
+	fakeConfig.EXPECT().GetString("web.auth.forward_auth.groups_header").Return("Remote-Groups").AnyTimes()
+	fakeConfig.EXPECT().GetString("web.auth.forward_auth.admin_group").Return("admin").AnyTimes()
+	fakeConfig.EXPECT().GetStringSlice("web.auth.forward_auth.trusted_proxies").Return([]string{"10.0.0.0/24"}).AnyTimes()
+	fakeDeviceRepo := mock_database.NewMockDeviceRepo(mockCtrl)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("CONFIG", fakeConfig)
+		c.Set("LOGGER", logrus.WithFields(logrus.Fields{}))
+		c.Set("DEVICE_REPOSITORY", fakeDeviceRepo)
+		c.Next()
+	})
+	userApi := r.Group("/api", middleware.AuthMiddleware())
+	userApi.GET("/summary", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"success": true})
+	})
+	userApi.DELETE("/device/:scrutiny_uuid", middleware.RequireRole(pkg.UserRoleAdmin), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"success": true})
+	})
+	userApi.POST("/collector/tokens", middleware.RequireRole(pkg.UserRoleAdmin), middleware.RequireAuthenticatedUser(), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"success": true})
+	})
+	return r, fakeDeviceRepo
+}
+
+func TestAuthMiddleware_Disabled(t *testing.T) {
+	//setup
+	router, _ := setupAuthRouter(t, false, false)
+	req, _ := http.NewRequest("DELETE", "/api/device/7c1a4d2e-8f3b-5a6c-9d0e-1f2a3b4c5d6e", nil)
+	w := httptest.NewRecorder()
+
+	//test
+	router.ServeHTTP(w, req)
+
+	//assert
+	require.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestRequireAuthenticatedUser_AuthDisabled(t *testing.T) {
+	//setup
+	router, _ := setupAuthRouter(t, false, false)
+	req, _ := http.NewRequest("POST", "/api/collector/tokens", nil)
+	w := httptest.NewRecorder()
+
+	//test
+	router.ServeHTTP(w, req)
+
+	//assert
+	require.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestAuthMiddleware_MissingSession(t *testing.T) {
+	//setup
+	router, _ := setupAuthRouter(t, true, false)
+	req, _ := http.NewRequest("GET", "/api/summary", nil)
+	w := httptest.NewRecorder()
+
+	//test
+	router.ServeHTTP(w, req)
+
+	//assert
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestAuthMiddleware_InvalidSession(t *testing.T) {
+	//setup
+	router, fakeDeviceRepo := setupAuthRouter(t, true, false)
+	fakeDeviceRepo.EXPECT().GetSessionUser(gomock.Any(), "session_expired").Return(models.User{}, errors.New("invalid session"))
+	req, _ := http.NewRequest("GET", "/api/summary", nil)
+	req.AddCookie(&http.Cookie{Name: middleware.SessionCookieName, Value: "session_expired"})
+	w := httptest.NewRecorder()
+
+	//test
+	router.ServeHTTP(w, req)
+
+	//assert
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestAuthMiddleware_ReadOnlySession(t *testing.T) {
+	//setup
+	router, fakeDeviceRepo := setupAuthRouter(t, true, false)
+	fakeDeviceRepo.EXPECT().GetSessionUser(gomock.Any(), "session_valid").Return(models.User{Username: "viewer", Role: pkg.UserRoleReadOnly}, nil).Times(2)
+
+	//test
+	readReq, _ := http.NewRequest("GET", "/api/summary", nil)
+	readReq.AddCookie(&http.Cookie{Name: middleware.SessionCookieName, Value: "session_valid"})
+	readW := httptest.NewRecorder()
+	router.ServeHTTP(readW, readReq)
+
+	deleteReq, _ := http.NewRequest("DELETE", "/api/device/7c1a4d2e-8f3b-5a6c-9d0e-1f2a3b4c5d6e", nil)
+	deleteReq.AddCookie(&http.Cookie{Name: middleware.SessionCookieName, Value: "session_valid"})
+	deleteW := httptest.NewRecorder()
+	router.ServeHTTP(deleteW, deleteReq)
+
+	//assert
+	require.Equal(t, http.StatusOK, readW.Code)
+	require.Equal(t, http.StatusForbidden, deleteW.Code)
+}
+
+func TestAuthMiddleware_ForwardAuth_TrustedProxy(t *testing.T) {
+	//setup
+	router, _ := setupAuthRouter(t, true, true)
+	req, _ := http.NewRequest("DELETE", "/api/device/7c1a4d2e-8f3b-5a6c-9d0e-1f2a3b4c5d6e", nil)
+	req.RemoteAddr = "10.0.0.5:41234"
+	req.Header.Set("Remote-User", "jdoe")
+	req.Header.Set("Remote-Groups", "users, admin")
+	w := httptest.NewRecorder()
+
+	//test
+	router.ServeHTTP(w, req)
+
+	//assert
+	require.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestAuthMiddleware_ForwardAuth_UntrustedProxy(t *testing.T) {
+	//setup
+	router, _ := setupAuthRouter(t, true, true)
+	req, _ := http.NewRequest("GET", "/api/summary", nil)
+	req.RemoteAddr = "192.168.1.20:41234"
+	req.Header.Set("Remote-User", "jdoe")
+	req.Header.Set("Remote-Groups", "admin")
+	w := httptest.NewRecorder()
+
+	//test
+	router.ServeHTTP(w, req)
+
+	//assert
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+}
@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/analogj/scrutiny/webapp/backend/pkg/config"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/database"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/gofrs/uuid/v5"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// CollectorAuthMiddleware requires collector routes to provide a valid `Authorization: Bearer <token>` header, when
+// `web.auth.collector.enabled` is set. The matching token is stored in the context as "COLLECTOR_TOKEN".
+func CollectorAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		appConfig := c.MustGet("CONFIG").(config.Interface)
+		if !appConfig.GetBool("web.auth.collector.enabled") {
+			c.Next()
+			return
+		}
+		logger := c.MustGet("LOGGER").(*logrus.Entry)
+		deviceRepo := c.MustGet("DEVICE_REPOSITORY").(database.DeviceRepo)
+
+		tokenValue, found := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !found || len(strings.TrimSpace(tokenValue)) == 0 {
+			logger.Warnln("Collector request is missing a bearer token")
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"success": false, "errors": []string{"missing collector token"}})
+			return
+		}
+
+		collectorToken, err := deviceRepo.ValidateCollectorToken(c, strings.TrimSpace(tokenValue))
+		if err != nil {
+			logger.Warnln("Collector request has an invalid bearer token", err)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"success": false, "errors": []string{"invalid collector token"}})
+			return
+		}
+
+		c.Set("COLLECTOR_TOKEN", collectorToken)
+		c.Next()
+	}
+}
+
+// CollectorHostAllowed returns true if the authenticated collector may write data for devices on the specified host.
+// Always true when collector authentication is disabled.
+func CollectorHostAllowed(c *gin.Context, hostId string) bool {
+	collectorToken, exists := c.Get("COLLECTOR_TOKEN")
+	if !exists {
+		return true
+	}
+	return collectorToken.(models.CollectorToken).HostId == hostId
+}
+
+// CollectorDeviceAllowed returns true if the authenticated collector may write data for the specified device, ie. the
+// device is not registered yet, or is registered to the host the collector token is bound to.
+func CollectorDeviceAllowed(c *gin.Context, deviceRepo database.DeviceRepo, scrutinyUuid uuid.UUID) bool {
+	if _, exists := c.Get("COLLECTOR_TOKEN"); !exists {
+		return true
+	}
+	device, err := deviceRepo.GetDeviceDetails(c, scrutinyUuid)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return true
+	} else if err != nil {
+		return false
+	}
+	return CollectorHostAllowed(c, device.HostId)
+}
@@ -0,0 +1,133 @@
+package middleware_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	mock_config "github.com/analogj/scrutiny/webapp/backend/pkg/config/mock"
+	mock_database "github.com/analogj/scrutiny/webapp/backend/pkg/database/mock"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/models"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/web/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/gofrs/uuid/v5"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"gorm.io/gorm"
+)
+
+func setupCollectorAuthRouter(t *testing.T, authEnabled bool) (*gin.Engine, *mock_database.MockDeviceRepo) {
+	mockCtrl := gomock.NewController(t)
+	fakeConfig := mock_config.NewMockInterface(mockCtrl)
+	fakeConfig.EXPECT().GetBool("web.auth.collector.enabled").Return(authEnabled).AnyTimes()
+	fakeDeviceRepo := mock_database.NewMockDeviceRepo(mockCtrl)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("CONFIG", fakeConfig)
+		c.Set("LOGGER", logrus.WithFields(logrus.Fields{}))
+		c.Set("DEVICE_REPOSITORY", fakeDeviceRepo)
+		c.Next()
+	})
+	r.POST("/api/device/:scrutiny_uuid/smart", middleware.CollectorAuthMiddleware(), func(c *gin.Context) {
+		scrutinyUuid := uuid.FromStringOrNil(c.Param("scrutiny_uuid"))
+		if !middleware.CollectorDeviceAllowed(c, fakeDeviceRepo, scrutinyUuid) {
+			c.JSON(http.StatusForbidden, gin.H{"success": false})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"success": true})
+	})
+	return r, fakeDeviceRepo
+}
+
+func TestCollectorAuthMiddleware_Disabled(t *testing.T) {
+	//setup
+	router, _ := setupCollectorAuthRouter(t, false)
+	req, _ := http.NewRequest("POST", "/api/device/7c1a4d2e-8f3b-5a6c-9d0e-1f2a3b4c5d6e/smart", nil)
+	w := httptest.NewRecorder()
+
+	//test
+	router.ServeHTTP(w, req)
+
+	//assert
+	require.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestCollectorAuthMiddleware_MissingToken(t *testing.T) {
+	//setup
+	router, _ := setupCollectorAuthRouter(t, true)
+	req, _ := http.NewRequest("POST", "/api/device/7c1a4d2e-8f3b-5a6c-9d0e-1f2a3b4c5d6e/smart", nil)
+	w := httptest.NewRecorder()
+
+	//test
+	router.ServeHTTP(w, req)
+
+	//assert
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestCollectorAuthMiddleware_InvalidToken(t *testing.T) {
+	//setup
+	router, fakeDeviceRepo := setupCollectorAuthRouter(t, true)
+	fakeDeviceRepo.EXPECT().ValidateCollectorToken(gomock.Any(), "scrutiny_invalid").Return(models.CollectorToken{}, errors.New("invalid collector token"))
+	req, _ := http.NewRequest("POST", "/api/device/7c1a4d2e-8f3b-5a6c-9d0e-1f2a3b4c5d6e/smart", nil)
+	req.Header.Set("Authorization", "Bearer scrutiny_invalid")
+	w := httptest.NewRecorder()
+
+	//test
+	router.ServeHTTP(w, req)
+
+	//assert
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestCollectorAuthMiddleware_DeviceOnSameHost(t *testing.T) {
+	//setup
+	router, fakeDeviceRepo := setupCollectorAuthRouter(t, true)
+	fakeDeviceRepo.EXPECT().ValidateCollectorToken(gomock.Any(), "scrutiny_valid").Return(models.CollectorToken{HostId: "nas01"}, nil)
+	fakeDeviceRepo.EXPECT().GetDeviceDetails(gomock.Any(), gomock.Any()).Return(models.Device{HostId: "nas01"}, nil)
+	req, _ := http.NewRequest("POST", "/api/device/7c1a4d2e-8f3b-5a6c-9d0e-1f2a3b4c5d6e/smart", nil)
+	req.Header.Set("Authorization", "Bearer scrutiny_valid")
+	w := httptest.NewRecorder()
+
+	//test
+	router.ServeHTTP(w, req)
+
+	//assert
+	require.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestCollectorAuthMiddleware_DeviceOnOtherHost(t *testing.T) {
+	//setup
+	router, fakeDeviceRepo := setupCollectorAuthRouter(t, true)
+	fakeDeviceRepo.EXPECT().ValidateCollectorToken(gomock.Any(), "scrutiny_valid").Return(models.CollectorToken{HostId: "nas01"}, nil)
+	fakeDeviceRepo.EXPECT().GetDeviceDetails(gomock.Any(), gomock.Any()).Return(models.Device{HostId: "nas02"}, nil)
+	req, _ := http.NewRequest("POST", "/api/device/7c1a4d2e-8f3b-5a6c-9d0e-1f2a3b4c5d6e/smart", nil)
+	req.Header.Set("Authorization", "Bearer scrutiny_valid")
+	w := httptest.NewRecorder()
+
+	//test
+	router.ServeHTTP(w, req)
+
+	//assert
+	require.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestCollectorAuthMiddleware_UnregisteredDevice(t *testing.T) {
+	//setup
+	router, fakeDeviceRepo := setupCollectorAuthRouter(t, true)
+	fakeDeviceRepo.EXPECT().ValidateCollectorToken(gomock.Any(), "scrutiny_valid").Return(models.CollectorToken{HostId: "nas01"}, nil)
+	fakeDeviceRepo.EXPECT().GetDeviceDetails(gomock.Any(), gomock.Any()).Return(models.Device{}, gorm.ErrRecordNotFound)
+	req, _ := http.NewRequest("POST", "/api/device/7c1a4d2e-8f3b-5a6c-9d0e-1f2a3b4c5d6e/smart", nil)
+	req.Header.Set("Authorization", "Bearer scrutiny_valid")
+	w := httptest.NewRecorder()
+
+	//test
+	router.ServeHTTP(w, req)
+
+	//assert
+	require.Equal(t, http.StatusOK, w.Code)
+}
@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"strings"
+
+	"github.com/analogj/scrutiny/webapp/backend/pkg/config"
+	"github.com/gin-gonic/gin"
+)
+
+// MetricsAuthMiddleware allows Prometheus to scrape the metrics route using the `web.auth.metrics.token` bearer token,
+// since a scraper cannot log in. Requests without the token fall back to AuthMiddleware, so logged-in users can still
+// view the metrics.
+func MetricsAuthMiddleware() gin.HandlerFunc {
+	authMiddleware := AuthMiddleware()
+	return func(c *gin.Context) {
+		appConfig := c.MustGet("CONFIG").(config.Interface)
+
+		metricsToken := appConfig.GetString("web.auth.metrics.token")
+		tokenValue, found := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if len(metricsToken) > 0 && found && subtle.ConstantTimeCompare([]byte(strings.TrimSpace(tokenValue)), []byte(metricsToken)) == 1 {
+			c.Next()
+			return
+		}
+		authMiddleware(c)
+	}
+}
@@ -0,0 +1,89 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	mock_config "github.com/analogj/scrutiny/webapp/backend/pkg/config/mock"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/web/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func setupMetricsAuthRouter(t *testing.T, authEnabled bool, metricsToken string) *gin.Engine {
+	mockCtrl := gomock.NewController(t)
+	fakeConfig := mock_config.NewMockInterface(mockCtrl)
+	fakeConfig.EXPECT().GetBool("web.auth.enabled").Return(authEnabled).AnyTimes()
+	fakeConfig.EXPECT().GetBool("web.auth.forward_auth.enabled").Return(false).AnyTimes()
+	fakeConfig.EXPECT().GetString("web.auth.metrics.token").Return(metricsToken).AnyTimes()
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("CONFIG", fakeConfig)
+		c.Set("LOGGER", logrus.WithFields(logrus.Fields{}))
+		c.Next()
+	})
+	r.GET("/api/metrics", middleware.MetricsAuthMiddleware(), func(c *gin.Context) {
+		c.String(http.StatusOK, "scrutiny_device_status 0")
+	})
+	return r
+}
+
+func TestMetricsAuthMiddleware_AuthDisabled(t *testing.T) {
+	//setup
+	router := setupMetricsAuthRouter(t, false, "")
+	req, _ := http.NewRequest("GET", "/api/metrics", nil)
+	w := httptest.NewRecorder()
+
+	//test
+	router.ServeHTTP(w, req)
+
+	//assert
+	require.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestMetricsAuthMiddleware_ValidToken(t *testing.T) {
+	//setup
+	router := setupMetricsAuthRouter(t, true, "prometheus-secret")
+	req, _ := http.NewRequest("GET", "/api/metrics", nil)
+	req.Header.Set("Authorization", "Bearer prometheus-secret")
+	w := httptest.NewRecorder()
+
+	//test
+	router.ServeHTTP(w, req)
+
+	//assert
+	require.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestMetricsAuthMiddleware_InvalidToken(t *testing.T) {
+	//setup
+	router := setupMetricsAuthRouter(t, true, "prometheus-secret")
+	req, _ := http.NewRequest("GET", "/api/metrics", nil)
+	req.Header.Set("Authorization", "Bearer wrong")
+	w := httptest.NewRecorder()
+
+	//test
+	router.ServeHTTP(w, req)
+
+	//assert
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestMetricsAuthMiddleware_NoTokenConfigured(t *testing.T) {
+	//setup
+	router := setupMetricsAuthRouter(t, true, "")
+	req, _ := http.NewRequest("GET", "/api/metrics", nil)
+	req.Header.Set("Authorization", "Bearer ")
+	w := httptest.NewRecorder()
+
+	//test
+	router.ServeHTTP(w, req)
+
+	//assert
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+}
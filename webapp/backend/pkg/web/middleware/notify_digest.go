@@ -0,0 +1,14 @@
+package middleware
+
+import (
+	"github.com/analogj/scrutiny/webapp/backend/pkg/notify"
+	"github.com/gin-gonic/gin"
+)
+
+// NotifyDigestMiddleware shares a single notification digest queue between requests, see notify.Digest
+func NotifyDigestMiddleware(digest *notify.Digest) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("NOTIFY_DIGEST", digest)
+		c.Next()
+	}
+}
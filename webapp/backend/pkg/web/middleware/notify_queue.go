@@ -0,0 +1,14 @@
+package middleware
+
+import (
+	"github.com/analogj/scrutiny/webapp/backend/pkg/notify"
+	"github.com/gin-gonic/gin"
+)
+
+// NotifyQueueMiddleware shares a single background notification queue between requests, see notify.Queue
+func NotifyQueueMiddleware(queue *notify.Queue) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("NOTIFY_QUEUE", queue)
+		c.Next()
+	}
+}
@@ -8,7 +8,9 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
-func RepositoryMiddleware(appConfig config.Interface, globalLogger logrus.FieldLogger) gin.HandlerFunc {
+// NewRepository creates the repository shared by all requests & background jobs, and ensures the database is ready
+// to serve requests.
+func NewRepository(appConfig config.Interface, globalLogger logrus.FieldLogger) database.DeviceRepo {
 
 	deviceRepo, err := database.NewScrutinyRepository(appConfig, globalLogger)
 	if err != nil {
@@ -21,8 +23,18 @@ func RepositoryMiddleware(appConfig config.Interface, globalLogger logrus.FieldL
 		panic(err)
 	}
 
+	// create the initial admin user, so that the UI is accessible after enabling authentication.
+	err = deviceRepo.EnsureAdminUser(context.Background())
+	if err != nil {
+		panic(err)
+	}
+
 	//settings.UpdateSettingEntries()
 
+	return deviceRepo
+}
+
+func RepositoryMiddleware(deviceRepo database.DeviceRepo) gin.HandlerFunc {
 	//TODO: determine where we can call defer deviceRepo.Close()
 	return func(c *gin.Context) {
 		c.Set("DEVICE_REPOSITORY", deviceRepo)
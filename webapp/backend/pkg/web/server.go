@@ -1,31 +1,64 @@
 package web
 
 import (
+	"context"
 	"fmt"
 	"net/http"
+	"os"
+	"os/signal"
 	"path/filepath"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/analogj/go-util/utils"
+	"github.com/analogj/scrutiny/webapp/backend/pkg"
 	"github.com/analogj/scrutiny/webapp/backend/pkg/config"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/database"
 	"github.com/analogj/scrutiny/webapp/backend/pkg/errors"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/monitor"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/notify"
 	"github.com/analogj/scrutiny/webapp/backend/pkg/web/handler"
 	"github.com/analogj/scrutiny/webapp/backend/pkg/web/middleware"
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 )
 
+// how often the background checks (eg. stale device detection) run, see monitor.Monitor
+const monitorInterval = 10 * time.Minute
+
+// how long to wait for in-flight requests when the web server is stopped
+const shutdownTimeout = 30 * time.Second
+
+// the number of notifications sent concurrently, and the number of notifications waiting to be sent, see notify.Queue
+const (
+	notifyQueueWorkers = 4
+	notifyQueueSize    = 100
+)
+
 type AppEngine struct {
 	Config config.Interface
 	Logger *logrus.Entry
+
+	// shared between requests & the background monitor, populated by Setup
+	deviceRepo   database.DeviceRepo
+	notifyDigest *notify.Digest
+	notifyQueue  *notify.Queue
 }
 
 func (ae *AppEngine) Setup(logger *logrus.Entry) *gin.Engine {
 	r := gin.New()
 
 	r.Use(middleware.LoggerMiddleware(logger))
-	r.Use(middleware.RepositoryMiddleware(ae.Config, logger))
+	ae.deviceRepo = middleware.NewRepository(ae.Config, logger)
+	ae.notifyDigest = notify.NewDigest(logger, ae.Config)
+	ae.notifyQueue = notify.NewQueue(logger, notifyQueueWorkers, notifyQueueSize)
+	ae.notifyDigest.Queue = ae.notifyQueue
+
+	r.Use(middleware.RepositoryMiddleware(ae.deviceRepo))
 	r.Use(middleware.ConfigMiddleware(ae.Config))
+	r.Use(middleware.NotifyDigestMiddleware(ae.notifyDigest))
+	r.Use(middleware.NotifyQueueMiddleware(ae.notifyQueue))
 	r.Use(gin.Recovery())
 
 	basePath := ae.Config.GetString("web.listen.basepath")
@@ -36,20 +69,72 @@ func (ae *AppEngine) Setup(logger *logrus.Entry) *gin.Engine {
 		api := base.Group("/api")
 		{
 			api.GET("/health", handler.HealthCheck)
-			api.POST("/health/notify", handler.SendTestNotification) //check if notifications are configured correctly
-
-			api.POST("/devices/register", handler.RegisterDevices)                //used by Collector to register new devices and retrieve filtered list
-			api.GET("/summary", handler.GetDevicesSummary)                        //used by Dashboard
-			api.GET("/summary/temp", handler.GetDevicesSummaryTempHistory)        //used by Dashboard (Temperature history dropdown)
-			api.POST("/device/:scrutiny_uuid/smart", handler.UploadDeviceMetrics) //used by Collector to upload data
-			api.POST("/device/:scrutiny_uuid/selftest", handler.UploadDeviceSelfTests)
-			api.GET("/device/:scrutiny_uuid/details", handler.GetDeviceDetails)   //used by Details
-			api.POST("/device/:scrutiny_uuid/archive", handler.ArchiveDevice)     //used by UI to archive device
-			api.POST("/device/:scrutiny_uuid/unarchive", handler.UnarchiveDevice) //used by UI to unarchive device
-			api.DELETE("/device/:scrutiny_uuid", handler.DeleteDevice)            //used by UI to delete device
-
-			api.GET("/settings", handler.GetSettings)   //used to get settings
-			api.POST("/settings", handler.SaveSettings) //used to save settings
+			api.POST("/auth/login", handler.Login) //used by UI to create a user session
+
+			//used by Prometheus to scrape the latest smart data, accepts the `web.auth.metrics.token` bearer token
+			api.GET("/metrics", middleware.MetricsAuthMiddleware(), handler.GetPrometheusMetrics)
+
+			//used by Collector, requires a collector token when `web.auth.collector.enabled` is set
+			collectorApi := api.Group("", middleware.CollectorAuthMiddleware())
+			{
+				collectorApi.POST("/devices/register", handler.RegisterDevices)                //used by Collector to register new devices and retrieve filtered list
+				collectorApi.POST("/device/:scrutiny_uuid/smart", handler.UploadDeviceMetrics) //used by Collector to upload data
+				collectorApi.POST("/device/:scrutiny_uuid/selftest", handler.UploadDeviceSelfTests)
+			}
+
+			//used by UI, requires a logged-in user when `web.auth.enabled` is set
+			userApi := api.Group("", middleware.AuthMiddleware())
+			{
+				userApi.POST("/auth/logout", handler.Logout)    //used by UI to delete the user session
+				userApi.GET("/auth/me", handler.GetCurrentUser) //used by UI to determine the logged-in user & role
+
+				userApi.GET("/devices", handler.GetDevices)                                 //used to filter, sort & page devices
+				userApi.GET("/summary", handler.GetDevicesSummary)                          //used by Dashboard
+				userApi.GET("/summary/temp", handler.GetDevicesSummaryTempHistory)          //used by Dashboard (Temperature history dropdown)
+				userApi.GET("/device/:scrutiny_uuid/details", handler.GetDeviceDetails)     //used by Details
+				userApi.GET("/device/:scrutiny_uuid/endurance", handler.GetDeviceEndurance) //used to get SSD endurance analytics
+				userApi.GET("/hosts", handler.GetHosts)                                     //used to list hosts & their aggregate health
+				userApi.GET("/host/:host_id", handler.GetHost)                              //used to get a host & its devices
+				userApi.GET("/settings", handler.GetSettings)                               //used to get settings
+				userApi.GET("/thresholds/overrides", handler.GetAttributeOverrideRules)     //used to list attribute threshold overrides
+				userApi.GET("/warranty/rules", handler.GetWarrantyRules)                    //used to list per-model warranty terms
+				userApi.GET("/notifications/history", handler.GetNotificationHistory)       //used to list notification delivery attempts
+			}
+
+			//mutating routes, only allowed for admin users
+			adminApi := userApi.Group("", middleware.RequireRole(pkg.UserRoleAdmin))
+			{
+				adminApi.POST("/health/notify", handler.SendTestNotification)              //check if notifications are configured correctly
+				adminApi.POST("/device/:scrutiny_uuid/archive", handler.ArchiveDevice)     //used by UI to archive device
+				adminApi.POST("/device/:scrutiny_uuid/unarchive", handler.UnarchiveDevice) //used by UI to unarchive device
+				adminApi.DELETE("/device/:scrutiny_uuid", handler.DeleteDevice)            //used by UI to delete device
+				adminApi.PATCH("/device/:scrutiny_uuid", handler.UpdateDeviceMetadata)     //used to update user provided device metadata
+
+				adminApi.POST("/host/:host_id/archive", handler.ArchiveHost)     //used to archive a host & all of its devices
+				adminApi.POST("/host/:host_id/unarchive", handler.UnarchiveHost) //used to unarchive a host & all of its devices
+				adminApi.DELETE("/host/:host_id", handler.DeleteHost)            //used to delete a host & all of its devices
+				adminApi.PATCH("/host/:host_id", handler.UpdateHostMetadata)     //used to update user provided host metadata
+
+				adminApi.POST("/device/:scrutiny_uuid/replacements", handler.CreateDeviceReplacement)       //used to link a device to the device it replaced
+				adminApi.DELETE("/device/:scrutiny_uuid/replacements/:id", handler.DeleteDeviceReplacement) //used to remove an incorrect replacement
+
+				adminApi.POST("/settings", handler.SaveSettings) //used to save settings
+
+				adminApi.GET("/collector/tokens", middleware.RequireAuthenticatedUser(), handler.GetCollectorTokens)          //used to list collector tokens
+				adminApi.POST("/collector/tokens", middleware.RequireAuthenticatedUser(), handler.CreateCollectorToken)       //used to create a collector token for a host
+				adminApi.DELETE("/collector/tokens/:id", middleware.RequireAuthenticatedUser(), handler.DeleteCollectorToken) //used to revoke a collector token
+
+				adminApi.POST("/thresholds/overrides", handler.CreateAttributeOverrideRule)       //used to create an attribute threshold override
+				adminApi.PUT("/thresholds/overrides/:id", handler.UpdateAttributeOverrideRule)    //used to replace an attribute threshold override
+				adminApi.DELETE("/thresholds/overrides/:id", handler.DeleteAttributeOverrideRule) //used to delete an attribute threshold override
+
+				adminApi.POST("/warranty/rules", handler.CreateWarrantyRule)       //used to create per-model warranty terms
+				adminApi.DELETE("/warranty/rules/:id", handler.DeleteWarrantyRule) //used to delete per-model warranty terms
+
+				adminApi.GET("/users", handler.GetUsers)          //used to list local users
+				adminApi.POST("/users", handler.CreateUser)       //used to create a local user
+				adminApi.DELETE("/users/:id", handler.DeleteUser) //used to delete a local user
+			}
 		}
 	}
 
@@ -82,7 +167,50 @@ func (ae *AppEngine) Start() error {
 			filepath.Dir(ae.Config.GetString("web.database.location"))))
 	}
 
+	//check the notification endpoints, an invalid filter would otherwise cause every notification to fail.
+	if _, err := notify.LoadTargets(ae.Config); err != nil {
+		return errors.ConfigValidationError(err.Error())
+	}
+
+	//collector tokens are managed by admin users, without user authentication anyone could create or revoke them.
+	if ae.Config.GetBool("web.auth.collector.enabled") && !ae.Config.GetBool("web.auth.enabled") {
+		return errors.ConfigValidationError("`web.auth.collector.enabled` requires user authentication (`web.auth.enabled`)")
+	}
+
 	r := ae.Setup(ae.Logger)
+	stopMonitor := monitor.New(ae.Logger, ae.Config, ae.deviceRepo, ae.notifyDigest, ae.notifyQueue).Start(monitorInterval)
 
-	return r.Run(fmt.Sprintf("%s:%s", ae.Config.GetString("web.listen.host"), ae.Config.GetString("web.listen.port")))
+	server := &http.Server{
+		Addr:    fmt.Sprintf("%s:%s", ae.Config.GetString("web.listen.host"), ae.Config.GetString("web.listen.port")),
+		Handler: r,
+	}
+	serverErr := make(chan error, 1)
+	go func() {
+		ae.Logger.Infof("Listening and serving HTTP on %s", server.Addr)
+		serverErr <- server.ListenAndServe()
+	}()
+
+	//stop gracefully on SIGINT/SIGTERM: wait for in-flight requests, then send the digest & the queued notifications.
+	signalCtx, stopSignals := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stopSignals()
+
+	var err error
+	select {
+	case err = <-serverErr:
+	case <-signalCtx.Done():
+		ae.Logger.Infof("Shutting down, waiting up to %s for in-flight requests", shutdownTimeout)
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		err = server.Shutdown(shutdownCtx)
+	}
+
+	stopMonitor()
+	if ae.notifyDigest.Enabled() {
+		if flushErr := ae.notifyDigest.Flush(); flushErr != nil {
+			ae.Logger.Errorf("One or more digest notifications failed to send: %v", flushErr)
+		}
+	}
+	ae.Logger.Infof("Sending queued notifications")
+	ae.notifyQueue.Close()
+	return err
 }
@@ -18,6 +18,7 @@ import (
 	mock_config "github.com/analogj/scrutiny/webapp/backend/pkg/config/mock"
 	"github.com/analogj/scrutiny/webapp/backend/pkg/models"
 	"github.com/analogj/scrutiny/webapp/backend/pkg/models/collector"
+	"github.com/analogj/scrutiny/webapp/backend/pkg/models/measurements"
 	"github.com/analogj/scrutiny/webapp/backend/pkg/web"
 	"github.com/gofrs/uuid/v5"
 	"github.com/sirupsen/logrus"
@@ -27,7 +28,8 @@ import (
 )
 
 /*
-All tests in this file require the existance of a influxDB listening on port 8086
+All tests in this file require the existance of a influxDB listening on port 8086, except when running with the sqlite
+time-series backend (TestServerTestSuite_WithSqliteBackend)
 
 docker run --rm -it -p 8086:8086 \
 -e DOCKER_INFLUXDB_INIT_MODE=setup \
@@ -70,21 +72,73 @@ func helperReadSmartDataFileFixTimestamp(t *testing.T, smartDataFilepath string)
 // returns the current testing context
 type ServerTestSuite struct {
 	suite.Suite
-	Basepath string
+	Basepath          string
+	TimeSeriesBackend string
 }
 
 func TestServerTestSuite_WithEmptyBasePath(t *testing.T) {
 	emptyBasePathSuite := new(ServerTestSuite)
 	emptyBasePathSuite.Basepath = ""
+	emptyBasePathSuite.TimeSeriesBackend = "influxdb"
 	suite.Run(t, emptyBasePathSuite)
 }
 
 func TestServerTestSuite_WithCustomBasePath(t *testing.T) {
 	emptyBasePathSuite := new(ServerTestSuite)
 	emptyBasePathSuite.Basepath = "/basepath"
+	emptyBasePathSuite.TimeSeriesBackend = "influxdb"
 	suite.Run(t, emptyBasePathSuite)
 }
 
+func TestServerTestSuite_WithSqliteBackend(t *testing.T) {
+	sqliteBackendSuite := new(ServerTestSuite)
+	sqliteBackendSuite.Basepath = ""
+	sqliteBackendSuite.TimeSeriesBackend = "sqlite"
+	suite.Run(t, sqliteBackendSuite)
+}
+
+func TestAppEngine_Start_InvalidNotifyUrls(t *testing.T) {
+	//setup
+	parentPath := t.TempDir()
+	mockCtrl := gomock.NewController(t)
+	fakeConfig := mock_config.NewMockInterface(mockCtrl)
+	fakeConfig.EXPECT().GetString("log.level").Return("INFO").AnyTimes()
+	fakeConfig.EXPECT().GetString("web.database.location").Return(path.Join(parentPath, "scrutiny_test.db")).AnyTimes()
+	fakeConfig.EXPECT().Get("notify.urls").Return([]string{"discord://token@id", " "}).AnyTimes()
+	ae := web.AppEngine{
+		Config: fakeConfig,
+		Logger: logrus.WithField("test", t.Name()),
+	}
+
+	//test
+	err := ae.Start()
+
+	//assert
+	require.ErrorContains(t, err, "invalid notify.urls configuration")
+}
+
+func TestAppEngine_Start_CollectorAuthWithoutUserAuth(t *testing.T) {
+	//setup
+	parentPath := t.TempDir()
+	mockCtrl := gomock.NewController(t)
+	fakeConfig := mock_config.NewMockInterface(mockCtrl)
+	fakeConfig.EXPECT().GetString("log.level").Return("INFO").AnyTimes()
+	fakeConfig.EXPECT().GetString("web.database.location").Return(path.Join(parentPath, "scrutiny_test.db")).AnyTimes()
+	fakeConfig.EXPECT().Get("notify.urls").Return([]string{}).AnyTimes()
+	fakeConfig.EXPECT().GetBool("web.auth.collector.enabled").Return(true).AnyTimes()
+	fakeConfig.EXPECT().GetBool("web.auth.enabled").Return(false).AnyTimes()
+	ae := web.AppEngine{
+		Config: fakeConfig,
+		Logger: logrus.WithField("test", t.Name()),
+	}
+
+	//test
+	err := ae.Start()
+
+	//assert
+	require.ErrorContains(t, err, "web.auth.collector.enabled")
+}
+
 func (suite *ServerTestSuite) TestHealthRoute() {
 	//setup
 	parentPath, _ := os.MkdirTemp("", "")
@@ -105,6 +159,18 @@ func (suite *ServerTestSuite) TestHealthRoute() {
 	fakeConfig.EXPECT().GetString("web.influxdb.bucket").Return("metrics").AnyTimes()
 	fakeConfig.EXPECT().GetBool("web.influxdb.tls.insecure_skip_verify").Return(false).AnyTimes()
 	fakeConfig.EXPECT().GetBool("web.influxdb.retention_policy").Return(false).AnyTimes()
+	fakeConfig.EXPECT().GetBool("web.auth.collector.enabled").Return(false).AnyTimes()
+	fakeConfig.EXPECT().GetBool("web.auth.enabled").Return(false).AnyTimes()
+	fakeConfig.EXPECT().GetInt(fmt.Sprintf("%s.metrics.notify_failure_score", config.DB_USER_SETTINGS_SUBKEY)).Return(0).AnyTimes()
+	fakeConfig.EXPECT().GetInt(fmt.Sprintf("%s.metrics.warning_notify_interval_hours", config.DB_USER_SETTINGS_SUBKEY)).Return(24).AnyTimes()
+	fakeConfig.EXPECT().GetString("notify.templates.subject").Return("").AnyTimes()
+	fakeConfig.EXPECT().GetString("notify.templates.message").Return("").AnyTimes()
+	fakeConfig.EXPECT().GetString("notify.dashboard_url").Return("").AnyTimes()
+	fakeConfig.EXPECT().GetInt("notify.timeout_seconds").Return(30).AnyTimes()
+	fakeConfig.EXPECT().GetInt("notify.retries").Return(0).AnyTimes()
+	fakeConfig.EXPECT().GetInt("notify.retry_backoff_seconds").Return(0).AnyTimes()
+	fakeConfig.EXPECT().GetBool("notify.digest.enabled").Return(false).AnyTimes()
+	fakeConfig.EXPECT().GetString("web.timeseries.backend").Return(suite.TimeSeriesBackend).AnyTimes()
 	if _, isGithubActions := os.LookupEnv("GITHUB_ACTIONS"); isGithubActions {
 		// when running test suite in github actions, we run an influxdb service as a sidecar.
 		fakeConfig.EXPECT().GetString("web.influxdb.host").Return("influxdb").AnyTimes()
@@ -147,6 +213,18 @@ func (suite *ServerTestSuite) TestRegisterDevicesRoute() {
 	fakeConfig.EXPECT().GetString("web.influxdb.bucket").Return("metrics").AnyTimes()
 	fakeConfig.EXPECT().GetBool("web.influxdb.tls.insecure_skip_verify").Return(false).AnyTimes()
 	fakeConfig.EXPECT().GetBool("web.influxdb.retention_policy").Return(false).AnyTimes()
+	fakeConfig.EXPECT().GetBool("web.auth.collector.enabled").Return(false).AnyTimes()
+	fakeConfig.EXPECT().GetBool("web.auth.enabled").Return(false).AnyTimes()
+	fakeConfig.EXPECT().GetInt(fmt.Sprintf("%s.metrics.notify_failure_score", config.DB_USER_SETTINGS_SUBKEY)).Return(0).AnyTimes()
+	fakeConfig.EXPECT().GetInt(fmt.Sprintf("%s.metrics.warning_notify_interval_hours", config.DB_USER_SETTINGS_SUBKEY)).Return(24).AnyTimes()
+	fakeConfig.EXPECT().GetString("notify.templates.subject").Return("").AnyTimes()
+	fakeConfig.EXPECT().GetString("notify.templates.message").Return("").AnyTimes()
+	fakeConfig.EXPECT().GetString("notify.dashboard_url").Return("").AnyTimes()
+	fakeConfig.EXPECT().GetInt("notify.timeout_seconds").Return(30).AnyTimes()
+	fakeConfig.EXPECT().GetInt("notify.retries").Return(0).AnyTimes()
+	fakeConfig.EXPECT().GetInt("notify.retry_backoff_seconds").Return(0).AnyTimes()
+	fakeConfig.EXPECT().GetBool("notify.digest.enabled").Return(false).AnyTimes()
+	fakeConfig.EXPECT().GetString("web.timeseries.backend").Return(suite.TimeSeriesBackend).AnyTimes()
 	if _, isGithubActions := os.LookupEnv("GITHUB_ACTIONS"); isGithubActions {
 		// when running test suite in github actions, we run an influxdb service as a sidecar.
 		fakeConfig.EXPECT().GetString("web.influxdb.host").Return("influxdb").AnyTimes()
@@ -191,6 +269,18 @@ func (suite *ServerTestSuite) TestUploadDeviceMetricsRoute() {
 	fakeConfig.EXPECT().GetBool("user.collector.discard_sct_temp_history").Return(false).AnyTimes()
 	fakeConfig.EXPECT().GetBool("web.influxdb.tls.insecure_skip_verify").Return(false).AnyTimes()
 	fakeConfig.EXPECT().GetBool("web.influxdb.retention_policy").Return(false).AnyTimes()
+	fakeConfig.EXPECT().GetBool("web.auth.collector.enabled").Return(false).AnyTimes()
+	fakeConfig.EXPECT().GetBool("web.auth.enabled").Return(false).AnyTimes()
+	fakeConfig.EXPECT().GetInt(fmt.Sprintf("%s.metrics.notify_failure_score", config.DB_USER_SETTINGS_SUBKEY)).Return(0).AnyTimes()
+	fakeConfig.EXPECT().GetInt(fmt.Sprintf("%s.metrics.warning_notify_interval_hours", config.DB_USER_SETTINGS_SUBKEY)).Return(24).AnyTimes()
+	fakeConfig.EXPECT().GetString("notify.templates.subject").Return("").AnyTimes()
+	fakeConfig.EXPECT().GetString("notify.templates.message").Return("").AnyTimes()
+	fakeConfig.EXPECT().GetString("notify.dashboard_url").Return("").AnyTimes()
+	fakeConfig.EXPECT().GetInt("notify.timeout_seconds").Return(30).AnyTimes()
+	fakeConfig.EXPECT().GetInt("notify.retries").Return(0).AnyTimes()
+	fakeConfig.EXPECT().GetInt("notify.retry_backoff_seconds").Return(0).AnyTimes()
+	fakeConfig.EXPECT().GetBool("notify.digest.enabled").Return(false).AnyTimes()
+	fakeConfig.EXPECT().GetString("web.timeseries.backend").Return(suite.TimeSeriesBackend).AnyTimes()
 	if _, isGithubActions := os.LookupEnv("GITHUB_ACTIONS"); isGithubActions {
 		// when running test suite in github actions, we run an influxdb service as a sidecar.
 		fakeConfig.EXPECT().GetString("web.influxdb.host").Return("influxdb").AnyTimes()
@@ -224,6 +314,90 @@ func (suite *ServerTestSuite) TestUploadDeviceMetricsRoute() {
 	//assert
 }
 
+func (suite *ServerTestSuite) TestUploadDeviceSelfTestsRoute() {
+	//setup
+	parentPath, _ := os.MkdirTemp("", "")
+	defer os.RemoveAll(parentPath)
+	mockCtrl := gomock.NewController(suite.T())
+	fakeConfig := mock_config.NewMockInterface(mockCtrl)
+	fakeConfig.EXPECT().SetDefault(gomock.Any(), gomock.Any()).AnyTimes()
+	fakeConfig.EXPECT().UnmarshalKey(gomock.Any(), gomock.Any()).AnyTimes().Return(nil)
+	fakeConfig.EXPECT().GetString("web.database.location").AnyTimes().Return(path.Join(parentPath, "scrutiny_test.db"))
+	fakeConfig.EXPECT().GetString("web.src.frontend.path").AnyTimes().Return(parentPath)
+	fakeConfig.EXPECT().GetString("web.listen.basepath").Return(suite.Basepath).AnyTimes()
+	fakeConfig.EXPECT().GetString("web.influxdb.scheme").Return("http").AnyTimes()
+	fakeConfig.EXPECT().GetString("web.influxdb.port").Return("8086").AnyTimes()
+	fakeConfig.EXPECT().IsSet("web.influxdb.token").Return(true).AnyTimes()
+	fakeConfig.EXPECT().GetString("web.influxdb.token").Return("my-super-secret-auth-token").AnyTimes()
+	fakeConfig.EXPECT().GetString("web.influxdb.org").Return("scrutiny").AnyTimes()
+	fakeConfig.EXPECT().GetString("web.influxdb.bucket").Return("metrics").AnyTimes()
+	fakeConfig.EXPECT().GetBool("web.influxdb.tls.insecure_skip_verify").Return(false).AnyTimes()
+	fakeConfig.EXPECT().GetBool("web.influxdb.retention_policy").Return(false).AnyTimes()
+	fakeConfig.EXPECT().GetBool("web.auth.collector.enabled").Return(false).AnyTimes()
+	fakeConfig.EXPECT().GetBool("web.auth.enabled").Return(false).AnyTimes()
+	fakeConfig.EXPECT().GetInt(fmt.Sprintf("%s.metrics.notify_failure_score", config.DB_USER_SETTINGS_SUBKEY)).Return(0).AnyTimes()
+	fakeConfig.EXPECT().GetInt(fmt.Sprintf("%s.metrics.warning_notify_interval_hours", config.DB_USER_SETTINGS_SUBKEY)).Return(24).AnyTimes()
+	fakeConfig.EXPECT().GetString("notify.templates.subject").Return("").AnyTimes()
+	fakeConfig.EXPECT().GetString("notify.templates.message").Return("").AnyTimes()
+	fakeConfig.EXPECT().GetString("notify.dashboard_url").Return("").AnyTimes()
+	fakeConfig.EXPECT().GetInt("notify.timeout_seconds").Return(30).AnyTimes()
+	fakeConfig.EXPECT().GetInt("notify.retries").Return(0).AnyTimes()
+	fakeConfig.EXPECT().GetInt("notify.retry_backoff_seconds").Return(0).AnyTimes()
+	fakeConfig.EXPECT().GetBool("notify.digest.enabled").Return(false).AnyTimes()
+	fakeConfig.EXPECT().GetString("web.timeseries.backend").Return(suite.TimeSeriesBackend).AnyTimes()
+	if _, isGithubActions := os.LookupEnv("GITHUB_ACTIONS"); isGithubActions {
+		// when running test suite in github actions, we run an influxdb service as a sidecar.
+		fakeConfig.EXPECT().GetString("web.influxdb.host").Return("influxdb").AnyTimes()
+	} else {
+		fakeConfig.EXPECT().GetString("web.influxdb.host").Return("localhost").AnyTimes()
+	}
+
+	ae := web.AppEngine{
+		Config: fakeConfig,
+	}
+	router := ae.Setup(logrus.WithField("test", suite.T().Name()))
+	devicesfile, err := os.Open("testdata/register-devices-single-req.json")
+	require.NoError(suite.T(), err)
+
+	selftestfile := helperReadSmartDataFileFixTimestamp(suite.T(), "../models/testdata/smart-ata-selftest.json")
+
+	//test
+	wr := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", suite.Basepath+"/api/devices/register", devicesfile)
+	router.ServeHTTP(wr, req)
+	require.Equal(suite.T(), 200, wr.Code)
+
+	sr := httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", suite.Basepath+"/api/device/9a4d34b5-b2ee-51ef-8506-90eea09be417/selftest", selftestfile)
+	router.ServeHTTP(sr, req)
+	require.Equal(suite.T(), 200, sr.Code)
+
+	// uploading the same self-test log again does not store duplicate entries
+	rr := httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", suite.Basepath+"/api/device/9a4d34b5-b2ee-51ef-8506-90eea09be417/selftest", helperReadSmartDataFileFixTimestamp(suite.T(), "../models/testdata/smart-ata-selftest.json"))
+	router.ServeHTTP(rr, req)
+	require.Equal(suite.T(), 200, rr.Code)
+
+	dr := httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", suite.Basepath+"/api/device/9a4d34b5-b2ee-51ef-8506-90eea09be417/details", nil)
+	router.ServeHTTP(dr, req)
+	require.Equal(suite.T(), 200, dr.Code)
+
+	//assert
+	var deviceDetails struct {
+		Data struct {
+			SelfTests []measurements.SmartSelfTest `json:"self_tests"`
+		} `json:"data"`
+	}
+	err = json.Unmarshal(dr.Body.Bytes(), &deviceDetails)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 2, len(deviceDetails.Data.SelfTests))
+	require.Equal(suite.T(), "Short offline", deviceDetails.Data.SelfTests[0].TestType)
+	require.True(suite.T(), deviceDetails.Data.SelfTests[0].Passed)
+	require.Equal(suite.T(), int64(41288), deviceDetails.Data.SelfTests[0].LifetimeHours)
+	require.Equal(suite.T(), int64(41200), deviceDetails.Data.SelfTests[1].LifetimeHours)
+}
+
 func (suite *ServerTestSuite) TestPopulateMultiple() {
 	//setup
 	parentPath, _ := os.MkdirTemp("", "")
@@ -233,7 +407,7 @@ func (suite *ServerTestSuite) TestPopulateMultiple() {
 	fakeConfig.EXPECT().SetDefault(gomock.Any(), gomock.Any()).AnyTimes()
 	fakeConfig.EXPECT().UnmarshalKey(gomock.Any(), gomock.Any()).AnyTimes().Return(nil)
 	//fakeConfig.EXPECT().GetString("web.database.location").AnyTimes().Return("testdata/scrutiny_test.db")
-	fakeConfig.EXPECT().GetStringSlice("notify.urls").Return([]string{}).AnyTimes()
+	fakeConfig.EXPECT().Get("notify.urls").Return([]string{}).AnyTimes()
 	fakeConfig.EXPECT().GetInt(fmt.Sprintf("%s.metrics.notify_level", config.DB_USER_SETTINGS_SUBKEY)).AnyTimes().Return(int(pkg.MetricsNotifyLevelFail))
 	fakeConfig.EXPECT().GetInt(fmt.Sprintf("%s.metrics.status_filter_attributes", config.DB_USER_SETTINGS_SUBKEY)).AnyTimes().Return(int(pkg.MetricsStatusFilterAttributesAll))
 	fakeConfig.EXPECT().GetInt(fmt.Sprintf("%s.metrics.status_threshold", config.DB_USER_SETTINGS_SUBKEY)).AnyTimes().Return(int(pkg.MetricsStatusThresholdBoth))
@@ -250,6 +424,18 @@ func (suite *ServerTestSuite) TestPopulateMultiple() {
 	fakeConfig.EXPECT().GetBool("user.collector.discard_sct_temp_history").Return(false).AnyTimes()
 	fakeConfig.EXPECT().GetBool("web.influxdb.tls.insecure_skip_verify").Return(false).AnyTimes()
 	fakeConfig.EXPECT().GetBool("web.influxdb.retention_policy").Return(false).AnyTimes()
+	fakeConfig.EXPECT().GetBool("web.auth.collector.enabled").Return(false).AnyTimes()
+	fakeConfig.EXPECT().GetBool("web.auth.enabled").Return(false).AnyTimes()
+	fakeConfig.EXPECT().GetInt(fmt.Sprintf("%s.metrics.notify_failure_score", config.DB_USER_SETTINGS_SUBKEY)).Return(0).AnyTimes()
+	fakeConfig.EXPECT().GetInt(fmt.Sprintf("%s.metrics.warning_notify_interval_hours", config.DB_USER_SETTINGS_SUBKEY)).Return(24).AnyTimes()
+	fakeConfig.EXPECT().GetString("notify.templates.subject").Return("").AnyTimes()
+	fakeConfig.EXPECT().GetString("notify.templates.message").Return("").AnyTimes()
+	fakeConfig.EXPECT().GetString("notify.dashboard_url").Return("").AnyTimes()
+	fakeConfig.EXPECT().GetInt("notify.timeout_seconds").Return(30).AnyTimes()
+	fakeConfig.EXPECT().GetInt("notify.retries").Return(0).AnyTimes()
+	fakeConfig.EXPECT().GetInt("notify.retry_backoff_seconds").Return(0).AnyTimes()
+	fakeConfig.EXPECT().GetBool("notify.digest.enabled").Return(false).AnyTimes()
+	fakeConfig.EXPECT().GetString("web.timeseries.backend").Return(suite.TimeSeriesBackend).AnyTimes()
 	if _, isGithubActions := os.LookupEnv("GITHUB_ACTIONS"); isGithubActions {
 		// when running test suite in github actions, we run an influxdb service as a sidecar.
 		fakeConfig.EXPECT().GetString("web.influxdb.host").Return("influxdb").AnyTimes()
@@ -316,7 +502,7 @@ func (suite *ServerTestSuite) TestPopulateMultiple() {
 //	fakeConfig := mock_config.NewMockInterface(mockCtrl)
 //	fakeConfig.EXPECT().GetString("web.database.location").AnyTimes().Return(path.Join(parentPath, "scrutiny_test.db"))
 //	fakeConfig.EXPECT().GetString("web.src.frontend.path").AnyTimes().Return(parentPath)
-//	fakeConfig.EXPECT().GetStringSlice("notify.urls").AnyTimes().Return([]string{"https://scrutiny.requestcatcher.com/test"})
+//	fakeConfig.EXPECT().Get("notify.urls").AnyTimes().Return([]string{"https://scrutiny.requestcatcher.com/test"})
 //	ae := web.AppEngine{
 //		Config: fakeConfig,
 //	}
@@ -350,7 +536,19 @@ func (suite *ServerTestSuite) TestSendTestNotificationRoute_WebhookFailure() {
 	fakeConfig.EXPECT().GetString("web.influxdb.bucket").Return("metrics").AnyTimes()
 	fakeConfig.EXPECT().GetBool("web.influxdb.tls.insecure_skip_verify").Return(false).AnyTimes()
 	fakeConfig.EXPECT().GetBool("web.influxdb.retention_policy").Return(false).AnyTimes()
-	fakeConfig.EXPECT().GetStringSlice("notify.urls").AnyTimes().Return([]string{"https://unroutable.domain.example.asdfghj"})
+	fakeConfig.EXPECT().GetBool("web.auth.collector.enabled").Return(false).AnyTimes()
+	fakeConfig.EXPECT().GetBool("web.auth.enabled").Return(false).AnyTimes()
+	fakeConfig.EXPECT().GetInt(fmt.Sprintf("%s.metrics.notify_failure_score", config.DB_USER_SETTINGS_SUBKEY)).Return(0).AnyTimes()
+	fakeConfig.EXPECT().GetInt(fmt.Sprintf("%s.metrics.warning_notify_interval_hours", config.DB_USER_SETTINGS_SUBKEY)).Return(24).AnyTimes()
+	fakeConfig.EXPECT().GetString("notify.templates.subject").Return("").AnyTimes()
+	fakeConfig.EXPECT().GetString("notify.templates.message").Return("").AnyTimes()
+	fakeConfig.EXPECT().GetString("notify.dashboard_url").Return("").AnyTimes()
+	fakeConfig.EXPECT().GetInt("notify.timeout_seconds").Return(30).AnyTimes()
+	fakeConfig.EXPECT().GetInt("notify.retries").Return(0).AnyTimes()
+	fakeConfig.EXPECT().GetInt("notify.retry_backoff_seconds").Return(0).AnyTimes()
+	fakeConfig.EXPECT().GetBool("notify.digest.enabled").Return(false).AnyTimes()
+	fakeConfig.EXPECT().GetString("web.timeseries.backend").Return(suite.TimeSeriesBackend).AnyTimes()
+	fakeConfig.EXPECT().Get("notify.urls").AnyTimes().Return([]string{"https://unroutable.domain.example.asdfghj"})
 	fakeConfig.EXPECT().GetInt(fmt.Sprintf("%s.metrics.notify_level", config.DB_USER_SETTINGS_SUBKEY)).AnyTimes().Return(int(pkg.MetricsNotifyLevelFail))
 	fakeConfig.EXPECT().GetInt(fmt.Sprintf("%s.metrics.status_filter_attributes", config.DB_USER_SETTINGS_SUBKEY)).AnyTimes().Return(int(pkg.MetricsStatusFilterAttributesAll))
 	fakeConfig.EXPECT().GetInt(fmt.Sprintf("%s.metrics.status_threshold", config.DB_USER_SETTINGS_SUBKEY)).AnyTimes().Return(int(pkg.MetricsStatusThresholdBoth))
@@ -395,7 +593,19 @@ func (suite *ServerTestSuite) TestSendTestNotificationRoute_ScriptFailure() {
 	fakeConfig.EXPECT().GetString("web.influxdb.bucket").Return("metrics").AnyTimes()
 	fakeConfig.EXPECT().GetBool("web.influxdb.tls.insecure_skip_verify").Return(false).AnyTimes()
 	fakeConfig.EXPECT().GetBool("web.influxdb.retention_policy").Return(false).AnyTimes()
-	fakeConfig.EXPECT().GetStringSlice("notify.urls").AnyTimes().Return([]string{"script:///missing/path/on/disk"})
+	fakeConfig.EXPECT().GetBool("web.auth.collector.enabled").Return(false).AnyTimes()
+	fakeConfig.EXPECT().GetBool("web.auth.enabled").Return(false).AnyTimes()
+	fakeConfig.EXPECT().GetInt(fmt.Sprintf("%s.metrics.notify_failure_score", config.DB_USER_SETTINGS_SUBKEY)).Return(0).AnyTimes()
+	fakeConfig.EXPECT().GetInt(fmt.Sprintf("%s.metrics.warning_notify_interval_hours", config.DB_USER_SETTINGS_SUBKEY)).Return(24).AnyTimes()
+	fakeConfig.EXPECT().GetString("notify.templates.subject").Return("").AnyTimes()
+	fakeConfig.EXPECT().GetString("notify.templates.message").Return("").AnyTimes()
+	fakeConfig.EXPECT().GetString("notify.dashboard_url").Return("").AnyTimes()
+	fakeConfig.EXPECT().GetInt("notify.timeout_seconds").Return(30).AnyTimes()
+	fakeConfig.EXPECT().GetInt("notify.retries").Return(0).AnyTimes()
+	fakeConfig.EXPECT().GetInt("notify.retry_backoff_seconds").Return(0).AnyTimes()
+	fakeConfig.EXPECT().GetBool("notify.digest.enabled").Return(false).AnyTimes()
+	fakeConfig.EXPECT().GetString("web.timeseries.backend").Return(suite.TimeSeriesBackend).AnyTimes()
+	fakeConfig.EXPECT().Get("notify.urls").AnyTimes().Return([]string{"script:///missing/path/on/disk"})
 	fakeConfig.EXPECT().GetInt(fmt.Sprintf("%s.metrics.notify_level", config.DB_USER_SETTINGS_SUBKEY)).AnyTimes().Return(int(pkg.MetricsNotifyLevelFail))
 	fakeConfig.EXPECT().GetInt(fmt.Sprintf("%s.metrics.status_filter_attributes", config.DB_USER_SETTINGS_SUBKEY)).AnyTimes().Return(int(pkg.MetricsStatusFilterAttributesAll))
 	fakeConfig.EXPECT().GetInt(fmt.Sprintf("%s.metrics.status_threshold", config.DB_USER_SETTINGS_SUBKEY)).AnyTimes().Return(int(pkg.MetricsStatusThresholdBoth))
@@ -440,7 +650,19 @@ func (suite *ServerTestSuite) TestSendTestNotificationRoute_ScriptSuccess() {
 	fakeConfig.EXPECT().GetString("web.influxdb.bucket").Return("metrics").AnyTimes()
 	fakeConfig.EXPECT().GetBool("web.influxdb.tls.insecure_skip_verify").Return(false).AnyTimes()
 	fakeConfig.EXPECT().GetBool("web.influxdb.retention_policy").Return(false).AnyTimes()
-	fakeConfig.EXPECT().GetStringSlice("notify.urls").AnyTimes().Return([]string{"script:///usr/bin/env"})
+	fakeConfig.EXPECT().GetBool("web.auth.collector.enabled").Return(false).AnyTimes()
+	fakeConfig.EXPECT().GetBool("web.auth.enabled").Return(false).AnyTimes()
+	fakeConfig.EXPECT().GetInt(fmt.Sprintf("%s.metrics.notify_failure_score", config.DB_USER_SETTINGS_SUBKEY)).Return(0).AnyTimes()
+	fakeConfig.EXPECT().GetInt(fmt.Sprintf("%s.metrics.warning_notify_interval_hours", config.DB_USER_SETTINGS_SUBKEY)).Return(24).AnyTimes()
+	fakeConfig.EXPECT().GetString("notify.templates.subject").Return("").AnyTimes()
+	fakeConfig.EXPECT().GetString("notify.templates.message").Return("").AnyTimes()
+	fakeConfig.EXPECT().GetString("notify.dashboard_url").Return("").AnyTimes()
+	fakeConfig.EXPECT().GetInt("notify.timeout_seconds").Return(30).AnyTimes()
+	fakeConfig.EXPECT().GetInt("notify.retries").Return(0).AnyTimes()
+	fakeConfig.EXPECT().GetInt("notify.retry_backoff_seconds").Return(0).AnyTimes()
+	fakeConfig.EXPECT().GetBool("notify.digest.enabled").Return(false).AnyTimes()
+	fakeConfig.EXPECT().GetString("web.timeseries.backend").Return(suite.TimeSeriesBackend).AnyTimes()
+	fakeConfig.EXPECT().Get("notify.urls").AnyTimes().Return([]string{"script:///usr/bin/env"})
 	fakeConfig.EXPECT().GetInt(fmt.Sprintf("%s.metrics.notify_level", config.DB_USER_SETTINGS_SUBKEY)).AnyTimes().Return(int(pkg.MetricsNotifyLevelFail))
 	fakeConfig.EXPECT().GetInt(fmt.Sprintf("%s.metrics.status_filter_attributes", config.DB_USER_SETTINGS_SUBKEY)).AnyTimes().Return(int(pkg.MetricsStatusFilterAttributesAll))
 	fakeConfig.EXPECT().GetInt(fmt.Sprintf("%s.metrics.status_threshold", config.DB_USER_SETTINGS_SUBKEY)).AnyTimes().Return(int(pkg.MetricsStatusThresholdBoth))
@@ -485,7 +707,19 @@ func (suite *ServerTestSuite) TestSendTestNotificationRoute_ShoutrrrFailure() {
 	fakeConfig.EXPECT().GetString("web.influxdb.bucket").Return("metrics").AnyTimes()
 	fakeConfig.EXPECT().GetBool("web.influxdb.tls.insecure_skip_verify").Return(false).AnyTimes()
 	fakeConfig.EXPECT().GetBool("web.influxdb.retention_policy").Return(false).AnyTimes()
-	fakeConfig.EXPECT().GetStringSlice("notify.urls").AnyTimes().Return([]string{"discord://invalidtoken@channel"})
+	fakeConfig.EXPECT().GetBool("web.auth.collector.enabled").Return(false).AnyTimes()
+	fakeConfig.EXPECT().GetBool("web.auth.enabled").Return(false).AnyTimes()
+	fakeConfig.EXPECT().GetInt(fmt.Sprintf("%s.metrics.notify_failure_score", config.DB_USER_SETTINGS_SUBKEY)).Return(0).AnyTimes()
+	fakeConfig.EXPECT().GetInt(fmt.Sprintf("%s.metrics.warning_notify_interval_hours", config.DB_USER_SETTINGS_SUBKEY)).Return(24).AnyTimes()
+	fakeConfig.EXPECT().GetString("notify.templates.subject").Return("").AnyTimes()
+	fakeConfig.EXPECT().GetString("notify.templates.message").Return("").AnyTimes()
+	fakeConfig.EXPECT().GetString("notify.dashboard_url").Return("").AnyTimes()
+	fakeConfig.EXPECT().GetInt("notify.timeout_seconds").Return(30).AnyTimes()
+	fakeConfig.EXPECT().GetInt("notify.retries").Return(0).AnyTimes()
+	fakeConfig.EXPECT().GetInt("notify.retry_backoff_seconds").Return(0).AnyTimes()
+	fakeConfig.EXPECT().GetBool("notify.digest.enabled").Return(false).AnyTimes()
+	fakeConfig.EXPECT().GetString("web.timeseries.backend").Return(suite.TimeSeriesBackend).AnyTimes()
+	fakeConfig.EXPECT().Get("notify.urls").AnyTimes().Return([]string{"discord://invalidtoken@channel"})
 	fakeConfig.EXPECT().GetInt(fmt.Sprintf("%s.metrics.notify_level", config.DB_USER_SETTINGS_SUBKEY)).AnyTimes().Return(int(pkg.MetricsNotifyLevelFail))
 	fakeConfig.EXPECT().GetInt(fmt.Sprintf("%s.metrics.status_filter_attributes", config.DB_USER_SETTINGS_SUBKEY)).AnyTimes().Return(int(pkg.MetricsStatusFilterAttributesAll))
 	fakeConfig.EXPECT().GetInt(fmt.Sprintf("%s.metrics.status_threshold", config.DB_USER_SETTINGS_SUBKEY)).AnyTimes().Return(int(pkg.MetricsStatusThresholdBoth))
@@ -531,7 +765,19 @@ func (suite *ServerTestSuite) TestGetDevicesSummaryRoute_Nvme() {
 	fakeConfig.EXPECT().GetBool("user.collector.discard_sct_temp_history").Return(false).AnyTimes()
 	fakeConfig.EXPECT().GetBool("web.influxdb.tls.insecure_skip_verify").Return(false).AnyTimes()
 	fakeConfig.EXPECT().GetBool("web.influxdb.retention_policy").Return(false).AnyTimes()
-	fakeConfig.EXPECT().GetStringSlice("notify.urls").AnyTimes().Return([]string{})
+	fakeConfig.EXPECT().GetBool("web.auth.collector.enabled").Return(false).AnyTimes()
+	fakeConfig.EXPECT().GetBool("web.auth.enabled").Return(false).AnyTimes()
+	fakeConfig.EXPECT().GetInt(fmt.Sprintf("%s.metrics.notify_failure_score", config.DB_USER_SETTINGS_SUBKEY)).Return(0).AnyTimes()
+	fakeConfig.EXPECT().GetInt(fmt.Sprintf("%s.metrics.warning_notify_interval_hours", config.DB_USER_SETTINGS_SUBKEY)).Return(24).AnyTimes()
+	fakeConfig.EXPECT().GetString("notify.templates.subject").Return("").AnyTimes()
+	fakeConfig.EXPECT().GetString("notify.templates.message").Return("").AnyTimes()
+	fakeConfig.EXPECT().GetString("notify.dashboard_url").Return("").AnyTimes()
+	fakeConfig.EXPECT().GetInt("notify.timeout_seconds").Return(30).AnyTimes()
+	fakeConfig.EXPECT().GetInt("notify.retries").Return(0).AnyTimes()
+	fakeConfig.EXPECT().GetInt("notify.retry_backoff_seconds").Return(0).AnyTimes()
+	fakeConfig.EXPECT().GetBool("notify.digest.enabled").Return(false).AnyTimes()
+	fakeConfig.EXPECT().GetString("web.timeseries.backend").Return(suite.TimeSeriesBackend).AnyTimes()
+	fakeConfig.EXPECT().Get("notify.urls").AnyTimes().Return([]string{})
 	fakeConfig.EXPECT().GetInt(fmt.Sprintf("%s.metrics.notify_level", config.DB_USER_SETTINGS_SUBKEY)).AnyTimes().Return(int(pkg.MetricsNotifyLevelFail))
 	fakeConfig.EXPECT().GetInt(fmt.Sprintf("%s.metrics.status_filter_attributes", config.DB_USER_SETTINGS_SUBKEY)).AnyTimes().Return(int(pkg.MetricsStatusFilterAttributesAll))
 	fakeConfig.EXPECT().GetInt(fmt.Sprintf("%s.metrics.status_threshold", config.DB_USER_SETTINGS_SUBKEY)).AnyTimes().Return(int(pkg.MetricsStatusThresholdBoth))
@@ -601,7 +847,19 @@ func (suite *ServerTestSuite) TestGetDevicesSummaryRoute_DeviceStatusResetsToPas
 	fakeConfig.EXPECT().GetBool("user.collector.discard_sct_temp_history").Return(false).AnyTimes()
 	fakeConfig.EXPECT().GetBool("web.influxdb.tls.insecure_skip_verify").Return(false).AnyTimes()
 	fakeConfig.EXPECT().GetBool("web.influxdb.retention_policy").Return(false).AnyTimes()
-	fakeConfig.EXPECT().GetStringSlice("notify.urls").AnyTimes().Return([]string{})
+	fakeConfig.EXPECT().GetBool("web.auth.collector.enabled").Return(false).AnyTimes()
+	fakeConfig.EXPECT().GetBool("web.auth.enabled").Return(false).AnyTimes()
+	fakeConfig.EXPECT().GetInt(fmt.Sprintf("%s.metrics.notify_failure_score", config.DB_USER_SETTINGS_SUBKEY)).Return(0).AnyTimes()
+	fakeConfig.EXPECT().GetInt(fmt.Sprintf("%s.metrics.warning_notify_interval_hours", config.DB_USER_SETTINGS_SUBKEY)).Return(24).AnyTimes()
+	fakeConfig.EXPECT().GetString("notify.templates.subject").Return("").AnyTimes()
+	fakeConfig.EXPECT().GetString("notify.templates.message").Return("").AnyTimes()
+	fakeConfig.EXPECT().GetString("notify.dashboard_url").Return("").AnyTimes()
+	fakeConfig.EXPECT().GetInt("notify.timeout_seconds").Return(30).AnyTimes()
+	fakeConfig.EXPECT().GetInt("notify.retries").Return(0).AnyTimes()
+	fakeConfig.EXPECT().GetInt("notify.retry_backoff_seconds").Return(0).AnyTimes()
+	fakeConfig.EXPECT().GetBool("notify.digest.enabled").Return(false).AnyTimes()
+	fakeConfig.EXPECT().GetString("web.timeseries.backend").Return(suite.TimeSeriesBackend).AnyTimes()
+	fakeConfig.EXPECT().Get("notify.urls").AnyTimes().Return([]string{})
 	fakeConfig.EXPECT().GetInt(fmt.Sprintf("%s.metrics.notify_level", config.DB_USER_SETTINGS_SUBKEY)).AnyTimes().Return(int(pkg.MetricsNotifyLevelFail))
 	fakeConfig.EXPECT().GetInt(fmt.Sprintf("%s.metrics.status_filter_attributes", config.DB_USER_SETTINGS_SUBKEY)).AnyTimes().Return(int(pkg.MetricsStatusFilterAttributesAll))
 	fakeConfig.EXPECT().GetInt(fmt.Sprintf("%s.metrics.status_threshold", config.DB_USER_SETTINGS_SUBKEY)).AnyTimes().Return(int(pkg.MetricsStatusThresholdBoth))
@@ -638,9 +896,9 @@ func (suite *ServerTestSuite) TestGetDevicesSummaryRoute_DeviceStatusResetsToPas
 	router.ServeHTTP(fr, req)
 	require.Equal(suite.T(), 200, fr.Code)
 
-	//assert - device is now failed
+	//assert - device is now failed (smart-fail2.json also contains attributes above the warning threshold)
 	failSummary := helperGetDeviceSummary(suite.T(), router, suite.Basepath)
-	require.Equal(suite.T(), pkg.DeviceStatusFailedScrutiny|pkg.DeviceStatusFailedSmart, failSummary.Data.Summary[deviceUUIDString].Device.DeviceStatus)
+	require.Equal(suite.T(), pkg.DeviceStatusFailedScrutiny|pkg.DeviceStatusFailedSmart|pkg.DeviceStatusWarningScrutiny, failSummary.Data.Summary[deviceUUIDString].Device.DeviceStatus)
 
 	//test - upload clean SMART data for the same device
 	pr := httptest.NewRecorder()
@@ -648,9 +906,9 @@ func (suite *ServerTestSuite) TestGetDevicesSummaryRoute_DeviceStatusResetsToPas
 	router.ServeHTTP(pr, req)
 	require.Equal(suite.T(), 200, pr.Code)
 
-	//assert - device status is reset back to passing
+	//assert - the failed status is reset (smart-ata.json only contains a Spin_Up_Time warning)
 	passSummary := helperGetDeviceSummary(suite.T(), router, suite.Basepath)
-	require.Equal(suite.T(), pkg.DeviceStatusPassed, passSummary.Data.Summary[deviceUUIDString].Device.DeviceStatus)
+	require.Equal(suite.T(), pkg.DeviceStatusWarningScrutiny, passSummary.Data.Summary[deviceUUIDString].Device.DeviceStatus)
 }
 
 func helperGetDeviceSummary(t *testing.T, router http.Handler, basepath string) models.DeviceSummaryWrapper {
@@ -27,6 +27,7 @@ export const appRoutes: Route[] = [
         component: EmptyLayoutComponent,
         children   : [
             {path: 'home', loadChildren: () => import('app/modules/landing/home/home.module').then(m => m.LandingHomeModule)},
+            {path: 'login', loadChildren: () => import('app/modules/auth/login/login.module').then(m => m.AuthLoginModule)},
         ]
     },
 
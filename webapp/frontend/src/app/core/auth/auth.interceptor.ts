@@ -0,0 +1,41 @@
+import {Injectable} from '@angular/core';
+import {HttpErrorResponse, HttpEvent, HttpHandler, HttpInterceptor, HttpRequest} from '@angular/common/http';
+import {Router} from '@angular/router';
+import {Observable, throwError} from 'rxjs';
+import {catchError} from 'rxjs/operators';
+
+@Injectable()
+export class AuthInterceptor implements HttpInterceptor
+{
+    /**
+     * Constructor
+     *
+     * @param {Router} _router
+     */
+    constructor(
+        private _router: Router
+    )
+    {
+    }
+
+    /**
+     * Redirect to the login page when the API requires authentication (`web.auth.enabled`), and the session is
+     * missing or expired. Failed logins are handled by the login page.
+     *
+     * @param req
+     * @param next
+     */
+    intercept(req: HttpRequest<any>, next: HttpHandler): Observable<HttpEvent<any>>
+    {
+        return next.handle(req).pipe(
+            catchError((error) => {
+                if ( error instanceof HttpErrorResponse && error.status === 401 &&
+                    !req.url.endsWith('/api/auth/login') && !this._router.url.startsWith('/login') )
+                {
+                    this._router.navigate(['/login'], {queryParams: {redirectURL: this._router.url}});
+                }
+                return throwError(() => error);
+            })
+        );
+    }
+}
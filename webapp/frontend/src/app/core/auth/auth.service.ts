@@ -0,0 +1,80 @@
+import {Injectable} from '@angular/core';
+import {HttpClient} from '@angular/common/http';
+import {BehaviorSubject, Observable} from 'rxjs';
+import {map, tap} from 'rxjs/operators';
+import {getBasePath} from 'app/app.routing';
+import {UserModel} from 'app/core/models/user-model';
+
+@Injectable({
+    providedIn: 'root'
+})
+export class AuthService {
+    // Observables
+    private _user: BehaviorSubject<UserModel>;
+
+    /**
+     * Constructor
+     *
+     * @param {HttpClient} _httpClient
+     */
+    constructor(
+        private _httpClient: HttpClient
+    )
+    {
+        // Set the private defaults
+        this._user = new BehaviorSubject(null);
+    }
+
+    // -----------------------------------------------------------------------------------------------------
+    // @ Accessors
+    // -----------------------------------------------------------------------------------------------------
+
+    /**
+     * Getter for the logged-in user, null when authentication is disabled or the user is not logged in
+     */
+    get user$(): Observable<UserModel> {
+        return this._user.asObservable();
+    }
+
+    // -----------------------------------------------------------------------------------------------------
+    // @ Public methods
+    // -----------------------------------------------------------------------------------------------------
+
+    /**
+     * Get the logged-in user. The request fails with a 401 when authentication is enabled and the session expired.
+     */
+    getCurrentUser(): Observable<UserModel> {
+        return this._httpClient.get(getBasePath() + '/api/auth/me').pipe(
+            map((response: { data?: UserModel }) => response.data || null),
+            tap((user: UserModel) => {
+                this._user.next(user);
+            })
+        );
+    }
+
+    /**
+     * Log in a local user, the session cookie is set by the API
+     *
+     * @param username
+     * @param password
+     */
+    login(username: string, password: string): Observable<UserModel> {
+        return this._httpClient.post(getBasePath() + '/api/auth/login', {username, password}).pipe(
+            map((response: { data: UserModel }) => response.data),
+            tap((user: UserModel) => {
+                this._user.next(user);
+            })
+        );
+    }
+
+    /**
+     * Delete the current session
+     */
+    logout(): Observable<any> {
+        return this._httpClient.post(getBasePath() + '/api/auth/logout', {}).pipe(
+            tap(() => {
+                this._user.next(null);
+            })
+        );
+    }
+}
@@ -61,9 +61,12 @@ export interface AppConfig {
 
     metrics?: {
         notify_level?: MetricsNotifyLevel
+        warning_notify_interval_hours?: number
         status_filter_attributes?: MetricsStatusFilterAttributes
         status_threshold?: MetricsStatusThreshold
         repeat_notifications?: boolean
+        notify_failure_score?: number
+        stale_interval_hours?: number
     }
 
 }
@@ -95,9 +98,12 @@ export const appConfig: AppConfig = {
 
     metrics: {
         notify_level: MetricsNotifyLevel.Fail,
+        warning_notify_interval_hours: 24,
         status_filter_attributes: MetricsStatusFilterAttributes.All,
         status_threshold: MetricsStatusThreshold.Both,
-        repeat_notifications: true
+        repeat_notifications: true,
+        notify_failure_score: 0,
+        stale_interval_hours: 0
     }
 };
 
@@ -1,13 +1,20 @@
 import { NgModule, Optional, SkipSelf } from '@angular/core';
-import { HttpClientModule } from '@angular/common/http';
+import { HTTP_INTERCEPTORS, HttpClientModule } from '@angular/common/http';
 import { DomSanitizer } from '@angular/platform-browser';
 import { MatIconRegistry } from '@angular/material/icon';
+import { AuthInterceptor } from 'app/core/auth/auth.interceptor';
 
 @NgModule({
     imports  : [
         HttpClientModule
     ],
-    providers: []
+    providers: [
+        {
+            provide : HTTP_INTERCEPTORS,
+            useClass: AuthInterceptor,
+            multi   : true
+        }
+    ]
 })
 export class CoreModule
 {
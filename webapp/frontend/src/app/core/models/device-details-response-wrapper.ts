@@ -1,4 +1,5 @@
 import {DeviceModel} from 'app/core/models/device-model';
+import {DeviceReplacementModel} from 'app/core/models/device-replacement-model';
 import {SmartModel} from 'app/core/models/measurements/smart-model';
 import {AttributeMetadataModel} from 'app/core/models/thresholds/attribute-metadata-model';
 
@@ -9,6 +10,7 @@ export interface DeviceDetailsResponseWrapper {
     data: {
         device: DeviceModel;
         smart_results: SmartModel[];
+        replacements?: DeviceReplacementModel[];
     },
     metadata: { [key: string]: AttributeMetadataModel } | { [key: number]: AttributeMetadataModel };
 }
@@ -8,6 +8,11 @@ export interface DeviceModel {
     device_serial_id?: string;
     device_label?: string;
 
+    device_by_path?: string;
+    enclosure_id?: string;
+    enclosure_slot?: string;
+    controller_pci_address?: string;
+
     manufacturer: string;
     model_name: string;
     interface_type: string;
@@ -17,6 +22,7 @@ export interface DeviceModel {
     rotational_speed: number;
     capacity: number;
     form_factor: string;
+    logical_block_size?: number;
     smart_support: boolean;
     device_protocol: string;
     device_type: string;
@@ -24,5 +30,25 @@ export interface DeviceModel {
     label: string;
     host_id: string;
 
+    location?: string;
+    purchase_date?: string;
+    warranty_expiry_date?: string;
+    rma_url?: string;
+    purchase_cost?: number;
+    rated_tbw?: number;
+    notes?: string;
+
     device_status: number;
+
+    failure_score?: number;
+    failure_score_attribute_id?: string;
+    estimated_failure_date?: string;
+
+    daily_bytes_written?: number;
+    wear_percent_used?: number;
+    endurance_percent_used?: number;
+    estimated_wear_out_date?: string;
+
+    last_seen_at?: string;
+    stale?: boolean;
 }
@@ -0,0 +1,10 @@
+// maps to webapp/backend/pkg/models/device_replacement.go
+export interface DeviceReplacementModel {
+    id: number;
+    created_at: string;
+    host_id: string;
+    device_name: string;
+    replaced_scrutiny_uuid: string;
+    replacement_scrutiny_uuid: string;
+    automatic: boolean;
+}
@@ -0,0 +1,6 @@
+// maps to webapp/backend/pkg/models/user.go
+export interface UserModel {
+    username: string;
+    role: 'admin' | 'readonly';
+    external: boolean; // authenticated by a reverse proxy (forward auth), cannot log out
+}
@@ -57,7 +57,7 @@ export class DashboardDeviceComponent implements OnInit {
         const deviceStatus = DeviceStatusPipe.deviceStatusForModelWithThreshold(deviceSummary.device, !!deviceSummary.smart, this.config.metrics.status_threshold)
         if (deviceStatus === 'failed') {
             return 'text-red' // if the device has failed, always highlight in red
-        } else if (deviceStatus === 'passed') {
+        } else if (deviceStatus === 'passed' || deviceStatus === 'warning') {
             if (moment().subtract(14, 'days').isBefore(deviceSummary.smart.collector_date)) {
                 // this device was updated in the last 2 weeks.
                 return 'text-green'
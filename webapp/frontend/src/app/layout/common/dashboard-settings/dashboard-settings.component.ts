@@ -3,6 +3,7 @@ import {
     AppConfig,
     DashboardDisplay,
     DashboardSort,
+    MetricsNotifyLevel,
     MetricsStatusFilterAttributes,
     MetricsStatusThreshold,
     TemperatureUnit,
@@ -30,8 +31,12 @@ export class DashboardSettingsComponent implements OnInit {
     theme: string;
     discardSCTTempHistory: boolean;
     statusThreshold: number;
+    notifyLevel: number;
+    warningNotifyIntervalHours: number;
     statusFilterAttributes: number;
     repeatNotifications: boolean;
+    notifyFailureScore: number;
+    staleIntervalHours: number;
 
     // Private
     private _unsubscribeAll: Subject<void>;
@@ -62,7 +67,11 @@ export class DashboardSettingsComponent implements OnInit {
 
                 this.statusFilterAttributes = config.metrics.status_filter_attributes;
                 this.statusThreshold = config.metrics.status_threshold;
+                this.notifyLevel = config.metrics.notify_level;
+                this.warningNotifyIntervalHours = config.metrics.warning_notify_interval_hours;
                 this.repeatNotifications = config.metrics.repeat_notifications;
+                this.notifyFailureScore = config.metrics.notify_failure_score;
+                this.staleIntervalHours = config.metrics.stale_interval_hours;
 
             });
 
@@ -81,9 +90,13 @@ export class DashboardSettingsComponent implements OnInit {
                 discard_sct_temp_history: this.discardSCTTempHistory
             },
             metrics: {
+                notify_level: this.notifyLevel as MetricsNotifyLevel,
+                warning_notify_interval_hours: this.warningNotifyIntervalHours,
                 status_filter_attributes: this.statusFilterAttributes as MetricsStatusFilterAttributes,
                 status_threshold: this.statusThreshold as MetricsStatusThreshold,
-                repeat_notifications: this.repeatNotifications
+                repeat_notifications: this.repeatNotifications,
+                notify_failure_score: this.notifyFailureScore,
+                stale_interval_hours: this.staleIntervalHours
             }
         }
         this._configService.config = newSettings
@@ -5,6 +5,8 @@ import { takeUntil } from 'rxjs/operators';
 import { TreoMediaWatcherService } from '@treo/services/media-watcher';
 import { TreoNavigationService } from '@treo/components/navigation';
 import {versionInfo} from 'environments/versions';
+import { AuthService } from 'app/core/auth/auth.service';
+import { UserModel } from 'app/core/models/user-model';
 
 @Component({
     selector     : 'material-layout',
@@ -17,6 +19,7 @@ export class MaterialLayoutComponent implements OnInit, OnDestroy
     appVersion: string;
     data: any;
     isScreenSmall: boolean;
+    user: UserModel;
 
     @HostBinding('class.fixed-header')
     fixedHeader: boolean;
@@ -31,12 +34,14 @@ export class MaterialLayoutComponent implements OnInit, OnDestroy
      * Constructor
      *
      * @param {ActivatedRoute} _activatedRoute
+     * @param {AuthService} _authService
      * @param {TreoMediaWatcherService} _treoMediaWatcherService
      * @param {TreoNavigationService} _treoNavigationService
      * @param {Router} _router
      */
     constructor(
         private _activatedRoute: ActivatedRoute,
+        private _authService: AuthService,
         private _treoMediaWatcherService: TreoMediaWatcherService,
         private _treoNavigationService: TreoNavigationService,
         private _router: Router
@@ -86,6 +91,14 @@ export class MaterialLayoutComponent implements OnInit, OnDestroy
                 // Check if the breakpoint is 'lt-md'
                 this.isScreenSmall = matchingAliases.includes('lt-md');
             });
+
+        // Subscribe to the logged-in user, null when authentication is disabled
+        this._authService.user$
+            .pipe(takeUntil(this._unsubscribeAll))
+            .subscribe((user) => {
+                this.user = user;
+            });
+        this._authService.getCurrentUser().subscribe(() => {}, () => {});
     }
 
     /**
@@ -118,4 +131,14 @@ export class MaterialLayoutComponent implements OnInit, OnDestroy
             navigation.toggle();
         }
     }
+
+    /**
+     * Log out, and return to the login page
+     */
+    logout(): void
+    {
+        this._authService.logout().subscribe(() => {
+            this._router.navigate(['/login']);
+        });
+    }
 }
@@ -0,0 +1,79 @@
+import { Component, OnInit, ViewEncapsulation } from '@angular/core';
+import { FormBuilder, FormGroup, Validators } from '@angular/forms';
+import { ActivatedRoute, Router } from '@angular/router';
+import { HttpErrorResponse } from '@angular/common/http';
+import { AuthService } from 'app/core/auth/auth.service';
+
+@Component({
+    selector     : 'auth-login',
+    templateUrl  : './login.component.html',
+    styleUrls    : ['./login.component.scss'],
+    encapsulation: ViewEncapsulation.None
+})
+export class AuthLoginComponent implements OnInit
+{
+    loginForm: FormGroup;
+    errorMessage: string;
+
+    /**
+     * Constructor
+     *
+     * @param {ActivatedRoute} _activatedRoute
+     * @param {AuthService} _authService
+     * @param {FormBuilder} _formBuilder
+     * @param {Router} _router
+     */
+    constructor(
+        private _activatedRoute: ActivatedRoute,
+        private _authService: AuthService,
+        private _formBuilder: FormBuilder,
+        private _router: Router
+    )
+    {
+    }
+
+    // -----------------------------------------------------------------------------------------------------
+    // @ Lifecycle hooks
+    // -----------------------------------------------------------------------------------------------------
+
+    /**
+     * On init
+     */
+    ngOnInit(): void
+    {
+        this.loginForm = this._formBuilder.group({
+            username: ['', Validators.required],
+            password: ['', Validators.required]
+        });
+    }
+
+    // -----------------------------------------------------------------------------------------------------
+    // @ Public methods
+    // -----------------------------------------------------------------------------------------------------
+
+    /**
+     * Log in, and return to the page that required authentication
+     */
+    login(): void
+    {
+        if ( this.loginForm.invalid )
+        {
+            return;
+        }
+
+        this.loginForm.disable();
+        this.errorMessage = null;
+
+        const {username, password} = this.loginForm.getRawValue();
+        this._authService.login(username, password).subscribe(
+            () => {
+                const redirectURL = this._activatedRoute.snapshot.queryParamMap.get('redirectURL') || '/dashboard';
+                this._router.navigateByUrl(redirectURL);
+            },
+            (error: HttpErrorResponse) => {
+                this.loginForm.enable();
+                this.errorMessage = error.status === 401 ? 'Invalid username or password' : 'Could not log in, please try again';
+            }
+        );
+    }
+}
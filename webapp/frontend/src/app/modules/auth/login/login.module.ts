@@ -0,0 +1,24 @@
+import { NgModule } from '@angular/core';
+import { RouterModule } from '@angular/router';
+import { MatButtonModule } from '@angular/material/button';
+import { MatFormFieldModule } from '@angular/material/form-field';
+import { MatInputModule } from '@angular/material/input';
+import { SharedModule } from 'app/shared/shared.module';
+import { AuthLoginComponent } from 'app/modules/auth/login/login.component';
+import { authLoginRoutes } from 'app/modules/auth/login/login.routing';
+
+@NgModule({
+    declarations: [
+        AuthLoginComponent
+    ],
+    imports     : [
+        RouterModule.forChild(authLoginRoutes),
+        MatButtonModule,
+        MatFormFieldModule,
+        MatInputModule,
+        SharedModule
+    ]
+})
+export class AuthLoginModule
+{
+}
@@ -13,6 +13,7 @@ import {animate, state, style, transition, trigger} from '@angular/animations';
 import {formatDate} from '@angular/common';
 import {takeUntil} from 'rxjs/operators';
 import {DeviceModel} from 'app/core/models/device-model';
+import {DeviceReplacementModel} from 'app/core/models/device-replacement-model';
 import {SmartModel} from 'app/core/models/measurements/smart-model';
 import {SmartAttributeModel} from 'app/core/models/measurements/smart-attribute-model';
 import {AttributeMetadataModel} from 'app/core/models/thresholds/attribute-metadata-model';
@@ -76,6 +77,7 @@ export class DetailComponent implements OnInit, AfterViewInit, OnDestroy {
     device: DeviceModel;
     // tslint:disable-next-line:variable-name
     smart_results: SmartModel[];
+    replacements: DeviceReplacementModel[];
 
     commonSparklineOptions: Partial<ApexOptions>;
     smartAttributeDataSource: MatTableDataSource<SmartAttributeModel>;
@@ -116,6 +118,7 @@ export class DetailComponent implements OnInit, AfterViewInit, OnDestroy {
                 // this.data = data;
                 this.device = respWrapper.data.device;
                 this.smart_results = respWrapper.data.smart_results
+                this.replacements = respWrapper.data.replacements || [];
                 this.metadata = respWrapper.metadata;
 
 
@@ -1,5 +1,6 @@
 import { Pipe, PipeTransform } from '@angular/core';
 import {DeviceTitlePipe} from 'app/shared/device-title.pipe';
+import {DEVICE_STATUS_WARNING_SCRUTINY} from 'app/shared/device-status.pipe';
 
 @Pipe({
   name: 'deviceSort'
@@ -12,8 +13,11 @@ export class DeviceSortPipe implements PipeTransform {
                 return 0
             } else if (deviceSummary.device.device_status === 0){
                 return 1
+            } else if (deviceSummary.device.device_status === DEVICE_STATUS_WARNING_SCRUTINY){
+                return -0.5 // warnings are sorted after failures
             } else {
-                return deviceSummary.device.device_status * -1 // will return range from -1, -2, -3
+                // tslint:disable-next-line:no-bitwise
+                return (deviceSummary.device.device_status & ~DEVICE_STATUS_WARNING_SCRUTINY) * -1 // will return range from -1, -2, -3
             }
         }
 
@@ -127,6 +127,28 @@ describe('DeviceStatusPipe', () => {
                 'threshold': MetricsStatusThreshold.Both,
                 'includeReason': true,
                 'result': 'failed: both'
+            },
+
+            {
+                'deviceStatus': 4,
+                'hasSmartResults': true,
+                'threshold': MetricsStatusThreshold.Both,
+                'includeReason': false,
+                'result': 'warning'
+            },
+            {
+                'deviceStatus': 6,
+                'hasSmartResults': true,
+                'threshold': MetricsStatusThreshold.Both,
+                'includeReason': true,
+                'result': 'failed: scrutiny'
+            },
+            {
+                'deviceStatus': 6,
+                'hasSmartResults': true,
+                'threshold': MetricsStatusThreshold.Smart,
+                'includeReason': false,
+                'result': 'warning'
             }
 
 
@@ -2,6 +2,9 @@ import {Pipe, PipeTransform} from '@angular/core';
 import {MetricsStatusThreshold} from '../core/config/app.config';
 import {DeviceModel} from '../core/models/device-model';
 
+// warning bit, set when an attribute has a warning but the device is not failing
+export const DEVICE_STATUS_WARNING_SCRUTINY = 4
+
 const DEVICE_STATUS_NAMES: { [key: number]: string } = {
     0: 'passed',
     1: 'failed',
@@ -41,6 +44,10 @@ export class DeviceStatusPipe implements PipeTransform {
         // determine the device status, by comparing it against the allowed threshold
         // tslint:disable-next-line:no-bitwise
         const deviceStatus = deviceModel.device_status & threshold
+        // tslint:disable-next-line:no-bitwise
+        if (deviceStatus === 0 && (deviceModel.device_status & DEVICE_STATUS_WARNING_SCRUTINY)) {
+            return 'warning'
+        }
         return statusNameLookup[deviceStatus]
     }
 